@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Bounds and mutation step sizes of self-adaptive ant parameters
+const (
+	adaptiveMaxAlpha = 5
+	adaptiveMaxBeta  = 10
+	// adaptiveSigma is the standard deviation of the log-normal factor alpha
+	// and beta are mutated by, and adaptiveQ0Sigma that of the step added to q0
+	adaptiveSigma   = 0.1
+	adaptiveQ0Sigma = 0.05
+)
+
+// antParameters are the choice parameters one ant of a self-adaptive colony
+// carries from one iteration to the next
+type antParameters struct {
+	Alpha, Beta, Q0 float64
+}
+
+// initAdaptiveParameters gives every ant a starting point spread around the
+// colony's parameters. Only Ant Colony System starts from its q0; the other
+// variants start from pure roulette choice and may evolve towards greed
+func (ac *AntColony) initAdaptiveParameters() {
+	ac.adaptive = make([]antParameters, ac.NumAnts)
+	q0 := 0.0
+	if ac.Variant == AntColonySystem {
+		q0 = ac.Q0
+	}
+	for k := range ac.adaptive {
+		ac.adaptive[k] = antParameters{Alpha: ac.Alpha, Beta: ac.Beta, Q0: q0}
+		ac.mutate(&ac.adaptive[k])
+	}
+}
+
+// adaptParameters evolves the ants' parameters after an iteration whose ants
+// built tours of the given lengths: each ant in the worse half takes over the
+// parameters of the ant as far from the top as it is from the middle, and
+// mutates them, so parameters that build short tours spread
+func (ac *AntColony) adaptParameters(ants []*Ant, lengths []float64) {
+	order := make([]int, len(ants))
+	for k := range order {
+		order[k] = k
+	}
+	sort.SliceStable(order, func(a, b int) bool { return lengths[order[a]] < lengths[order[b]] })
+	half := len(order) / 2
+	for r := len(order) - half; r < len(order); r++ {
+		worse, better := order[r], order[r-(len(order)-half)]
+		*ants[worse].params = *ants[better].params
+		ac.mutate(ants[worse].params)
+	}
+}
+
+// mutate perturbs parameters randomly, keeping them within their bounds
+func (ac *AntColony) mutate(p *antParameters) {
+	p.Alpha = math.Min(p.Alpha*math.Exp(adaptiveSigma*ac.rng.NormFloat64()), adaptiveMaxAlpha)
+	p.Beta = math.Min(p.Beta*math.Exp(adaptiveSigma*ac.rng.NormFloat64()), adaptiveMaxBeta)
+	p.Q0 = math.Min(math.Max(p.Q0+adaptiveQ0Sigma*ac.rng.NormFloat64(), 0), 1)
+}
+
+// AdaptedParameters returns the mean alpha, beta and q0 of the ants of a
+// self-adaptive colony, or the colony's own parameters otherwise
+func (ac *AntColony) AdaptedParameters() (alpha, beta, q0 float64) {
+	if len(ac.adaptive) == 0 {
+		return ac.Alpha, ac.Beta, ac.Q0
+	}
+	n := float64(len(ac.adaptive))
+	for _, p := range ac.adaptive {
+		alpha += p.Alpha / n
+		beta += p.Beta / n
+		q0 += p.Q0 / n
+	}
+	return alpha, beta, q0
+}
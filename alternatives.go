@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// AlternativeTour is one of the distinct tours a run keeps besides its best
+type AlternativeTour struct {
+	Tour   []int   `json:"tour"`
+	Length float64 `json:"length"`
+}
+
+// tourArchive keeps the k shortest structurally distinct tours offered to it
+type tourArchive struct {
+	k int
+	// tours are the kept tours, shortest first, and keys their tourKey
+	tours []AlternativeTour
+	keys  []string
+}
+
+// offer keeps a copy of the tour if it is among the k shortest and no tour
+// taking the same edges is kept already; reversible tells whether a tour and
+// its reverse are the same
+func (a *tourArchive) offer(ac *AntColony, tour []int, length float64, reversible bool) {
+	if a.k <= 0 || math.IsInf(length, 0) || math.IsNaN(length) || (len(a.tours) == a.k && length >= a.tours[a.k-1].Length) {
+		return
+	}
+	key := ac.tourKey(tour, reversible)
+	if slices.Contains(a.keys, key) {
+		return
+	}
+	i := sort.Search(len(a.tours), func(i int) bool { return a.tours[i].Length > length })
+	a.tours = slices.Insert(a.tours, i, AlternativeTour{Tour: slices.Clone(tour), Length: length})
+	a.keys = slices.Insert(a.keys, i, key)
+	if len(a.tours) > a.k {
+		a.tours, a.keys = a.tours[:a.k], a.keys[:a.k]
+	}
+}
+
+// tourKey identifies the edges a tour takes, the same for its rotations and,
+// when reversible, its reflections
+func (ac *AntColony) tourKey(tour []int, reversible bool) string {
+	return fmt.Sprint(canonicalTour(tour, ac.ReturnToStart, reversible))
+}
+
+// CanonicalTour returns the form in which the colony reports a tour, so that
+// the same tour reads the same across runs, seeds and machines: closed tours
+// start at their lowest city, city 0 in a full tour, and on symmetric
+// instances tours run in the direction that reads lexicographically smaller.
+// Tours whose cost depends on where they start or which way they run, under
+// constraints, time-dependent or stochastic costs, are returned unchanged
+func (ac *AntColony) CanonicalTour(tour []int) []int {
+	if !ac.Constraints.empty() || ac.TimeCost != nil || ac.Stochastic != nil {
+		return slices.Clone(tour)
+	}
+	return canonicalTour(tour, ac.ReturnToStart, len(ac.Objectives) == 0 && ac.symmetric())
+}
+
+// canonicalTour returns a copy of tour read from its lowest city if closed,
+// and if reversible in the direction whose second city, or for open tours
+// whose first, is the lower
+func canonicalTour(tour []int, closed, reversible bool) []int {
+	t := slices.Clone(tour)
+	switch {
+	case len(t) < 2:
+	case closed:
+		first := slices.Index(t, slices.Min(t))
+		t = append(t[first:], t[:first]...)
+		if reversible && len(t) > 2 && t[len(t)-1] < t[1] {
+			slices.Reverse(t[1:])
+		}
+	case reversible && t[len(t)-1] < t[0]:
+		slices.Reverse(t)
+	}
+	return t
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// TourFrame is the best tour at one iteration of a run
+type TourFrame struct {
+	Iteration int
+	Tour      []int
+	Length    float64
+}
+
+// RecordFrames runs the solver to the end, keeping the best tour after every
+// every-th iteration and after the last one
+func RecordFrames(solver *Solver, every int) []TourFrame {
+	var frames []TourFrame
+	for !solver.Done() {
+		stats := solver.Step()
+		if done := stats.Iteration + 1; done%every == 0 || solver.Done() {
+			solution := solver.Solution()
+			frames = append(frames, TourFrame{Iteration: done, Tour: solution.Tour, Length: solution.Length})
+		}
+	}
+	return frames
+}
+
+// drawFrame draws a frame's tour captioned with its iteration and length
+func drawFrame(cities []*City, frame TourFrame, options RenderOptions) *image.RGBA {
+	img := drawTour(cities, frame.Tour, options)
+	drawText(img, 4, 4, fmt.Sprintf("iteration %d  length %.6g", frame.Iteration, frame.Length), labelColor)
+	return img
+}
+
+// WriteTourGIF writes the frames as an animated GIF showing the tour
+// improve, delay hundredths of a second apart, holding the last frame longer
+func WriteTourGIF(w io.Writer, cities []*City, frames []TourFrame, delay int, options RenderOptions) error {
+	palette := color.Palette{color.White, routeColor, cityColor, labelColor}
+	animation := &gif.GIF{}
+	for k, frame := range frames {
+		img := drawFrame(cities, frame, options)
+		paletted := image.NewPaletted(img.Bounds(), palette)
+		draw.Draw(paletted, img.Bounds(), img, image.Point{}, draw.Src)
+		animation.Image = append(animation.Image, paletted)
+		if k == len(frames)-1 {
+			animation.Delay = append(animation.Delay, 10*delay)
+		} else {
+			animation.Delay = append(animation.Delay, delay)
+		}
+	}
+	return gif.EncodeAll(w, animation)
+}
+
+// WriteTourFrames writes the frames as numbered PNG images in dir, frame-0001.png
+// and on, which ffmpeg -i dir/frame-%04d.png turns into a video
+func WriteTourFrames(dir string, cities []*City, frames []TourFrame, options RenderOptions) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for k, frame := range frames {
+		path := filepath.Join(dir, fmt.Sprintf("frame-%04d.png", k+1))
+		if err := writeFile(path, func(w io.Writer) error {
+			return png.Encode(w, drawFrame(cities, frame, options))
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Run states reported by the REST API
+const (
+	RunQueued    = "queued"
+	RunRunning   = "running"
+	RunDone      = "done"
+	RunCancelled = "cancelled"
+)
+
+// maxUploadBytes caps the size of an uploaded instance
+const maxUploadBytes = 64 << 20
+
+// APIServer lets other programs upload instances, start and cancel runs on
+// them, poll their progress and fetch their solutions over HTTP. Runs beyond
+// MaxRuns at once wait their turn
+type APIServer struct {
+	MaxRuns int
+	// Metrics, if set, records the progress of the runs as "api/" and their id
+	Metrics *Metrics
+	// Tracer, if set, traces the runs under the traceparent of their request
+	Tracer *Tracer
+	// Events, if set, receives the events of the runs, named like their metrics
+	Events RunListener
+	// Store, if set, keeps the instances and runs across restarts
+	Store *JobStore
+	// Archive, if set, receives the report of every run that finishes
+	Archive *RunArchive
+
+	mu        sync.Mutex
+	instances map[string]*Instance
+	runs      map[string]*apiRun
+	lastID    int
+	slots     chan struct{}
+}
+
+// apiRun is a run made through the API. Its fields after mu are guarded by it
+type apiRun struct {
+	id       string
+	instance string
+	config   Config
+	// ctx is done once the run is cancelled
+	ctx    context.Context
+	cancel context.CancelFunc
+	// solver runs the run, nil for finished runs restored from a previous server
+	solver *Solver
+
+	mu         sync.Mutex
+	state      string
+	iteration  int
+	best       float64
+	startedAt  time.Time
+	finishedAt time.Time
+	solution   *Solution
+}
+
+// apiInstance describes an uploaded instance
+type apiInstance struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Cities      int    `json:"cities"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// apiRunStatus is a run's progress as the API reports it; Best is null until
+// a feasible tour is found
+type apiRunStatus struct {
+	ID         string   `json:"id"`
+	Instance   string   `json:"instance"`
+	State      string   `json:"state"`
+	Iteration  int      `json:"iteration"`
+	Iterations int      `json:"iterations"`
+	Best       *float64 `json:"best"`
+	Seconds    float64  `json:"seconds"`
+	Config     Config   `json:"config"`
+}
+
+// NewAPIServer returns a server with no instances, running up to maxRuns at
+// once, or one per CPU if maxRuns is 0
+func NewAPIServer(maxRuns int) *APIServer {
+	if maxRuns <= 0 {
+		maxRuns = runtime.NumCPU()
+	}
+	return &APIServer{
+		MaxRuns:   maxRuns,
+		instances: make(map[string]*Instance),
+		runs:      make(map[string]*apiRun),
+		slots:     make(chan struct{}, maxRuns),
+	}
+}
+
+// Handler routes the API:
+//
+//	POST /instances                upload an instance as JSON, TSPLIB, CSV or GeoJSON; ?format= overrides detection
+//	GET  /instances                list the uploaded instances
+//	GET  /instances/{id}           describe an instance
+//	POST /runs                     start a run, {"instance": id, "config": {parameters}}
+//	GET  /runs                     list the runs
+//	GET  /runs/{id}                poll a run
+//	PATCH /runs/{id}/parameters    change rho, beta, q0, local_search or search of a run from its next iteration
+//	POST /runs/{id}/cancel         cancel a run, also DELETE /runs/{id}
+//	GET  /runs/{id}/solution       fetch the solution of a finished or cancelled run
+func (s *APIServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /instances", s.uploadInstance)
+	mux.HandleFunc("GET /instances", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		list := make([]apiInstance, 0, len(s.instances))
+		for id, instance := range s.instances {
+			list = append(list, describeInstance(id, instance))
+		}
+		s.mu.Unlock()
+		sort.Slice(list, func(a, b int) bool { return idLess(list[a].ID, list[b].ID) })
+		writeAPIJSON(w, http.StatusOK, list)
+	})
+	mux.HandleFunc("GET /instances/{id}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		instance, ok := s.instances[r.PathValue("id")]
+		s.mu.Unlock()
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, fmt.Errorf("no instance %q", r.PathValue("id")))
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, describeInstance(r.PathValue("id"), instance))
+	})
+	mux.HandleFunc("POST /runs", s.startRun)
+	mux.HandleFunc("GET /runs", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		list := make([]apiRunStatus, 0, len(s.runs))
+		for _, run := range s.runs {
+			list = append(list, run.status())
+		}
+		s.mu.Unlock()
+		sort.Slice(list, func(a, b int) bool { return idLess(list[a].ID, list[b].ID) })
+		writeAPIJSON(w, http.StatusOK, list)
+	})
+	mux.HandleFunc("GET /runs/{id}", s.withRun(func(w http.ResponseWriter, run *apiRun) {
+		writeAPIJSON(w, http.StatusOK, run.status())
+	}))
+	cancel := s.withRun(func(w http.ResponseWriter, run *apiRun) {
+		run.stop()
+		writeAPIJSON(w, http.StatusAccepted, run.status())
+	})
+	mux.HandleFunc("PATCH /runs/{id}/parameters", s.reloadRun)
+	mux.HandleFunc("POST /runs/{id}/cancel", cancel)
+	mux.HandleFunc("DELETE /runs/{id}", cancel)
+	mux.HandleFunc("GET /runs/{id}/solution", s.withRun(func(w http.ResponseWriter, run *apiRun) {
+		run.mu.Lock()
+		solution := run.solution
+		run.mu.Unlock()
+		if solution == nil {
+			writeAPIError(w, http.StatusConflict, errors.New("run has not finished"))
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, solution)
+	}))
+	return mux
+}
+
+// uploadInstance stores the instance in the request body
+func (s *APIServer) uploadInstance(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxUploadBytes))
+	if err != nil {
+		writeAPIError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = DetectFormat("", data)
+	}
+	instance, err := parseInstance(format, data)
+	if err == nil {
+		err = instance.Validate()
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.mu.Lock()
+	id := s.newID()
+	s.mu.Unlock()
+	if s.Store != nil {
+		if err := s.Store.SaveInstance(id, instance); err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	s.mu.Lock()
+	s.instances[id] = instance
+	s.mu.Unlock()
+	w.Header().Set("Location", "/instances/"+id)
+	writeAPIJSON(w, http.StatusCreated, describeInstance(id, instance))
+}
+
+// startRun starts a run on an uploaded instance. Parameters missing from the
+// request take the instance's options, or those AutoConfig recommends
+func (s *APIServer) startRun(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Instance string          `json:"instance"`
+		Config   json.RawMessage `json:"config"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUploadBytes)).Decode(&request); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.mu.Lock()
+	instance, ok := s.instances[request.Instance]
+	s.mu.Unlock()
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no instance %q", request.Instance))
+		return
+	}
+	config, err := instanceConfig(instance, func(base Config) (Config, error) {
+		if len(request.Config) == 0 {
+			return base, nil
+		}
+		type plain Config
+		err := json.Unmarshal(request.Config, (*plain)(&base))
+		return base, err
+	}, true)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if config.Seed == 0 {
+		// Fix the seed so the solution says how to reproduce the run
+		config.Seed = time.Now().UnixNano()
+	}
+	solver, err := NewSolver(instance, config)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	solver.Tracer, solver.TraceParent = s.Tracer, SpanFromTraceParent(r.Header.Get("traceparent"))
+	s.mu.Lock()
+	run := &apiRun{id: s.newID(), instance: request.Instance, config: config, solver: solver, state: RunQueued, best: math.Inf(1)}
+	run.ctx, run.cancel = context.WithCancel(context.Background())
+	s.runs[run.id] = run
+	s.mu.Unlock()
+	solver.Events, solver.EventRun = s.Events, "api/"+run.id
+	s.persist(run)
+	go s.solve(run, solver)
+	w.Header().Set("Location", "/runs/"+run.id)
+	writeAPIJSON(w, http.StatusAccepted, run.status())
+}
+
+// reloadRun changes the parameters of a queued or running run, those
+// ParameterUpdate lists, from its next iteration
+func (s *APIServer) reloadRun(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	run, ok := s.runs[r.PathValue("id")]
+	s.mu.Unlock()
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no run %q", r.PathValue("id")))
+		return
+	}
+	var update ParameterUpdate
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUploadBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&update); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	run.mu.Lock()
+	if run.solver == nil || (run.state != RunQueued && run.state != RunRunning) {
+		run.mu.Unlock()
+		writeAPIError(w, http.StatusConflict, errors.New("run has finished"))
+		return
+	}
+	config := update.Apply(run.config)
+	err := run.solver.Reload(config)
+	if err == nil {
+		run.config = config
+	}
+	run.mu.Unlock()
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusAccepted, run.status())
+}
+
+// solve waits for a free slot and runs the solver until it finishes or is cancelled
+func (s *APIServer) solve(run *apiRun, solver *Solver) {
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-run.ctx.Done():
+		run.finish(RunCancelled, solver.Solution())
+		s.persist(run)
+		return
+	}
+	run.mu.Lock()
+	run.state, run.startedAt = RunRunning, time.Now()
+	run.mu.Unlock()
+	s.persist(run)
+	defer s.Metrics.Finish("api/" + run.id)
+	defer solver.TraceRun()()
+	for !solver.Done() {
+		stats, err := solver.StepContext(run.ctx)
+		if err != nil {
+			solver.publish(EventRunFinished, RunCancelled)
+			run.finish(RunCancelled, solver.Solution())
+			s.persist(run)
+			return
+		}
+		s.Metrics.Observe("api/"+run.id, solver, stats)
+		run.mu.Lock()
+		run.iteration, run.best = stats.Iteration+1, stats.BestSoFar
+		run.mu.Unlock()
+	}
+	run.finish(RunDone, solver.Solution())
+	s.persist(run)
+	if s.Archive != nil {
+		s.mu.Lock()
+		instance := s.instances[run.instance]
+		s.mu.Unlock()
+		if err := s.Archive.Append(NewRunReport(instance, "/instances/"+run.instance, solver, run.startedAt)); err != nil {
+			slog.Error("archiving run", "run", run.id, "err", err)
+		}
+	}
+}
+
+// persist saves the run in the store, if the server has one
+func (s *APIServer) persist(run *apiRun) {
+	if s.Store == nil {
+		return
+	}
+	if err := s.Store.SaveRun(run.record()); err != nil {
+		slog.Error("saving run", "run", run.id, "err", err)
+	}
+}
+
+// withRun adapts a handler of the run named in the path, answering 404 for unknown runs
+func (s *APIServer) withRun(handle func(w http.ResponseWriter, run *apiRun)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		run, ok := s.runs[r.PathValue("id")]
+		s.mu.Unlock()
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, fmt.Errorf("no run %q", r.PathValue("id")))
+			return
+		}
+		handle(w, run)
+	}
+}
+
+// newID returns the next identifier for an instance or run; s.mu must be held
+func (s *APIServer) newID() string {
+	s.lastID++
+	return strconv.Itoa(s.lastID)
+}
+
+// idLess orders identifiers by when they were handed out
+func idLess(a, b string) bool {
+	return len(a) < len(b) || len(a) == len(b) && a < b
+}
+
+// stop asks the run to end; it is a no-op once the run has ended
+func (run *apiRun) stop() {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.state == RunQueued || run.state == RunRunning {
+		run.cancel()
+	}
+}
+
+// finish records the end of the run and releases its context
+func (run *apiRun) finish(state string, solution *Solution) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	run.state, run.solution, run.finishedAt = state, solution, time.Now()
+	run.cancel()
+}
+
+// status reports the run's progress
+func (run *apiRun) status() apiRunStatus {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	status := apiRunStatus{
+		ID:         run.id,
+		Instance:   run.instance,
+		State:      run.state,
+		Iteration:  run.iteration,
+		Iterations: run.config.iterationLimit(),
+		Best:       finite(run.best),
+		Config:     run.config,
+	}
+	switch {
+	case !run.finishedAt.IsZero() && !run.startedAt.IsZero():
+		status.Seconds = run.finishedAt.Sub(run.startedAt).Seconds()
+	case !run.startedAt.IsZero():
+		status.Seconds = time.Since(run.startedAt).Seconds()
+	}
+	return status
+}
+
+// describeInstance summarizes an uploaded instance
+func describeInstance(id string, instance *Instance) apiInstance {
+	return apiInstance{ID: id, Name: instance.Name, Cities: len(instance.Cities), Fingerprint: instance.Fingerprint()}
+}
+
+// writeAPIJSON writes v as the JSON body of a response with the given status
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeAPIError writes err as a JSON error response
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
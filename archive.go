@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// RunArchive is an append-only store of run reports, one JSON object per
+// line, for comparing experiments over time. A plain file keeps the solver
+// free of database drivers while staying easy to load into SQLite, DuckDB or
+// pandas for heavier analysis
+type RunArchive struct {
+	Path string
+}
+
+// Append adds a run's report to the archive, creating the file if needed
+func (a *RunArchive) Append(report *RunReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(a.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Runs returns the archived reports in the order they were added, keeping only
+// those accepted by keep if it is not nil. A run's ID is its 1-based position
+func (a *RunArchive) Runs(keep func(id int, report *RunReport) bool) (ids []int, reports []*RunReport, err error) {
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for id := 1; scanner.Scan(); id++ {
+		report := &RunReport{}
+		if err := json.Unmarshal(scanner.Bytes(), report); err != nil {
+			return nil, nil, fmt.Errorf("%s: run %d: %w", a.Path, id, err)
+		}
+		if keep == nil || keep(id, report) {
+			ids = append(ids, id)
+			reports = append(reports, report)
+		}
+	}
+	return ids, reports, scanner.Err()
+}
+
+// runsCommand implements "runs list", which prints archived runs as a table
+func runsCommand(args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return errors.New("usage: runs list [-db file] [-instance name]")
+	}
+	flags := flag.NewFlagSet("runs list", flag.ExitOnError)
+	dbPath := flags.String("db", "runs.jsonl", "read runs from this archive `file`")
+	instance := flags.String("instance", "", "list only runs on the instance with this `name`, source file or fingerprint prefix")
+	flags.Parse(args[1:])
+
+	archive := &RunArchive{Path: *dbPath}
+	ids, reports, err := archive.Runs(func(id int, report *RunReport) bool {
+		summary := report.Instance
+		return *instance == "" || summary.Name == *instance || summary.Source == *instance ||
+			strings.HasPrefix(summary.Fingerprint, *instance) || strings.HasPrefix(summary.Fingerprint, "sha256:"+*instance)
+	})
+	if err != nil {
+		return err
+	}
+	return writeRuns(os.Stdout, ids, reports)
+}
+
+// writeRuns prints one line per run with the parameters that tell runs apart
+func writeRuns(w io.Writer, ids []int, reports []*RunReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTARTED\tINSTANCE\tCITIES\tANTS\tALPHA\tBETA\tRHO\tITERATIONS\tSEED\tBEST")
+	for k, report := range reports {
+		name := report.Instance.Name
+		if name == "" {
+			name = report.Instance.Source
+		}
+		if name == "" {
+			fingerprint := strings.TrimPrefix(report.Instance.Fingerprint, "sha256:")
+			name = fingerprint[:min(12, len(fingerprint))]
+		}
+		s := report.Solution
+		best := "-"
+		if !math.IsInf(s.Length, 0) {
+			best = fmt.Sprint(s.Length)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%g\t%g\t%g\t%d\t%d\t%s\n",
+			ids[k], report.StartedAt.Format("2006-01-02 15:04:05"), name, report.Instance.Cities,
+			s.Config.NumAnts, s.Config.Alpha, s.Config.Beta, s.Config.Rho, s.Config.Iterations, s.Seed, best)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Terminal cells are about twice as tall as they are wide, so a map keeps
+// the instance's aspect ratio by using half as many rows as columns per unit
+const cellAspect = 2
+
+// mapGlyphs are the characters a text map is drawn with
+type mapGlyphs struct {
+	city, start                           rune
+	horizontal, vertical, rising, falling rune
+}
+
+var (
+	unicodeGlyphs = mapGlyphs{'●', '◆', '─', '│', '╱', '╲'}
+	asciiGlyphs   = mapGlyphs{'o', '@', '-', '|', '/', '\\'}
+)
+
+// WriteTextMap draws the cities and the tour through them as a grid of
+// characters at most width columns by height rows, in Unicode or plain ASCII.
+// The tour's first city is marked apart from the others
+func WriteTextMap(w io.Writer, cities []*City, tour []int, closed bool, width, height int, ascii bool) error {
+	glyphs := unicodeGlyphs
+	if ascii {
+		glyphs = asciiGlyphs
+	}
+	if len(cities) == 0 || width < 2 || height < 2 {
+		return nil
+	}
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, c := range cities {
+		minX, maxX = math.Min(minX, c.X), math.Max(maxX, c.X)
+		minY, maxY = math.Min(minY, c.Y), math.Max(maxY, c.Y)
+	}
+	spanX, spanY := math.Max(maxX-minX, 1e-9), math.Max(maxY-minY, 1e-9)
+	scale := math.Min(float64(width-1)/spanX, float64(height-1)*cellAspect/spanY)
+	cols := int(math.Round(spanX*scale)) + 1
+	rows := int(math.Round(spanY*scale/cellAspect)) + 1
+	cell := func(c *City) (int, int) {
+		return int(math.Round((c.X - minX) * scale)), rows - 1 - int(math.Round((c.Y-minY)*scale/cellAspect))
+	}
+
+	grid := make([][]rune, rows)
+	for r := range grid {
+		grid[r] = []rune(strings.Repeat(" ", cols))
+	}
+	route := tourRoute(cities, tour, closed)
+	for k := 1; k < len(route); k++ {
+		x0, y0 := cell(route[k-1])
+		x1, y1 := cell(route[k])
+		edge := edgeGlyph(x1-x0, y1-y0, glyphs)
+		steps := max(abs(x1-x0), abs(y1-y0))
+		for s := 1; s < steps; s++ {
+			x := x0 + int(math.Round(float64(s*(x1-x0))/float64(steps)))
+			y := y0 + int(math.Round(float64(s*(y1-y0))/float64(steps)))
+			grid[y][x] = edge
+		}
+	}
+	for _, c := range cities {
+		x, y := cell(c)
+		grid[y][x] = glyphs.city
+	}
+	if len(tour) > 0 {
+		x, y := cell(cities[tour[0]])
+		grid[y][x] = glyphs.start
+	}
+
+	var b strings.Builder
+	for _, row := range grid {
+		b.WriteString(strings.TrimRight(string(row), " "))
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// edgeGlyph returns the character that best follows a line dx columns
+// across and dy rows down
+func edgeGlyph(dx, dy int, glyphs mapGlyphs) rune {
+	// Compare slopes in screen units, where a row is cellAspect columns tall
+	angle := math.Atan2(float64(-dy*cellAspect), float64(dx)) * 180 / math.Pi
+	switch angle = math.Mod(angle+180, 180); {
+	case angle < 22.5 || angle >= 157.5:
+		return glyphs.horizontal
+	case angle < 67.5:
+		return glyphs.rising
+	case angle < 112.5:
+		return glyphs.vertical
+	default:
+		return glyphs.falling
+	}
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// terminalSize returns the columns and rows of the terminal, from the
+// COLUMNS and LINES variables or stty, or 80 by 24 if neither knows
+func terminalSize() (int, int) {
+	width, errW := strconv.Atoi(os.Getenv("COLUMNS"))
+	height, errH := strconv.Atoi(os.Getenv("LINES"))
+	if errW == nil && errH == nil && width > 0 && height > 0 {
+		return width, height
+	}
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	if out, err := cmd.Output(); err == nil {
+		if _, err := fmt.Sscan(string(out), &height, &width); err == nil && width > 0 && height > 0 {
+			return width, height
+		}
+	}
+	return 80, 24
+}
+
+// LiveMap redraws a text map of the best tour so far over the whole
+// terminal as a run progresses, with a status line under it
+type LiveMap struct {
+	w      io.Writer
+	cities []*City
+	closed bool
+	ascii  bool
+	total  int
+	drawn  float64
+}
+
+// NewLiveMap starts a live map of a run of total iterations
+func NewLiveMap(w io.Writer, cities []*City, closed, ascii bool, total int) *LiveMap {
+	return &LiveMap{w: w, cities: cities, closed: closed, ascii: ascii, total: total, drawn: math.Inf(1)}
+}
+
+// Update redraws the map when the solver's best tour got shorter, and on the
+// last iteration
+func (m *LiveMap) Update(solver *Solver, stats IterationStats) {
+	done := stats.Iteration + 1
+	if stats.BestSoFar >= m.drawn && done < m.total {
+		return
+	}
+	m.drawn = stats.BestSoFar
+	width, height := terminalSize()
+	fmt.Fprint(m.w, "\033[H\033[2J")
+	WriteTextMap(m.w, m.cities, solver.bestTour, m.closed, width, height-2, m.ascii)
+	fmt.Fprintf(m.w, "iteration %d/%d  best %.6g  diversity %.3f\n", done, m.total, stats.BestSoFar, stats.Diversity)
+}
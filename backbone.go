@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// BackboneEdge is an edge with how often the iteration-best tours took it
+type BackboneEdge struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	// Frequency is the share of the iterations whose best tour took the edge
+	Frequency float64 `json:"frequency"`
+}
+
+// edgeFrequency counts the iteration-best tours taking each edge, in both
+// directions on symmetric instances
+type edgeFrequency struct {
+	counts     map[[2]int]int
+	iterations int
+}
+
+// add counts the edges of an iteration's best tour
+func (f *edgeFrequency) add(ac *AntColony, tour []int, undirected bool) {
+	if f.counts == nil {
+		f.counts = make(map[[2]int]int)
+	}
+	f.iterations++
+	ac.forEachEdge(tour, func(i, j int) {
+		if undirected && j < i {
+			i, j = j, i
+		}
+		f.counts[[2]int{i, j}]++
+	})
+}
+
+// backbone returns the n edges the iteration-best tours took most often,
+// most frequent first
+func (f *edgeFrequency) backbone(n int) []BackboneEdge {
+	edges := make([]BackboneEdge, 0, len(f.counts))
+	for edge, count := range f.counts {
+		edges = append(edges, BackboneEdge{From: edge[0], To: edge[1], Frequency: float64(count) / float64(f.iterations)})
+	}
+	slices.SortFunc(edges, func(a, b BackboneEdge) int {
+		switch {
+		case a.Frequency != b.Frequency:
+			if a.Frequency > b.Frequency {
+				return -1
+			}
+			return 1
+		case a.From != b.From:
+			return a.From - b.From
+		}
+		return a.To - b.To
+	})
+	return edges[:min(n, len(edges))]
+}
+
+// BackboneMatrix returns the frequencies of the solution's backbone edges
+// as a matrix over n cities, the same in both directions, for drawing like
+// pheromone trails
+func (s *Solution) BackboneMatrix(n int) [][]float64 {
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+	}
+	for _, e := range s.Backbone {
+		if e.From < n && e.To < n {
+			matrix[e.From][e.To] = max(matrix[e.From][e.To], e.Frequency)
+			matrix[e.To][e.From] = max(matrix[e.To][e.From], e.Frequency)
+		}
+	}
+	return matrix
+}
+
+// backboneShare is the frequency above which the report counts an edge as
+// part of the backbone
+const backboneShare = 0.9
+
+// visualizeBackbone implements "visualize backbone", which runs the colony on
+// an instance and draws the edges its iteration-best tours took most often
+func visualizeBackbone(args []string) error {
+	flags := flag.NewFlagSet("visualize backbone", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	outPath := flags.String("o", "-", "write the image to this `file`, - for stdout")
+	format := flags.String("format", "", "output `format`, svg or png; by default from the -o file's extension, svg if unknown")
+	size := flags.Int("size", svgSize, "width and height of the image in `pixels`")
+	threshold := flags.Float64("threshold", pheromoneThreshold, "leave out edges taken by less than this `share` of the iteration-best tours")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s visualize backbone [flags] file\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Draws the edges of the instance the iteration-best tours took most often, thicker the more often.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("visualize: want exactly one instance file")
+	}
+	if *format == "" {
+		*format = renderFormat(*outPath)
+	}
+	if *threshold <= 0 || *threshold > 1 {
+		return fmt.Errorf("visualize: -threshold must be in (0, 1]")
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	config, err := instanceConfig(instance, parameters, true)
+	if err != nil {
+		return err
+	}
+	solver, err := NewSolver(instance, config)
+	if err != nil {
+		return err
+	}
+	solution := solver.Run()
+	options := RenderOptions{Format: *format, Size: *size, Threshold: *threshold}
+	return writeFile(*outPath, func(w io.Writer) error {
+		return RenderPheromones(w, instance.Cities, solution.BackboneMatrix(len(instance.Cities)), nil, PheromoneTrails, options)
+	})
+}
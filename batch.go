@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// batchResult is the outcome of solving one file of a directory
+type batchResult struct {
+	path    string
+	report  *RunReport
+	elapsed time.Duration
+	err     error
+}
+
+// solveDirectory solves every instance file in dir, recognized by extension,
+// parallel files at once, with the parameters configure returns for each and
+// the solvers set up by prepare, such as for logging. Each run's report is written to outDir as
+// <file>.json next to a summary.csv of all runs, and the summary is printed.
+// Files that fail to solve are reported without stopping the others
+func solveDirectory(dir, outDir string, parallel int, configure func(*Instance) (Config, error), prepare func(*Solver)) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if _, ok := formatExtensions[strings.ToLower(filepath.Ext(entry.Name()))]; ok && !entry.IsDir() {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("%s: no instance files", dir)
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+	if parallel <= 0 {
+		parallel = runtime.GOMAXPROCS(0)
+	}
+
+	results := make([]batchResult, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(parallel, len(paths)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range jobs {
+				results[k] = solveFile(paths[k], configure, prepare)
+			}
+		}()
+	}
+	for k := range paths {
+		jobs <- k
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			continue
+		}
+		reportPath := filepath.Join(outDir, filepath.Base(result.path)+".json")
+		if err := writeJSON(reportPath, result.report); err != nil {
+			return err
+		}
+	}
+	summaryPath := filepath.Join(outDir, "summary.csv")
+	if err := writeFile(summaryPath, func(w io.Writer) error {
+		return writeBatchSummaryCSV(w, results)
+	}); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tCITIES\tLENGTH\tGAP\tTIME")
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\tfailed: %v\n", filepath.Base(result.path), result.err)
+			continue
+		}
+		gap := "-"
+		if optimum, ok := KnownOptima[result.report.Instance.Name]; ok {
+			gap = fmt.Sprintf("%.2f%%", Gap(result.report.Solution.Length, optimum))
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.6g\t%s\t%s\n", filepath.Base(result.path), result.report.Instance.Cities,
+			result.report.Solution.Length, gap, result.elapsed.Round(time.Millisecond))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d instances failed", failed, len(paths))
+	}
+	return nil
+}
+
+// solveFile reads and solves one instance file of a batch
+func solveFile(path string, configure func(*Instance) (Config, error), prepare func(*Solver)) batchResult {
+	result := batchResult{path: path}
+	instance, err := ReadInstance(path, "")
+	if err != nil {
+		result.err = err
+		return result
+	}
+	config, err := configure(instance)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	solver, err := NewSolver(instance, config)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	solver.Logger = slog.With("path", path)
+	solver.EventRun = fmt.Sprintf("%s/%d", filepath.Base(path), solver.seed)
+	prepare(solver)
+	startedAt := time.Now()
+	solver.Run()
+	result.elapsed = time.Since(startedAt)
+	result.report = NewRunReport(instance, path, solver, startedAt)
+	return result
+}
+
+// writeBatchSummaryCSV writes a row per file of a batch
+func writeBatchSummaryCSV(w io.Writer, results []batchResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"file", "name", "cities", "length", "optimum", "gap", "seconds", "seed", "error"})
+	format := func(x float64) string { return strconv.FormatFloat(x, 'g', -1, 64) }
+	for _, result := range results {
+		if result.err != nil {
+			cw.Write([]string{filepath.Base(result.path), "", "", "", "", "", "", "", result.err.Error()})
+			continue
+		}
+		report := result.report
+		optimum, gap := "", ""
+		if o, ok := KnownOptima[report.Instance.Name]; ok {
+			optimum, gap = format(o), format(Gap(report.Solution.Length, o))
+		}
+		cw.Write([]string{
+			filepath.Base(result.path),
+			report.Instance.Name,
+			strconv.Itoa(report.Instance.Cities),
+			format(report.Solution.Length),
+			optimum,
+			gap,
+			format(result.elapsed.Seconds()),
+			strconv.FormatInt(report.Solution.Seed, 10),
+			"",
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// bayesCandidates is the number of random points whose expected improvement
+// is compared to choose each point of a Bayesian search
+const bayesCandidates = 1000
+
+// bayesSearch minimizes objective over the box spanned by the smallest and
+// largest values of each list of space in n evaluations: a few at random, then
+// each at the point a Gaussian process fitted to the results so far expects
+// to improve most on the best. It returns the points evaluated in order
+func bayesSearch(space [5][]float64, n int, rng *rand.Rand, objective func(point [5]float64) (float64, error)) ([][5]float64, error) {
+	var lo, hi [5]float64
+	for k, values := range space {
+		lo[k], hi[k] = values[0], values[0]
+		for _, v := range values {
+			lo[k], hi[k] = math.Min(lo[k], v), math.Max(hi[k], v)
+		}
+	}
+	// The process works on the unit cube, which makes one length scale fit every parameter
+	toPoint := func(u []float64) [5]float64 {
+		var point [5]float64
+		for k := range point {
+			point[k] = lo[k] + u[k]*(hi[k]-lo[k])
+		}
+		return point
+	}
+	random := func() []float64 {
+		u := make([]float64, 5)
+		for k := range u {
+			u[k] = rng.Float64()
+		}
+		return u
+	}
+
+	initial := max(3, n/4)
+	var xs [][]float64
+	var ys []float64
+	var points [][5]float64
+	for len(points) < n {
+		u := random()
+		if len(points) >= initial {
+			gp := fitGaussianProcess(xs, ys)
+			best := math.Inf(1)
+			for _, y := range ys {
+				best = math.Min(best, y)
+			}
+			bestEI := math.Inf(-1)
+			for range bayesCandidates {
+				candidate := random()
+				mu, sigma := gp.predict(candidate)
+				if ei := expectedImprovement(mu, sigma, best); ei > bestEI {
+					u, bestEI = candidate, ei
+				}
+			}
+		}
+		point := toPoint(u)
+		y, err := objective(point)
+		if err != nil {
+			return points, err
+		}
+		xs, ys, points = append(xs, u), append(ys, y), append(points, point)
+	}
+	return points, nil
+}
+
+// gaussianProcess is a Gaussian process regression with a squared
+// exponential kernel, fitted to standardized observations
+type gaussianProcess struct {
+	xs          [][]float64
+	lengthScale float64
+	// chol is the Cholesky factor of the kernel matrix plus noise, and weights
+	// solves it against the standardized observations
+	chol    [][]float64
+	weights []float64
+	mean    float64
+	scale   float64
+}
+
+// fitGaussianProcess fits a process to observations ys at xs in the unit cube
+func fitGaussianProcess(xs [][]float64, ys []float64) *gaussianProcess {
+	gp := &gaussianProcess{xs: xs, lengthScale: 0.3}
+	gp.mean, gp.scale = meanStddev(ys)
+	if gp.scale == 0 {
+		gp.scale = 1
+	}
+	n := len(xs)
+	kernel := make([][]float64, n)
+	for i := range kernel {
+		kernel[i] = make([]float64, n)
+		for j := range kernel[i] {
+			kernel[i][j] = gp.kernel(xs[i], xs[j])
+		}
+		kernel[i][i] += 1e-4
+	}
+	gp.chol = cholesky(kernel)
+	z := make([]float64, n)
+	for i, y := range ys {
+		z[i] = (y - gp.mean) / gp.scale
+	}
+	gp.weights = choleskySolve(gp.chol, z)
+	return gp
+}
+
+// kernel returns the covariance of the process at a and b
+func (gp *gaussianProcess) kernel(a, b []float64) float64 {
+	d := 0.0
+	for k := range a {
+		d += (a[k] - b[k]) * (a[k] - b[k])
+	}
+	return math.Exp(-d / (2 * gp.lengthScale * gp.lengthScale))
+}
+
+// predict returns the mean and standard deviation the process expects at x
+func (gp *gaussianProcess) predict(x []float64) (mu, sigma float64) {
+	k := make([]float64, len(gp.xs))
+	for i, xi := range gp.xs {
+		k[i] = gp.kernel(x, xi)
+		mu += k[i] * gp.weights[i]
+	}
+	v := forwardSubstitute(gp.chol, k)
+	variance := 1.0
+	for _, vi := range v {
+		variance -= vi * vi
+	}
+	return gp.mean + gp.scale*mu, gp.scale * math.Sqrt(math.Max(variance, 0))
+}
+
+// expectedImprovement returns how far below best a normal variable with mean
+// mu and standard deviation sigma is expected to fall
+func expectedImprovement(mu, sigma, best float64) float64 {
+	if sigma == 0 {
+		return math.Max(best-mu, 0)
+	}
+	z := (best - mu) / sigma
+	cdf := 0.5 * math.Erfc(-z/math.Sqrt2)
+	pdf := math.Exp(-z*z/2) / math.Sqrt(2*math.Pi)
+	return (best-mu)*cdf + sigma*pdf
+}
+
+// cholesky returns the lower triangular L with L Lᵀ = a, for a symmetric positive definite a
+func cholesky(a [][]float64) [][]float64 {
+	n := len(a)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+		for j := 0; j <= i; j++ {
+			sum := a[i][j]
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				l[i][i] = math.Sqrt(math.Max(sum, 1e-12))
+			} else {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+	return l
+}
+
+// forwardSubstitute solves L x = b for lower triangular L
+func forwardSubstitute(l [][]float64, b []float64) []float64 {
+	x := make([]float64, len(b))
+	for i := range b {
+		sum := b[i]
+		for k := 0; k < i; k++ {
+			sum -= l[i][k] * x[k]
+		}
+		x[i] = sum / l[i][i]
+	}
+	return x
+}
+
+// choleskySolve solves L Lᵀ x = b
+func choleskySolve(l [][]float64, b []float64) []float64 {
+	y := forwardSubstitute(l, b)
+	x := make([]float64, len(y))
+	for i := len(y) - 1; i >= 0; i-- {
+		sum := y[i]
+		for k := i + 1; k < len(y); k++ {
+			sum -= l[k][i] * x[k]
+		}
+		x[i] = sum / l[i][i]
+	}
+	return x
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// benchmarkCommand implements "benchmark", which solves every instance with
+// every variant over consecutive seeds and tabulates the distribution of the
+// best tour lengths and the time taken to reach a target length
+func benchmarkCommand(args []string) error {
+	flags := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	logging := logFlags(flags)
+	variantList := flags.String("variants", strings.Join(Variants, ","), "comma-separated `list` of variants to compare")
+	seeds := flags.Int("seeds", 5, "number of seeds to run each variant with")
+	flags.IntVar(seeds, "runs", 5, "same as -seeds")
+	parallel := flags.Int("parallel", 1, "number of seeds to run at once")
+	target := flags.Float64("target", 0, "time runs to reach this tour `length`, the known optimum if 0")
+	scaling := flags.Bool("scaling", false, "instead of comparing variants, time each with 1, 2, 4, ... workers up to -max-workers and report throughput and speedup")
+	maxWorkers := flags.Int("max-workers", runtime.GOMAXPROCS(0), "with -scaling, time up to this many workers")
+	suite := flags.String("suite", "", fmt.Sprintf("also run the instances of this built-in `suite`, one of %s", strings.Join(suiteNames(), ", ")))
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s benchmark [flags] file...\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "       %s benchmark -suite name [flags] [file...]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Compares variants on each instance, running seeds -seed, -seed+1, ... (1, 2, ... by default).")
+		fmt.Fprintln(flags.Output(), "TTT is the mean time to reach the target, over the runs that reached it. Confidence intervals")
+		fmt.Fprintln(flags.Output(), "of the means are bootstrapped from the runs. With -scaling, it times the variants with more and")
+		fmt.Fprintln(flags.Output(), "more workers instead, to tell how many pay off on this machine.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 && *suite == "" {
+		flags.Usage()
+		return fmt.Errorf("benchmark: no instance files or suite given")
+	}
+	if *seeds <= 0 {
+		return fmt.Errorf("benchmark: -seeds must be positive")
+	}
+	if *maxWorkers <= 0 {
+		return fmt.Errorf("benchmark: -max-workers must be positive")
+	}
+	variants, err := parseVariants(*variantList)
+	if err != nil {
+		return err
+	}
+	logEvery, err := logging()
+	if err != nil {
+		return err
+	}
+
+	var instances []*Instance
+	if *suite != "" {
+		if instances, err = LoadSuite(*suite); err != nil {
+			return err
+		}
+	}
+	paths := make([]string, len(instances))
+	for _, path := range flags.Args() {
+		instance, err := ReadInstance(path, "")
+		if err != nil {
+			return err
+		}
+		instances, paths = append(instances, instance), append(paths, path)
+	}
+
+	if *scaling {
+		return benchmarkScaling(os.Stdout, instances, paths, variants, parameters, *seeds, *maxWorkers)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "INSTANCE\tVARIANT\tSEEDS\tMIN\tMEAN\t%g%% CI\tMEDIAN\tSTDDEV\tGAP\tTIME\tTTT\n", 100*ConfidenceLevel)
+	for i, instance := range instances {
+		path := paths[i]
+		base := DefaultConfig()
+		if instance.Options != nil {
+			base = *instance.Options
+		}
+		if base, err = parameters(base); err != nil {
+			return err
+		}
+		name := instanceName(instance, path)
+		optimum, known := KnownOptima[instance.Name]
+		goal := *target
+		if goal == 0 && known {
+			goal = optimum
+		}
+		for _, variant := range variants {
+			experiment := &Experiment{
+				Instance: instance,
+				Config:   base,
+				Seeds:    *seeds,
+				Parallel: *parallel,
+				Target:   goal,
+				Logger:   slog.With("instance", name, "variant", variant),
+				LogEvery: logEvery,
+			}
+			experiment.Config.Variant = variant
+			stats, err := experiment.Run()
+			if err != nil {
+				return err
+			}
+			gap := "-"
+			if known {
+				gap = fmt.Sprintf("%.2f%%", Gap(stats.Min, optimum))
+			}
+			ttt := "-"
+			if goal > 0 && stats.Reached > 0 {
+				ttt = fmt.Sprintf("%s %s (%d/%d)", stats.TimeToTarget.Round(time.Millisecond), formatDurationCI(stats.TimeToTargetCI), stats.Reached, *seeds)
+			} else if goal > 0 {
+				ttt = fmt.Sprintf("- (0/%d)", *seeds)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%.6g\t%.6g\t%s\t%.6g\t%.4g\t%s\t%s\t%s\n", name, experiment.Config.Variant, *seeds,
+				stats.Min, stats.Mean, formatCI(stats.MeanCI), stats.Median, stats.Stddev, gap, stats.MeanTime.Round(time.Millisecond), ttt)
+		}
+	}
+	return tw.Flush()
+}
+
+// scalingWorkers returns the worker counts -scaling times: the powers of two
+// below most, and most
+func scalingWorkers(most int) []int {
+	var workers []int
+	for w := 1; w < most; w *= 2 {
+		workers = append(workers, w)
+	}
+	return append(workers, most)
+}
+
+// benchmarkScaling times every variant on every instance with each of the
+// scalingWorkers up to maxWorkers, over the same seeds, and tabulates the
+// tours built per second, the speedup over one worker and the efficiency,
+// speedup per worker. Results for a seed do not depend on the workers, so
+// only the times differ
+func benchmarkScaling(w io.Writer, instances []*Instance, paths []string, variants []string, parameters func(Config) (Config, error), seeds, maxWorkers int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INSTANCE\tVARIANT\tWORKERS\tTOURS/S\tSPEEDUP\tEFFICIENCY\tUTILIZATION")
+	for i, instance := range instances {
+		base := DefaultConfig()
+		if instance.Options != nil {
+			base = *instance.Options
+		}
+		base, err := parameters(base)
+		if err != nil {
+			return err
+		}
+		name := instanceName(instance, paths[i])
+		for _, variant := range variants {
+			serial := 0.0
+			for _, workers := range scalingWorkers(maxWorkers) {
+				var tours int
+				var timings Timings
+				for r := 0; r < seeds; r++ {
+					config := base
+					config.Variant, config.Workers = variant, workers
+					config.Seed = max(base.Seed, 1) + int64(r)
+					solver, err := NewSolver(instance, config)
+					if err != nil {
+						return err
+					}
+					tours += solver.Run().Evaluations
+					t := solver.Timings()
+					timings.Total += t.Total
+					timings.Construction += t.Construction
+					timings.WorkerBusy += t.WorkerBusy
+				}
+				throughput := float64(tours) / max(timings.Total.Seconds(), 1e-9)
+				if workers == 1 {
+					serial = throughput
+				}
+				speedup := throughput / serial
+				// Utilization is the share of the construction phase workers spent building tours
+				utilization := timings.WorkerBusy.Seconds() / max(timings.Construction.Seconds()*float64(workers), 1e-9)
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%.0f\t%.2fx\t%.0f%%\t%.0f%%\n", name, variant, workers,
+					throughput, speedup, 100*speedup/float64(workers), 100*utilization)
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+// instanceName names an instance in tables: its own name, or its file's
+func instanceName(instance *Instance, path string) string {
+	if instance.Name != "" {
+		return instance.Name
+	}
+	if path == "-" {
+		return "stdin"
+	}
+	return filepath.Base(path)
+}
+
+// meanStddev returns the mean and sample standard deviation of xs
+func meanStddev(xs []float64) (mean, stddev float64) {
+	for _, x := range xs {
+		mean += x / float64(len(xs))
+	}
+	if len(xs) < 2 {
+		return mean, 0
+	}
+	for _, x := range xs {
+		stddev += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(xs)-1))
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// prepareCandidateLists sorts every city's neighbours by distance, once, for
+// colonies with candidate lists, keeping only the CandidateList nearest so
+// the lists take memory by the cities times their length. Once the colony's
+// context is done it leaves the lists unprepared, to be sorted again next time
+func (ac *AntColony) prepareCandidateLists() {
+	if ac.CandidateList <= 0 || len(ac.nearest) == len(ac.Cities) {
+		return
+	}
+	start := time.Now()
+	defer func() { ac.listTime += time.Since(start) }()
+	n := len(ac.Cities)
+	k := min(ac.CandidateList, n-1)
+	ac.nearest = make([][]int, n)
+	// One row of distances and one order serve every city in turn
+	row := make([]float64, n)
+	order := make([]int, 0, n)
+	for i := range ac.nearest {
+		if ac.cancelled() {
+			ac.nearest = nil
+			return
+		}
+		order = order[:0]
+		for j := 0; j < n; j++ {
+			if j == i {
+				continue
+			}
+			row[j] = ac.distance(i, j)
+			if 2*k >= n {
+				order = append(order, j)
+				continue
+			}
+			// Short lists keep the k nearest so far, a later city going after
+			// an equally near one as a stable sort would place it
+			if len(order) == k && row[j] >= row[order[k-1]] {
+				continue
+			}
+			at := sort.Search(len(order), func(p int) bool { return row[order[p]] > row[j] })
+			if len(order) == k {
+				order = order[:k-1]
+			}
+			order = slices.Insert(order, at, j)
+		}
+		if 2*k >= n {
+			sort.SliceStable(order, func(a, b int) bool { return row[order[a]] < row[order[b]] })
+		}
+		ac.nearest[i] = slices.Clone(order[:k])
+	}
+}
+
+// nearCandidates returns the cities an ant at city from may move to that are
+// on from's candidate list, or all those it may move to when none is. Without
+// constraints only the list is scanned, which is what makes lists fast
+func (ac *AntColony) nearCandidates(ant *Ant, from int) []int {
+	if ac.CandidateList <= 0 {
+		return ac.candidates(ant)
+	}
+	var near []int
+	if ac.Constraints.empty() {
+		for _, city := range ac.nearest[from] {
+			if !ant.Visited[city] {
+				near = append(near, city)
+			}
+		}
+		// The move to the last city is no choice, whether on the list or not
+		choice := len(ant.Tour) < len(ac.Cities)-1
+		if choice {
+			ant.listMoves++
+		}
+		if len(near) > 0 {
+			return near
+		}
+		if choice {
+			ant.fallbacks++
+		}
+		return ac.candidates(ant)
+	}
+	candidates := ac.candidates(ant)
+	if len(candidates) == 1 {
+		return candidates
+	}
+	// Candidates come in city order, which the choice among them keeps
+	for _, city := range ac.nearest[from] {
+		if _, found := slices.BinarySearch(candidates, city); found {
+			near = append(near, city)
+		}
+	}
+	slices.Sort(near)
+	ant.listMoves++
+	if len(near) == 0 {
+		ant.fallbacks++
+		return candidates
+	}
+	return near
+}
+
+// candidateFallbacks returns the share of the ants' choices of a next city
+// for which their candidate lists held no city they could move to, so they
+// considered every city
+func candidateFallbacks(ants []*Ant) float64 {
+	moves, fallbacks := 0, 0
+	for _, ant := range ants {
+		moves += ant.listMoves
+		fallbacks += ant.fallbacks
+	}
+	if moves == 0 {
+		return 0
+	}
+	return float64(fallbacks) / float64(moves)
+}
+
+// growCandidateLists doubles the length of the colony's candidate lists, up
+// to every other city. The lists are sorted again before the next tours
+func (ac *AntColony) growCandidateLists() {
+	ac.CandidateList = min(2*ac.CandidateList, len(ac.Cities)-1)
+	ac.nearest = nil
+}
@@ -0,0 +1,193 @@
+//go:build cshared
+
+package main
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"unsafe"
+)
+
+// The C API of the shared library, for calling the solver from Python, R or
+// C++ through their foreign function interfaces. Build it with
+//
+//	go build -tags cshared -buildmode=c-shared -o libaco.so .
+//
+// which also writes libaco.h. Solvers are named by handles:
+//
+//	int     aco_api_version(void)                  1; raised only when calls change incompatibly
+//	int64_t aco_create(const char *config)         new solver, parameters as JSON like config files, the
+//	                                               rest recommended for the cities; NULL or "" for all
+//	int     aco_add_city(int64_t s, double x, double y)
+//	int     aco_run(int64_t s)                     run the remaining iterations on the cities added
+//	double  aco_tour_length(int64_t s)             length of the best closed tour, INFINITY before a run
+//	int     aco_get_tour(int64_t s, int *tour, int capacity)
+//	                                               copy up to capacity cities of the best tour, returning
+//	                                               its number of cities
+//	void    aco_free(int64_t s)
+//	const char *aco_last_error(void)               why the last failing call failed
+//
+// Calls returning int return -1 on failure and aco_create returns 0. A
+// solver must not be used from two threads at once; different solvers can.
+// See capi/ for examples in C and Python.
+
+// capiVersion is the version aco_api_version returns
+const capiVersion = 1
+
+// capiSolver is a solver being set up or run through the C API
+type capiSolver struct {
+	config json.RawMessage
+	cities []*City
+	solver *Solver
+}
+
+var capi struct {
+	mu        sync.Mutex
+	solvers   map[int64]*capiSolver
+	lastID    int64
+	lastError *C.char
+}
+
+// capiFail records err for aco_last_error and returns the failure code
+func capiFail(err error) C.int {
+	capi.mu.Lock()
+	defer capi.mu.Unlock()
+	if capi.lastError != nil {
+		C.free(unsafe.Pointer(capi.lastError))
+	}
+	capi.lastError = C.CString(err.Error())
+	return -1
+}
+
+// capiLookup returns the solver with handle s
+func capiLookup(s C.int64_t) (*capiSolver, error) {
+	capi.mu.Lock()
+	defer capi.mu.Unlock()
+	solver, ok := capi.solvers[int64(s)]
+	if !ok {
+		return nil, fmt.Errorf("no solver %d", int64(s))
+	}
+	return solver, nil
+}
+
+//export aco_api_version
+func aco_api_version() C.int {
+	return capiVersion
+}
+
+//export aco_create
+func aco_create(config *C.char) C.int64_t {
+	var raw json.RawMessage
+	if config != nil {
+		if s := C.GoString(config); s != "" {
+			if !json.Valid([]byte(s)) {
+				capiFail(errors.New("aco_create: config is not valid JSON"))
+				return 0
+			}
+			raw = json.RawMessage(s)
+		}
+	}
+	capi.mu.Lock()
+	defer capi.mu.Unlock()
+	if capi.solvers == nil {
+		capi.solvers = make(map[int64]*capiSolver)
+	}
+	capi.lastID++
+	capi.solvers[capi.lastID] = &capiSolver{config: raw}
+	return C.int64_t(capi.lastID)
+}
+
+//export aco_add_city
+func aco_add_city(s C.int64_t, x, y C.double) C.int {
+	solver, err := capiLookup(s)
+	if err != nil {
+		return capiFail(err)
+	}
+	if solver.solver != nil {
+		return capiFail(errors.New("aco_add_city: the solver has already run"))
+	}
+	solver.cities = append(solver.cities, &City{X: float64(x), Y: float64(y)})
+	return 0
+}
+
+//export aco_run
+func aco_run(s C.int64_t) C.int {
+	solver, err := capiLookup(s)
+	if err != nil {
+		return capiFail(err)
+	}
+	if solver.solver == nil {
+		instance := &Instance{Cities: solver.cities, ReturnToStart: true}
+		config, err := instanceConfig(instance, func(base Config) (Config, error) {
+			if len(solver.config) == 0 {
+				return base, nil
+			}
+			type plain Config
+			err := json.Unmarshal(solver.config, (*plain)(&base))
+			return base, err
+		}, true)
+		if err == nil {
+			solver.solver, err = NewSolver(instance, config)
+		}
+		if err != nil {
+			return capiFail(fmt.Errorf("aco_run: %w", err))
+		}
+	}
+	solver.solver.Run()
+	return 0
+}
+
+//export aco_tour_length
+func aco_tour_length(s C.int64_t) C.double {
+	solver, err := capiLookup(s)
+	if err != nil {
+		capiFail(err)
+		return C.double(math.Inf(1))
+	}
+	if solver.solver == nil {
+		return C.double(math.Inf(1))
+	}
+	return C.double(solver.solver.bestLength)
+}
+
+//export aco_get_tour
+func aco_get_tour(s C.int64_t, tour *C.int, capacity C.int) C.int {
+	solver, err := capiLookup(s)
+	if err != nil {
+		return capiFail(err)
+	}
+	if solver.solver == nil {
+		return 0
+	}
+	best := solver.solver.bestTour
+	if tour != nil && capacity > 0 {
+		out := unsafe.Slice(tour, int(capacity))
+		for i := range min(len(best), int(capacity)) {
+			out[i] = C.int(best[i])
+		}
+	}
+	return C.int(len(best))
+}
+
+//export aco_free
+func aco_free(s C.int64_t) {
+	capi.mu.Lock()
+	defer capi.mu.Unlock()
+	delete(capi.solvers, int64(s))
+}
+
+//export aco_last_error
+func aco_last_error() *C.char {
+	capi.mu.Lock()
+	defer capi.mu.Unlock()
+	return capi.lastError
+}
@@ -0,0 +1,64 @@
+"""Calls the solver of libaco from Python through ctypes.
+
+Build the library from the repository root with
+
+    go build -tags cshared -buildmode=c-shared -o libaco.so .
+
+then run this file, or import solve from it with ACO_LIBRARY naming the
+library if it is not libaco.so in the current directory.
+"""
+
+import ctypes
+import json
+import math
+import os
+import random
+
+_lib = ctypes.CDLL(os.environ.get("ACO_LIBRARY", "./libaco.so"))
+_lib.aco_api_version.restype = ctypes.c_int
+_lib.aco_create.argtypes = [ctypes.c_char_p]
+_lib.aco_create.restype = ctypes.c_int64
+_lib.aco_add_city.argtypes = [ctypes.c_int64, ctypes.c_double, ctypes.c_double]
+_lib.aco_add_city.restype = ctypes.c_int
+_lib.aco_run.argtypes = [ctypes.c_int64]
+_lib.aco_run.restype = ctypes.c_int
+_lib.aco_tour_length.argtypes = [ctypes.c_int64]
+_lib.aco_tour_length.restype = ctypes.c_double
+_lib.aco_get_tour.argtypes = [ctypes.c_int64, ctypes.POINTER(ctypes.c_int), ctypes.c_int]
+_lib.aco_get_tour.restype = ctypes.c_int
+_lib.aco_free.argtypes = [ctypes.c_int64]
+_lib.aco_last_error.restype = ctypes.c_char_p
+
+if _lib.aco_api_version() != 1:
+    raise ImportError("unexpected libaco API version %d" % _lib.aco_api_version())
+
+
+def _error():
+    return RuntimeError(_lib.aco_last_error().decode())
+
+
+def solve(cities, **config):
+    """Returns the best tour of the (x, y) cities and its length, with
+    config holding parameters as in config files, like iterations=200."""
+    s = _lib.aco_create(json.dumps(config).encode() if config else None)
+    if s == 0:
+        raise _error()
+    try:
+        for x, y in cities:
+            if _lib.aco_add_city(s, x, y) != 0:
+                raise _error()
+        if _lib.aco_run(s) != 0:
+            raise _error()
+        tour = (ctypes.c_int * len(cities))()
+        n = _lib.aco_get_tour(s, tour, len(cities))
+        return list(tour[:n]), _lib.aco_tour_length(s)
+    finally:
+        _lib.aco_free(s)
+
+
+if __name__ == "__main__":
+    rng = random.Random(1)
+    cities = [(rng.uniform(0, 1000), rng.uniform(0, 1000)) for _ in range(30)]
+    tour, length = solve(cities, iterations=200, seed=1)
+    assert not math.isinf(length)
+    print("length %.2f: %s" % (length, " ".join(map(str, tour))))
@@ -0,0 +1,42 @@
+/*
+ * Solves a small random instance through libaco. Build the library and this
+ * example from the repository root with
+ *
+ *   go build -tags cshared -buildmode=c-shared -o libaco.so .
+ *   cc -I. -o example capi/example.c -L. -laco
+ *   LD_LIBRARY_PATH=. ./example
+ */
+#include <stdio.h>
+#include <stdlib.h>
+
+#include "libaco.h"
+
+int main(void) {
+	if (aco_api_version() != 1) {
+		fprintf(stderr, "unexpected libaco API version %d\n", aco_api_version());
+		return 1;
+	}
+	int64_t s = aco_create("{\"iterations\": 200, \"seed\": 1}");
+	if (s == 0) {
+		fprintf(stderr, "aco_create: %s\n", aco_last_error());
+		return 1;
+	}
+	srand(1);
+	for (int i = 0; i < 30; i++) {
+		aco_add_city(s, rand() % 1000, rand() % 1000);
+	}
+	if (aco_run(s) != 0) {
+		fprintf(stderr, "%s\n", aco_last_error());
+		aco_free(s);
+		return 1;
+	}
+	int tour[30];
+	int n = aco_get_tour(s, tour, 30);
+	printf("length %.2f:", aco_tour_length(s));
+	for (int i = 0; i < n && i < 30; i++) {
+		printf(" %d", tour[i]);
+	}
+	printf("\n");
+	aco_free(s);
+	return 0;
+}
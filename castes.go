@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Built-in castes of Config.Castes
+const (
+	// CasteStandard ants choose by the colony's parameters
+	CasteStandard = "standard"
+	// CasteGreedy ants, scouts, always take the edge the heuristic likes best,
+	// ignoring the trails
+	CasteGreedy = "greedy"
+	// CasteRandom ants, explorers, move to any allowed city with equal
+	// probability
+	CasteRandom = "random"
+)
+
+// CasteNames lists the built-in castes; a caste may also be named by its
+// alpha, beta and q0 separated by colons
+var CasteNames = []string{CasteStandard, CasteGreedy, CasteRandom}
+
+// builtinCastes holds the choice parameters of the built-in castes but
+// CasteStandard, whose ants have none of their own
+var builtinCastes = map[string]antParameters{
+	CasteGreedy: {Alpha: 0, Beta: 1, Q0: 1},
+	CasteRandom: {Alpha: 0, Beta: 0, Q0: 0},
+}
+
+// Caste is a share of a colony's ants choosing their moves by parameters of
+// their own
+type Caste struct {
+	Name  string
+	Share float64
+	// params are the caste's choice parameters, nil for CasteStandard
+	params *antParameters
+}
+
+// parseCastes reads Config.Castes, "caste=share" pairs separated by commas,
+// in order. A caste is one of CasteNames or "alpha:beta:q0"
+func parseCastes(castes string) ([]Caste, error) {
+	if strings.TrimSpace(castes) == "" {
+		return nil, nil
+	}
+	var parsed []Caste
+	for _, pair := range strings.Split(castes, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("caste %q is not caste=share", strings.TrimSpace(pair))
+		}
+		share, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || share <= 0 || math.IsInf(share, 0) {
+			return nil, fmt.Errorf("share of caste %s: %q is not a positive number", name, strings.TrimSpace(value))
+		}
+		if slices.ContainsFunc(parsed, func(c Caste) bool { return c.Name == name }) {
+			return nil, fmt.Errorf("caste %s given twice", name)
+		}
+		caste := Caste{Name: name, Share: share}
+		if params, ok := builtinCastes[name]; ok {
+			caste.params = &params
+		} else if name != CasteStandard {
+			if caste.params, err = parseCasteParameters(name); err != nil {
+				return nil, err
+			}
+		}
+		parsed = append(parsed, caste)
+	}
+	return parsed, nil
+}
+
+// parseCasteParameters reads a caste named by its "alpha:beta:q0"
+func parseCasteParameters(name string) (*antParameters, error) {
+	fields := strings.Split(name, ":")
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unknown caste %q, want one of %v or alpha:beta:q0", name, CasteNames)
+	}
+	var values [3]float64
+	for k, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("caste %s: %q is not a non-negative number", name, field)
+		}
+		values[k] = v
+	}
+	if values[2] > 1 {
+		return nil, fmt.Errorf("caste %s: q0 must be in [0, 1], got %g", name, values[2])
+	}
+	return &antParameters{Alpha: values[0], Beta: values[1], Q0: values[2]}, nil
+}
+
+// assignCastes gives every ant of the colony the parameters of its caste,
+// the castes taking consecutive ants by CasteSizes
+func (ac *AntColony) assignCastes() {
+	ac.casteParams = make([]*antParameters, 0, ac.NumAnts)
+	for k, size := range ac.CasteSizes() {
+		for range size {
+			ac.casteParams = append(ac.casteParams, ac.Castes[k].params)
+		}
+	}
+}
+
+// CasteSizes returns how many of the colony's ants each of its castes
+// holds, in order, in proportion to their shares, rounded; nil without castes
+func (ac *AntColony) CasteSizes() []int {
+	if len(ac.Castes) == 0 {
+		return nil
+	}
+	total := 0.0
+	for _, c := range ac.Castes {
+		total += c.Share
+	}
+	sizes := make([]int, len(ac.Castes))
+	start, cumulative := 0, 0.0
+	for k, c := range ac.Castes {
+		cumulative += c.Share
+		end := int(math.Round(cumulative / total * float64(ac.NumAnts)))
+		sizes[k], start = end-start, end
+	}
+	return sizes
+}
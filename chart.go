@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// chartPalette colours the series of a chart in turn
+var chartPalette = []color.RGBA{
+	{0x1f, 0x77, 0xb4, 0xff},
+	{0xd6, 0x27, 0x28, 0xff},
+	{0x2c, 0xa0, 0x2c, 0xff},
+	{0xff, 0x7f, 0x0e, 0xff},
+	{0x94, 0x67, 0xbd, 0xff},
+	{0x8c, 0x56, 0x4b, 0xff},
+	{0xe3, 0x77, 0xc2, 0xff},
+	{0x7f, 0x7f, 0x7f, 0xff},
+}
+
+// Chart layout: the plot area's margins, in pixels
+const (
+	chartLeft   = 80
+	chartRight  = 20
+	chartTop    = 20
+	chartBottom = 50
+	chartTicks  = 5
+)
+
+// ConvergenceSeries is the history of one run, named in the chart's legend
+type ConvergenceSeries struct {
+	Name    string
+	History []IterationStats
+}
+
+// ChartOptions controls how RenderConvergence draws a chart
+type ChartOptions struct {
+	// Format is "svg" or "png", svg if empty
+	Format        string
+	Width, Height int
+	// Mean adds each run's mean tour length per iteration as a dashed line
+	Mean bool
+}
+
+// chartLine is a polyline of a chart in pixels
+type chartLine struct {
+	points [][2]float64
+	color  color.RGBA
+	dashed bool
+}
+
+// chartText is a label of a chart, anchored at its start unless right aligned
+type chartText struct {
+	x, y  float64
+	text  string
+	color color.RGBA
+	right bool
+}
+
+// RenderConvergence draws the best tour length found so far against the
+// iteration for every series on shared axes
+func RenderConvergence(w io.Writer, series []ConvergenceSeries, options ChartOptions) error {
+	if options.Width == 0 {
+		options.Width = svgSize
+	}
+	if options.Height == 0 {
+		options.Height = svgSize * 3 / 4
+	}
+	if options.Width <= chartLeft+chartRight || options.Height <= chartTop+chartBottom {
+		return fmt.Errorf("chart size %dx%d is too small", options.Width, options.Height)
+	}
+
+	// Fit the axes to every finite value plotted
+	maxIteration := 1.0
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		for _, stats := range s.History {
+			maxIteration = math.Max(maxIteration, float64(stats.Iteration+1))
+			for _, v := range chartValues(stats, options.Mean) {
+				if !math.IsInf(v, 0) && !math.IsNaN(v) {
+					low, high = math.Min(low, v), math.Max(high, v)
+				}
+			}
+		}
+	}
+	if math.IsInf(low, 0) {
+		return fmt.Errorf("no tour lengths to plot")
+	}
+	if high == low {
+		high, low = high+1, low-1
+	}
+	plotWidth := float64(options.Width - chartLeft - chartRight)
+	plotHeight := float64(options.Height - chartTop - chartBottom)
+	project := func(iteration int, v float64) [2]float64 {
+		return [2]float64{
+			chartLeft + float64(iteration+1)/maxIteration*plotWidth,
+			chartTop + (high-v)/(high-low)*plotHeight,
+		}
+	}
+
+	black := color.RGBA{0, 0, 0, 0xff}
+	grey := color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	var lines []chartLine
+	var texts []chartText
+	bottom, right := chartTop+plotHeight, chartLeft+plotWidth
+	for t := 0; t <= chartTicks; t++ {
+		v := low + (high-low)*float64(t)/chartTicks
+		y := chartTop + (high-v)/(high-low)*plotHeight
+		lines = append(lines, chartLine{points: [][2]float64{{chartLeft, y}, {right, y}}, color: grey})
+		texts = append(texts, chartText{x: chartLeft - 6, y: y, text: strconv.FormatFloat(v, 'g', 5, 64), color: black, right: true})
+		iteration := maxIteration * float64(t) / chartTicks
+		x := chartLeft + iteration/maxIteration*plotWidth
+		texts = append(texts, chartText{x: x - 8, y: bottom + 16, text: strconv.Itoa(int(iteration)), color: black})
+	}
+	lines = append(lines, chartLine{points: [][2]float64{{chartLeft, chartTop}, {chartLeft, bottom}, {right, bottom}}, color: black})
+	texts = append(texts, chartText{x: right, y: bottom + 36, text: "iteration", color: black, right: true})
+	for k, s := range series {
+		c := chartPalette[k%len(chartPalette)]
+		var best, mean chartLine
+		best.color, mean.color, mean.dashed = c, c, true
+		for _, stats := range s.History {
+			if !math.IsInf(stats.BestSoFar, 0) {
+				best.points = append(best.points, project(stats.Iteration, stats.BestSoFar))
+			}
+			if options.Mean && !math.IsInf(stats.Mean, 0) && !math.IsNaN(stats.Mean) {
+				mean.points = append(mean.points, project(stats.Iteration, stats.Mean))
+			}
+		}
+		lines = append(lines, best, mean)
+		texts = append(texts, chartText{x: right - 10, y: chartTop + 14 + 16*float64(k), text: s.Name, color: c, right: true})
+	}
+
+	switch options.Format {
+	case "", "svg":
+		return writeChartSVG(w, options.Width, options.Height, lines, texts)
+	case "png":
+		img := newCanvas(options.Width, options.Height)
+		for _, line := range lines {
+			for i := 1; i < len(line.points); i++ {
+				a, b := line.points[i-1], line.points[i]
+				if line.dashed {
+					drawDashedLine(img, a[0], a[1], b[0], b[1], line.color)
+				} else {
+					drawLine(img, a[0], a[1], b[0], b[1], line.color)
+				}
+			}
+		}
+		for _, t := range texts {
+			x := t.x
+			if t.right {
+				x -= float64(len(t.text) * 4 * glyphScale)
+			}
+			drawText(img, int(x), int(t.y)-5, t.text, t.color)
+		}
+		return png.Encode(w, img)
+	}
+	return fmt.Errorf("unknown image format %q, want svg or png", options.Format)
+}
+
+// chartValues returns the values of an iteration a chart plots
+func chartValues(stats IterationStats, mean bool) []float64 {
+	if mean {
+		return []float64{stats.BestSoFar, stats.Mean}
+	}
+	return []float64{stats.BestSoFar}
+}
+
+// writeChartSVG writes the lines and texts of a chart as SVG
+func writeChartSVG(w io.Writer, width, height int, lines []chartLine, texts []chartText) error {
+	hex := func(c color.RGBA) string { return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B) }
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+	fmt.Fprintln(bw, `<rect width="100%" height="100%" fill="white"/>`)
+	for _, line := range lines {
+		if len(line.points) == 0 {
+			continue
+		}
+		dash := ""
+		if line.dashed {
+			dash = ` stroke-dasharray="6 4"`
+		}
+		fmt.Fprintf(bw, `<polyline fill="none" stroke="%s" stroke-width="1.5"%s points="`, hex(line.color), dash)
+		for k, p := range line.points {
+			if k > 0 {
+				bw.WriteByte(' ')
+			}
+			fmt.Fprintf(bw, "%.2f,%.2f", p[0], p[1])
+		}
+		fmt.Fprintln(bw, `"/>`)
+	}
+	for _, t := range texts {
+		anchor := "start"
+		if t.right {
+			anchor = "end"
+		}
+		fmt.Fprintf(bw, "<text x=\"%.2f\" y=\"%.2f\" font-size=\"12\" font-family=\"sans-serif\" fill=\"%s\" text-anchor=\"%s\" dominant-baseline=\"middle\">",
+			t.x, t.y, hex(t.color), anchor)
+		xml.EscapeText(bw, []byte(t.text))
+		fmt.Fprintln(bw, "</text>")
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// drawDashedLine draws a line in dashes of six pixels with gaps of four
+func drawDashedLine(img *image.RGBA, x0, y0, x1, y1 float64, c color.RGBA) {
+	length := math.Hypot(x1-x0, y1-y0)
+	for start := 0.0; start < length; start += 10 {
+		end := math.Min(start+6, length)
+		drawLine(img, x0+(x1-x0)*start/length, y0+(y1-y0)*start/length, x0+(x1-x0)*end/length, y0+(y1-y0)*end/length, c)
+	}
+}
+
+// LoadHistory reads the per-iteration statistics of a run from a history CSV
+// written by solve -history, or from a solution or run report JSON file
+func LoadHistory(path string) ([]IterationStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		history, err := ReadHistoryCSV(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return history, nil
+	}
+	var run struct {
+		History  []IterationStats `json:"history"`
+		Solution *Solution        `json:"solution"`
+	}
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if run.Solution != nil {
+		return run.Solution.History, nil
+	}
+	return run.History, nil
+}
+
+// ReadHistoryCSV reads statistics written by WriteHistoryCSV, matching columns by header
+func ReadHistoryCSV(r io.Reader) ([]IterationStats, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty history")
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"iteration", "best_so_far"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("history has no %s column", name)
+		}
+	}
+	history := make([]IterationStats, 0, len(records)-1)
+	for line, record := range records[1:] {
+		value := func(name string) (float64, error) {
+			i, ok := columns[name]
+			if !ok {
+				return math.NaN(), nil
+			}
+			return strconv.ParseFloat(record[i], 64)
+		}
+		var stats IterationStats
+		var errs [16]error
+		var iteration, evaluations float64
+		iteration, errs[0] = value("iteration")
+		stats.Iteration = int(iteration)
+		stats.Best, errs[1] = value("best")
+		stats.Mean, errs[2] = value("mean")
+		stats.Worst, errs[3] = value("worst")
+		stats.BestSoFar, errs[4] = value("best_so_far")
+		stats.Diversity, errs[5] = value("diversity")
+		stats.Branching, errs[6] = value("branching")
+		stats.Identical, errs[7] = value("identical")
+		stats.Median, errs[8] = value("median")
+		stats.StdDev, errs[9] = value("stddev")
+		if evaluations, errs[10] = value("evaluations"); !math.IsNaN(evaluations) {
+			stats.Evaluations = int(evaluations)
+		}
+		if q0, err := value("q0"); err != nil || !math.IsNaN(q0) {
+			stats.Q0, errs[11] = q0, err
+		}
+		if fallbacks, err := value("candidate_fallbacks"); err != nil || !math.IsNaN(fallbacks) {
+			stats.CandidateFallbacks, errs[12] = fallbacks, err
+		}
+		if trailMin, err := value("trail_min"); err != nil || !math.IsNaN(trailMin) {
+			stats.TrailMin, errs[13] = trailMin, err
+		}
+		if trailMax, err := value("trail_max"); err != nil || !math.IsNaN(trailMax) {
+			stats.TrailMax, errs[14] = trailMax, err
+		}
+		if clamped, err := value("clamped"); err != nil || !math.IsNaN(clamped) {
+			stats.Clamped, errs[15] = int(clamped), err
+		}
+		for _, err := range errs {
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line+2, err)
+			}
+		}
+		history = append(history, stats)
+	}
+	return history, nil
+}
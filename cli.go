@@ -0,0 +1,11 @@
+//go:build !(js && wasm)
+
+package main
+
+import "os"
+
+func main() {
+	if err := runCommand(os.Args[1:]); err != nil {
+		fatal(err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import "fmt"
+
+// AddCluster declares a group of cities that must be visited contiguously
+func (c *Constraints) AddCluster(cities ...int) {
+	c.Clusters = append(c.Clusters, cities)
+}
+
+// clusterOf returns the index of the cluster containing city, or -1
+func (c *Constraints) clusterOf(city int) int {
+	for k, cluster := range c.Clusters {
+		for _, member := range cluster {
+			if member == city {
+				return k
+			}
+		}
+	}
+	return -1
+}
+
+// AllowsCluster reports whether moving from city from to city keeps every
+// cluster contiguous: a started cluster must be finished before leaving it,
+// and a cluster cannot be re-entered once left
+func (c *Constraints) AllowsCluster(visited map[int]bool, from, city int) bool {
+	current, next := c.clusterOf(from), c.clusterOf(city)
+	if current >= 0 && current != next {
+		for _, member := range c.Clusters[current] {
+			if !visited[member] {
+				return false
+			}
+		}
+	}
+	if next >= 0 && next != current {
+		for _, member := range c.Clusters[next] {
+			if visited[member] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validateClusters checks that clusters are disjoint and refer to existing cities
+func (c *Constraints) validateClusters(n int) error {
+	seen := make(map[int]int)
+	for k, cluster := range c.Clusters {
+		for _, city := range cluster {
+			if city < 0 || city >= n {
+				return fmt.Errorf("cluster %d: city %d out of range [0, %d)", k, city, n)
+			}
+			if other, ok := seen[city]; ok {
+				return fmt.Errorf("city %d is in clusters %d and %d", city, other, k)
+			}
+			seen[city] = k
+		}
+	}
+	return nil
+}
+
+// interCluster reports whether the edge between cities i and j joins two different clusters
+func (ac *AntColony) interCluster(i, j int) (from, to int, ok bool) {
+	from, to = ac.Constraints.clusterOf(i), ac.Constraints.clusterOf(j)
+	return from, to, from >= 0 && to >= 0 && from != to
+}
+
+// pheromone returns the trail on the edge from city i to city j. Moves between
+// clusters follow the cluster-level trail, so the colony learns the order of
+// clusters separately from the order of cities inside them
+func (ac *AntColony) pheromone(i, j int) float64 {
+	if _, _, ok := ac.interCluster(i, j); !ok && ac.sparse != nil {
+		// Reading leaves untouched edges out of the sparse trails
+		return ac.sparse.get(i, j)
+	}
+	return *ac.trail(i, j)
+}
+
+// trail points at the pheromone level read by pheromone for the edge from city i to city j
+func (ac *AntColony) trail(i, j int) *float64 {
+	if from, to, ok := ac.interCluster(i, j); ok {
+		ac.ensureClusterPheromones()
+		return &ac.ClusterPheromones[from][to]
+	}
+	if ac.sparse != nil {
+		return ac.sparse.at(i, j)
+	}
+	return &ac.Pheromones[i][j]
+}
+
+// ensureClusterPheromones sizes the cluster-level trail matrix to the declared clusters
+func (ac *AntColony) ensureClusterPheromones() {
+	k := len(ac.Constraints.Clusters)
+	if len(ac.ClusterPheromones) == k {
+		return
+	}
+	ac.ClusterPheromones = make([][]float64, k)
+	for i := range ac.ClusterPheromones {
+		ac.ClusterPheromones[i] = make([]float64, k)
+	}
+}
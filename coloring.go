@@ -0,0 +1,176 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// GraphColoring is a graph coloring instance: assign each node a color so that
+// no two adjacent nodes share one, using as few colors as possible
+type GraphColoring struct {
+	Neighbors [][]int
+
+	// maxColors bounds the palette, a greedy coloring never needs more than max degree + 1
+	maxColors int
+}
+
+// NewGraphColoring builds a coloring instance on n nodes from undirected edges
+func NewGraphColoring(n int, edges [][2]int) (*GraphColoring, error) {
+	gc := &GraphColoring{Neighbors: make([][]int, n)}
+	for _, e := range edges {
+		u, v := e[0], e[1]
+		if u < 0 || u >= n || v < 0 || v >= n {
+			return nil, fmt.Errorf("edge %d-%d: node out of range [0, %d)", u, v, n)
+		}
+		if u == v {
+			return nil, fmt.Errorf("edge %d-%d: self-loops cannot be colored", u, v)
+		}
+		gc.Neighbors[u] = append(gc.Neighbors[u], v)
+		gc.Neighbors[v] = append(gc.Neighbors[v], u)
+	}
+	for _, neighbors := range gc.Neighbors {
+		if len(neighbors)+1 > gc.maxColors {
+			gc.maxColors = len(neighbors) + 1
+		}
+	}
+	return gc, nil
+}
+
+// Conflicts counts the edges whose endpoints share a color in coloring
+func (gc *GraphColoring) Conflicts(coloring []int) int {
+	conflicts := 0
+	for u, neighbors := range gc.Neighbors {
+		for _, v := range neighbors {
+			if u < v && coloring[u] == coloring[v] {
+				conflicts++
+			}
+		}
+	}
+	return conflicts
+}
+
+// PheromoneShape places pheromone on (node, color) pairs
+func (gc *GraphColoring) PheromoneShape() (rows, cols int) {
+	return len(gc.Neighbors), gc.maxColors
+}
+
+// NewConstruction starts with every node uncolored
+func (gc *GraphColoring) NewConstruction() Construction {
+	coloring := make([]int, len(gc.Neighbors))
+	for i := range coloring {
+		coloring[i] = -1
+	}
+	return &coloringConstruction{
+		graph:      gc,
+		coloring:   coloring,
+		saturation: make([]map[int]bool, len(gc.Neighbors)),
+		colorUsed:  make([]bool, gc.maxColors),
+	}
+}
+
+// coloringConstruction colors nodes in DSATUR order, the uncolored node with the
+// most distinctly colored neighbors first, letting ants choose among its legal colors
+type coloringConstruction struct {
+	graph      *GraphColoring
+	coloring   []int
+	saturation []map[int]bool
+	colorUsed  []bool
+	numColors  int
+	colored    int
+}
+
+// nextNode returns the uncolored node with the highest saturation, ties broken by degree
+func (c *coloringConstruction) nextNode() int {
+	best := -1
+	for node, color := range c.coloring {
+		if color >= 0 {
+			continue
+		}
+		if best < 0 || len(c.saturation[node]) > len(c.saturation[best]) ||
+			(len(c.saturation[node]) == len(c.saturation[best]) && len(c.graph.Neighbors[node]) > len(c.graph.Neighbors[best])) {
+			best = node
+		}
+	}
+	return best
+}
+
+func (c *coloringConstruction) Moves() []Move {
+	if c.colored == len(c.coloring) {
+		return nil
+	}
+	node := c.nextNode()
+	var moves []Move
+	for color := 0; color < c.graph.maxColors; color++ {
+		if c.saturation[node][color] {
+			continue
+		}
+		// Reusing a color costs nothing, opening a new one is discouraged
+		heuristic := 1.0
+		if !c.colorUsed[color] {
+			heuristic = 1 / float64(c.numColors+1)
+		}
+		moves = append(moves, Move{Row: node, Col: color, Heuristic: heuristic})
+	}
+	return moves
+}
+
+func (c *coloringConstruction) Apply(m Move) {
+	c.coloring[m.Row] = m.Col
+	c.colored++
+	if !c.colorUsed[m.Col] {
+		c.colorUsed[m.Col] = true
+		c.numColors++
+	}
+	for _, v := range c.graph.Neighbors[m.Row] {
+		if c.saturation[v] == nil {
+			c.saturation[v] = make(map[int]bool)
+		}
+		c.saturation[v][m.Col] = true
+	}
+}
+
+func (c *coloringConstruction) Cost() float64 {
+	return float64(c.numColors)
+}
+
+func (c *coloringConstruction) Solution() []int {
+	return append([]int(nil), c.coloring...)
+}
+
+// colorCommand colors the nodes of a graph with as few colors as it can
+func colorCommand(args []string) error {
+	flags := flag.NewFlagSet("color", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s color [flags] graph\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Colors the nodes of a DIMACS or edge-list graph, - for stdin, so that no edge joins two")
+		fmt.Fprintln(flags.Output(), "nodes of one color, using as few colors as it can. Arcs count as undirected edges. Of the")
+		fmt.Fprintln(flags.Output(), "parameters only -ants, -alpha, -beta, -rho, -q, -iterations and -seed apply.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("color: want exactly one graph file")
+	}
+	config, err := parameters(DefaultConfig())
+	if err != nil {
+		return err
+	}
+	graph, err := ReadGraph(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	gc, err := NewGraphColoring(len(graph.Arcs), graph.UndirectedEdges())
+	if err != nil {
+		return err
+	}
+	coloring, colors, err := solveProblem(gc, config)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Best coloring:", coloring)
+	fmt.Println("Colors:", colors)
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGraphColoring checks that seeded colonies color graphs of known
+// chromatic number properly and with that many colors
+func TestGraphColoring(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		graph     string
+		chromatic int
+	}{
+		{"odd cycle", "p edge 5 5\ne 1 2\ne 2 3\ne 3 4\ne 4 5\ne 5 1\n", 3},
+		{"complete", "p edge 4 6\ne 1 2\ne 1 3\ne 1 4\ne 2 3\ne 2 4\ne 3 4\n", 4},
+		{"bipartite", "0 3\n0 4\n1 3\n1 5\n2 4\n2 5\n", 2},
+		{"petersen", "p edge 10 15\ne 1 2\ne 2 3\ne 3 4\ne 4 5\ne 5 1\ne 1 6\ne 2 7\ne 3 8\ne 4 9\ne 5 10\ne 6 8\ne 8 10\ne 10 7\ne 7 9\ne 9 6\n", 3},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			graph, err := LoadGraph(strings.NewReader(c.graph))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gc, err := NewGraphColoring(len(graph.Arcs), graph.UndirectedEdges())
+			if err != nil {
+				t.Fatal(err)
+			}
+			config := DefaultConfig()
+			config.Seed = 1
+			coloring, colors, err := solveProblem(gc, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(coloring) != len(graph.Arcs) {
+				t.Fatalf("coloring %v of %d nodes", coloring, len(graph.Arcs))
+			}
+			used := make(map[int]bool)
+			for node, color := range coloring {
+				if color < 0 {
+					t.Errorf("node %d uncolored", node)
+				}
+				used[color] = true
+			}
+			if conflicts := gc.Conflicts(coloring); conflicts != 0 {
+				t.Errorf("coloring %v has %d conflicts", coloring, conflicts)
+			}
+			if len(used) != int(colors) {
+				t.Errorf("coloring %v uses %d colors, reported %g", coloring, len(used), colors)
+			}
+			if int(colors) != c.chromatic {
+				t.Errorf("%g colors, chromatic number %d", colors, c.chromatic)
+			}
+		})
+	}
+}
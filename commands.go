@@ -0,0 +1,246 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// command is a subcommand of the solver binary
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// commands lists the subcommands in the order usage shows them
+func commands() []command {
+	return []command{
+		{"solve", "solve an instance and export the best tour", solveCommand},
+		{"benchmark", "compare variants on instances over several seeds", benchmarkCommand},
+		{"compare", "compare variants side by side on one instance", compareCommand},
+		{"tune", "search alpha, beta, rho, ants and q0 by grid, random, bayes or race for the best parameters", tuneCommand},
+		{"sweep", "vary one parameter over a range and write quality per value as CSV", sweepCommand},
+		{"fuzz", "solve random instances with random parameters, checking invariants after every iteration", fuzzCommand},
+		{"regress", "check fixed-seed runs on the built-in instances against recorded tour lengths", regressCommand},
+		{"rtd", "measure the distribution of the time runs take to reach a target length", rtdCommand},
+		{"baseline", "compare the constructive heuristics that can seed the trails", baselineCommand},
+		{"landscape", "measure fitness-distance correlation and autocorrelation of an instance", landscapeCommand},
+		{"demo", "solve a built-in example instance while drawing the tour as it improves", demoCommand},
+		{"generate", "write a reproducible random instance", generateCommand},
+		{"matrix", "export the distance matrix of an instance, or attach one computed elsewhere", matrixCommand},
+		{"visualize", "draw a tour, convergence chart, trails, backbone or animation as an image", visualizeCommand},
+		{"report", "solve an instance and write the run as an HTML page", reportCommand},
+		{"improve", "shorten an existing tour by local search", improveCommand},
+		{"score", "report the length of a tour under exact, rounded and the instance's own costs", scoreCommand},
+		{"diff", "compare two tours: lengths, shared edges and the segments one is made of from the other", diffCommand},
+		{"diagnose", "count the crossing edges of a tour and the gain local search offers", diagnoseCommand},
+		{"serve", "run the solver behind a live web dashboard", serveCommand},
+		{"islands", "run colonies on several machines that exchange their best tours", islandsCommand},
+		{"worker", "build ants' tours for the colonies of solve -remote", workerCommand},
+		{"color", "color the nodes of a graph so that no edge joins two of one color", colorCommand},
+		{"jobshop", "schedule a job-shop or flow-shop instance, minimizing the makespan", jobShopCommand},
+		{"qap", "assign the facilities of a QAPLIB instance to locations", qapCommand},
+		{"setcover", "choose the cheapest sets covering an OR-Library set covering instance", setCoverCommand},
+		{"knapsack", "pack the items of an OR-Library knapsack instance for the most value", knapsackCommand},
+		{"repl", "edit an instance and run the colony interactively", replCommand},
+		{"runs", "list the runs archived with solve -db", runsCommand},
+	}
+}
+
+// runCommand runs the subcommand named by the first argument. Without one,
+// or when the first argument is a flag or file, it solves, as the binary did
+// before it had subcommands
+func runCommand(args []string) error {
+	if len(args) > 0 {
+		if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+			usage()
+			return nil
+		}
+		for _, c := range commands() {
+			if c.name == args[0] {
+				return c.run(args[1:])
+			}
+		}
+	}
+	return solveCommand(args)
+}
+
+// usage lists the subcommands
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: %s <command> [flags] [arguments]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands() {
+		fmt.Fprintf(out, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(out, "\nRun \"%s <command> -h\" for the flags of a command.\n", os.Args[0])
+}
+
+// instanceConfig returns the parameters to solve instance with: its own
+// options if it has them, AutoConfig for the variant the flags select if auto
+// is set, DefaultConfig otherwise, with the parameters applied over them
+func instanceConfig(instance *Instance, parameters func(Config) (Config, error), auto bool) (Config, error) {
+	base := DefaultConfig()
+	switch {
+	case instance.Options != nil:
+		base = *instance.Options
+	case auto:
+		chosen, err := parameters(base)
+		if err != nil {
+			return chosen, err
+		}
+		base = AutoConfig(instance, chosen.Variant)
+	}
+	return parameters(base)
+}
+
+// parameterFlags registers a flag for every Config parameter on flags, and a
+// -config flag naming a parameter file, and returns a function that applies
+// the file, then ACO_* environment variables, then the flags given on the
+// command line to a base config
+func parameterFlags(flags *flag.FlagSet) func(Config) (Config, error) {
+	defaults := DefaultConfig()
+	configPath := flags.String("config", os.Getenv("ACO_CONFIG"), "read parameters from this JSON, YAML or TOML `file`; ACO_* variables and flags override it")
+	numAnts := flags.Int("ants", defaults.NumAnts, "number of ants per iteration")
+	alpha := flags.Float64("alpha", defaults.Alpha, "weight of pheromone in edge selection")
+	beta := flags.Float64("beta", defaults.Beta, "weight of inverse distance in edge selection")
+	rho := flags.Float64("rho", defaults.Rho, "pheromone evaporation rate in (0, 1]")
+	q := flags.Float64("q", defaults.Q, "pheromone deposited by an ant, divided by its tour length")
+	iterations := flags.Int("iterations", defaults.Iterations, "number of iterations to run")
+	evaluations := flags.Int("evaluations", 0, "instead of -iterations, stop once the ants have built this many tours, for comparisons fair across machines; 0 for no budget")
+	seed := flags.Int64("seed", 0, "random `seed` for a reproducible run, 0 to seed from the clock")
+	variant := flags.String("variant", AntSystem, fmt.Sprintf("ACO `variant`, one of %s", strings.Join(Variants, ", ")))
+	q0 := flags.Float64("q0", defaults.Q0, "with -variant acs, probability of taking the most attractive edge")
+	q0Schedule := flags.String("q0-schedule", Q0Constant, fmt.Sprintf("with -variant acs, how q0 moves from -q0 to -q0-final over the run, one of %s", strings.Join(Q0Schedules, ", ")))
+	q0Final := flags.Float64("q0-final", 0, "with -q0-schedule, q0 at the last iteration")
+	workers := flags.Int("workers", 1, "number of ants building tours in parallel")
+	localSearch := flags.Bool("localsearch", false, "improve every ant's tour with the -search local search before updating trails")
+	search := flags.String("search", TwoOptSearch, fmt.Sprintf("local `search` of -localsearch, one of %s", strings.Join(LocalSearches, ", ")))
+	heuristic := flags.String("heuristic", DistanceHeuristic, fmt.Sprintf("desirability of moves, one of %s", strings.Join(Heuristics, ", ")))
+	candidates := flags.Int("candidates", 0, "consider only this many nearest cities for each move while any is allowed, 0 for all")
+	candidateFallbacks := flags.Float64("candidate-fallbacks", 0, "double the -candidates lists whenever more than this share of an iteration's moves found nothing on them, 0 for never")
+	trailMin := flags.Float64("trail-min", 0, "keep every trail at least this `level` after each update, for variants other than mmas; 0 for no bound")
+	trailMax := flags.Float64("trail-max", 0, "keep every trail at most this `level` after each update, for variants other than mmas; 0 for no bound")
+	castes := flags.String("castes", "", fmt.Sprintf("split the ants into castes as comma-separated caste=`share` pairs, a caste one of %s or alpha:beta:q0", strings.Join(CasteNames, ", ")))
+	adaptive := flags.Bool("adaptive", false, "let every ant evolve its own alpha, beta and q0, starting from the given ones")
+	seedHeuristic := flags.String("seed-heuristic", "", fmt.Sprintf("lay the tour of this constructive `heuristic` on the trails before the first iteration, one of %s", strings.Join(SeedHeuristics, ", ")))
+	alternatives := flags.Int("alternatives", 0, "keep this many of the shortest distinct tours found in the solution")
+	restartStagnation := flags.Int("restart-stagnation", 0, "restart the trails after `n` iterations without a shorter tour, 0 for never")
+	restartBranching := flags.Float64("restart-branching", 0, "restart the trails when the lambda branching factor falls below this, 0 for never")
+	restartEntropy := flags.Float64("restart-entropy", 0, "restart the trails when the pheromone diversity falls below this, 0 for never")
+	placement := flags.String("placement", PlacementRandom, fmt.Sprintf("where the ants start every iteration, one of %s", strings.Join(Placements, ", ")))
+	startCity := flags.Int("start-city", 0, "with -placement fixed, the 0-based `city` every ant starts from")
+	sparsePheromones := flags.Bool("sparse-pheromones", false, "store only the trails ants touch and compute distances as needed, for instances too large for full matrices")
+	edgeWeights := flags.String("edge-weights", "", "score tours by their length plus edge attributes weighted as comma-separated name=`weights`, length=1 unless given")
+	penalty := flags.Float64("penalty", 0, "score tours by their length plus this much per constraint they break, 0 for length alone")
+	maximize := flags.Bool("maximize", false, "seek the tour of highest score rather than lowest, such as the longest tour")
+	vehicle := flags.String("vehicle", "", fmt.Sprintf("run the tours with this vehicle, one of %s or speed:cost_per_km:fixed_cost[:cost_per_hour], and break down their cost", strings.Join(vehicleNames(), ", ")))
+	costModel := flags.String("cost-model", CostDistance, fmt.Sprintf("what to minimize, one of %s; time and cost need -vehicle", strings.Join(CostModels, ", ")))
+	costNoise := flags.String("cost-noise", "", "make edge costs random as stddev:samples[:tail], stddev an edge attribute or share of the distance, and score tours by their mean or worst-tail mean cost")
+	rushHour := flags.String("rush-hour", "", "multiply the distances of edges departed within [start, end) of every period by factor, as period:start:end:factor; slows local search")
+	lengthCache := flags.Int("length-cache", 0, "remember the lengths of up to this many tours, for costs slow to add up, 0 for none")
+	memoryLimit := flags.Int64("max-memory", 0, "fail before allocating if the run is estimated to take more than this many `bytes`, 0 for the memory available, -1 for no check")
+	restartArchive := flags.Int("restart-archive", 0, "lay this many of the shortest distinct tours found on the trails again after every restart, 0 for none")
+	restartStrength := flags.Float64("restart-strength", 1, "with -restart-archive, scale what each archived tour lays after a restart by this `factor`")
+	restartAction := flags.String("restart-action", RestartReset, fmt.Sprintf("what restarts do to the trails, one of %s", strings.Join(RestartActions, ", ")))
+	return func(config Config) (Config, error) {
+		if *configPath != "" {
+			if err := LoadConfigFile(*configPath, &config); err != nil {
+				return config, err
+			}
+		}
+		if err := LoadConfigEnv(&config); err != nil {
+			return config, err
+		}
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "ants":
+				config.NumAnts = *numAnts
+			case "alpha":
+				config.Alpha = *alpha
+			case "beta":
+				config.Beta = *beta
+			case "rho":
+				config.Rho = *rho
+			case "q":
+				config.Q = *q
+			case "iterations":
+				config.Iterations = *iterations
+			case "evaluations":
+				config.Evaluations = *evaluations
+			case "seed":
+				config.Seed = *seed
+			case "variant":
+				config.Variant = *variant
+			case "q0":
+				config.Q0 = *q0
+			case "q0-schedule":
+				config.Q0Schedule = *q0Schedule
+			case "q0-final":
+				config.Q0Final = *q0Final
+			case "workers":
+				config.Workers = *workers
+			case "localsearch":
+				config.LocalSearch = *localSearch
+			case "search":
+				config.Search = *search
+			case "heuristic":
+				config.Heuristic = *heuristic
+			case "adaptive":
+				config.Adaptive = *adaptive
+			case "castes":
+				config.Castes = *castes
+			case "trail-min":
+				config.TrailMin = *trailMin
+			case "trail-max":
+				config.TrailMax = *trailMax
+			case "candidates":
+				config.Candidates = *candidates
+			case "candidate-fallbacks":
+				config.CandidateFallbacks = *candidateFallbacks
+			case "alternatives":
+				config.Alternatives = *alternatives
+			case "seed-heuristic":
+				config.SeedHeuristic = *seedHeuristic
+			case "restart-stagnation":
+				config.RestartStagnation = *restartStagnation
+			case "restart-branching":
+				config.RestartBranching = *restartBranching
+			case "restart-entropy":
+				config.RestartEntropy = *restartEntropy
+			case "restart-action":
+				config.RestartAction = *restartAction
+			case "restart-archive":
+				config.RestartArchive = *restartArchive
+			case "restart-strength":
+				config.RestartStrength = *restartStrength
+			case "placement":
+				config.Placement = *placement
+			case "start-city":
+				config.StartCity = *startCity
+			case "sparse-pheromones":
+				config.SparsePheromones = *sparsePheromones
+			case "edge-weights":
+				config.EdgeWeights = *edgeWeights
+			case "penalty":
+				config.Penalty = *penalty
+			case "maximize":
+				config.Maximize = *maximize
+			case "vehicle":
+				config.Vehicle = *vehicle
+			case "cost-model":
+				config.CostModel = *costModel
+			case "cost-noise":
+				config.CostNoise = *costNoise
+			case "rush-hour":
+				config.RushHour = *rushHour
+			case "length-cache":
+				config.LengthCache = *lengthCache
+			case "max-memory":
+				config.MemoryLimit = *memoryLimit
+			}
+		})
+		return config, nil
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// helpCommandEnv names the command the test binary runs with -h when it is
+// started again by TestCommandFlags
+const helpCommandEnv = "ACO_TEST_HELP_COMMAND"
+
+// subcommands lists the subcommands of commands that have them, whose flags
+// belong to the subcommand
+var subcommands = map[string][]string{"runs": {"list"}}
+
+// TestMain runs a command's -h in place of the tests when TestCommandFlags
+// asks for it, since -h exits the process
+func TestMain(m *testing.M) {
+	if name := os.Getenv(helpCommandEnv); name != "" {
+		runCommand(append(strings.Fields(name), "-h"))
+		os.Exit(3)
+	}
+	os.Exit(m.Run())
+}
+
+// TestCommandFlags builds the flag set of every subcommand, which panics
+// when two of its flags share a name, such as a shared parameter flag and
+// one of the command's own
+func TestCommandFlags(t *testing.T) {
+	var names []string
+	for _, c := range commands() {
+		if subs, ok := subcommands[c.name]; ok {
+			for _, sub := range subs {
+				names = append(names, c.name+" "+sub)
+			}
+			continue
+		}
+		names = append(names, c.name)
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^$")
+			cmd.Env = append(os.Environ(), helpCommandEnv+"="+name)
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("%s -h: %v\n%s", name, err, out)
+			}
+			if !strings.Contains(strings.ToLower(string(out)), "usage") {
+				t.Errorf("%s -h printed no usage:\n%s", name, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// compareCommand implements "compare", which runs several variants on one
+// instance over the same seeds and sets their results side by side
+func compareCommand(args []string) error {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	logging := logFlags(flags)
+	variantList := flags.String("variants", strings.Join(Variants, ","), "comma-separated `list` of variants to compare, by name or alias such as elitist")
+	instancePath := flags.String("instance", "", "the instance `file` to compare on, or give it as argument")
+	seeds := flags.Int("seeds", 10, "number of seeds to run each variant with")
+	parallel := flags.Int("parallel", 1, "number of seeds to run at once")
+	target := flags.Float64("target", 0, "time runs to reach this tour `length`, the known optimum if 0")
+	csvPath := flags.String("csv", "", "also write the comparison as CSV to this `file`, - for stdout")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s compare [flags] -instance file\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Runs each variant with seeds -seed, -seed+1, ... (1, 2, ... by default) and tabulates them.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	path := *instancePath
+	if path == "" && flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+	if path == "" {
+		flags.Usage()
+		return fmt.Errorf("compare: want exactly one instance file")
+	}
+	if *seeds <= 0 {
+		return fmt.Errorf("compare: -seeds must be positive")
+	}
+	variants, err := parseVariants(*variantList)
+	if err != nil {
+		return err
+	}
+	logEvery, err := logging()
+	if err != nil {
+		return err
+	}
+	instance, err := ReadInstance(path, "")
+	if err != nil {
+		return err
+	}
+	base := DefaultConfig()
+	if instance.Options != nil {
+		base = *instance.Options
+	}
+	if base, err = parameters(base); err != nil {
+		return err
+	}
+	optimum, known := KnownOptima[instance.Name]
+	goal := *target
+	if goal == 0 && known {
+		goal = optimum
+	}
+
+	rows := make([]comparison, len(variants))
+	for k, variant := range variants {
+		experiment := &Experiment{
+			Instance: instance,
+			Config:   base,
+			Seeds:    *seeds,
+			Parallel: *parallel,
+			Target:   goal,
+			Logger:   slog.With("variant", variant),
+			LogEvery: logEvery,
+		}
+		experiment.Config.Variant = variant
+		stats, err := experiment.Run()
+		if err != nil {
+			return err
+		}
+		rows[k] = comparison{variant: variant, stats: stats}
+		if known {
+			rows[k].gap = Gap(stats.Mean, optimum)
+		}
+	}
+
+	// Test every variant against the one with the shortest mean
+	best := 0
+	for k := range rows {
+		if rows[k].stats.Mean < rows[best].stats.Mean {
+			best = k
+		}
+	}
+	for k := range rows {
+		if k != best {
+			_, rows[k].p = RankSumTest(rows[k].stats.Lengths, rows[best].stats.Lengths)
+			rows[k].a = VarghaDelaneyA(rows[k].stats.Lengths, rows[best].stats.Lengths)
+		}
+	}
+
+	out := io.Writer(os.Stdout)
+	if *csvPath == "-" {
+		out = os.Stderr
+	}
+	fmt.Fprintf(out, "%s: %d cities, %d seeds each\n", instanceName(instance, path), len(instance.Cities), *seeds)
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "VARIANT\tMIN\tMEAN\t%g%% CI\tMEDIAN\tSTDDEV\tMEAN GAP\tTIME\tTTT\tREACHED\tP VS BEST\tA12\n", 100*ConfidenceLevel)
+	for k, row := range rows {
+		gap, ttt, p, a := "-", "-", "best", "-"
+		if known {
+			gap = fmt.Sprintf("%.2f%%", row.gap)
+		}
+		if row.stats.Reached > 0 {
+			ttt = row.stats.TimeToTarget.Round(time.Millisecond).String() + " " + formatDurationCI(row.stats.TimeToTargetCI)
+		}
+		if k != best {
+			p, a = fmt.Sprintf("%.3g", row.p), fmt.Sprintf("%.2f", row.a)
+		}
+		fmt.Fprintf(tw, "%s\t%.6g\t%.6g\t%s\t%.6g\t%.4g\t%s\t%s\t%s\t%d/%d\t%s\t%s\n", row.variant, row.stats.Min, row.stats.Mean,
+			formatCI(row.stats.MeanCI), row.stats.Median, row.stats.Stddev, gap, row.stats.MeanTime.Round(time.Millisecond), ttt, row.stats.Reached, *seeds, p, a)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "P VS BEST is the two-sided Wilcoxon rank-sum p-value against %s; A12 is the chance a run is longer than one of it\n", rows[best].variant)
+	fmt.Fprintf(out, "CI and the interval after TTT are %g%% bootstrap confidence intervals of the means\n", 100*ConfidenceLevel)
+	if *csvPath != "" {
+		return writeFile(*csvPath, func(w io.Writer) error {
+			return writeComparisonCSV(w, rows, best, known)
+		})
+	}
+	return nil
+}
+
+// comparison is one variant's row of a comparison
+type comparison struct {
+	variant string
+	stats   *ExperimentStats
+	// gap is the percentage by which the mean exceeds the optimum, if known
+	gap float64
+	// p and a are the rank-sum p-value and Vargha-Delaney effect size of the
+	// variant's lengths against those of the variant with the shortest mean,
+	// zero for that variant itself
+	p, a float64
+}
+
+// writeComparisonCSV writes a row per variant, with a header; best is the
+// row the others were tested against
+func writeComparisonCSV(w io.Writer, rows []comparison, best int, known bool) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"variant", "seeds", "min", "mean", "mean_ci_low", "mean_ci_high", "median", "stddev", "mean_gap", "mean_seconds",
+		"reached", "time_to_target_seconds", "time_to_target_ci_low_seconds", "time_to_target_ci_high_seconds", "p_vs_best", "a12_vs_best"})
+	format := func(x float64) string { return strconv.FormatFloat(x, 'g', -1, 64) }
+	for k, row := range rows {
+		gap, ttt, tttLow, tttHigh, p, a := "", "", "", "", "", ""
+		if k != best {
+			p, a = format(row.p), format(row.a)
+		}
+		if known {
+			gap = format(row.gap)
+		}
+		if row.stats.Reached > 0 {
+			ttt = format(row.stats.TimeToTarget.Seconds())
+			tttLow, tttHigh = format(row.stats.TimeToTargetCI[0].Seconds()), format(row.stats.TimeToTargetCI[1].Seconds())
+		}
+		cw.Write([]string{
+			row.variant,
+			strconv.Itoa(len(row.stats.Lengths)),
+			format(row.stats.Min),
+			format(row.stats.Mean),
+			format(row.stats.MeanCI[0]),
+			format(row.stats.MeanCI[1]),
+			format(row.stats.Median),
+			format(row.stats.Stddev),
+			gap,
+			format(row.stats.MeanTime.Seconds()),
+			strconv.Itoa(row.stats.Reached),
+			ttt,
+			tttLow,
+			tttHigh,
+			p,
+			a,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
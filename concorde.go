@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// WriteTSPLIB writes the instance as a TSPLIB problem for Concorde or LKH. The
+// distances are multiplied by scale and rounded, since both solvers work with
+// integer weights, and written as an explicit full matrix so that any distance
+// function survives the trip; unreachable pairs get a weight larger than any
+// tour. Asymmetric instances are written as ATSP, which LKH accepts and
+// Concorde does not. Both solvers look for closed tours
+func WriteTSPLIB(w io.Writer, inst *Instance, distance func(i, j int) float64, scale float64) error {
+	n := len(inst.Cities)
+	problemType := "TSP"
+	finite := 0.0
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if distance(i, j) != distance(j, i) {
+				problemType = "ATSP"
+			}
+			if d := distance(i, j); !math.IsInf(d, 0) {
+				finite = max(finite, d)
+			}
+		}
+	}
+	unreachable := nint(finite*scale) * float64(n+1)
+
+	bw := bufio.NewWriter(w)
+	name := inst.Name
+	if name == "" {
+		name = "aco"
+	}
+	fmt.Fprintf(bw, "NAME : %s\n", name)
+	fmt.Fprintf(bw, "TYPE : %s\n", problemType)
+	fmt.Fprintf(bw, "COMMENT : distances scaled by %g\n", scale)
+	fmt.Fprintf(bw, "DIMENSION : %d\n", n)
+	fmt.Fprintln(bw, "EDGE_WEIGHT_TYPE : EXPLICIT")
+	fmt.Fprintln(bw, "EDGE_WEIGHT_FORMAT : FULL_MATRIX")
+	fmt.Fprintln(bw, "EDGE_WEIGHT_SECTION")
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if j > 0 {
+				bw.WriteByte(' ')
+			}
+			d := distance(i, j)
+			weight := nint(d * scale)
+			if math.IsInf(d, 0) {
+				weight = unreachable
+			}
+			bw.WriteString(strconv.FormatFloat(weight, 'f', -1, 64))
+		}
+		bw.WriteByte('\n')
+	}
+	fmt.Fprintln(bw, "EOF")
+	return bw.Flush()
+}
+
+// WriteTSPLIBTour writes tour, numbered from zero, as a TSPLIB tour file with
+// 1-based node ids, as LKH reads for INITIAL_TOUR_FILE
+func WriteTSPLIBTour(w io.Writer, name string, tour []int) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "NAME : %s\n", name)
+	fmt.Fprintln(bw, "TYPE : TOUR")
+	fmt.Fprintf(bw, "DIMENSION : %d\n", len(tour))
+	fmt.Fprintln(bw, "TOUR_SECTION")
+	for _, city := range tour {
+		fmt.Fprintln(bw, city+1)
+	}
+	fmt.Fprintln(bw, "-1")
+	fmt.Fprintln(bw, "EOF")
+	return bw.Flush()
+}
+
+// WriteLKHParameters writes an LKH parameter file that solves problemFile and
+// writes its best tour to tourFile
+func WriteLKHParameters(w io.Writer, problemFile, tourFile string) error {
+	_, err := fmt.Fprintf(w, "PROBLEM_FILE = %s\nOUTPUT_TOUR_FILE = %s\nRUNS = 1\n", problemFile, tourFile)
+	return err
+}
+
+// ParseConcordeSolution reads a Concorde .sol file: the number of nodes
+// followed by the tour as 0-based node ids
+func ParseConcordeSolution(r io.Reader) ([]int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	n := -1
+	var tour []int
+	for scanner.Scan() {
+		v, err := strconv.Atoi(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("node id %q is not an integer", scanner.Text())
+		}
+		if n < 0 {
+			n = v
+			continue
+		}
+		tour = append(tour, v)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("empty solution file")
+	}
+	if len(tour) != n {
+		return nil, fmt.Errorf("solution lists %d nodes, header says %d", len(tour), n)
+	}
+	return tour, nil
+}
+
+// LoadTour reads a tour numbered from zero from a TSPLIB or LKH .tour file or
+// a Concorde .sol file, telling them apart by whether the file starts with a number
+func LoadTour(path string) ([]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tour []int
+	if fields := bytes.Fields(data); len(fields) > 0 && startsWithNumber(string(fields[0])) {
+		tour, err = ParseConcordeSolution(bytes.NewReader(data))
+	} else {
+		var t *TSPLIBTour
+		if t, err = ParseTSPLIBTour(bytes.NewReader(data)); err == nil {
+			tour = t.Tour
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tour, nil
+}
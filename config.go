@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Config holds the parameters of an ACO run
+type Config struct {
+	NumAnts    int     `json:"ants"`
+	Alpha      float64 `json:"alpha"`
+	Beta       float64 `json:"beta"`
+	Rho        float64 `json:"rho"`
+	Q          float64 `json:"q"`
+	Iterations int     `json:"iterations"`
+	// Seed makes a run reproducible, zero picks one from the clock
+	Seed int64 `json:"seed,omitempty"`
+	// Variant names the algorithm, one of Variants; empty means Ant System
+	Variant string `json:"variant,omitempty"`
+	// Q0 is the Ant Colony System exploitation probability, that of the
+	// first iteration under a Q0Schedule
+	Q0 float64 `json:"q0"`
+	// Workers is how many ants build tours in parallel, one if zero. Results
+	// for a seed do not depend on it
+	Workers int `json:"workers,omitempty"`
+	// LocalSearch improves every ant's tour with 2-opt before the trails are updated
+	LocalSearch bool `json:"local_search,omitempty"`
+	// Adaptive lets every ant evolve its own alpha, beta and q0, starting
+	// from these, by the quality of its tours
+	Adaptive bool `json:"adaptive,omitempty"`
+	// Castes splits the ants into castes choosing by parameters of their
+	// own, as "caste=share" pairs separated by commas, such as
+	// "standard=0.8,greedy=0.1,random=0.1". A caste is one of CasteNames or
+	// its alpha, beta and q0 separated by colons; standard ants choose by the
+	// colony's parameters. A mix is more robust on deceptive instances than
+	// any caste alone. Empty makes every ant standard
+	Castes string `json:"castes,omitempty"`
+	// Candidates limits each move to the given number of nearest cities while
+	// any of them is still allowed, which speeds up large instances; 0 for no limit
+	Candidates int `json:"candidates,omitempty"`
+	// CandidateFallbacks doubles the candidate lists after every iteration
+	// in which ants found no city they could move to on their list in more
+	// than that share of their moves, and so considered every city; 0 keeps
+	// the lists as they are. Lists too short for an instance otherwise cost
+	// tour quality silently
+	CandidateFallbacks float64 `json:"candidate_fallbacks,omitempty"`
+	// TrailMin and TrailMax keep the trails of variants other than MMAS,
+	// which bounds them itself, within them after every update: a floor
+	// keeps edges from starving for good, which brings on premature
+	// convergence, and a ceiling keeps trails from growing without end. 0
+	// for no bound
+	TrailMin float64 `json:"trail_min,omitempty"`
+	TrailMax float64 `json:"trail_max,omitempty"`
+	// Heuristic names the desirability of moves, one of Heuristics; empty
+	// means DistanceHeuristic
+	Heuristic string `json:"heuristic,omitempty"`
+	// Search names the local search LocalSearch applies, one of
+	// LocalSearches; empty means TwoOptSearch
+	Search string `json:"search,omitempty"`
+	// RestartStagnation restarts the trails after that many iterations
+	// without a shorter tour, 0 for never
+	RestartStagnation int `json:"restart_stagnation,omitempty"`
+	// RestartBranching restarts the trails when the lambda branching factor
+	// falls below it, 0 for never
+	RestartBranching float64 `json:"restart_branching,omitempty"`
+	// RestartEntropy restarts the trails when the pheromone diversity falls
+	// below it, 0 for never
+	RestartEntropy float64 `json:"restart_entropy,omitempty"`
+	// RestartAction is what a restart does to the trails, one of
+	// RestartActions; empty means RestartReset
+	RestartAction string `json:"restart_action,omitempty"`
+	// RestartArchive keeps that many of the shortest tours found that take
+	// different edges and lays them on the trails again after every restart,
+	// so that the colony explores anew without forgetting all it learned; 0
+	// for none. MMAS trails stay within their bounds, and a reset leaves
+	// them at the upper one, so there only smoothing leaves the archive room
+	RestartArchive int `json:"restart_archive,omitempty"`
+	// RestartStrength scales what each archived tour lays after a restart,
+	// relative to a deposit of Q over its length; 1 if zero
+	RestartStrength float64 `json:"restart_strength,omitempty"`
+	// Alternatives keeps that many of the shortest tours found that take
+	// different edges, returned in Solution.Alternatives; 0 for none
+	Alternatives int `json:"alternatives,omitempty"`
+	// SeedHeuristic names a constructive heuristic, one of SeedHeuristics,
+	// whose tour is laid on the trails before the first iteration as the best
+	// so far; empty for none
+	SeedHeuristic string `json:"seed_heuristic,omitempty"`
+	// Evaluations, if positive, ends the run once the ants have built that
+	// many tours in all, in place of Iterations; the last iteration sends out
+	// only the ants left in the budget. Runs compared by tours built are fair
+	// whatever the speed of the machine or the number of workers
+	Evaluations int `json:"evaluations,omitempty"`
+	// SparsePheromones stores only the trails that ants have touched, the
+	// others sharing one level, and computes distances from the coordinates
+	// as they are needed rather than keeping a matrix, so that huge instances
+	// take memory by the edges touched rather than the square of the cities.
+	// Explicit distance matrices and edge attributes stay whole. A warm start
+	// needs the full matrix
+	SparsePheromones bool `json:"sparse_pheromones,omitempty"`
+	// EdgeWeights scores tours by a weighted sum of their length and the edge
+	// attributes of the instance, as "name=weight" pairs separated by commas;
+	// the length, named "length", weighs 1 unless given. Empty scores tours
+	// by their length alone. Heuristic "weighted" guides ants by the same sum
+	EdgeWeights string `json:"edge_weights,omitempty"`
+	// Penalty adds that much to the score of a tour for every constraint it
+	// breaks, as ants do where the constraints leave them no city; 0 scores
+	// tours by their length alone
+	Penalty float64 `json:"penalty,omitempty"`
+	// Maximize seeks the tour of highest score rather than lowest, such as
+	// the longest tour; the heuristic and candidate lists still favour short
+	// edges unless beta and candidates are 0 or a heuristic replaces them
+	Maximize bool `json:"maximize,omitempty"`
+	// Vehicle runs the tours, one of VehicleProfiles or
+	// "speed:cost_per_km:fixed_cost[:cost_per_hour]", so that they can be
+	// scored in hours or money and solutions break down what their routes
+	// cost. Empty for none
+	Vehicle string `json:"vehicle,omitempty"`
+	// CostModel is what the colony minimizes, one of CostModels; empty means
+	// CostDistance. CostTime and CostMoney score tours by the Vehicle and
+	// cannot be combined with EdgeWeights
+	CostModel string `json:"cost_model,omitempty"`
+	// CostNoise makes edge costs random, as "stddev:samples[:tail]": normal
+	// around the distances with standard deviations the edge attribute stddev
+	// holds, or that share of the distances if stddev is a number, truncated
+	// at zero. Tours are scored by their mean cost over samples draws, or the
+	// mean of their worst tail share of draws if tail is given. Empty for
+	// fixed costs
+	CostNoise string `json:"cost_noise,omitempty"`
+	// RushHour makes travel times depend on the departure, as
+	// "period:start:end:factor": edges departed within [start, end) of every
+	// period take factor times their distance, the clock starting at 0 and
+	// advancing by travel and service times. Local search then re-evaluates
+	// whole tours rather than the edges a move changes, which is far slower.
+	// Empty for fixed costs
+	RushHour string `json:"rush_hour,omitempty"`
+	// LengthCache memoizes the lengths of up to that many tours, for costs
+	// slower to add up than a tour is to hash, such as time-dependent ones;
+	// 0 for none. Sampled stochastic lengths are never cached
+	LengthCache int `json:"length_cache,omitempty"`
+	// MemoryLimit is the memory in bytes a run may estimate it takes before
+	// allocating its matrices; it fails fast beyond it rather than be killed
+	// midway. Zero means the memory available to the process, negative no check
+	MemoryLimit int64 `json:"memory_limit,omitempty"`
+	// Placement decides where the ants start every iteration, one of
+	// Placements; empty means PlacementRandom. On clustered instances it
+	// shapes how evenly the colony explores
+	Placement string `json:"placement,omitempty"`
+	// StartCity is the city every ant starts from with PlacementFixed
+	StartCity int `json:"start_city,omitempty"`
+	// Q0Schedule moves q0 over the run from Q0 to Q0Final, one of
+	// Q0Schedules; empty means Q0Constant. Starting low and ending high lets
+	// the colony explore early and exploit what it found late. Self-adaptive
+	// ants start from Q0 and evolve their own
+	Q0Schedule string `json:"q0_schedule,omitempty"`
+	// Q0Final is q0 at the last iteration under a Q0Schedule
+	Q0Final float64 `json:"q0_final,omitempty"`
+}
+
+// DefaultConfig returns the parameters of the built-in example
+func DefaultConfig() Config {
+	return Config{
+		NumAnts:    10,
+		Alpha:      1.0,
+		Beta:       2.0,
+		Rho:        0.5,
+		Q:          100.0,
+		Iterations: 100,
+		Q0:         0.9,
+	}
+}
+
+// autoMaxAnts caps the one ant per city AutoConfig recommends, beyond which
+// iterations get slow for little gain
+const autoMaxAnts = 100
+
+// AutoConfig returns parameters for variant recommended for an instance of
+// its size by Dorigo and Stützle, Ant Colony Optimization (2004), table 3.1:
+// as many ants as cities (at most autoMaxAnts) except for ACS's ten, the
+// variant's evaporation rate, q0 = 0.9 for ACS, and candidate lists of the 20
+// nearest cities. MMAS trail bounds follow from rho as the book derives them
+func AutoConfig(inst *Instance, variant string) Config {
+	n := len(inst.Cities)
+	config := DefaultConfig()
+	config.Variant = variant
+	config.NumAnts = max(min(n, autoMaxAnts), 1)
+	config.Candidates = 20
+	if n <= config.Candidates+1 {
+		config.Candidates = 0
+	}
+	switch variant {
+	case "", AntSystem, ElitistAntSystem:
+		config.Beta, config.Rho = 3, 0.5
+	case RankBasedAntSystem:
+		config.Beta, config.Rho = 3, 0.1
+	case MaxMinAntSystem:
+		config.Beta, config.Rho = 2, 0.02
+		// MMAS converges slowly by design and needs more iterations to pay off
+		config.Iterations = 500
+	case AntColonySystem:
+		config.Beta, config.Rho, config.Q0 = 2, 0.1, 0.9
+		config.NumAnts = 10
+	}
+	return config
+}
+
+// Validate checks that every parameter is within its meaningful range
+func (c *Config) Validate() error {
+	// NaN fails every range check below, so it is rejected first
+	for _, p := range []struct {
+		name  string
+		value float64
+	}{
+		{"alpha", c.Alpha}, {"beta", c.Beta}, {"rho", c.Rho}, {"q", c.Q}, {"q0", c.Q0}, {"q0_final", c.Q0Final},
+		{"candidate_fallbacks", c.CandidateFallbacks}, {"penalty", c.Penalty}, {"trail_min", c.TrailMin},
+		{"trail_max", c.TrailMax}, {"restart_branching", c.RestartBranching}, {"restart_entropy", c.RestartEntropy},
+		{"restart_strength", c.RestartStrength},
+	} {
+		if math.IsNaN(p.value) || math.IsInf(p.value, 0) {
+			return fmt.Errorf("%s must be a finite number, got %g", p.name, p.value)
+		}
+	}
+	switch {
+	case c.NumAnts <= 0:
+		return fmt.Errorf("ants must be positive, got %d", c.NumAnts)
+	case c.Alpha < 0:
+		return fmt.Errorf("alpha must not be negative, got %g", c.Alpha)
+	case c.Beta < 0:
+		return fmt.Errorf("beta must not be negative, got %g", c.Beta)
+	case c.Rho <= 0 || c.Rho > 1:
+		return fmt.Errorf("rho must be in (0, 1], got %g", c.Rho)
+	case c.Q <= 0:
+		return fmt.Errorf("q must be positive, got %g", c.Q)
+	case c.Iterations < 0:
+		return fmt.Errorf("iterations must not be negative, got %d", c.Iterations)
+	case c.Q0 < 0 || c.Q0 > 1:
+		return fmt.Errorf("q0 must be in [0, 1], got %g", c.Q0)
+	case c.Workers < 0:
+		return fmt.Errorf("workers must not be negative, got %d", c.Workers)
+	case c.Candidates < 0:
+		return fmt.Errorf("candidates must not be negative, got %d", c.Candidates)
+	case c.CandidateFallbacks < 0 || c.CandidateFallbacks > 1:
+		return fmt.Errorf("candidate_fallbacks must be in [0, 1], got %g", c.CandidateFallbacks)
+	case c.TrailMin < 0:
+		return fmt.Errorf("trail_min must not be negative, got %g", c.TrailMin)
+	case c.TrailMax < 0:
+		return fmt.Errorf("trail_max must not be negative, got %g", c.TrailMax)
+	case c.TrailMax > 0 && c.TrailMin >= c.TrailMax:
+		return fmt.Errorf("trail_min %g must be below trail_max %g", c.TrailMin, c.TrailMax)
+	case (c.TrailMin > 0 || c.TrailMax > 0) && c.Variant == MaxMinAntSystem:
+		return fmt.Errorf("mmas bounds its trails itself, without trail_min and trail_max")
+	case c.RestartStagnation < 0:
+		return fmt.Errorf("restart_stagnation must not be negative, got %d", c.RestartStagnation)
+	case c.RestartBranching < 0:
+		return fmt.Errorf("restart_branching must not be negative, got %g", c.RestartBranching)
+	case c.RestartEntropy < 0 || c.RestartEntropy > 1:
+		return fmt.Errorf("restart_entropy must be in [0, 1], got %g", c.RestartEntropy)
+	case c.RestartArchive < 0:
+		return fmt.Errorf("restart_archive must not be negative, got %d", c.RestartArchive)
+	case c.RestartStrength < 0:
+		return fmt.Errorf("restart_strength must not be negative, got %g", c.RestartStrength)
+	case c.Alternatives < 0:
+		return fmt.Errorf("alternatives must not be negative, got %d", c.Alternatives)
+	case c.Evaluations < 0:
+		return fmt.Errorf("evaluations must not be negative, got %d", c.Evaluations)
+	case c.StartCity < 0:
+		return fmt.Errorf("start_city must not be negative, got %d", c.StartCity)
+	case c.Penalty < 0:
+		return fmt.Errorf("penalty must not be negative, got %g", c.Penalty)
+	case c.LengthCache < 0:
+		return fmt.Errorf("length_cache must not be negative, got %d", c.LengthCache)
+	case c.Q0Final < 0 || c.Q0Final > 1:
+		return fmt.Errorf("q0_final must be in [0, 1], got %g", c.Q0Final)
+	case c.Q0Schedule == Q0Exponential && (c.Q0 == 1 || c.Q0Final == 1):
+		return fmt.Errorf("an exponential q0 schedule needs q0 and q0_final below 1")
+	}
+	if err := checkQ0Schedule(c.Q0Schedule); err != nil {
+		return err
+	}
+	if _, _, err := parseEdgeWeights(c.EdgeWeights); err != nil {
+		return err
+	}
+	if _, err := parseCastes(c.Castes); err != nil {
+		return err
+	}
+	if err := checkCostModel(c.CostModel, c.Vehicle); err != nil {
+		return err
+	}
+	if _, err := parseCostNoise(c.CostNoise); err != nil {
+		return err
+	}
+	if _, err := parseRushHour(c.RushHour); err != nil {
+		return err
+	}
+	if (c.CostModel == CostTime || c.CostModel == CostMoney) && c.EdgeWeights != "" {
+		return fmt.Errorf("edge weights and cost model %s cannot be combined", c.CostModel)
+	}
+	if c.Castes != "" && c.Adaptive {
+		return fmt.Errorf("castes and adaptive parameters cannot be combined")
+	}
+	if err := checkPlacement(c.Placement); err != nil {
+		return err
+	}
+	if err := checkRestartAction(c.RestartAction); err != nil {
+		return err
+	}
+	if err := checkHeuristic(c.Heuristic); err != nil {
+		return err
+	}
+	if err := checkLocalSearch(c.Search); err != nil {
+		return err
+	}
+	if err := checkSeedHeuristic(c.SeedHeuristic); err != nil {
+		return err
+	}
+	return checkVariant(c.Variant)
+}
+
+// iterationLimit returns the number of iterations a run takes: Iterations,
+// or as many as the ants take to spend an evaluation budget
+func (c *Config) iterationLimit() int {
+	if c.Evaluations > 0 {
+		return (c.Evaluations + c.NumAnts - 1) / c.NumAnts
+	}
+	return c.Iterations
+}
+
+// UnmarshalJSON decodes a config, taking parameters it does not mention from DefaultConfig
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type plain Config
+	decoded := plain(DefaultConfig())
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*c = Config(decoded)
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// LoadConfigFile applies the parameters set in a JSON, YAML or TOML file to
+// config, leaving the others unchanged. The format follows the extension:
+// .json, .yaml or .yml, and .toml; keys are the JSON field names of Config
+func LoadConfigFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		type plain Config
+		err = json.Unmarshal(data, (*plain)(config))
+	case ".yaml", ".yml":
+		err = parseFlatConfig(data, ":", config)
+	case ".toml":
+		err = parseFlatConfig(data, "=", config)
+	default:
+		err = fmt.Errorf("unknown config format %q, want .json, .yaml or .toml", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// WriteConfigFile writes config to a JSON, YAML or TOML file that
+// LoadConfigFile reads back, the format following the extension as there
+func WriteConfigFile(path string, config Config) error {
+	sep := ""
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return writeJSON(path, config)
+	case ".yaml", ".yml":
+		sep = ": "
+	case ".toml":
+		sep = " = "
+	default:
+		return fmt.Errorf("%s: unknown config format %q, want .json, .yaml or .toml", path, ext)
+	}
+	return writeFile(path, func(w io.Writer) error {
+		v := reflect.ValueOf(config)
+		for i := 0; i < v.NumField(); i++ {
+			name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+			value := fmt.Sprint(v.Field(i).Interface())
+			if v.Field(i).Kind() == reflect.String {
+				value = strconv.Quote(value)
+			}
+			if _, err := fmt.Fprintf(w, "%s%s%s\n", name, sep, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// parseFlatConfig reads "key<sep>value" lines, the subset of YAML and TOML
+// that a flat set of parameters needs. Blank lines, "#" comments, a YAML
+// "---" document marker and quoted strings are accepted
+func parseFlatConfig(data []byte, sep string, config *Config) error {
+	fields := configFields(config)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if text == "" || text == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(text, sep)
+		if !ok {
+			return fmt.Errorf("line %d: want \"key %s value\", got %q", line, sep, text)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"'`)
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("line %d: unknown parameter %q", line, key)
+		}
+		if err := setConfigField(field, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("line %d: %s: %w", line, key, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// configEnvPrefix starts the names of environment variables holding parameters
+const configEnvPrefix = "ACO_"
+
+// LoadConfigEnv applies parameters set in the environment to config, for
+// containerized runs: each parameter is read from ACO_ followed by its JSON
+// name in upper case, such as ACO_ANTS, ACO_RHO or ACO_LOCAL_SEARCH
+func LoadConfigEnv(config *Config) error {
+	for name, field := range configFields(config) {
+		value, ok := os.LookupEnv(configEnvPrefix + strings.ToUpper(name))
+		if !ok {
+			continue
+		}
+		if err := setConfigField(field, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("%s%s: %w", configEnvPrefix, strings.ToUpper(name), err)
+		}
+	}
+	return nil
+}
+
+// stripComment drops a "#" comment that is not inside a quoted string
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// configFields maps the JSON names of the config's fields to the fields themselves
+func configFields(config *Config) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		fields[name] = v.Field(i)
+	}
+	return fields
+}
+
+// setConfigField parses value into a config field of any of the kinds Config uses
+func setConfigField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, "'")
+		}
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		x, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		field.SetFloat(x)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported parameter type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import "fmt"
+
+// Precedence requires city Before to be visited before city After
+type Precedence struct {
+	Before int `json:"before"`
+	After  int `json:"after"`
+}
+
+// Constraints holds the feasibility rules a tour must satisfy
+type Constraints struct {
+	Precedences []Precedence `json:"precedences,omitempty"`
+	// ForbiddenEdges are never traversed, RequiredEdges must join consecutive
+	// cities of the tour; both are undirected
+	ForbiddenEdges [][2]int `json:"forbidden_edges,omitempty"`
+	RequiredEdges  [][2]int `json:"required_edges,omitempty"`
+	// Clusters are groups of cities that must be visited contiguously
+	Clusters [][]int `json:"clusters,omitempty"`
+	// Segments are locked sequences of cities that must be visited one right
+	// after the other in the given order, wherever the tour takes them
+	Segments [][]int `json:"segments,omitempty"`
+}
+
+// AddPrecedence declares that city before must be visited before city after
+func (c *Constraints) AddPrecedence(before, after int) {
+	c.Precedences = append(c.Precedences, Precedence{Before: before, After: after})
+}
+
+// AddPickupDelivery declares a pickup/delivery pair, the pickup must be visited before the delivery
+func (c *Constraints) AddPickupDelivery(pickup, delivery int) {
+	c.AddPrecedence(pickup, delivery)
+}
+
+// AddForbiddenEdge declares that cities a and b must never be visited consecutively
+func (c *Constraints) AddForbiddenEdge(a, b int) {
+	c.ForbiddenEdges = append(c.ForbiddenEdges, [2]int{a, b})
+}
+
+// AddRequiredEdge declares that cities a and b must be visited consecutively
+func (c *Constraints) AddRequiredEdge(a, b int) {
+	c.RequiredEdges = append(c.RequiredEdges, [2]int{a, b})
+}
+
+// AddSegment locks a sequence of cities, which the tour must visit one right
+// after the other in this order
+func (c *Constraints) AddSegment(cities ...int) {
+	c.Segments = append(c.Segments, cities)
+}
+
+// requiredEdges returns the required edges together with those joining the
+// consecutive cities of segments
+func (c *Constraints) requiredEdges() [][2]int {
+	if len(c.Segments) == 0 {
+		return c.RequiredEdges
+	}
+	edges := append([][2]int(nil), c.RequiredEdges...)
+	for _, segment := range c.Segments {
+		for k := 1; k < len(segment); k++ {
+			edges = append(edges, [2]int{segment[k-1], segment[k]})
+		}
+	}
+	return edges
+}
+
+// precedences returns the precedences together with those ordering the
+// consecutive cities of segments, which with their required edges lock them
+func (c *Constraints) precedences() []Precedence {
+	if len(c.Segments) == 0 {
+		return c.Precedences
+	}
+	precedences := append([]Precedence(nil), c.Precedences...)
+	for _, segment := range c.Segments {
+		for k := 1; k < len(segment); k++ {
+			precedences = append(precedences, Precedence{Before: segment[k-1], After: segment[k]})
+		}
+	}
+	return precedences
+}
+
+// AllowsEdge reports whether the tour may travel directly between cities from and to
+func (c *Constraints) AllowsEdge(from, to int) bool {
+	for _, e := range c.ForbiddenEdges {
+		if (e[0] == from && e[1] == to) || (e[0] == to && e[1] == from) {
+			return false
+		}
+	}
+	return true
+}
+
+// requiredPartners lists the cities joined to city by a required edge
+func (c *Constraints) requiredPartners(city int) []int {
+	var partners []int
+	for _, e := range c.requiredEdges() {
+		if e[0] == city {
+			partners = append(partners, e[1])
+		} else if e[1] == city {
+			partners = append(partners, e[0])
+		}
+	}
+	return partners
+}
+
+// canEnter reports whether entering city from city from keeps all of city's
+// required edges satisfiable: any other required partner must still be
+// unvisited, and at most one can follow
+func (c *Constraints) canEnter(visited map[int]bool, from, city int) bool {
+	pending := 0
+	for _, p := range c.requiredPartners(city) {
+		if p == from {
+			continue
+		}
+		if visited[p] {
+			return false
+		}
+		pending++
+	}
+	return pending <= 1
+}
+
+// Allows reports whether city may be visited next given the set of visited cities
+func (c *Constraints) Allows(visited map[int]bool, city int) bool {
+	for _, p := range c.precedences() {
+		if p.After == city && !visited[p.Before] {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that the constraints refer to existing cities and can be satisfied by some tour
+func (c *Constraints) Validate(n int) error {
+	indegree := make([]int, n)
+	successors := make([][]int, n)
+	if err := c.validateSegments(n); err != nil {
+		return err
+	}
+	for _, p := range c.precedences() {
+		if p.Before < 0 || p.Before >= n || p.After < 0 || p.After >= n {
+			return fmt.Errorf("precedence %d -> %d: city out of range [0, %d)", p.Before, p.After, n)
+		}
+		if p.Before == p.After {
+			return fmt.Errorf("precedence %d -> %d: city cannot precede itself", p.Before, p.After)
+		}
+		successors[p.Before] = append(successors[p.Before], p.After)
+		indegree[p.After]++
+	}
+
+	if err := c.validateEdges(n); err != nil {
+		return err
+	}
+	if err := c.validateClusters(n); err != nil {
+		return err
+	}
+
+	// Kahn's algorithm: every city must become reachable once its predecessors are placed
+	var queue []int
+	for i := range indegree {
+		if indegree[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	placed := 0
+	for len(queue) > 0 {
+		city := queue[0]
+		queue = queue[1:]
+		placed++
+		for _, next := range successors[city] {
+			indegree[next]--
+			if indegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+	if placed < n {
+		return fmt.Errorf("precedence constraints contain a cycle")
+	}
+	return nil
+}
+
+// validateEdges checks that forbidden and required edges are consistent: each
+// city has at most two required edges and they form paths rather than cycles
+func (c *Constraints) validateEdges(n int) error {
+	required := c.requiredEdges()
+	for _, e := range append(append([][2]int(nil), c.ForbiddenEdges...), required...) {
+		if e[0] < 0 || e[0] >= n || e[1] < 0 || e[1] >= n {
+			return fmt.Errorf("edge %d-%d: city out of range [0, %d)", e[0], e[1], n)
+		}
+		if e[0] == e[1] {
+			return fmt.Errorf("edge %d-%d: city cannot be joined to itself", e[0], e[1])
+		}
+	}
+	degree := make([]int, n)
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, e := range required {
+		if !c.AllowsEdge(e[0], e[1]) {
+			return fmt.Errorf("edge %d-%d is both required and forbidden", e[0], e[1])
+		}
+		degree[e[0]]++
+		degree[e[1]]++
+		if degree[e[0]] > 2 || degree[e[1]] > 2 {
+			return fmt.Errorf("edge %d-%d: a city can have at most two required edges", e[0], e[1])
+		}
+		a, b := find(e[0]), find(e[1])
+		if a == b {
+			return fmt.Errorf("required edges through %d-%d form a cycle", e[0], e[1])
+		}
+		parent[a] = b
+	}
+	return nil
+}
+
+// validateSegments checks that segments refer to existing cities, each at
+// most once, as a city cannot be locked in two places
+func (c *Constraints) validateSegments(n int) error {
+	seen := make(map[int]int)
+	for k, segment := range c.Segments {
+		for _, city := range segment {
+			if city < 0 || city >= n {
+				return fmt.Errorf("segment %d: city %d out of range [0, %d)", k, city, n)
+			}
+			if other, ok := seen[city]; ok {
+				return fmt.Errorf("city %d is in segments %d and %d", city, other, k)
+			}
+			seen[city] = k
+		}
+	}
+	return nil
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Method paths of the aco.v1.Construction service
+const (
+	grpcPreparePath   = "/aco.v1.Construction/Prepare"
+	grpcConstructPath = "/aco.v1.Construction/Construct"
+)
+
+// grpcNotFound is the status of a Construct call naming a colony the worker
+// does not hold, as after it restarted
+const grpcNotFound = 5
+
+// ConstructRequest is a decoded aco.v1.ConstructRequest: ants to move on a
+// snapshot of the colony's trails
+type ConstructRequest struct {
+	Colony            string
+	Pheromones        [][]float64
+	ClusterPheromones [][]float64
+	// Starts and Seeds give every ant's first city and the seed of its choices
+	Starts []int
+	Seeds  []int64
+}
+
+// MarshalProto encodes the request as an aco.v1.ConstructRequest message
+func (r *ConstructRequest) MarshalProto() []byte {
+	var b protoBuffer
+	b.string(1, r.Colony)
+	b.matrix(2, r.Pheromones)
+	b.matrix(3, r.ClusterPheromones)
+	for k := range r.Starts {
+		b.message(4, func(b *protoBuffer) {
+			b.int(1, int64(r.Starts[k]))
+			b.int(2, r.Seeds[k])
+		})
+	}
+	return b.data
+}
+
+// UnmarshalProto decodes an aco.v1.ConstructRequest message
+func (r *ConstructRequest) UnmarshalProto(data []byte) error {
+	*r = ConstructRequest{}
+	return protoDecode(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			r.Colony = f.string()
+		case 2, 3:
+			row, err := f.row()
+			if f.num == 2 {
+				r.Pheromones = append(r.Pheromones, row)
+			} else {
+				r.ClusterPheromones = append(r.ClusterPheromones, row)
+			}
+			return err
+		case 4:
+			start, seed := 0, int64(0)
+			err := protoDecode(f.data, func(g protoField) error {
+				switch g.num {
+				case 1:
+					start = g.int()
+				case 2:
+					seed = g.int64()
+				}
+				return nil
+			})
+			r.Starts, r.Seeds = append(r.Starts, start), append(r.Seeds, seed)
+			return err
+		}
+		return nil
+	})
+}
+
+// encodeTours encodes tours as an aco.v1.ConstructResponse message
+func encodeTours(tours [][]int) []byte {
+	var b protoBuffer
+	for _, tour := range tours {
+		b.message(1, func(b *protoBuffer) { b.ints(1, tour) })
+	}
+	return b.data
+}
+
+// decodeTours decodes an aco.v1.ConstructResponse message
+func decodeTours(data []byte) ([][]int, error) {
+	var tours [][]int
+	err := protoDecode(data, func(f protoField) error {
+		if f.num != 1 {
+			return nil
+		}
+		var tour []int
+		err := protoDecode(f.data, func(g protoField) error {
+			if g.num != 1 {
+				return nil
+			}
+			cities, err := g.ints()
+			tour = append(tour, cities...)
+			return err
+		})
+		tours = append(tours, tour)
+		return err
+	})
+	return tours, err
+}
+
+// ConstructionWorker serves the aco.v1.Construction service: it holds the
+// colonies masters prepared on it and moves batches of their ants on the
+// trails each iteration sends, using Workers goroutines
+type ConstructionWorker struct {
+	Workers int
+
+	mu       sync.Mutex
+	colonies map[string]*workerColony
+}
+
+// workerColony is a colony prepared on a worker. Its mutex keeps the
+// batches of concurrent calls from sharing trails
+type workerColony struct {
+	mu     sync.Mutex
+	colony *AntColony
+}
+
+// NewConstructionWorker returns a worker moving ants on workers goroutines,
+// one per CPU if workers is 0
+func NewConstructionWorker(workers int) *ConstructionWorker {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &ConstructionWorker{Workers: workers, colonies: make(map[string]*workerColony)}
+}
+
+// Handler returns the HTTP/2 handler of the service
+func (cw *ConstructionWorker) Handler() http.Handler {
+	return grpcHandler(cw.call)
+}
+
+// call runs the method named by the request path and returns its status
+func (cw *ConstructionWorker) call(w http.ResponseWriter, r *http.Request) (int, error) {
+	data, err := readGRPCMessage(r.Body)
+	if err != nil {
+		return grpcInvalidArgument, err
+	}
+	var reply []byte
+	switch r.URL.Path {
+	case grpcPreparePath:
+		id, err := cw.prepare(data)
+		if err != nil {
+			return grpcInvalidArgument, err
+		}
+		var b protoBuffer
+		b.string(1, id)
+		reply = b.data
+	case grpcConstructPath:
+		var request ConstructRequest
+		if err := request.UnmarshalProto(data); err != nil {
+			return grpcInvalidArgument, err
+		}
+		cw.mu.Lock()
+		prepared, ok := cw.colonies[request.Colony]
+		cw.mu.Unlock()
+		if !ok {
+			return grpcNotFound, fmt.Errorf("no colony %q", request.Colony)
+		}
+		tours, err := prepared.construct(&request)
+		if err != nil {
+			return grpcInvalidArgument, err
+		}
+		reply = encodeTours(tours)
+	default:
+		return grpcUnimplemented, fmt.Errorf("unknown method %s", r.URL.Path)
+	}
+	if err := writeGRPCMessage(w, reply); err != nil {
+		return grpcInternal, err
+	}
+	return grpcOK, nil
+}
+
+// prepare builds the colony of an aco.v1.PrepareRequest, naming it by a
+// hash of the request so masters preparing it again get the same one
+func (cw *ConstructionWorker) prepare(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:16])
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	if _, ok := cw.colonies[id]; ok {
+		return id, nil
+	}
+	// aco.v1.PrepareRequest has the fields of a SolveRequest
+	var request SolveRequest
+	if err := request.UnmarshalProto(data); err != nil {
+		return "", err
+	}
+	if request.Instance == nil {
+		return "", fmt.Errorf("no instance to prepare")
+	}
+	config, err := decodeConfig(request.Config)
+	if err != nil {
+		return "", err
+	}
+	config.Workers = cw.Workers
+	solver, err := NewSolver(request.Instance, *config)
+	if err != nil {
+		return "", err
+	}
+	cw.colonies[id] = &workerColony{colony: solver.Colony}
+	slog.Info("prepared colony", "colony", id, "cities", len(request.Instance.Cities), "variant", config.Variant)
+	return id, nil
+}
+
+// construct moves the request's ants on its trails and returns their tours
+func (wc *workerColony) construct(request *ConstructRequest) ([][]int, error) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	ac := wc.colony
+	n := len(ac.Cities)
+	if len(request.Pheromones) != n {
+		return nil, fmt.Errorf("trails have %d rows, want %d", len(request.Pheromones), n)
+	}
+	for _, row := range request.Pheromones {
+		if len(row) != n {
+			return nil, fmt.Errorf("trail row has %d values, want %d", len(row), n)
+		}
+	}
+	ac.Pheromones, ac.ClusterPheromones = request.Pheromones, request.ClusterPheromones
+	ac.prepareCandidateLists()
+	ants := make([]*Ant, len(request.Starts))
+	for k, start := range request.Starts {
+		if start < 0 || start >= n {
+			return nil, fmt.Errorf("ant starts at city %d of %d", start, n)
+		}
+		ants[k] = ac.newAnt(start, request.Seeds[k])
+	}
+	ac.AntsMove(ants)
+	tours := make([][]int, len(ants))
+	for k, ant := range ants {
+		tours[k] = ant.Tour
+	}
+	return tours, nil
+}
+
+// RemoteConstruction moves the ants of a master's colony on construction
+// workers, splitting every iteration's ants between them and sending each
+// the trails as they stand. Tours come out as they would locally, except
+// under Ant Colony System, whose ants only see the local trail updates of
+// their own batch. A batch whose worker fails is moved locally
+type RemoteConstruction struct {
+	// Workers are the addresses of the workers, such as host:9100
+	Workers []string
+	// Instance and Config prepare the colony on the workers
+	Instance *Instance
+	Config   Config
+
+	client   *http.Client
+	mu       sync.Mutex
+	colonies map[string]string
+}
+
+// NewRemoteConstruction prepares to move the ants of a colony solving
+// instance with config on the workers at addresses
+func NewRemoteConstruction(addresses []string, instance *Instance, config Config) (*RemoteConstruction, error) {
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no construction workers")
+	}
+	if config.Adaptive {
+		return nil, fmt.Errorf("remote construction does not support adaptive parameters")
+	}
+	if config.Castes != "" {
+		return nil, fmt.Errorf("remote construction does not support castes")
+	}
+	return &RemoteConstruction{Workers: addresses, Instance: instance, Config: config,
+		client: grpcClient(), colonies: make(map[string]string)}, nil
+}
+
+// Construct moves ants, built by the colony, to complete tours
+func (rc *RemoteConstruction) Construct(ac *AntColony, ants []*Ant) {
+	if len(ac.Constraints.Clusters) > 0 {
+		ac.ensureClusterPheromones()
+	}
+	failed := make([][]*Ant, len(rc.Workers))
+	var wg sync.WaitGroup
+	for w, address := range rc.Workers {
+		batch := ants[w*len(ants)/len(rc.Workers) : (w+1)*len(ants)/len(rc.Workers)]
+		if len(batch) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := rc.construct(address, ac, batch); err != nil {
+				slog.Warn("moving ants locally", "worker", address, "err", err)
+				failed[w] = batch
+			}
+		}()
+	}
+	wg.Wait()
+	for _, batch := range failed {
+		if batch != nil {
+			ac.AntsMove(batch)
+		}
+	}
+}
+
+// construct moves a batch of ants on the worker at address, preparing the
+// colony there first if it does not hold it
+func (rc *RemoteConstruction) construct(address string, ac *AntColony, batch []*Ant) error {
+	request := ConstructRequest{Pheromones: ac.pheromoneMatrix(), ClusterPheromones: ac.ClusterPheromones}
+	for _, ant := range batch {
+		request.Starts = append(request.Starts, ant.Tour[0])
+		request.Seeds = append(request.Seeds, ant.seed)
+	}
+	var reply []byte
+	for attempt := 0; ; attempt++ {
+		colony, err := rc.prepare(address, attempt > 0)
+		if err != nil {
+			return err
+		}
+		request.Colony = colony
+		reply, err = grpcUnary(rc.client, address, grpcConstructPath, request.MarshalProto())
+		if err == nil {
+			break
+		}
+		if attempt > 0 || !strings.Contains(err.Error(), fmt.Sprintf("status %d:", grpcNotFound)) {
+			return err
+		}
+	}
+	tours, err := decodeTours(reply)
+	if err != nil {
+		return err
+	}
+	if len(tours) != len(batch) {
+		return fmt.Errorf("worker returned %d tours for %d ants", len(tours), len(batch))
+	}
+	for k, tour := range tours {
+		if err := ValidateTour(tour, len(ac.Cities), nil); err != nil {
+			return err
+		}
+		batch[k].Tour = tour
+	}
+	return nil
+}
+
+// prepare returns the name of the colony on the worker at address,
+// preparing it there on first use, or again if again is set
+func (rc *RemoteConstruction) prepare(address string, again bool) (string, error) {
+	rc.mu.Lock()
+	colony, ok := rc.colonies[address]
+	rc.mu.Unlock()
+	if ok && !again {
+		return colony, nil
+	}
+	config := rc.Config
+	var b protoBuffer
+	b.message(1, func(b *protoBuffer) { b.data = append(b.data, rc.Instance.MarshalProto()...) })
+	b.message(2, func(b *protoBuffer) { encodeConfig(b, &config) })
+	reply, err := grpcUnary(rc.client, address, grpcPreparePath, b.data)
+	if err != nil {
+		return "", fmt.Errorf("preparing colony: %w", err)
+	}
+	err = protoDecode(reply, func(f protoField) error {
+		if f.num == 1 {
+			colony = f.string()
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	rc.mu.Lock()
+	rc.colonies[address] = colony
+	rc.mu.Unlock()
+	return colony, nil
+}
+
+// workerCommand implements "worker", which serves construction for the
+// colonies of "solve -remote"
+func workerCommand(args []string) error {
+	flags := flag.NewFlagSet("worker", flag.ExitOnError)
+	logging := logFlags(flags)
+	listen := flags.String("listen", ":9100", "serve the aco.v1.Construction gRPC service at this `address`")
+	workers := flags.Int("workers", 0, "number of ants moving at once, 0 for one per CPU")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s worker [-listen address] [-workers n]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Moves ants for the colonies solved by \"solve -remote\", on the trails they send every iteration.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return fmt.Errorf("worker: want no arguments")
+	}
+	if _, err := logging(); err != nil {
+		return err
+	}
+	worker := NewConstructionWorker(*workers)
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	server := &http.Server{Addr: *listen, Handler: worker.Handler(), Protocols: &protocols, ReadHeaderTimeout: 10 * time.Second}
+	slog.Info("serving construction", "address", *listen, "workers", worker.Workers)
+	return server.ListenAndServe()
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Constructive heuristics, which build a single tour without trails, selected
+// by Config.SeedHeuristic to seed the trails and compared by "baseline"
+const (
+	// SeedNearestNeighbor always moves on to the nearest allowed city
+	SeedNearestNeighbor = "nearest"
+	// SeedGreedy takes edges shortest first, skipping those that would give
+	// a city a third edge or close a cycle too early (greedy edge matching)
+	SeedGreedy = "greedy"
+	// SeedSavings joins the cities into one route by the savings of Clarke
+	// and Wright over visiting each from a hub, the most central city
+	SeedSavings = "savings"
+	// SeedChristofides shortcuts an Euler tour of a minimum spanning tree and
+	// a matching of its odd-degree cities, matched greedily rather than
+	// optimally, so without the guarantee of Christofides' algorithm
+	SeedChristofides = "christofides"
+)
+
+// SeedHeuristics lists the names of the constructive heuristics
+var SeedHeuristics = []string{SeedNearestNeighbor, SeedGreedy, SeedSavings, SeedChristofides}
+
+// constructions builds a tour with each constructive heuristic. All but
+// SeedNearestNeighbor ignore constraints, and on asymmetric instances work on
+// the mean of the distances both ways
+var constructions = map[string]func(ac *AntColony) []int{
+	SeedNearestNeighbor: (*AntColony).nearestNeighborTour,
+	SeedGreedy:          (*AntColony).greedyTour,
+	SeedSavings:         (*AntColony).savingsTour,
+	SeedChristofides:    (*AntColony).christofidesTour,
+}
+
+// checkSeedHeuristic reports an error for unknown constructive heuristics; empty means none
+func checkSeedHeuristic(name string) error {
+	if name != "" && !slices.Contains(SeedHeuristics, name) {
+		return fmt.Errorf("unknown seed heuristic %q, want one of %v", name, SeedHeuristics)
+	}
+	return nil
+}
+
+// weightedEdge is an edge between cities A and B ranked by Weight
+type weightedEdge struct {
+	A, B   int
+	Weight float64
+}
+
+// meanDistance returns the mean of the distances between cities i and j both ways
+func (ac *AntColony) meanDistance(i, j int) float64 {
+	return (ac.distance(i, j) + ac.distance(j, i)) / 2
+}
+
+// pathFromEdges joins cities into a single path with edges taken in order,
+// each unless it would give a city a third edge or close a cycle, and
+// returns the path from its lower end
+func pathFromEdges(cities []int, edges []weightedEdge) []int {
+	if len(cities) < 2 {
+		return slices.Clone(cities)
+	}
+	sort.SliceStable(edges, func(a, b int) bool { return edges[a].Weight < edges[b].Weight })
+	links := make(map[int][]int, len(cities))
+	parent := make(map[int]int, len(cities))
+	for _, city := range cities {
+		parent[city] = city
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	taken := 0
+	for _, e := range edges {
+		if taken == len(cities)-1 {
+			break
+		}
+		if len(links[e.A]) == 2 || len(links[e.B]) == 2 || find(e.A) == find(e.B) {
+			continue
+		}
+		links[e.A] = append(links[e.A], e.B)
+		links[e.B] = append(links[e.B], e.A)
+		parent[find(e.A)] = find(e.B)
+		taken++
+	}
+	start := -1
+	for _, city := range cities {
+		if len(links[city]) < 2 && (start < 0 || city < start) {
+			start = city
+		}
+	}
+	path := []int{start}
+	for previous, city := -1, start; len(path) < len(cities); {
+		next := links[city][0]
+		if next == previous {
+			next = links[city][1]
+		}
+		path = append(path, next)
+		previous, city = city, next
+	}
+	return path
+}
+
+// openTour turns a cycle into the tour the colony builds: the cycle itself
+// for closed tours, or the path left by dropping its longest edge
+func (ac *AntColony) openTour(cycle []int) []int {
+	if ac.ReturnToStart || len(cycle) < 2 {
+		return cycle
+	}
+	longest, drop := math.Inf(-1), 0
+	for k := range cycle {
+		if d := ac.distance(cycle[k], cycle[(k+1)%len(cycle)]); d > longest {
+			longest, drop = d, k
+		}
+	}
+	return append(slices.Clone(cycle[drop+1:]), cycle[:drop+1]...)
+}
+
+// greedyTour builds a tour by greedy edge matching
+func (ac *AntColony) greedyTour() []int {
+	n := len(ac.Cities)
+	cities := make([]int, n)
+	var edges []weightedEdge
+	for i := range cities {
+		cities[i] = i
+		for j := i + 1; j < n; j++ {
+			edges = append(edges, weightedEdge{i, j, ac.meanDistance(i, j)})
+		}
+	}
+	return ac.openTour(pathFromEdges(cities, edges))
+}
+
+// savingsTour builds a tour by the savings algorithm
+func (ac *AntColony) savingsTour() []int {
+	n := len(ac.Cities)
+	if n < 3 {
+		return ac.greedyTour()
+	}
+	hub, least := 0, math.Inf(1)
+	for i := range n {
+		sum := 0.0
+		for j := range n {
+			sum += ac.meanDistance(i, j)
+		}
+		if sum < least {
+			hub, least = i, sum
+		}
+	}
+	var cities []int
+	var edges []weightedEdge
+	for i := range n {
+		if i == hub {
+			continue
+		}
+		cities = append(cities, i)
+		for j := i + 1; j < n; j++ {
+			if j != hub {
+				saving := ac.meanDistance(hub, i) + ac.meanDistance(hub, j) - ac.meanDistance(i, j)
+				edges = append(edges, weightedEdge{i, j, -saving})
+			}
+		}
+	}
+	return ac.openTour(append([]int{hub}, pathFromEdges(cities, edges)...))
+}
+
+// christofidesTour builds a tour from a minimum spanning tree and a greedy
+// matching of its odd-degree cities
+func (ac *AntColony) christofidesTour() []int {
+	n := len(ac.Cities)
+	if n < 3 {
+		return ac.greedyTour()
+	}
+	// Prim's algorithm on the complete graph
+	links := make([][]int, n)
+	inTree := make([]bool, n)
+	cost := make([]float64, n)
+	from := make([]int, n)
+	for i := range cost {
+		cost[i], from[i] = math.Inf(1), -1
+	}
+	cost[0] = 0
+	for range n {
+		next := -1
+		for i := range n {
+			if !inTree[i] && (next < 0 || cost[i] < cost[next]) {
+				next = i
+			}
+		}
+		inTree[next] = true
+		if from[next] >= 0 {
+			links[next] = append(links[next], from[next])
+			links[from[next]] = append(links[from[next]], next)
+		}
+		for i := range n {
+			if d := ac.meanDistance(next, i); !inTree[i] && d < cost[i] {
+				cost[i], from[i] = d, next
+			}
+		}
+	}
+
+	var odd []int
+	for i := range n {
+		if len(links[i])%2 == 1 {
+			odd = append(odd, i)
+		}
+	}
+	var pairs []weightedEdge
+	for a := range odd {
+		for b := a + 1; b < len(odd); b++ {
+			pairs = append(pairs, weightedEdge{odd[a], odd[b], ac.meanDistance(odd[a], odd[b])})
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool { return pairs[a].Weight < pairs[b].Weight })
+	matched := make([]bool, n)
+	for _, e := range pairs {
+		if !matched[e.A] && !matched[e.B] {
+			matched[e.A], matched[e.B] = true, true
+			links[e.A] = append(links[e.A], e.B)
+			links[e.B] = append(links[e.B], e.A)
+		}
+	}
+
+	// Hierholzer's algorithm, removing every edge as it is walked, then
+	// shortcutting cities already visited
+	remove := func(i, j int) {
+		k := slices.Index(links[i], j)
+		links[i] = slices.Delete(links[i], k, k+1)
+	}
+	stack, circuit := []int{0}, []int(nil)
+	for len(stack) > 0 {
+		city := stack[len(stack)-1]
+		if len(links[city]) == 0 {
+			circuit = append(circuit, city)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		next := links[city][0]
+		remove(city, next)
+		remove(next, city)
+		stack = append(stack, next)
+	}
+	visited := make([]bool, n)
+	var cycle []int
+	for _, city := range circuit {
+		if !visited[city] {
+			visited[city] = true
+			cycle = append(cycle, city)
+		}
+	}
+	return ac.openTour(cycle)
+}
+
+// baselineCommand implements "baseline", which compares the constructive
+// heuristics on an instance
+func baselineCommand(args []string) error {
+	flags := flag.NewFlagSet("baseline", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s baseline file\n\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Builds a tour of the instance with each constructive heuristic, %s, and prints\n", strings.Join(SeedHeuristics, ", "))
+		fmt.Fprintln(flags.Output(), "its length before and after 2-opt: the baselines the colony should beat, and the seeds")
+		fmt.Fprintln(flags.Output(), "-seed-heuristic lays on the trails before the first iteration.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("baseline: want exactly one instance file")
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	config := DefaultConfig()
+	if instance.Options != nil {
+		config = *instance.Options
+	}
+	solver, err := NewSolver(instance, config)
+	if err != nil {
+		return err
+	}
+	colony := solver.Colony
+	optimum, known := KnownOptima[instance.Name]
+	fmt.Printf("%s: %d cities\n", instanceName(instance, flags.Arg(0)), len(instance.Cities))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "heuristic\tlength\tgap\twith 2-opt\tgap\ttime\tvalid")
+	gap := func(length float64) string {
+		if !known {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f%%", Gap(length, optimum))
+	}
+	for _, name := range SeedHeuristics {
+		start := time.Now()
+		tour := constructions[name](colony)
+		elapsed := time.Since(start)
+		length := colony.TourLength(tour)
+		improved := colony.TourLength(colony.TwoOpt(tour))
+		valid := "yes"
+		if err := instance.ValidateTour(tour); err != nil {
+			valid = err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%.6g\t%s\t%.6g\t%s\t%s\t%s\n", name, length, gap(length), improved, gap(improved), elapsed.Round(time.Microsecond), valid)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// CrashDump is what a run that failed unexpectedly leaves behind: the best
+// tour so far, which the -tour flags read like solution JSON, and enough of
+// the run to tell how to continue it
+type CrashDump struct {
+	// Reason is the panic or signal that ended the run, and Stack the stack
+	// of a panic
+	Reason string    `json:"reason"`
+	Stack  string    `json:"stack,omitempty"`
+	Time   time.Time `json:"time"`
+	// Run names the run the tour belongs to, its instance and seed
+	Run       string  `json:"run,omitempty"`
+	Config    *Config `json:"config,omitempty"`
+	Iteration int     `json:"iteration"`
+	Tour      []int   `json:"tour,omitempty"`
+	Length    float64 `json:"length,omitempty"`
+}
+
+// CrashRecorder is a RunListener keeping the shortest tour of the runs it
+// hears of, to write it to Path should the program panic or be killed. It
+// keeps copies, so Write may run at any time, while the runs go on
+type CrashRecorder struct {
+	Path string
+
+	mu   sync.Mutex
+	dump CrashDump
+}
+
+// NewCrashRecorder returns a recorder writing to path
+func NewCrashRecorder(path string) *CrashRecorder {
+	return &CrashRecorder{Path: path}
+}
+
+// Publish records the configuration a run started with and the shortest tour
+// any run found
+func (r *CrashRecorder) Publish(event RunEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event.Config != nil && r.dump.Config == nil {
+		config := *event.Config
+		r.dump.Config = &config
+	}
+	if event.Length != nil && (r.dump.Tour == nil || *event.Length < r.dump.Length) {
+		r.dump.Run, r.dump.Iteration = event.Run, event.Iteration
+		r.dump.Tour, r.dump.Length = append([]int(nil), event.Tour...), *event.Length
+	}
+}
+
+// Close does nothing: a run that ends leaves no crash behind
+func (r *CrashRecorder) Close() error { return nil }
+
+// Write writes the crash dump for reason, with stack if it panicked, and logs
+// where it went
+func (r *CrashRecorder) Write(reason string, stack []byte) {
+	r.mu.Lock()
+	dump := r.dump
+	r.mu.Unlock()
+	dump.Reason, dump.Stack, dump.Time = reason, string(stack), time.Now()
+	if err := writeJSON(r.Path, &dump); err != nil {
+		slog.Error("writing crash dump", "path", r.Path, "err", err)
+		return
+	}
+	slog.Error("run crashed, best tour so far saved", "reason", reason, "path", r.Path, "length", dump.Length)
+}
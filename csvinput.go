@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvColumns locates the coordinate columns of a city CSV file
+type csvColumns struct {
+	name, x, y, z int
+	geographic    bool
+	// metadata is the column holding the cities' metadata as JSON, -1 if
+	// none; extra names the other columns, whose values then make up the
+	// metadata, by index
+	metadata int
+	extra    map[int]string
+}
+
+// csvHeaderNames maps recognized header names to the coordinate they hold
+var csvHeaderNames = map[string]string{
+	"x": "x", "lon": "x", "lng": "x", "long": "x", "longitude": "x",
+	"y": "y", "lat": "y", "latitude": "y",
+	"z": "z", "alt": "z", "altitude": "z", "elevation": "z",
+	"id": "name", "name": "name", "city": "name", "label": "name",
+}
+
+// LoadCitiesCSV reads cities from CSV rows of "id,x,y" or "name,lat,lon", or
+// plain "x,y". A header row naming the columns (x/y/z, lat/lon, ...) is
+// detected automatically; longitude maps to X and latitude to Y, and the id or
+// name column becomes the city's Name. A "metadata" column holding JSON
+// becomes the city's Metadata, or without one the columns the header names
+// otherwise do, as an object of strings by column name
+func LoadCitiesCSV(r io.Reader) ([]*City, error) {
+	inst, err := LoadInstanceCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	return inst.Cities, nil
+}
+
+// LoadInstanceCSV reads cities like LoadCitiesCSV and marks the instance
+// geographic when the header names latitude and longitude columns
+func LoadInstanceCSV(r io.Reader) (*Instance, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var cities []*City
+	var columns *csvColumns
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if columns == nil {
+			if columns, err = csvHeader(record); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if columns != nil {
+				continue
+			}
+			columns = defaultCSVColumns(len(record))
+		}
+		city, err := columns.city(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		cities = append(cities, city)
+	}
+	if len(cities) == 0 {
+		return nil, fmt.Errorf("no cities in CSV input")
+	}
+	return &Instance{Cities: cities, Geographic: columns.geographic}, nil
+}
+
+// csvHeader returns the columns named by record, or nil if record holds data
+func csvHeader(record []string) (*csvColumns, error) {
+	if isCSVData(record) {
+		return nil, nil
+	}
+	columns := &csvColumns{name: -1, x: -1, y: -1, z: -1, metadata: -1}
+	for i, name := range record {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if strings.HasPrefix(name, "lat") {
+			columns.geographic = true
+		}
+		if name == "metadata" {
+			columns.metadata = i
+			continue
+		}
+		if _, ok := csvHeaderNames[name]; !ok && name != "" {
+			if columns.extra == nil {
+				columns.extra = make(map[int]string)
+			}
+			columns.extra[i] = strings.TrimSpace(record[i])
+		}
+		switch csvHeaderNames[name] {
+		case "x":
+			columns.x = i
+		case "y":
+			columns.y = i
+		case "z":
+			columns.z = i
+		case "name":
+			if columns.name < 0 || name == "name" {
+				columns.name = i
+			}
+		}
+	}
+	if columns.x < 0 || columns.y < 0 {
+		return nil, fmt.Errorf("header %q must name x and y (or lat and lon) columns", strings.Join(record, ","))
+	}
+	return columns, nil
+}
+
+// isCSVData reports whether the trailing fields of record are numeric, as in a data row
+func isCSVData(record []string) bool {
+	if len(record) < 2 {
+		return false
+	}
+	for _, field := range record[len(record)-2:] {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultCSVColumns picks columns for headerless input: "x,y" or "id,x,y[,z]"
+func defaultCSVColumns(fields int) *csvColumns {
+	switch {
+	case fields == 2:
+		return &csvColumns{name: -1, x: 0, y: 1, z: -1, metadata: -1}
+	case fields >= 4:
+		return &csvColumns{name: 0, x: 1, y: 2, z: 3, metadata: -1}
+	default:
+		return &csvColumns{name: 0, x: 1, y: 2, z: -1, metadata: -1}
+	}
+}
+
+// city parses the coordinates of a data record
+func (c *csvColumns) city(record []string) (*City, error) {
+	parse := func(column int, name string) (float64, error) {
+		if column < 0 {
+			return 0, nil
+		}
+		if column >= len(record) {
+			return 0, fmt.Errorf("missing %s column (want at least %d fields, got %d)", name, column+1, len(record))
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(record[column]), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s %q is not a number", name, record[column])
+		}
+		return v, nil
+	}
+	x, err := parse(c.x, "x")
+	if err != nil {
+		return nil, err
+	}
+	y, err := parse(c.y, "y")
+	if err != nil {
+		return nil, err
+	}
+	z, err := parse(c.z, "z")
+	if err != nil {
+		return nil, err
+	}
+	city := &City{X: x, Y: y, Z: z}
+	if c.name >= 0 && c.name < len(record) {
+		city.Name = strings.TrimSpace(record[c.name])
+	}
+	if city.Metadata, err = c.cityMetadata(record); err != nil {
+		return nil, err
+	}
+	return city, nil
+}
+
+// cityMetadata returns the metadata of a data record: its metadata column,
+// or an object of its extra columns by name, nil if it has neither
+func (c *csvColumns) cityMetadata(record []string) (json.RawMessage, error) {
+	if c.metadata >= 0 {
+		if c.metadata >= len(record) || strings.TrimSpace(record[c.metadata]) == "" {
+			return nil, nil
+		}
+		value := strings.TrimSpace(record[c.metadata])
+		if !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("metadata %q is not valid JSON", value)
+		}
+		return json.RawMessage(value), nil
+	}
+	if len(c.extra) == 0 {
+		return nil, nil
+	}
+	// Build the object by hand to keep the columns in order
+	var object []byte
+	for i := range record {
+		name, ok := c.extra[i]
+		if !ok {
+			continue
+		}
+		key, _ := json.Marshal(name)
+		value, _ := json.Marshal(strings.TrimSpace(record[i]))
+		object = append(append(append(append(object, ','), key...), ':'), value...)
+	}
+	if object == nil {
+		return nil, nil
+	}
+	object[0] = '{'
+	return json.RawMessage(append(object, '}')), nil
+}
+
+// WriteCitiesCSV writes cities as "id,x,y" rows under a header, adding a z
+// column when any city has an altitude and a metadata column of JSON when
+// any has metadata; ids are the cities' labels
+func WriteCitiesCSV(w io.Writer, cities []*City) error {
+	threeD, metadata := false, hasMetadata(cities)
+	for _, c := range cities {
+		threeD = threeD || c.Z != 0
+	}
+	cw := csv.NewWriter(w)
+	header := []string{"id", "x", "y"}
+	if threeD {
+		header = append(header, "z")
+	}
+	if metadata {
+		header = append(header, "metadata")
+	}
+	cw.Write(header)
+	format := func(x float64) string { return strconv.FormatFloat(x, 'g', -1, 64) }
+	for i, c := range cities {
+		record := []string{c.Label(i), format(c.X), format(c.Y)}
+		if threeD {
+			record = append(record, format(c.Z))
+		}
+		if metadata {
+			record = append(record, string(c.Metadata))
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
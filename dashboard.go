@@ -0,0 +1,382 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//go:embed web/index.html
+var dashboardPage []byte
+
+// Dashboard runs the colony on an instance in the background and streams
+// its progress to the browsers watching, which can restart it with other
+// parameters. Only one run is live at a time; starting another stops it
+type Dashboard struct {
+	Instance *Instance
+	// Delay is waited between iterations so fast runs can be followed by eye
+	Delay time.Duration
+	// MaxUpdates caps the iteration events sent per second: the iterations
+	// in between go together in one "iterations" event, so batches grow as
+	// runs speed up and encoding and sending them cannot slow a fast run
+	// down. New best tours are always sent at once. 0 sends every iteration
+	// in an event of its own
+	MaxUpdates float64
+	// Metrics, if set, records the progress of the runs as "web/1", "web/2", ...
+	Metrics *Metrics
+	// Events, if set, receives the events of the runs under the same names
+	Events RunListener
+
+	mu     sync.Mutex
+	config Config
+	run    int
+	stop   chan struct{}
+	// start, best and done are the latest messages of their type in the
+	// current run, and history the statistics of its iterations, for
+	// clients connecting later to catch up
+	start   *dashboardMessage
+	best    *dashboardMessage
+	done    *dashboardMessage
+	history []dashboardStats
+	clients map[chan dashboardMessage]struct{}
+}
+
+// dashboardMessage is an encoded event together with its type
+type dashboardMessage struct {
+	kind string
+	data []byte
+}
+
+// dashboardMaxUpdates is the default Dashboard.MaxUpdates, about as often as
+// a chart can usefully be redrawn
+const dashboardMaxUpdates = 10
+
+// dashboardHistoryLimit caps the iterations a dashboard keeps for clients
+// connecting later: past it, every other one is dropped, so long runs still
+// replay from start to end, only more coarsely
+const dashboardHistoryLimit = 4096
+
+// dashboardEvent is a message streamed to the browsers: "start" with the
+// cities and parameters of a run, "iteration" with an iteration's
+// statistics, or "iterations" with a Batch of them under MaxUpdates, "best"
+// with a new best tour, and "done" at the end. Lengths are null while no
+// feasible tour is known
+type dashboardEvent struct {
+	Type      string   `json:"type"`
+	Run       int      `json:"run"`
+	Config    *Config  `json:"config,omitempty"`
+	Cities    []*City  `json:"cities,omitempty"`
+	Closed    bool     `json:"closed,omitempty"`
+	Iteration int      `json:"iteration,omitempty"`
+	Best      *float64 `json:"best,omitempty"`
+	Mean      *float64 `json:"mean,omitempty"`
+	Diversity float64  `json:"diversity,omitempty"`
+	Tour      []int    `json:"tour,omitempty"`
+	Length    *float64 `json:"length,omitempty"`
+	// Batch holds the statistics of the iterations of an "iterations" event, in order
+	Batch []dashboardStats `json:"batch,omitempty"`
+}
+
+// dashboardStats is the statistics of an iteration, as an "iteration" event
+// gives them
+type dashboardStats struct {
+	Iteration int      `json:"iteration"`
+	Best      *float64 `json:"best,omitempty"`
+	Mean      *float64 `json:"mean,omitempty"`
+	Diversity float64  `json:"diversity,omitempty"`
+}
+
+// finite returns a pointer to x, or nil if x is infinite or not a number
+func finite(x float64) *float64 {
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return nil
+	}
+	return &x
+}
+
+// NewDashboard prepares a dashboard for an instance, sending up to
+// dashboardMaxUpdates iteration events a second; nothing runs until Start
+func NewDashboard(instance *Instance, config Config) *Dashboard {
+	return &Dashboard{Instance: instance, MaxUpdates: dashboardMaxUpdates, config: config, clients: make(map[chan dashboardMessage]struct{})}
+}
+
+// Start stops the current run, if any, and solves the instance with config
+func (d *Dashboard) Start(config Config) error {
+	solver, err := NewSolver(d.Instance, config)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	if d.stop != nil {
+		close(d.stop)
+	}
+	stop := make(chan struct{})
+	d.run++
+	run := d.run
+	d.config, d.stop = config, stop
+	d.start, d.best, d.done, d.history = nil, nil, nil, nil
+	d.mu.Unlock()
+
+	d.publish(dashboardEvent{Type: "start", Run: run, Config: &config, Cities: d.Instance.Cities, Closed: d.Instance.ReturnToStart})
+	go d.solve(run, solver, stop)
+	return nil
+}
+
+// Stop ends the current run after its iteration in progress
+func (d *Dashboard) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stop != nil {
+		close(d.stop)
+		d.stop = nil
+	}
+}
+
+// Config returns the parameters of the latest run
+func (d *Dashboard) Config() Config {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.config
+}
+
+// solve runs the solver until its iterations are done or stop is closed
+func (d *Dashboard) solve(run int, solver *Solver, stop chan struct{}) {
+	name := "web/" + strconv.Itoa(run)
+	defer d.Metrics.Finish(name)
+	solver.Events, solver.EventRun = d.Events, name
+	shortest := math.Inf(1)
+	// batch holds the iterations since the last event, sent once MaxUpdates
+	// allows another one
+	var batch []dashboardStats
+	var sent time.Time
+	flush := func() {
+		if len(batch) > 0 {
+			d.publish(dashboardEvent{Type: "iterations", Run: run, Batch: batch})
+			batch, sent = nil, time.Now()
+		}
+	}
+	defer flush()
+	for !solver.Done() {
+		select {
+		case <-stop:
+			solver.publish(EventRunFinished, RunCancelled)
+			flush()
+			d.publish(dashboardEvent{Type: "done", Run: run})
+			return
+		default:
+		}
+		stats := solver.Step()
+		d.Metrics.Observe(name, solver, stats)
+		s := dashboardStats{Iteration: stats.Iteration + 1, Best: finite(stats.BestSoFar), Mean: finite(stats.Mean), Diversity: stats.Diversity}
+		if d.MaxUpdates > 0 {
+			batch = append(batch, s)
+			if time.Since(sent).Seconds() >= 1/d.MaxUpdates {
+				flush()
+			}
+		} else {
+			d.publish(dashboardEvent{Type: "iteration", Run: run, Iteration: s.Iteration, Best: s.Best, Mean: s.Mean, Diversity: s.Diversity})
+		}
+		if stats.BestSoFar < shortest {
+			shortest = stats.BestSoFar
+			solution := solver.Solution()
+			d.publish(dashboardEvent{Type: "best", Run: run, Iteration: stats.Iteration + 1,
+				Tour: solution.Tour, Length: finite(solution.Length)})
+		}
+		if d.Delay > 0 {
+			time.Sleep(d.Delay)
+		}
+	}
+	flush()
+	d.publish(dashboardEvent{Type: "done", Run: run})
+}
+
+// publish sends an event of the current run to every client, keeping what a
+// client connecting later needs to catch up. Clients too slow to keep up
+// miss events rather than hold up the run
+func (d *Dashboard) publish(event dashboardEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("encoding dashboard event", "err", err)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if event.Run != d.run {
+		return
+	}
+	message := dashboardMessage{kind: event.Type, data: data}
+	switch event.Type {
+	case "start":
+		d.start = &message
+	case "iteration":
+		d.record(dashboardStats{Iteration: event.Iteration, Best: event.Best, Mean: event.Mean, Diversity: event.Diversity})
+	case "iterations":
+		d.record(event.Batch...)
+	case "best":
+		d.best = &message
+	case "done":
+		d.done = &message
+	}
+	for client := range d.clients {
+		select {
+		case client <- message:
+		default:
+		}
+	}
+}
+
+// record adds iterations to the history of the current run, thinning it to
+// every other iteration when it grows past dashboardHistoryLimit. The latest
+// iteration is always kept
+func (d *Dashboard) record(stats ...dashboardStats) {
+	d.history = append(d.history, stats...)
+	for len(d.history) > dashboardHistoryLimit {
+		last := len(d.history) - 1
+		kept := d.history[:0]
+		for k, s := range d.history {
+			if k%2 == last%2 {
+				kept = append(kept, s)
+			}
+		}
+		d.history = kept
+	}
+}
+
+// subscribe registers a client and returns the events of the current run so
+// far: its start, its iterations merged into one "iterations" event, its
+// best tour and its end
+func (d *Dashboard) subscribe() (chan dashboardMessage, []dashboardMessage) {
+	client := make(chan dashboardMessage, 1024)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clients[client] = struct{}{}
+	var history []dashboardMessage
+	if d.start != nil {
+		history = append(history, *d.start)
+	}
+	if len(d.history) > 0 {
+		data, err := json.Marshal(dashboardEvent{Type: "iterations", Run: d.run, Batch: d.history})
+		if err != nil {
+			slog.Error("encoding dashboard event", "err", err)
+		} else {
+			history = append(history, dashboardMessage{kind: "iterations", data: data})
+		}
+	}
+	for _, message := range []*dashboardMessage{d.best, d.done} {
+		if message != nil {
+			history = append(history, *message)
+		}
+	}
+	return client, history
+}
+
+// unsubscribe forgets a client
+func (d *Dashboard) unsubscribe(client chan dashboardMessage) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.clients, client)
+}
+
+// Handler serves the page at /, the events over a WebSocket at /ws and as
+// Server-Sent Events at /events, the parameters at /config, and starts and
+// stops runs on POST to /run and /stop
+func (d *Dashboard) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(dashboardPage)
+	})
+	mux.HandleFunc("GET /ws", d.serveWebSocket)
+	mux.HandleFunc("GET /events", d.serveEvents)
+	mux.HandleFunc("GET /config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(d.Config())
+	})
+	mux.HandleFunc("POST /run", func(w http.ResponseWriter, r *http.Request) {
+		// Parameters left out of the body keep their current values
+		type plain Config
+		config := d.Config()
+		if err := json.NewDecoder(r.Body).Decode((*plain)(&config)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := d.Start(config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("POST /stop", func(w http.ResponseWriter, r *http.Request) {
+		d.Stop()
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return mux
+}
+
+// serveWebSocket streams the events of the current run and those after it
+// to a browser, starting with what happened before it connected
+func (d *Dashboard) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		slog.Debug("websocket", "err", err)
+		return
+	}
+	defer conn.Close()
+	client, history := d.subscribe()
+	defer d.unsubscribe(client)
+	closed := make(chan struct{})
+	go func() {
+		conn.readUntilClose()
+		close(closed)
+	}()
+	for _, message := range history {
+		if err := conn.WriteText(message.data); err != nil {
+			return
+		}
+	}
+	for {
+		select {
+		case message := <-client:
+			if err := conn.WriteText(message.data); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// serveEvents streams the same events as Server-Sent Events, named by their
+// type, so scripts can follow a run with nothing but an HTTP client
+func (d *Dashboard) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	client, history := d.subscribe()
+	defer d.unsubscribe(client)
+	for _, message := range history {
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.kind, message.data)
+	}
+	flusher.Flush()
+	for {
+		select {
+		case message := <-client:
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.kind, message.data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
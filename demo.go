@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// exampleFiles holds the small instances of Examples made for demos
+//
+//go:embed examples/*.json
+var exampleFiles embed.FS
+
+// Example is an instance built into the binary, for demo to solve without
+// the user having to find one
+type Example struct {
+	Name string
+	// Summary says what the example is and what to watch for as it is solved
+	Summary string
+	// Optimum is the length of the shortest tour where it is known
+	Optimum float64
+	files   embed.FS
+	path    string
+}
+
+// Examples lists the built-in examples, smallest first
+var Examples = []Example{
+	{Name: "delivery", Summary: "a courier's route from the depot, picking up five parcels before delivering them", files: exampleFiles, path: "examples/delivery.json"},
+	{Name: "circle", Summary: "12 cities on a circle, whose shortest tour plainly goes round it", files: exampleFiles, path: "examples/circle.json"},
+	{Name: "towns", Summary: "20 named towns carrying their population as metadata", files: exampleFiles, path: "examples/towns.json"},
+	{Name: "clusters", Summary: "30 cities in three clusters, which the tour should enter and leave once each", files: exampleFiles, path: "examples/clusters.json"},
+	{Name: "grid", Summary: "36 cities on a 6x6 grid, with many tours of the shortest length", Optimum: 360, files: exampleFiles, path: "examples/grid.json"},
+	{Name: "eil51", Summary: "the classic TSPLIB benchmark of 51 cities", Optimum: KnownOptima["eil51"], files: benchmarkFiles, path: "benchmarks/eil51.tsp"},
+	{Name: "berlin52", Summary: "52 places in Berlin, from TSPLIB", Optimum: KnownOptima["berlin52"], files: benchmarkFiles, path: "benchmarks/berlin52.tsp"},
+}
+
+// exampleNames lists the names of Examples in order
+func exampleNames() []string {
+	names := make([]string, len(Examples))
+	for k, e := range Examples {
+		names[k] = e.Name
+	}
+	return names
+}
+
+// LoadExample returns the built-in example of a name
+func LoadExample(name string) (*Instance, *Example, error) {
+	for k := range Examples {
+		e := &Examples[k]
+		if e.Name != name {
+			continue
+		}
+		data, err := e.files.ReadFile(e.path)
+		if err != nil {
+			return nil, nil, err
+		}
+		instance, err := parseInstance(DetectFormat(e.path, data), data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("example %s: %w", name, err)
+		}
+		return instance, e, nil
+	}
+	return nil, nil, fmt.Errorf("unknown example %q, want one of %s", name, strings.Join(exampleNames(), ", "))
+}
+
+// demoCommand implements "demo", which solves a built-in example while
+// drawing the best tour as it improves, in the terminal or on the dashboard,
+// so the solver can be seen working before any instance of one's own
+func demoCommand(args []string) error {
+	flags := flag.NewFlagSet("demo", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	list := flags.Bool("list", false, "list the examples and exit")
+	webAddr := flags.String("web", "", "follow the run on the live dashboard at this `address`, such as :8080, instead of in the terminal")
+	delay := flags.Duration("delay", 30*time.Millisecond, "wait this long between iterations so the run can be followed")
+	asciiMap := flags.Bool("ascii", false, "draw the terminal map with ASCII characters only")
+	outPath := flags.String("o", "", "instead of solving the example, write it as JSON to this `file`, - for stdout, to start from")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s demo [flags] [example]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Solves one of the built-in examples, towns if none is named, redrawing the best tour in the")
+		fmt.Fprintln(flags.Output(), "terminal as it improves, or on the dashboard with -web. Parameter flags apply as for solve.")
+		fmt.Fprintln(flags.Output(), "\nExamples:")
+		tw := tabwriter.NewWriter(flags.Output(), 0, 0, 2, ' ', 0)
+		for _, e := range Examples {
+			fmt.Fprintf(tw, "  %s\t%s\n", e.Name, e.Summary)
+		}
+		tw.Flush()
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *list {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, e := range Examples {
+			fmt.Fprintf(tw, "%s\t%s\n", e.Name, e.Summary)
+		}
+		return tw.Flush()
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("demo: want at most one example")
+	}
+	name := "towns"
+	if flags.NArg() == 1 {
+		name = flags.Arg(0)
+	}
+	instance, example, err := LoadExample(name)
+	if err != nil {
+		return fmt.Errorf("demo: %w", err)
+	}
+	if *outPath != "" {
+		return writeJSON(*outPath, instance)
+	}
+	config, err := instanceConfig(instance, parameters, true)
+	if err != nil {
+		return err
+	}
+
+	if *webAddr != "" {
+		dashboard := NewDashboard(instance, config)
+		dashboard.Delay = *delay
+		if err := dashboard.Start(config); err != nil {
+			return err
+		}
+		url := *webAddr
+		if strings.HasPrefix(url, ":") {
+			url = "localhost" + url
+		}
+		slog.Info("serving dashboard", "example", example.Name, "url", "http://"+url)
+		server := &http.Server{Addr: *webAddr, Handler: dashboard.Handler(), ReadHeaderTimeout: 10 * time.Second}
+		return server.ListenAndServe()
+	}
+
+	solver, err := NewSolver(instance, config)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	live := NewLiveMap(os.Stdout, instance.Cities, instance.ReturnToStart, *asciiMap, config.iterationLimit())
+	for !solver.Done() {
+		stats, err := solver.StepContext(ctx)
+		if err != nil {
+			break
+		}
+		live.Update(solver, stats)
+		time.Sleep(*delay)
+	}
+	solution := solver.Solution()
+	if len(solution.Tour) == 0 {
+		return fmt.Errorf("demo: stopped before the first tour")
+	}
+	fmt.Printf("\nBest tour of %s after %d iterations: length %.6g", example.Name, len(solution.History), solution.Length)
+	if example.Optimum > 0 {
+		fmt.Printf(" (shortest %g, gap %.2f%%)", example.Optimum, 100*(solution.Length-example.Optimum)/example.Optimum)
+	}
+	fmt.Printf("\n%s\n", FormatTour(instance.Cities, solution.Tour))
+	fmt.Printf("\nTo solve it yourself: %s demo -o %s.json %s && %s solve -map end %s.json\n", os.Args[0], name, name, os.Args[0], name)
+	return nil
+}
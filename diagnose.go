@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// Crossing is a pair of tour edges that intersect in the plane, each given by
+// the position of the city it leaves in the tour
+type Crossing struct {
+	First  int `json:"first"`
+	Second int `json:"second"`
+	// Gain is how much shorter the 2-opt move that uncrosses the edges makes the tour
+	Gain float64 `json:"gain"`
+}
+
+// TourDiagnostics describes the quality of a tour of a Euclidean instance
+type TourDiagnostics struct {
+	Length float64 `json:"length"`
+	// Crossings lists the pairs of crossing edges, the largest gain first
+	Crossings []Crossing `json:"crossings"`
+	// MaxGain is the largest gain of uncrossing a single pair of edges
+	MaxGain float64 `json:"max_gain"`
+	// TwoOptLength is the length 2-opt brings the tour down to, and
+	// Improvement that improvement in percent, the gain local search offers
+	TwoOptLength float64 `json:"two_opt_length"`
+	Improvement  float64 `json:"improvement"`
+	// NeedsLocalSearch tells that the tour crosses itself, which no tour that
+	// has been through 2-opt does when the triangle inequality holds
+	NeedsLocalSearch bool `json:"needs_local_search"`
+}
+
+// DiagnoseTour finds the crossing edges of tour, measured on the cities'
+// planar coordinates, and the improvement 2-opt finds on it
+func (ac *AntColony) DiagnoseTour(tour []int) *TourDiagnostics {
+	type edge struct{ position, from, to int }
+	var edges []edge
+	position := 0
+	ac.forEachEdge(tour, func(i, j int) {
+		edges = append(edges, edge{position, i, j})
+		position++
+	})
+	d := &TourDiagnostics{Length: ac.TourLength(tour), Crossings: []Crossing{}}
+	for a := range edges {
+		for b := a + 1; b < len(edges); b++ {
+			e, f := edges[a], edges[b]
+			if e.from == f.from || e.from == f.to || e.to == f.from || e.to == f.to {
+				continue
+			}
+			if !segmentsCross(ac.Cities[e.from], ac.Cities[e.to], ac.Cities[f.from], ac.Cities[f.to]) {
+				continue
+			}
+			cost := ac.distance
+			gain := cost(e.from, e.to) + cost(f.from, f.to) - cost(e.from, f.from) - cost(e.to, f.to)
+			d.Crossings = append(d.Crossings, Crossing{First: e.position, Second: f.position, Gain: gain})
+			d.MaxGain = max(d.MaxGain, gain)
+		}
+	}
+	sort.SliceStable(d.Crossings, func(a, b int) bool { return d.Crossings[a].Gain > d.Crossings[b].Gain })
+	d.TwoOptLength = ac.TourLength(ac.TwoOpt(tour))
+	if d.Length > 0 {
+		d.Improvement = 100 * (d.Length - d.TwoOptLength) / d.Length
+	}
+	d.NeedsLocalSearch = len(d.Crossings) > 0
+	return d
+}
+
+// segmentsCross reports whether the segments ab and cd cross at a point
+// inside both, in the X-Y plane
+func segmentsCross(a, b, c, d *City) bool {
+	orientation := func(p, q, r *City) float64 {
+		return (q.X-p.X)*(r.Y-p.Y) - (q.Y-p.Y)*(r.X-p.X)
+	}
+	d1, d2 := orientation(a, b, c), orientation(a, b, d)
+	d3, d4 := orientation(c, d, a), orientation(c, d, b)
+	return d1*d2 < 0 && d3*d4 < 0
+}
+
+// diagnoseCommand implements "diagnose", which reports the crossing edges of
+// a tour and how much local search would shorten it
+func diagnoseCommand(args []string) error {
+	flags := flag.NewFlagSet("diagnose", flag.ExitOnError)
+	tourPath := flags.String("tour", "", "diagnose the tour in this solution JSON or TSPLIB/Concorde tour `file`")
+	format := flags.String("format", "text", "print the diagnostics as text or json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s diagnose -tour file [flags] instance\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Counts the pairs of edges of a tour that cross each other and measures the improvement 2-opt")
+		fmt.Fprintln(flags.Output(), "finds. A tour that crosses itself has clearly not been through local search. The exit status")
+		fmt.Fprintln(flags.Output(), "is 1 when it does, for scripts grading tours.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 || *tourPath == "" {
+		flags.Usage()
+		return fmt.Errorf("diagnose: want a -tour file and exactly one instance file")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("diagnose: unknown format %q, want text or json", *format)
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	if !located(instance.Cities) {
+		return errors.New("diagnose: crossings need cities with coordinates, not only distances")
+	}
+	tour, err := loadTourFile(*tourPath)
+	if err != nil {
+		return err
+	}
+	if err := instance.ValidateTour(tour); err != nil {
+		return fmt.Errorf("%s: %w", *tourPath, err)
+	}
+	config := DefaultConfig()
+	if instance.Options != nil {
+		config = *instance.Options
+	}
+	solver, err := NewSolver(instance, config)
+	if err != nil {
+		return err
+	}
+	diagnostics := solver.Colony.DiagnoseTour(tour)
+	if *format == "json" {
+		err = writeJSON("-", diagnostics)
+	} else {
+		err = printDiagnostics(tour, diagnostics)
+	}
+	if err == nil && diagnostics.NeedsLocalSearch {
+		err = fmt.Errorf("diagnose: the tour crosses itself %d times, local search is needed", len(diagnostics.Crossings))
+	}
+	return err
+}
+
+// printDiagnostics prints tour diagnostics as text, followed by the crossings
+func printDiagnostics(tour []int, d *TourDiagnostics) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "length\t%g\n", d.Length)
+	fmt.Fprintf(tw, "crossing edge pairs\t%d\n", len(d.Crossings))
+	if len(d.Crossings) > 0 {
+		fmt.Fprintf(tw, "largest uncrossing gain\t%g\n", d.MaxGain)
+	}
+	fmt.Fprintf(tw, "after 2-opt\t%g (%.2f%% shorter)\n", d.TwoOptLength, d.Improvement)
+	verdict := "no crossings"
+	if d.NeedsLocalSearch {
+		verdict = "local search needed"
+	}
+	fmt.Fprintf(tw, "verdict\t%s\n", verdict)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	city := func(position int) int { return tour[position%len(tour)] }
+	for _, c := range d.Crossings {
+		fmt.Printf("  %d-%d crosses %d-%d, gain %g\n", city(c.First), city(c.First+1), city(c.Second), city(c.Second+1), c.Gain)
+	}
+	return nil
+}
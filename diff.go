@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// TourSegment is a run of cities two tours visit in the same order, or in
+// reverse, as the second tour visits it
+type TourSegment struct {
+	// From and To are the positions of the segment's first and last city in
+	// the first tour
+	From   int `json:"from"`
+	To     int `json:"to"`
+	Cities int `json:"cities"`
+	// Reversed is set if the second tour runs through the segment against the first
+	Reversed bool `json:"reversed,omitempty"`
+}
+
+// TourDiff is how two tours of the same cities differ
+type TourDiff struct {
+	// LengthA and LengthB are the lengths of the tours, zero where unknown
+	LengthA float64 `json:"length_a,omitempty"`
+	LengthB float64 `json:"length_b,omitempty"`
+	// Edges is the number of edges of each tour, SharedEdges of those both take
+	Edges       int `json:"edges"`
+	SharedEdges int `json:"shared_edges"`
+	// OnlyA and OnlyB are the edges only the first or the second tour takes
+	OnlyA [][2]int `json:"only_a"`
+	OnlyB [][2]int `json:"only_b"`
+	// Segments are the pieces of the first tour the second is laid out from,
+	// in the order it visits them: the fewest there can be, as the second
+	// tour must leave the first at each of its edges the first does not take
+	Segments []TourSegment `json:"segments"`
+}
+
+// tourEdges returns the edges of a tour in order, the closing one last if
+// closed, each with its lower city first unless directed
+func tourEdges(tour []int, closed, directed bool) [][2]int {
+	n := len(tour) - 1
+	if closed && len(tour) > 1 {
+		n = len(tour)
+	}
+	edges := make([][2]int, 0, max(n, 0))
+	for k := 0; k < n; k++ {
+		i, j := tour[k], tour[(k+1)%len(tour)]
+		if !directed && i > j {
+			i, j = j, i
+		}
+		edges = append(edges, [2]int{i, j})
+	}
+	return edges
+}
+
+// DiffTours compares tour b with tour a, both of the same cities, closed if
+// they return to start; edges taken in opposite directions count as shared
+// unless directed
+func DiffTours(a, b []int, closed, directed bool) (*TourDiff, error) {
+	if err := validateTour(a, len(a), nil, false); err != nil {
+		return nil, fmt.Errorf("first tour: %w", err)
+	}
+	if err := validateTour(b, len(a), nil, false); err != nil {
+		return nil, fmt.Errorf("second tour: %w", err)
+	}
+	edgesA, edgesB := tourEdges(a, closed, directed), tourEdges(b, closed, directed)
+	inA := make(map[[2]int]bool, len(edgesA))
+	for _, e := range edgesA {
+		inA[e] = true
+	}
+	inB := make(map[[2]int]bool, len(edgesB))
+	diff := &TourDiff{Edges: len(edgesA)}
+	var cuts []int
+	for k, e := range edgesB {
+		inB[e] = true
+		if inA[e] {
+			diff.SharedEdges++
+		} else {
+			diff.OnlyB = append(diff.OnlyB, e)
+			cuts = append(cuts, k)
+		}
+	}
+	for _, e := range edgesA {
+		if !inB[e] {
+			diff.OnlyA = append(diff.OnlyA, e)
+		}
+	}
+
+	n := len(b)
+	position := make([]int, n)
+	for p, city := range a {
+		position[city] = p
+	}
+	// A closed tour is read from just after a cut, so no segment wraps around
+	start := 0
+	if closed && len(cuts) > 0 {
+		start = (cuts[0] + 1) % n
+	}
+	cut := make(map[int]bool, len(cuts))
+	for _, k := range cuts {
+		cut[k] = true
+	}
+	first := start
+	for k := 0; k < n; k++ {
+		p := (start + k) % n
+		if k < n-1 && !cut[p] {
+			continue
+		}
+		segment := TourSegment{From: position[b[first]], To: position[b[p]], Cities: (p-first+n)%n + 1}
+		if segment.Cities > 1 {
+			next := position[b[(first+1)%n]]
+			segment.Reversed = next != (segment.From+1)%n
+		}
+		diff.Segments = append(diff.Segments, segment)
+		first = (p + 1) % n
+	}
+	return diff, nil
+}
+
+// Colours of tour diff overlays, besides routeColor for the first tour's own edges
+var (
+	sharedColor = color.RGBA{0xc0, 0xc0, 0xc0, 0xff} // silver
+	onlyBColor  = color.RGBA{0xff, 0x8c, 0x00, 0xff} // darkorange
+)
+
+// RenderTourDiff draws the cities and the edges of two tours over each
+// other: those both take in grey, those only the first takes in blue and
+// those only the second takes in orange
+func RenderTourDiff(w io.Writer, cities []*City, diff *TourDiff, shared [][2]int, options RenderOptions) error {
+	if options.Size == 0 {
+		options.Size = svgSize
+	}
+	if options.Size <= 2*svgMargin {
+		return fmt.Errorf("image size %d is too small", options.Size)
+	}
+	layers := []struct {
+		edges [][2]int
+		color color.RGBA
+		name  string
+	}{
+		{shared, sharedColor, "silver"},
+		{diff.OnlyA, routeColor, "steelblue"},
+		{diff.OnlyB, onlyBColor, "darkorange"},
+	}
+	project := newProjection(cities, options.Size)
+	switch options.Format {
+	case "", "svg":
+		bw := bufio.NewWriter(w)
+		size := options.Size
+		fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", size, size, size, size)
+		fmt.Fprintln(bw, `<rect width="100%" height="100%" fill="white"/>`)
+		for _, layer := range layers {
+			for _, e := range layer.edges {
+				x0, y0 := project.point(cities[e[0]])
+				x1, y1 := project.point(cities[e[1]])
+				fmt.Fprintf(bw, "<line x1=\"%.2f\" y1=\"%.2f\" x2=\"%.2f\" y2=\"%.2f\" stroke=\"%s\" stroke-width=\"1.5\"/>\n", x0, y0, x1, y1, layer.name)
+			}
+		}
+		for _, c := range cities {
+			x, y := project.point(c)
+			fmt.Fprintf(bw, "<circle cx=\"%.2f\" cy=\"%.2f\" r=\"3\" fill=\"firebrick\"/>\n", x, y)
+		}
+		fmt.Fprintln(bw, "</svg>")
+		return bw.Flush()
+	case "png":
+		img := newCanvas(options.Size, options.Size)
+		for _, layer := range layers {
+			for _, e := range layer.edges {
+				x0, y0 := project.point(cities[e[0]])
+				x1, y1 := project.point(cities[e[1]])
+				drawLine(img, x0, y0, x1, y1, layer.color)
+			}
+		}
+		for _, c := range cities {
+			x, y := project.point(c)
+			fillCircle(img, x, y, 3, cityColor)
+		}
+		return png.Encode(w, img)
+	}
+	return fmt.Errorf("unknown image format %q, want svg or png", options.Format)
+}
+
+// diffCommand implements "diff", which tells how two tours of the same
+// cities differ: in length, in the edges they share and in the segments of
+// the first the second is laid out from
+func diffCommand(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	instancePath := flags.String("instance", "", "score the tours on the instance in this `file`, and take from it whether they return to start")
+	open := flags.Bool("open", false, "without -instance, compare the tours as paths that do not return to start")
+	directed := flags.Bool("directed", false, "count an edge taken in opposite directions as different, as on asymmetric instances")
+	overlayPath := flags.String("o", "", "draw both tours over each other to this SVG or PNG `file`, which needs -instance")
+	size := flags.Int("size", svgSize, "width and height of the -o image in `pixels`")
+	format := flags.String("format", "text", "print the comparison as text or json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s diff [flags] tourA tourB\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Compares two tours, in solution JSON or TSPLIB/Concorde tour files: their lengths, the edges they")
+		fmt.Fprintln(flags.Output(), "share and the fewest segments of the first the second is made of.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("diff: want exactly two tour files")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("diff: unknown format %q, want text or json", *format)
+	}
+	if *overlayPath != "" && *instancePath == "" {
+		return fmt.Errorf("diff: -o needs the -instance the tours visit")
+	}
+	solutions := make([]*Solution, 2)
+	for k := range solutions {
+		var err error
+		if solutions[k], err = loadSolutionFile(flags.Arg(k)); err != nil {
+			return err
+		}
+	}
+	a, b := solutions[0], solutions[1]
+	closed := !*open
+	var instance *Instance
+	if *instancePath != "" {
+		var err error
+		if instance, err = ReadInstance(*instancePath, ""); err != nil {
+			return err
+		}
+		closed = instance.ReturnToStart
+		for k, s := range solutions {
+			if s.Length, err = ScoreTour(instance, s.Tour, ScoreMatrix); err != nil {
+				return fmt.Errorf("%s: %w", flags.Arg(k), err)
+			}
+		}
+	}
+	diff, err := DiffTours(a.Tour, b.Tour, closed, *directed)
+	if err != nil {
+		return err
+	}
+	diff.LengthA, diff.LengthB = a.Length, b.Length
+
+	if *overlayPath != "" {
+		var shared [][2]int
+		onlyA := make(map[[2]int]bool, len(diff.OnlyA))
+		for _, e := range diff.OnlyA {
+			onlyA[e] = true
+		}
+		for _, e := range tourEdges(a.Tour, closed, *directed) {
+			if !onlyA[e] {
+				shared = append(shared, e)
+			}
+		}
+		options := RenderOptions{Format: renderFormat(*overlayPath), Size: *size}
+		if err := writeFile(*overlayPath, func(w io.Writer) error {
+			return RenderTourDiff(w, instance.Cities, diff, shared, options)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	for k, s := range solutions {
+		fmt.Printf("Tour %c: %s", 'A'+k, flags.Arg(k))
+		if s.Length > 0 {
+			fmt.Printf(", length %g", s.Length)
+		}
+		if k == 1 && a.Length > 0 && b.Length > 0 {
+			fmt.Printf(" (%+g, %+.2f%%)", b.Length-a.Length, 100*(b.Length-a.Length)/a.Length)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Shared edges: %d of %d (%.1f%%)\n", diff.SharedEdges, diff.Edges, 100*float64(diff.SharedEdges)/float64(max(diff.Edges, 1)))
+	if len(diff.OnlyB) == 0 {
+		fmt.Println("The tours take the same edges")
+		return nil
+	}
+	fmt.Printf("Tour B lays out %d segments of tour A:\n", len(diff.Segments))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEGMENT\tA POSITIONS\tCITIES\tDIRECTION")
+	for k, s := range diff.Segments {
+		direction := "forward"
+		if s.Reversed {
+			direction = "reversed"
+		}
+		fmt.Fprintf(tw, "%d\t%d-%d\t%d\t%s\n", k+1, s.From, s.To, s.Cities, direction)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WritePheromoneDOT writes the cities and the edges carrying at least
+// threshold of the strongest trail as an undirected Graphviz graph. Edges
+// are drawn wider and darker the more pheromone they carry, with the amount
+// in their tooltip, and those of tour are red. Cities are pinned at their
+// coordinates, so neato -n or fdp lays the graph out like the map
+func WritePheromoneDOT(w io.Writer, cities []*City, pheromones [][]float64, tour []int, closed bool, threshold float64) error {
+	if len(pheromones) != len(cities) {
+		return fmt.Errorf("pheromone matrix has %d rows for %d cities", len(pheromones), len(cities))
+	}
+	onTour := make(map[[2]int]bool)
+	for k := 1; k < len(tour); k++ {
+		onTour[edgeKey(tour[k-1], tour[k])] = true
+	}
+	if closed && len(tour) > 1 {
+		onTour[edgeKey(tour[len(tour)-1], tour[0])] = true
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "graph pheromones {")
+	fmt.Fprintln(bw, "  graph [overlap=true, splines=false];")
+	fmt.Fprintln(bw, "  node [shape=circle, width=0.1, fixedsize=true, fontsize=8, label=\"\"];")
+	project := newProjection(cities, svgSize)
+	for i, c := range cities {
+		x, y := project.point(c)
+		fmt.Fprintf(bw, "  %d [xlabel=%s, pos=\"%.2f,%.2f!\"];\n", i, strconv.Quote(c.Label(i)), x, float64(svgSize)-y)
+	}
+	for _, e := range trailEdges(pheromones, strongestTrail(pheromones), threshold) {
+		colour := fmt.Sprintf("#4682b4%02x", int(64+191*e.strength))
+		if onTour[edgeKey(e.i, e.j)] {
+			colour = "firebrick"
+		}
+		fmt.Fprintf(bw, "  %d -- %d [penwidth=%.2f, color=%q, tooltip=\"%.4g\"];\n",
+			e.i, e.j, 0.5+4*e.strength, colour, (pheromones[e.i][e.j]+pheromones[e.j][e.i])/2)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// edgeKey names an undirected edge the same whichever way it is walked
+func edgeKey(i, j int) [2]int {
+	if i > j {
+		i, j = j, i
+	}
+	return [2]int{i, j}
+}
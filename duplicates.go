@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+)
+
+// Ways of handling duplicate cities, those at zero distance from each other
+// both ways, which would make the inverse distance heuristic infinite
+const (
+	// DuplicatesFloor keeps duplicates and floors the costs the heuristic
+	// inverts at half the smallest positive distance
+	DuplicatesFloor = "floor"
+	// DuplicatesMerge solves the instance with each group of duplicates merged
+	// into its first city, and visits the whole group there in the tour
+	DuplicatesMerge = "merge"
+)
+
+// DuplicateModes lists the ways of handling duplicate cities
+var DuplicateModes = []string{DuplicatesFloor, DuplicatesMerge}
+
+// checkDuplicateMode reports an error for unknown ways of handling duplicates
+func checkDuplicateMode(mode string) error {
+	if !slices.Contains(DuplicateModes, mode) {
+		return fmt.Errorf("unknown duplicate handling %q, want one of %v", mode, DuplicateModes)
+	}
+	return nil
+}
+
+// setCostFloor sets the cost the heuristic inverts in place of zero costs to
+// half the smallest positive distance, or 1 if there is none
+func (ac *AntColony) setCostFloor() {
+	least := math.Inf(1)
+	for i := range ac.Cities {
+		for j := range ac.Cities {
+			if d := ac.distance(i, j); i != j && d > 0 && d < least {
+				least = d
+			}
+		}
+	}
+	ac.costFloor = 1
+	if !math.IsInf(least, 1) {
+		ac.costFloor = least / 2
+	}
+}
+
+// duplicateGroups partitions the cities of an instance into groups of
+// duplicates, each in index order and the groups ordered by their first city.
+// Without a distance matrix duplicates share their coordinates; with one they
+// are at zero distance both ways and equally far from and to every other city
+func duplicateGroups(inst *Instance) [][]int {
+	var groups [][]int
+	index := make(map[uint64]int)
+	for i, c := range inst.Cities {
+		key := fnv.New64a()
+		put := func(x float64) { binary.Write(key, binary.LittleEndian, math.Float64bits(x)) }
+		if inst.Distances == nil {
+			put(c.X)
+			put(c.Y)
+			put(c.Z)
+		} else {
+			// Duplicates' rows and columns agree once the diagonal reads zero
+			for j := range inst.Distances {
+				if j == i {
+					put(0)
+					put(0)
+				} else {
+					put(inst.Distances[i][j])
+					put(inst.Distances[j][i])
+				}
+			}
+		}
+		g, ok := index[key.Sum64()]
+		if ok && inst.distance(i, groups[g][0]) == 0 && inst.distance(groups[g][0], i) == 0 {
+			groups[g] = append(groups[g], i)
+			continue
+		}
+		index[key.Sum64()] = len(groups)
+		groups = append(groups, []int{i})
+	}
+	return groups
+}
+
+// MergeDuplicates returns a copy of an instance with each group of duplicate
+// cities merged into the first of them, which takes on the service times of
+// the whole group, and the groups by merged city, to expand its tours with.
+// Constraints would need renumbering, so instances with any are refused
+func MergeDuplicates(inst *Instance) (*Instance, [][]int, error) {
+	if inst.Constraints != nil && !inst.Constraints.empty() {
+		return nil, nil, errors.New("cannot merge duplicate cities of an instance with constraints, handle them with a floor")
+	}
+	groups := duplicateGroups(inst)
+	merged := *inst
+	merged.Cities = make([]*City, len(groups))
+	for k, group := range groups {
+		city := *inst.Cities[group[0]]
+		for _, i := range group[1:] {
+			city.ServiceTime += inst.Cities[i].ServiceTime
+		}
+		merged.Cities[k] = &city
+	}
+	if inst.Distances != nil {
+		merged.Distances = mergeMatrix(inst.Distances, groups)
+	}
+	if inst.Attributes != nil {
+		merged.Attributes = make(map[string][][]float64, len(inst.Attributes))
+		for name, matrix := range inst.Attributes {
+			merged.Attributes[name] = mergeMatrix(matrix, groups)
+		}
+	}
+	return &merged, groups, nil
+}
+
+// mergeMatrix returns the matrix between groups of cities that one between
+// the cities gives, taking the first city for every group
+func mergeMatrix(m [][]float64, groups [][]int) [][]float64 {
+	merged := make([][]float64, len(groups))
+	for a, from := range groups {
+		merged[a] = make([]float64, len(groups))
+		for b, to := range groups {
+			merged[a][b] = m[from[0]][to[0]]
+		}
+	}
+	return merged
+}
+
+// ExpandTour maps a tour of a merged instance back to the original cities,
+// visiting every city of a group where the tour visits the group
+func ExpandTour(tour []int, groups [][]int) []int {
+	expanded := make([]int, 0, len(tour))
+	for _, k := range tour {
+		expanded = append(expanded, groups[k]...)
+	}
+	return expanded
+}
+
+// ExpandSolution maps the tours and backbone of a solution of a merged
+// instance back to the original cities, backbone edges to the first city of
+// each group. Duplicates are at zero distance, so lengths stay the same
+func ExpandSolution(solution *Solution, groups [][]int) *Solution {
+	expanded := *solution
+	expanded.Tour = ExpandTour(solution.Tour, groups)
+	expanded.Alternatives = nil
+	for _, alternative := range solution.Alternatives {
+		expanded.Alternatives = append(expanded.Alternatives, AlternativeTour{Tour: ExpandTour(alternative.Tour, groups), Length: alternative.Length})
+	}
+	expanded.Backbone = nil
+	for _, edge := range solution.Backbone {
+		expanded.Backbone = append(expanded.Backbone, BackboneEdge{From: groups[edge.From][0], To: groups[edge.To][0], Frequency: edge.Frequency})
+	}
+	return &expanded
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ChangeStrategy decides how existing pheromone is conserved when cities are
+// added to or removed from a running colony
+type ChangeStrategy int
+
+const (
+	// KeepPheromone keeps all existing trails, edges of a new city start at the mean trail level
+	KeepPheromone ChangeStrategy = iota
+	// ResetPheromone discards everything learned and restarts from the initial trails
+	ResetPheromone
+	// SmoothPheromone keeps the trails but pulls them toward the mean by the
+	// colony's Smoothing factor, so stale preferences can be unlearned quickly
+	SmoothPheromone
+)
+
+// AddCity appends a city to the instance while the colony is running and returns its index.
+// Objectives made by SetObjectives are made again, and others give the new
+// city's edges no cost
+func (ac *AntColony) AddCity(city *City) int {
+	ac.own()
+	level := ac.meanPheromone()
+	n := len(ac.Cities)
+	ac.Cities = append(ac.Cities, city)
+	for i := 0; i < n; i++ {
+		if ac.DistanceMatrix != nil {
+			ac.DistanceMatrix[i] = append(ac.DistanceMatrix[i], ac.metric(ac.Cities[i], city))
+		}
+		if ac.sparse == nil {
+			ac.Pheromones[i] = append(ac.Pheromones[i], level)
+		}
+	}
+	distances := make([]float64, n+1)
+	pheromones := make([]float64, n+1)
+	for j := range distances {
+		if j < n {
+			distances[j] = ac.metric(city, ac.Cities[j])
+		}
+		pheromones[j] = level
+	}
+	// Colonies without a distance matrix compute the new city's distances as they go
+	if ac.DistanceMatrix != nil {
+		ac.DistanceMatrix = append(ac.DistanceMatrix, distances)
+	}
+	// The new city's edges carry no attributes
+	for name, matrix := range ac.Attributes {
+		for i := range matrix {
+			matrix[i] = append(matrix[i], 0)
+		}
+		ac.Attributes[name] = append(matrix, make([]float64, n+1))
+	}
+	if ac.sparse != nil {
+		for j := 0; j < n && level != ac.sparse.level; j++ {
+			*ac.sparse.at(n, j), *ac.sparse.at(j, n) = level, level
+		}
+	} else {
+		ac.Pheromones = append(ac.Pheromones, pheromones)
+	}
+	if ac.objectiveNames != nil {
+		ac.SetObjectives(ac.objectiveNames...)
+	} else {
+		// The new city's edges cost nothing by objectives set directly
+		for k, matrix := range ac.Objectives {
+			grown := make([][]float64, n+1)
+			for i := 0; i < n; i++ {
+				grown[i] = append(slices.Clip(matrix[i][:n]), 0)
+			}
+			grown[n] = make([]float64, n+1)
+			ac.Objectives[k] = grown
+		}
+	}
+	ac.applyChangeStrategy()
+	return n
+}
+
+// RemoveCity deletes city i from the instance while the colony is running;
+// higher city indices shift down by one and constraints involving i are dropped
+func (ac *AntColony) RemoveCity(i int) error {
+	if i < 0 || i >= len(ac.Cities) {
+		return fmt.Errorf("city %d out of range [0, %d)", i, len(ac.Cities))
+	}
+	ac.own()
+	if ac.objectiveNames == nil {
+		// Objectives set directly may share the matrices about to change
+		for k, matrix := range ac.Objectives {
+			ac.Objectives[k] = removeRowCol(cloneMatrix(matrix), i)
+		}
+	}
+	ac.Cities = append(ac.Cities[:i], ac.Cities[i+1:]...)
+	if ac.DistanceMatrix != nil {
+		ac.DistanceMatrix = removeRowCol(ac.DistanceMatrix, i)
+	}
+	for name, matrix := range ac.Attributes {
+		ac.Attributes[name] = removeRowCol(matrix, i)
+	}
+	if ac.sparse != nil {
+		ac.sparse.removeCity(i)
+	} else {
+		ac.Pheromones = removeRowCol(ac.Pheromones, i)
+	}
+	if ac.objectiveNames != nil {
+		ac.SetObjectives(ac.objectiveNames...)
+	}
+	ac.Constraints.removeCity(i)
+	if ac.Placement == PlacementFixed && ac.StartCity > i {
+		ac.StartCity--
+	} else if ac.Placement == PlacementFixed && ac.StartCity == i {
+		// The ants have lost their start; let them start anywhere
+		ac.Placement = PlacementRandom
+	}
+	if ac.lengths != nil {
+		// Cities past i are renumbered, so cached tours name others
+		ac.lengths.reset()
+	}
+	ac.applyChangeStrategy()
+	return nil
+}
+
+// AddCity adds a city to the running solver's colony and returns its index.
+// The best tour takes the new city where it lengthens the tour least, so it
+// stays a tour of every city; the tours kept besides it are forgotten
+func (s *Solver) AddCity(city *City) int {
+	ac := s.Colony
+	i := ac.AddCity(city)
+	ac.setCostFloor()
+	if len(s.bestTour) > 0 {
+		tour := s.bestTour
+		// at is where the city goes, and growth what it adds there
+		at, growth := len(tour), ac.distance(tour[len(tour)-1], i)
+		if ac.ReturnToStart {
+			growth += ac.distance(i, tour[0]) - ac.distance(tour[len(tour)-1], tour[0])
+		} else if d := ac.distance(i, tour[0]); d < growth {
+			at, growth = 0, d
+		}
+		for k := 1; k < len(tour); k++ {
+			if d := ac.distance(tour[k-1], i) + ac.distance(i, tour[k]) - ac.distance(tour[k-1], tour[k]); d < growth {
+				at, growth = k, d
+			}
+		}
+		s.bestTour = slices.Insert(tour, at, i)
+		s.bestLength = ac.Score(s.bestTour)
+	}
+	s.forgetTours()
+	return i
+}
+
+// RemoveCity removes city i from the running solver's colony, renumbering
+// the cities after it. The best tour goes on without it; the tours kept
+// besides it are forgotten
+func (s *Solver) RemoveCity(i int) error {
+	if err := s.Colony.RemoveCity(i); err != nil {
+		return err
+	}
+	s.Colony.setCostFloor()
+	tour := s.bestTour[:0]
+	for _, city := range s.bestTour {
+		switch {
+		case city > i:
+			tour = append(tour, city-1)
+		case city < i:
+			tour = append(tour, city)
+		}
+	}
+	s.bestTour, s.bestLength = tour, math.Inf(1)
+	if len(tour) > 0 {
+		s.bestLength = s.Colony.Score(tour)
+	}
+	s.forgetTours()
+	return nil
+}
+
+// forgetTours drops the tours and edge counts kept besides the best tour,
+// whose cities an added or removed city has changed
+func (s *Solver) forgetTours() {
+	s.alternatives.tours, s.alternatives.keys = nil, nil
+	s.elite.tours, s.elite.keys = nil, nil
+	s.edges = edgeFrequency{}
+}
+
+// own copies what the colony shares with the instance it was made from,
+// its cities, distances, attributes and constraints, before it first adds or
+// removes a city, so that the instance stays as it was. It also drops the
+// candidate lists, to be sorted again for the cities as they are
+func (ac *AntColony) own() {
+	ac.nearest = nil
+	if ac.owned {
+		return
+	}
+	ac.owned = true
+	ac.Cities = slices.Clone(ac.Cities)
+	ac.DistanceMatrix = cloneMatrix(ac.DistanceMatrix)
+	if ac.Attributes != nil {
+		attributes := make(map[string][][]float64, len(ac.Attributes))
+		for name, matrix := range ac.Attributes {
+			attributes[name] = cloneMatrix(matrix)
+		}
+		ac.Attributes = attributes
+	}
+	if ac.Constraints != nil {
+		// removeCity makes new slices rather than edit the shared ones
+		constraints := *ac.Constraints
+		ac.Constraints = &constraints
+	}
+}
+
+// cloneMatrix returns a copy of a matrix sharing nothing with it, nil for nil
+func cloneMatrix(m [][]float64) [][]float64 {
+	if m == nil {
+		return nil
+	}
+	clone := make([][]float64, len(m))
+	for i := range m {
+		clone[i] = slices.Clone(m[i])
+	}
+	return clone
+}
+
+// removeRowCol deletes row and column i of a square matrix
+func removeRowCol(m [][]float64, i int) [][]float64 {
+	m = append(m[:i], m[i+1:]...)
+	for r := range m {
+		m[r] = append(m[r][:i], m[r][i+1:]...)
+	}
+	return m
+}
+
+// meanPheromone returns the average trail level over all edges
+func (ac *AntColony) meanPheromone() float64 {
+	if ac.sparse != nil {
+		return ac.sparse.mean(len(ac.Cities))
+	}
+	sum, count := 0.0, 0
+	for i := range ac.Pheromones {
+		for j := range ac.Pheromones[i] {
+			if i != j {
+				sum += ac.Pheromones[i][j]
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// applyChangeStrategy adjusts the pheromone matrix after the instance changed
+func (ac *AntColony) applyChangeStrategy() {
+	switch ac.ChangeStrategy {
+	case ResetPheromone:
+		if ac.sparse != nil {
+			ac.sparse.fill(ac.initialPheromone)
+		}
+		for i := range ac.Pheromones {
+			for j := range ac.Pheromones[i] {
+				ac.Pheromones[i][j] = ac.initialPheromone
+			}
+		}
+	case SmoothPheromone:
+		mean := ac.meanPheromone()
+		if ac.sparse != nil {
+			ac.sparse.update(func(trail float64) float64 { return trail + ac.Smoothing*(mean-trail) })
+		}
+		for i := range ac.Pheromones {
+			for j := range ac.Pheromones[i] {
+				ac.Pheromones[i][j] += ac.Smoothing * (mean - ac.Pheromones[i][j])
+			}
+		}
+	}
+}
+
+// removeCity drops constraints involving city i, except that it leaves the
+// rest of its segment locked, and renumbers the cities above it
+func (c *Constraints) removeCity(i int) {
+	shift := func(city int) int {
+		if city > i {
+			return city - 1
+		}
+		return city
+	}
+	var kept []Precedence
+	for _, p := range c.Precedences {
+		if p.Before != i && p.After != i {
+			kept = append(kept, Precedence{Before: shift(p.Before), After: shift(p.After)})
+		}
+	}
+	c.Precedences = kept
+	c.ForbiddenEdges = removeEdgesWith(c.ForbiddenEdges, i, shift)
+	c.RequiredEdges = removeEdgesWith(c.RequiredEdges, i, shift)
+	c.Clusters = removeMember(c.Clusters, i, shift)
+	// The cities either side of i in a segment become consecutive
+	c.Segments = removeMember(c.Segments, i, shift)
+}
+
+// removeMember drops city i from groups of cities and renumbers the rest with
+// shift, in new slices
+func removeMember(groups [][]int, i int, shift func(int) int) [][]int {
+	if groups == nil {
+		return nil
+	}
+	kept := make([][]int, len(groups))
+	for k, group := range groups {
+		for _, city := range group {
+			if city != i {
+				kept[k] = append(kept[k], shift(city))
+			}
+		}
+	}
+	return kept
+}
+
+// removeEdgesWith drops edges touching city i and renumbers the rest with
+// shift, in a new slice
+func removeEdgesWith(edges [][2]int, i int, shift func(int) int) [][2]int {
+	var kept [][2]int
+	for _, e := range edges {
+		if e[0] != i && e[1] != i {
+			kept = append(kept, [2]int{shift(e[0]), shift(e[1])})
+		}
+	}
+	return kept
+}
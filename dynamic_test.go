@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestSolverAddRemoveCity removes and adds cities while a solver runs, and
+// checks that the instance it was made from stays as it was and that the
+// best tour stays a tour of the colony's cities
+func TestSolverAddRemoveCity(t *testing.T) {
+	g, err := NewGenerator(UniformCities, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := g.Instance(12)
+	inst.Distances = instanceDistances(inst)
+	inst.Attributes = map[string][][]float64{"toll": make([][]float64, 12)}
+	for i := range inst.Attributes["toll"] {
+		inst.Attributes["toll"][i] = make([]float64, 12)
+		inst.Attributes["toll"][i][(i+1)%12] = 1
+	}
+	inst.Constraints = &Constraints{ForbiddenEdges: [][2]int{{2, 9}, {5, 11}}, Precedences: []Precedence{{Before: 1, After: 8}}}
+	before, err := json.Marshal(inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	config.Seed, config.Iterations, config.Candidates = 3, 100, 4
+	solver, err := NewSolver(inst, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(step string) {
+		t.Helper()
+		solution := solver.Solution()
+		if err := ValidateTour(solution.Tour, len(solver.Colony.Cities), nil); err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		if length := solver.Colony.TourLength(solver.bestTour); solver.bestLength != length {
+			t.Errorf("%s: best length %g, tour of length %g", step, solver.bestLength, length)
+		}
+	}
+	for range 5 {
+		solver.Step()
+	}
+	check("run")
+	if err := solver.RemoveCity(5); err != nil {
+		t.Fatal(err)
+	}
+	check("remove")
+	solver.AddCity(&City{X: 50, Y: 50})
+	check("add")
+	if err := solver.RemoveCity(0); err != nil {
+		t.Fatal(err)
+	}
+	solver.AddCity(&City{X: 10, Y: 90})
+	check("add and remove")
+	for range 5 {
+		solver.Step()
+	}
+	check("run again")
+	if n := len(solver.Colony.Cities); n != 12 {
+		t.Errorf("colony of %d cities, want 12", n)
+	}
+
+	after, err := json.Marshal(inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Errorf("editing the colony changed its instance:\n%s\n%s", before, after)
+	}
+}
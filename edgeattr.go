@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// LengthAttribute names the edge costs themselves among edge attributes, in
+// Config.EdgeWeights and AntColony.SetObjectives
+const LengthAttribute = "length"
+
+// LoadEdgeAttributes reads attributes of edges, such as tolls, scenic scores
+// or road classes, from CSV rows of "from,to,name..." into inst.Attributes,
+// under the names of the header. Cities are given by 0-based index or by
+// name. A row sets both directions of its edge, unless the file lists the
+// opposite direction too; edges the file leaves out carry zero
+func LoadEdgeAttributes(r io.Reader, inst *Instance) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("edge attributes: %w", err)
+	}
+	if len(header) < 3 || strings.TrimSpace(header[0]) != "from" || strings.TrimSpace(header[1]) != "to" {
+		return errors.New("edge attributes: want a header of from,to and attribute names")
+	}
+	names := header[2:]
+	for k, name := range names {
+		names[k] = strings.TrimSpace(name)
+		if names[k] == "" || names[k] == LengthAttribute || slices.Contains(names[:k], names[k]) {
+			return fmt.Errorf("edge attributes: bad attribute name %q", names[k])
+		}
+	}
+	index := make(map[string]int)
+	for i, c := range inst.Cities {
+		if c.Name != "" {
+			index[c.Name] = i
+		}
+	}
+	n := len(inst.Cities)
+	city := func(field string) (int, error) {
+		if i, ok := index[field]; ok {
+			return i, nil
+		}
+		i, err := strconv.Atoi(field)
+		if err != nil || i < 0 || i >= n {
+			return 0, fmt.Errorf("no city %q", field)
+		}
+		return i, nil
+	}
+	type edge struct{ from, to int }
+	values := make(map[edge][]float64)
+	var order []edge
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("edge attributes: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		var e edge
+		if e.from, err = city(record[0]); err == nil {
+			e.to, err = city(record[1])
+		}
+		if err != nil {
+			return fmt.Errorf("edge attributes: line %d: %w", line, err)
+		}
+		row := make([]float64, len(names))
+		for k := range names {
+			if row[k], err = strconv.ParseFloat(record[k+2], 64); err != nil {
+				return fmt.Errorf("edge attributes: line %d: %s %q is not a number", line, names[k], record[k+2])
+			}
+		}
+		if _, seen := values[e]; !seen {
+			order = append(order, e)
+		}
+		values[e] = row
+	}
+	if inst.Attributes == nil {
+		inst.Attributes = make(map[string][][]float64)
+	}
+	for k, name := range names {
+		matrix := make([][]float64, n)
+		for i := range matrix {
+			matrix[i] = make([]float64, n)
+		}
+		for _, e := range order {
+			matrix[e.from][e.to] = values[e][k]
+			if _, listed := values[edge{e.to, e.from}]; !listed {
+				matrix[e.to][e.from] = values[e][k]
+			}
+		}
+		inst.Attributes[name] = matrix
+	}
+	return nil
+}
+
+// LoadEdgeAttributesFile reads edge attributes from a CSV file into inst
+func LoadEdgeAttributesFile(path string, inst *Instance) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := LoadEdgeAttributes(f, inst); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// parseEdgeWeights reads Config.EdgeWeights, "name=weight" pairs separated
+// by commas, in order
+func parseEdgeWeights(weights string) (names []string, values []float64, err error) {
+	if strings.TrimSpace(weights) == "" {
+		return nil, nil, nil
+	}
+	for _, pair := range strings.Split(weights, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, nil, fmt.Errorf("edge weight %q is not name=weight", strings.TrimSpace(pair))
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("edge weight of %s: %q is not a number", name, strings.TrimSpace(value))
+		}
+		if slices.Contains(names, name) {
+			return nil, nil, fmt.Errorf("edge weight of %s given twice", name)
+		}
+		names, values = append(names, name), append(values, weight)
+	}
+	return names, values, nil
+}
+
+// attributeMatrix returns the matrix of an edge attribute of the colony, the
+// edge costs for LengthAttribute
+func (ac *AntColony) attributeMatrix(name string) ([][]float64, error) {
+	if name == LengthAttribute {
+		return ac.distanceMatrix(), nil
+	}
+	matrix, ok := ac.Attributes[name]
+	if !ok {
+		names := make([]string, 0, len(ac.Attributes))
+		for name := range ac.Attributes {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("unknown edge attribute %q, want one of %v", name, append(names, LengthAttribute))
+	}
+	return matrix, nil
+}
+
+// SetObjectives makes the named edge attributes, LengthAttribute among
+// them, the objectives of multi-objective runs. The objectives follow the
+// cities AddCity and RemoveCity change
+func (ac *AntColony) SetObjectives(names ...string) error {
+	objectives := make([][][]float64, len(names))
+	for k, name := range names {
+		matrix, err := ac.attributeMatrix(name)
+		if err != nil {
+			return err
+		}
+		objectives[k] = matrix
+	}
+	ac.Objectives, ac.objectiveNames = objectives, slices.Clone(names)
+	return nil
+}
+
+// weightedObjective returns the objective of Config.EdgeWeights: the sum of
+// the length and the weighted attributes, the length weighing 1 unless given
+func (ac *AntColony) weightedObjective(weights string) (*WeightedObjective, error) {
+	names, values, err := parseEdgeWeights(weights)
+	if err != nil {
+		return nil, err
+	}
+	objective := &WeightedObjective{Length: 1}
+	for k, name := range names {
+		if name == LengthAttribute {
+			objective.Length = values[k]
+			continue
+		}
+		matrix, err := ac.attributeMatrix(name)
+		if err != nil {
+			return nil, err
+		}
+		objective.Attributes = append(objective.Attributes, matrix)
+		objective.Weights = append(objective.Weights, values[k])
+	}
+	return objective, nil
+}
+
+// weightedHeuristic prefers cities in inverse proportion to the weighted
+// cost of the edge to them under the colony's WeightedObjective, or in
+// proportion to it when the colony maximizes; without one it is
+// DistanceHeuristic
+func weightedHeuristic(ac *AntColony, ant *Ant, from, to int) float64 {
+	objective, maximize := ac.Objective, false
+	for {
+		switch o := objective.(type) {
+		case *PenaltyObjective:
+			objective = o.Base
+			continue
+		case *MaximizeObjective:
+			objective, maximize = o.Base, !maximize
+			continue
+		case *WeightedObjective:
+			cost := o.Length * ac.edgeCost(from, to, ant.Elapsed)
+			for k, attribute := range o.Attributes {
+				cost += o.Weights[k] * attribute[from][to]
+			}
+			if maximize {
+				return max(cost, ac.costFloor)
+			}
+			return 1 / max(cost, ac.costFloor)
+		}
+		return 1 / max(ac.edgeCost(from, to, ant.Elapsed), ac.costFloor)
+	}
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Types of the events a run publishes
+const (
+	EventRunStarted  = "run.started"
+	EventRunBest     = "run.best"
+	EventRunFinished = "run.finished"
+)
+
+// eventQueueSize is the number of events waiting to be sent beyond which
+// new ones are dropped rather than slowing the colony down
+const eventQueueSize = 256
+
+// RunEvent tells downstream systems that a run started, found a shorter tour
+// or finished
+type RunEvent struct {
+	Type string    `json:"type"`
+	Run  string    `json:"run"`
+	Time time.Time `json:"time"`
+	// Cities and Config are set on run.started
+	Cities int     `json:"cities,omitempty"`
+	Config *Config `json:"config,omitempty"`
+	// Iteration is the number of iterations run so far
+	Iteration int `json:"iteration"`
+	// Length and Tour are the best tour so far, set on run.best and run.finished
+	Length *float64 `json:"length,omitempty"`
+	Tour   []int    `json:"tour,omitempty"`
+	// State is RunDone or RunCancelled on run.finished
+	State string `json:"state,omitempty"`
+}
+
+// MarshalProto encodes the event as an aco.v1.RunEvent message
+func (e *RunEvent) MarshalProto() []byte {
+	var b protoBuffer
+	b.string(1, e.Type)
+	b.string(2, e.Run)
+	b.int(3, e.Time.UnixNano())
+	b.int(4, int64(e.Cities))
+	if e.Config != nil {
+		b.message(5, func(b *protoBuffer) { encodeConfig(b, e.Config) })
+	}
+	b.int(6, int64(e.Iteration))
+	if e.Length != nil {
+		b.double(7, *e.Length)
+	}
+	b.ints(8, e.Tour)
+	b.string(9, e.State)
+	return b.data
+}
+
+// eventFlags registers the flags choosing where run events go on flags, and
+// returns a function connecting to their broker and webhook, which returns
+// nil without -events and -webhook
+func eventFlags(flags *flag.FlagSet) func() (RunListener, error) {
+	broker := flags.String("events", "", "publish run.started, run.best and run.finished events to the NATS server at this nats:// `url`, or the Kafka REST proxy at this http:// one")
+	prefix := flags.String("event-prefix", "aco", "with -events, start the subject or topic of every event with this `prefix`")
+	format := flags.String("event-format", "json", "with -events, encode events as json or proto (aco.v1.RunEvent)")
+	webhook := flags.String("webhook", "", "post a JSON notice, which Slack's incoming webhooks accept, to this `url` when a run finishes or improves")
+	threshold := flags.Float64("webhook-threshold", 1, "with -webhook, notify a shorter tour only when it improves on the last one notified by more than this `percent`")
+	return func() (RunListener, error) {
+		var listeners RunListeners
+		if *broker != "" {
+			publisher, err := NewEventPublisher(*broker, *prefix, *format)
+			if err != nil {
+				return nil, err
+			}
+			listeners = append(listeners, publisher)
+		}
+		if *webhook != "" {
+			hook, err := NewWebhook(*webhook, *threshold)
+			if err != nil {
+				return nil, err
+			}
+			listeners = append(listeners, hook)
+		}
+		switch len(listeners) {
+		case 0:
+			return nil, nil
+		case 1:
+			return listeners[0], nil
+		}
+		return listeners, nil
+	}
+}
+
+// Broker delivers messages to the topics, or subjects, of a message system
+type Broker interface {
+	Publish(topic string, payload []byte) error
+	Close() error
+}
+
+// EventPublisher sends the events of runs to a broker in the background, on
+// the topic named by Prefix and the event type, such as aco.run.best. A nil
+// *EventPublisher publishes nothing
+type EventPublisher struct {
+	Broker Broker
+	// Prefix starts every topic, "aco" if empty
+	Prefix string
+	// Format encodes the events, "json" or "proto"
+	Format string
+
+	once   sync.Once
+	queue  chan RunEvent
+	closed chan struct{}
+}
+
+// NewEventPublisher connects to the broker at rawURL: a NATS server for
+// nats://host:port, or a Kafka REST proxy for http:// and https:// URLs, and
+// publishes events encoded as format on topics starting with prefix
+func NewEventPublisher(rawURL, prefix, format string) (*EventPublisher, error) {
+	if format != "json" && format != "proto" {
+		return nil, fmt.Errorf("unknown event format %q, want json or proto", format)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("event broker: %w", err)
+	}
+	var broker Broker
+	switch u.Scheme {
+	case "nats":
+		broker, err = DialNATS(u)
+	case "http", "https":
+		broker = &KafkaRESTBroker{URL: strings.TrimSuffix(rawURL, "/"), Client: &http.Client{Timeout: 10 * time.Second}, Binary: format == "proto"}
+	default:
+		err = fmt.Errorf("event broker %q: want a nats:// URL or the http:// URL of a Kafka REST proxy", rawURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &EventPublisher{Broker: broker, Prefix: prefix, Format: format}, nil
+}
+
+// Publish queues an event for sending, dropping it if the broker lags too far behind
+func (p *EventPublisher) Publish(event RunEvent) {
+	if p == nil {
+		return
+	}
+	p.once.Do(p.start)
+	select {
+	case p.queue <- event:
+	default:
+		slog.Warn("dropping event, the broker is too slow", "type", event.Type, "run", event.Run)
+	}
+}
+
+// start launches the goroutine sending the queued events
+func (p *EventPublisher) start() {
+	p.queue = make(chan RunEvent, eventQueueSize)
+	p.closed = make(chan struct{})
+	go func() {
+		defer close(p.closed)
+		for event := range p.queue {
+			if err := p.send(event); err != nil {
+				slog.Warn("publishing event", "type", event.Type, "run", event.Run, "err", err)
+			}
+		}
+	}()
+}
+
+// send encodes an event and hands it to the broker
+func (p *EventPublisher) send(event RunEvent) error {
+	var payload []byte
+	if p.Format == "proto" {
+		payload = event.MarshalProto()
+	} else {
+		var err error
+		if payload, err = json.Marshal(event); err != nil {
+			return err
+		}
+	}
+	prefix := p.Prefix
+	if prefix == "" {
+		prefix = "aco"
+	}
+	return p.Broker.Publish(prefix+"."+event.Type, payload)
+}
+
+// Close sends the events still queued and disconnects from the broker
+func (p *EventPublisher) Close() error {
+	if p == nil {
+		return nil
+	}
+	p.once.Do(p.start)
+	close(p.queue)
+	<-p.closed
+	return p.Broker.Close()
+}
+
+// NATSBroker publishes to a NATS server over its text protocol
+type NATSBroker struct {
+	mu   sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+}
+
+// DialNATS connects to the NATS server at u, logging in with the user and
+// password or token of its user info
+func DialNATS(u *url.URL) (*NATSBroker, error) {
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	conn, err := net.DialTimeout("tcp", address, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("NATS: %w", err)
+	}
+	r := bufio.NewReader(conn)
+	// The server greets with INFO before anything else
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	line, err := r.ReadString('\n')
+	conn.SetReadDeadline(time.Time{})
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("NATS: %s did not greet with INFO: %q %v", address, strings.TrimSpace(line), err)
+	}
+	options := map[string]any{"verbose": false, "pedantic": false, "name": "aco", "lang": "go"}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			options["user"], options["pass"] = u.User.Username(), password
+		} else {
+			options["auth_token"] = u.User.Username()
+		}
+	}
+	connect, _ := json.Marshal(options)
+	b := &NATSBroker{conn: conn, w: bufio.NewWriter(conn)}
+	if err := b.write("CONNECT " + string(connect) + "\r\n"); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("NATS: %w", err)
+	}
+	go b.read(r)
+	return b, nil
+}
+
+// read answers the server's pings, which it sends to check the client is
+// alive, and logs the errors it reports
+func (b *NATSBroker) read(r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PING":
+			b.write("PONG\r\n")
+		case strings.HasPrefix(line, "-ERR"):
+			slog.Warn("NATS server error", "message", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// write sends a protocol line
+func (b *NATSBroker) write(s string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.w.WriteString(s)
+	return b.w.Flush()
+}
+
+// Publish sends payload to the subject topic
+func (b *NATSBroker) Publish(topic string, payload []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fmt.Fprintf(b.w, "PUB %s %d\r\n", topic, len(payload))
+	b.w.Write(payload)
+	b.w.WriteString("\r\n")
+	return b.w.Flush()
+}
+
+// Close disconnects from the server
+func (b *NATSBroker) Close() error {
+	return b.conn.Close()
+}
+
+// KafkaRESTBroker produces records to Kafka topics through a Confluent REST
+// proxy, so Kafka is reached without a client for its binary protocol
+type KafkaRESTBroker struct {
+	URL    string
+	Client *http.Client
+	// Binary produces payloads as binary records rather than JSON ones
+	Binary bool
+}
+
+// Publish produces payload as one record of topic
+func (b *KafkaRESTBroker) Publish(topic string, payload []byte) error {
+	contentType := "application/vnd.kafka.json.v2+json"
+	var value any = json.RawMessage(payload)
+	if b.Binary {
+		contentType = "application/vnd.kafka.binary.v2+json"
+		value = base64.StdEncoding.EncodeToString(payload)
+	}
+	body, err := json.Marshal(map[string]any{"records": []any{map[string]any{"value": value}}})
+	if err != nil {
+		return err
+	}
+	response, err := b.Client.Post(b.URL+"/topics/"+url.PathEscape(topic), contentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("Kafka REST proxy answered %s", response.Status)
+	}
+	return nil
+}
+
+// Close does nothing, as every record is produced by its own request
+func (b *KafkaRESTBroker) Close() error {
+	return nil
+}
+
+// publish sends an event of the given type about the run, with its best
+// tour so far unless it started
+func (s *Solver) publish(kind, state string) {
+	if s.Events == nil {
+		return
+	}
+	event := RunEvent{Type: kind, Run: s.EventRun, Time: time.Now(), Iteration: s.iteration, State: state}
+	if kind == EventRunStarted {
+		config := s.Config
+		event.Cities, event.Config = len(s.Colony.Cities), &config
+	} else if !math.IsInf(s.bestLength, 1) {
+		length := s.bestLength
+		event.Length, event.Tour = &length, s.Colony.CanonicalTour(s.bestTour)
+	}
+	s.Events.Publish(event)
+}
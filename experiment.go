@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Experiment solves one instance with one configuration over consecutive
+// seeds, since a single ACO run says little about how a configuration performs
+type Experiment struct {
+	Instance *Instance
+	// Config is the configuration of the first run; Config.Seed 0 starts from seed 1
+	Config Config
+	Seeds  int
+	// Parallel is the number of runs at once, one if zero
+	Parallel int
+	// Target is the tour length runs are timed to reach, none if zero
+	Target float64
+	// Budget stops each run after the iteration that exhausts it, none if zero
+	Budget time.Duration
+	// Logger and LogEvery are passed on to each run's solver, with its seed added
+	Logger   *slog.Logger
+	LogEvery int
+}
+
+// ExperimentStats aggregates the runs of an experiment
+type ExperimentStats struct {
+	Lengths  []float64     `json:"lengths"`
+	Min      float64       `json:"min"`
+	Mean     float64       `json:"mean"`
+	Median   float64       `json:"median"`
+	Stddev   float64       `json:"stddev"`
+	MeanTime time.Duration `json:"mean_time_ns"`
+	// Reached counts the runs that found a tour no longer than the target,
+	// and TimeToTarget is the mean time they took to find it
+	Reached      int           `json:"reached"`
+	TimeToTarget time.Duration `json:"time_to_target_ns"`
+	// MeanCI and TimeToTargetCI are bootstrap confidence intervals at
+	// ConfidenceLevel for Mean and TimeToTarget, the latter over the runs
+	// that reached the target
+	MeanCI         [2]float64       `json:"mean_ci"`
+	TimeToTargetCI [2]time.Duration `json:"time_to_target_ci_ns"`
+}
+
+// Run performs the experiment's runs and aggregates them
+func (e *Experiment) Run() (*ExperimentStats, error) {
+	first := e.Config.Seed
+	if first == 0 {
+		first = 1
+	}
+	solvers := make([]*Solver, e.Seeds)
+	for r := range solvers {
+		config := e.Config
+		config.Seed = first + int64(r)
+		solver, err := NewSolver(e.Instance, config)
+		if err != nil {
+			return nil, err
+		}
+		logger := e.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		solver.Logger = logger.With("seed", config.Seed)
+		solver.LogEvery = e.LogEvery
+		solvers[r] = solver
+	}
+
+	stats := &ExperimentStats{Lengths: make([]float64, e.Seeds)}
+	elapsed := make([]time.Duration, e.Seeds)
+	toTarget := make([]time.Duration, e.Seeds)
+	run := func(r int) {
+		solver := solvers[r]
+		start := time.Now()
+		toTarget[r] = -1
+		for !solver.Done() {
+			if s := solver.Step(); toTarget[r] < 0 && e.Target > 0 && s.BestSoFar <= e.Target {
+				toTarget[r] = time.Since(start)
+			}
+			if e.Budget > 0 && time.Since(start) >= e.Budget {
+				break
+			}
+		}
+		elapsed[r] = time.Since(start)
+		stats.Lengths[r] = solver.Solution().Length
+	}
+	runs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(e.Parallel, 1), e.Seeds); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range runs {
+				run(r)
+			}
+		}()
+	}
+	for r := range solvers {
+		runs <- r
+	}
+	close(runs)
+	wg.Wait()
+
+	stats.Mean, stats.Stddev = meanStddev(stats.Lengths)
+	stats.Min, stats.Median = math.Inf(1), median(stats.Lengths)
+	var reached []float64
+	for r, length := range stats.Lengths {
+		stats.Min = math.Min(stats.Min, length)
+		stats.MeanTime += elapsed[r] / time.Duration(e.Seeds)
+		if toTarget[r] >= 0 {
+			stats.Reached++
+			stats.TimeToTarget += toTarget[r]
+			reached = append(reached, float64(toTarget[r]))
+		}
+	}
+	stats.MeanCI[0], stats.MeanCI[1] = BootstrapMeanCI(stats.Lengths, ConfidenceLevel)
+	if stats.Reached > 0 {
+		stats.TimeToTarget /= time.Duration(stats.Reached)
+		low, high := BootstrapMeanCI(reached, ConfidenceLevel)
+		stats.TimeToTargetCI = [2]time.Duration{time.Duration(low), time.Duration(high)}
+	}
+	return stats, nil
+}
+
+// formatCI writes a confidence interval of lengths as [low, high]
+func formatCI(ci [2]float64) string {
+	return fmt.Sprintf("[%.6g, %.6g]", ci[0], ci[1])
+}
+
+// formatDurationCI writes a confidence interval of durations as [low, high]
+func formatDurationCI(ci [2]time.Duration) string {
+	return fmt.Sprintf("[%s, %s]", ci[0].Round(time.Millisecond), ci[1].Round(time.Millisecond))
+}
+
+// median returns the middle value of xs, the mean of the middle two for even lengths
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
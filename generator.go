@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strings"
+)
+
+// City layouts produced by Generator
+const (
+	// UniformCities scatters cities uniformly over the square
+	UniformCities = "uniform"
+	// ClusteredCities draws cities from normal distributions around n/10 uniform
+	// centres, as in the DIMACS TSP challenge generator
+	ClusteredCities = "clustered"
+	// GridCities places cities on a square lattice, filled row by row
+	GridCities = "grid"
+)
+
+// Generator produces reproducible synthetic instances: the same distribution,
+// size and seed always give the same cities
+type Generator struct {
+	Distribution string
+	// Size is the side of the square the cities lie in
+	Size float64
+	Seed int64
+}
+
+// NewGenerator returns a generator of the given distribution over a 1000×1000 square
+func NewGenerator(distribution string, seed int64) (*Generator, error) {
+	switch distribution {
+	case UniformCities, ClusteredCities, GridCities:
+	default:
+		return nil, fmt.Errorf("unknown distribution %q, want uniform, clustered or grid", distribution)
+	}
+	return &Generator{Distribution: distribution, Size: 1000, Seed: seed}, nil
+}
+
+// Cities generates n cities
+func (g *Generator) Cities(n int) []*City {
+	rng := rand.New(rand.NewSource(g.Seed))
+	cities := make([]*City, n)
+	switch g.Distribution {
+	case ClusteredCities:
+		centres := make([]City, max(n/10, 1))
+		for k := range centres {
+			centres[k] = City{X: rng.Float64() * g.Size, Y: rng.Float64() * g.Size}
+		}
+		spread := g.Size / math.Sqrt(float64(n))
+		for i := range cities {
+			centre := centres[rng.Intn(len(centres))]
+			cities[i] = &City{X: centre.X + rng.NormFloat64()*spread, Y: centre.Y + rng.NormFloat64()*spread}
+		}
+	case GridCities:
+		side := int(math.Ceil(math.Sqrt(float64(n))))
+		spacing := g.Size / float64(max(side, 1))
+		for i := range cities {
+			cities[i] = &City{X: float64(i%side) * spacing, Y: float64(i/side) * spacing}
+		}
+	default:
+		for i := range cities {
+			cities[i] = &City{X: rng.Float64() * g.Size, Y: rng.Float64() * g.Size}
+		}
+	}
+	return cities
+}
+
+// Instance generates a closed-tour instance of n cities, named after its parameters
+func (g *Generator) Instance(n int) *Instance {
+	return &Instance{
+		Name:          fmt.Sprintf("%s%d-s%d", g.Distribution, n, g.Seed),
+		Cities:        g.Cities(n),
+		ReturnToStart: true,
+	}
+}
+
+// generateCommand implements "generate", which writes a synthetic instance as CSV or JSON
+func generateCommand(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	n := flags.Int("n", 100, "generate this many cities")
+	distribution := flags.String("dist", UniformCities, "lay cities out as uniform, clustered or grid")
+	seed := flags.Int64("seed", 1, "random `seed`; the same seed gives the same instance")
+	size := flags.Float64("size", 1000, "side of the square the cities lie in")
+	outPath := flags.String("o", "-", "write the instance to this `file`, - for stdout")
+	format := flags.String("format", "", "write csv or json, by default chosen from the output file's extension or csv")
+	flags.Parse(args)
+
+	if *n <= 0 {
+		return fmt.Errorf("generate: -n must be positive")
+	}
+	g, err := NewGenerator(*distribution, *seed)
+	if err != nil {
+		return err
+	}
+	g.Size = *size
+	instance := g.Instance(*n)
+	if *format == "" {
+		*format = FormatCSV
+		if strings.EqualFold(filepath.Ext(*outPath), ".json") {
+			*format = FormatJSON
+		}
+	}
+	switch *format {
+	case FormatCSV:
+		return writeFile(*outPath, func(w io.Writer) error { return WriteCitiesCSV(w, instance.Cities) })
+	case FormatJSON:
+		return writeJSON(*outPath, instance)
+	}
+	return fmt.Errorf("generate: unknown format %q, want csv or json", *format)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// geoJSONGeometry is a GeoJSON geometry object with undecoded coordinates
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// geoJSONFeature is a GeoJSON feature with undecoded properties
+type geoJSONFeature struct {
+	Type       string           `json:"type"`
+	Geometry   *geoJSONGeometry `json:"geometry"`
+	Properties json.RawMessage  `json:"properties"`
+}
+
+// geoJSONFeatureCollection is a GeoJSON feature collection
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// LoadCitiesGeoJSON reads cities from the Point and MultiPoint features of a
+// GeoJSON FeatureCollection, longitude mapping to X, latitude to Y and any
+// altitude to Z. A feature's "name" or "id" property names its cities, and
+// its properties, as they are, become their Metadata
+func LoadCitiesGeoJSON(r io.Reader) ([]*City, error) {
+	var collection geoJSONFeatureCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, err
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("GeoJSON type is %q, want FeatureCollection", collection.Type)
+	}
+	var cities []*City
+	for i, feature := range collection.Features {
+		if feature.Geometry == nil {
+			return nil, fmt.Errorf("feature %d has no geometry", i)
+		}
+		var points [][]float64
+		switch feature.Geometry.Type {
+		case "Point":
+			var point []float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &point); err != nil {
+				return nil, fmt.Errorf("feature %d: %w", i, err)
+			}
+			points = [][]float64{point}
+		case "MultiPoint":
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &points); err != nil {
+				return nil, fmt.Errorf("feature %d: %w", i, err)
+			}
+		default:
+			return nil, fmt.Errorf("feature %d: %s geometry is not a point", i, feature.Geometry.Type)
+		}
+		var properties map[string]interface{}
+		if len(feature.Properties) > 0 {
+			if err := json.Unmarshal(feature.Properties, &properties); err != nil {
+				return nil, fmt.Errorf("feature %d: properties: %w", i, err)
+			}
+		}
+		var metadata json.RawMessage
+		if len(properties) > 0 {
+			metadata = feature.Properties
+		}
+		for _, p := range points {
+			if len(p) < 2 {
+				return nil, fmt.Errorf("feature %d: position needs longitude and latitude", i)
+			}
+			city := &City{X: p[0], Y: p[1], Name: featureName(properties), Metadata: metadata}
+			if len(p) > 2 {
+				city.Z = p[2]
+			}
+			cities = append(cities, city)
+		}
+	}
+	if len(cities) == 0 {
+		return nil, fmt.Errorf("no point features in GeoJSON input")
+	}
+	return cities, nil
+}
+
+// featureName returns the "name" property of a feature, or its "id" if unnamed
+func featureName(properties map[string]interface{}) string {
+	for _, key := range []string{"name", "id"} {
+		switch v := properties[key].(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	}
+	return ""
+}
+
+// WriteTourGeoJSON writes the solution's tour as a FeatureCollection holding
+// one LineString, closed back to the first city when closed is set, with the
+// metadata of the cities in visiting order among its properties
+func WriteTourGeoJSON(w io.Writer, cities []*City, solution *Solution, closed bool) error {
+	route := tourRoute(cities, solution.Tour, closed)
+	coordinates := make([][]float64, len(route))
+	for i, c := range route {
+		coordinates[i] = []float64{c.X, c.Y}
+		if c.Z != 0 {
+			coordinates[i] = append(coordinates[i], c.Z)
+		}
+	}
+	raw, err := json.Marshal(coordinates)
+	if err != nil {
+		return err
+	}
+	properties := map[string]interface{}{"tour": solution.Tour}
+	if !math.IsInf(solution.Length, 0) {
+		properties["length"] = solution.Length
+	}
+	if metadata := tourMetadata(cities, solution.Tour); metadata != nil {
+		properties["metadata"] = metadata
+	}
+	rawProperties, err := json.Marshal(properties)
+	if err != nil {
+		return err
+	}
+	collection := geoJSONFeatureCollection{
+		Type: "FeatureCollection",
+		Features: []geoJSONFeature{{
+			Type:       "Feature",
+			Geometry:   &geoJSONGeometry{Type: "LineString", Coordinates: raw},
+			Properties: rawProperties,
+		}},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(collection)
+}
+
+// tourRoute lists the cities of tour in visiting order, repeating the first at the end of a closed tour
+func tourRoute(cities []*City, tour []int, closed bool) []*City {
+	route := make([]*City, 0, len(tour)+1)
+	for _, city := range tour {
+		route = append(route, cities[city])
+	}
+	if closed && len(tour) > 0 {
+		route = append(route, cities[tour[0]])
+	}
+	return route
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// gpxPoint is a GPX track point; Desc holds the city's metadata as JSON
+type gpxPoint struct {
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Ele  *float64 `xml:"ele,omitempty"`
+	Name string   `xml:"name,omitempty"`
+	Desc string   `xml:"desc,omitempty"`
+}
+
+// gpxDocument is a GPX 1.1 file holding a single track
+type gpxDocument struct {
+	XMLName xml.Name   `xml:"gpx"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Version string     `xml:"version,attr"`
+	Creator string     `xml:"creator,attr"`
+	Name    string     `xml:"trk>name"`
+	Points  []gpxPoint `xml:"trk>trkseg>trkpt"`
+}
+
+// kmlDocument is a KML file holding a path placemark, followed by a point
+// placemark per stop when the stops carry metadata
+type kmlDocument struct {
+	XMLName    xml.Name       `xml:"kml"`
+	Xmlns      string         `xml:"xmlns,attr"`
+	Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+}
+
+// kmlPlacemark is a KML placemark holding a path or a point
+type kmlPlacemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description,omitempty"`
+	// Metadata is the stop's metadata as JSON, in a Data element of its ExtendedData
+	Metadata   *kmlData       `xml:"ExtendedData>Data,omitempty"`
+	LineString *kmlLineString `xml:"LineString,omitempty"`
+	Point      *kmlPoint      `xml:"Point,omitempty"`
+}
+
+// kmlData is a named value of a placemark's ExtendedData
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+// kmlLineString is a KML path
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+// kmlPoint is a KML point
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// checkGeographic verifies that every city holds a valid longitude in X and latitude in Y
+func checkGeographic(route []*City) error {
+	for _, c := range route {
+		if c.Y < -90 || c.Y > 90 || c.X < -180 || c.X > 180 {
+			return fmt.Errorf("city at (%g, %g) is not a longitude/latitude pair", c.X, c.Y)
+		}
+	}
+	return nil
+}
+
+// WriteTourGPX writes the solution's tour as a GPX track, for cities holding
+// longitude in X, latitude in Y and elevation in Z; the description of each
+// point is its city's metadata
+func WriteTourGPX(w io.Writer, cities []*City, solution *Solution, closed bool) error {
+	route := tourRoute(cities, solution.Tour, closed)
+	if err := checkGeographic(route); err != nil {
+		return err
+	}
+	doc := gpxDocument{
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		Version: "1.1",
+		Creator: "golandSwarmIntelligenceACO",
+		Name:    "ACO tour",
+		Points:  make([]gpxPoint, len(route)),
+	}
+	for i, c := range route {
+		doc.Points[i] = gpxPoint{Lat: c.Y, Lon: c.X, Name: c.Name, Desc: string(c.Metadata)}
+		if c.Z != 0 {
+			ele := c.Z
+			doc.Points[i].Ele = &ele
+		}
+	}
+	return writeXML(w, doc)
+}
+
+// WriteTourKML writes the solution's tour as a KML path, for cities holding
+// longitude in X, latitude in Y and altitude in Z. When the cities carry
+// metadata, a point placemark per stop follows, in visiting order, holding
+// its city's metadata
+func WriteTourKML(w io.Writer, cities []*City, solution *Solution, closed bool) error {
+	route := tourRoute(cities, solution.Tour, closed)
+	if err := checkGeographic(route); err != nil {
+		return err
+	}
+	coordinates := make([]string, len(route))
+	for i, c := range route {
+		coordinates[i] = kmlCoordinates(c)
+	}
+	doc := kmlDocument{
+		Xmlns: "http://www.opengis.net/kml/2.2",
+		Placemarks: []kmlPlacemark{{
+			Name:        "ACO tour",
+			Description: fmt.Sprintf("%d stops, length %g", len(solution.Tour), solution.Length),
+			LineString:  &kmlLineString{Tessellate: 1, Coordinates: strings.Join(coordinates, " ")},
+		}},
+	}
+	if hasMetadata(cities) {
+		for _, city := range solution.Tour {
+			c := cities[city]
+			stop := kmlPlacemark{Name: c.Label(city), Point: &kmlPoint{Coordinates: kmlCoordinates(c)}}
+			if c.Metadata != nil {
+				stop.Metadata = &kmlData{Name: "metadata", Value: string(c.Metadata)}
+			}
+			doc.Placemarks = append(doc.Placemarks, stop)
+		}
+	}
+	return writeXML(w, doc)
+}
+
+// kmlCoordinates formats the position of a city as KML coordinates
+func kmlCoordinates(c *City) string {
+	return strconv.FormatFloat(c.X, 'f', -1, 64) + "," +
+		strconv.FormatFloat(c.Y, 'f', -1, 64) + "," +
+		strconv.FormatFloat(c.Z, 'f', -1, 64)
+}
+
+// writeXML writes doc as an indented XML document
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
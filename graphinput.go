@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadGraph reads a graph in DIMACS format or as a plain edge list, telling
+// them apart by the DIMACS "p" problem line
+//
+// DIMACS files declare "p sp n m" (or "p edge n m") and list arcs as
+// "a u v [w [resource]]" or undirected edges as "e u v [w [resource]]", with
+// nodes numbered from 1; the resource, which standard DIMACS files leave out,
+// extends the format for constrained paths. Edge lists hold one
+// "u v [w [resource]]" arc per line with nodes numbered from 0. Weights
+// default to 1 and resources to 0 in both; lines starting with "#" or "c" are
+// comments
+func LoadGraph(r io.Reader) (*Digraph, error) {
+	scanner := bufio.NewScanner(r)
+	var g *Digraph
+	var dimacs bool
+	var edges [][4]float64 // edge-list arcs, held until the node count is known
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "c" || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch {
+		case fields[0] == "p":
+			if g != nil || len(edges) > 0 {
+				return nil, fmt.Errorf("line %d: problem line must come before any arc", line)
+			}
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("line %d: problem line must be \"p <type> <nodes> <arcs>\"", line)
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("line %d: invalid node count %q", line, fields[2])
+			}
+			g, dimacs = NewDigraph(n), true
+		case fields[0] == "a" || fields[0] == "e":
+			if !dimacs {
+				return nil, fmt.Errorf("line %d: %q line before the \"p\" problem line", line, fields[0])
+			}
+			values, err := parseGraphNumbers(fields[1:], 2, 4)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			u, v, w, resource := int(values[0])-1, int(values[1])-1, 1.0, 0.0
+			if len(values) > 2 {
+				w = values[2]
+			}
+			if len(values) > 3 {
+				resource = values[3]
+			}
+			if err := g.AddArc(u, v, w, resource); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if fields[0] == "e" {
+				if err := g.AddArc(v, u, w, resource); err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+			}
+		default:
+			if dimacs {
+				return nil, fmt.Errorf("line %d: unknown DIMACS line type %q", line, fields[0])
+			}
+			values, err := parseGraphNumbers(fields, 2, 4)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			edge := [4]float64{values[0], values[1], 1, 0}
+			copy(edge[2:], values[2:])
+			edges = append(edges, edge)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if dimacs {
+		return g, nil
+	}
+	if len(edges) == 0 {
+		return nil, fmt.Errorf("no arcs in graph input")
+	}
+	n := 0
+	for _, e := range edges {
+		n = max(n, int(e[0])+1, int(e[1])+1)
+	}
+	g = NewDigraph(n)
+	for _, e := range edges {
+		if err := g.AddArc(int(e[0]), int(e[1]), e[2], e[3]); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// parseGraphNumbers parses between least and most numeric fields, the first two being integer node ids
+func parseGraphNumbers(fields []string, least, most int) ([]float64, error) {
+	if len(fields) < least || len(fields) > most {
+		return nil, fmt.Errorf("got %d values, want %d to %d", len(fields), least, most)
+	}
+	values := make([]float64, len(fields))
+	for i, f := range fields {
+		var err error
+		if i < 2 {
+			var id int
+			id, err = strconv.Atoi(f)
+			values[i] = float64(id)
+		} else {
+			values[i], err = strconv.ParseFloat(f, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", f)
+		}
+	}
+	return values, nil
+}
+
+// UndirectedEdges lists each connected node pair once, for problems such as graph coloring
+func (g *Digraph) UndirectedEdges() [][2]int {
+	seen := make(map[[2]int]bool)
+	var edges [][2]int
+	for u, arcs := range g.Arcs {
+		for _, a := range arcs {
+			e := [2]int{min(u, a.To), max(u, a.To)}
+			if u != a.To && !seen[e] {
+				seen[e] = true
+				edges = append(edges, e)
+			}
+		}
+	}
+	return edges
+}
+
+// Instance returns a closed tour problem over the graph's nodes whose
+// distances are the shortest paths between them
+func (g *Digraph) Instance() *Instance {
+	start := time.Now()
+	instance := &Instance{Cities: make([]*City, len(g.Arcs)), Distances: g.ShortestPathMatrix(), ReturnToStart: true}
+	instance.matrixTime = time.Since(start)
+	for i := range instance.Cities {
+		instance.Cities[i] = &City{}
+	}
+	return instance
+}
+
+// ShortestPathMatrix returns the shortest path distance between every pair of
+// nodes, +Inf where no path exists. Solving a TSP over this metric closure
+// tours a sparse graph along its actual arcs
+func (g *Digraph) ShortestPathMatrix() [][]float64 {
+	distances := make([][]float64, len(g.Arcs))
+	for source := range distances {
+		distances[source] = g.dijkstra(source)
+	}
+	return distances
+}
+
+// dijkstra returns the shortest path distances from source to every node
+func (g *Digraph) dijkstra(source int) []float64 {
+	dist := make([]float64, len(g.Arcs))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	dist[source] = 0
+	queue := &distanceQueue{{node: source}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(distanceItem)
+		if item.dist > dist[item.node] {
+			continue
+		}
+		for _, a := range g.Arcs[item.node] {
+			if d := item.dist + a.Weight; d < dist[a.To] {
+				dist[a.To] = d
+				heap.Push(queue, distanceItem{node: a.To, dist: d})
+			}
+		}
+	}
+	return dist
+}
+
+// distanceItem is a node with its tentative distance in Dijkstra's algorithm
+type distanceItem struct {
+	node int
+	dist float64
+}
+
+// distanceQueue is a min-heap of distanceItems
+type distanceQueue []distanceItem
+
+func (q distanceQueue) Len() int            { return len(q) }
+func (q distanceQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q distanceQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *distanceQueue) Push(x interface{}) { *q = append(*q, x.(distanceItem)) }
+func (q *distanceQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
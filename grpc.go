@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// gRPC status codes the services return
+const (
+	grpcOK                 = 0
+	grpcCancelled          = 1
+	grpcInvalidArgument    = 3
+	grpcFailedPrecondition = 9
+	grpcUnimplemented      = 12
+	grpcInternal           = 13
+)
+
+// grpcSolvePath is the method path of aco.v1.Solver/Solve
+const grpcSolvePath = "/aco.v1.Solver/Solve"
+
+// SolveRequest is a decoded aco.v1.SolveRequest
+type SolveRequest struct {
+	Instance *Instance
+	// Config holds the encoded aco.v1.Config, whose present fields override
+	// the instance's parameters, or nil
+	Config        []byte
+	ProgressEvery int
+}
+
+// UnmarshalProto decodes an aco.v1.SolveRequest message
+func (r *SolveRequest) UnmarshalProto(data []byte) error {
+	*r = SolveRequest{}
+	return protoDecode(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			r.Instance = &Instance{}
+			return r.Instance.UnmarshalProto(f.data)
+		case 2:
+			r.Config = f.data
+		case 3:
+			r.ProgressEvery = f.int()
+		}
+		return nil
+	})
+}
+
+// GRPCServer serves the aco.v1.Solver service of proto/aco.proto. It speaks
+// the gRPC wire protocol over HTTP/2 with the standard library and the hand
+// written protobuf codec, so it needs no generated code: each Solve call runs
+// the colony, streams progress, and ends with the solution
+type GRPCServer struct {
+	// Metrics, if set, records the progress of the calls as "grpc/1", "grpc/2", ...
+	Metrics *Metrics
+	// Tracer, if set, traces the calls under the traceparent of their request
+	Tracer *Tracer
+	// Events, if set, receives the events of the calls, named like their metrics
+	Events RunListener
+
+	calls atomic.Int64
+}
+
+// Handler returns the HTTP/2 handler of the service
+func (s *GRPCServer) Handler() http.Handler {
+	return grpcHandler(s.call)
+}
+
+// grpcHandler adapts a function running the method named by the request
+// path and returning its status into a gRPC handler. Replies always carry
+// their status in trailers
+func grpcHandler(call func(w http.ResponseWriter, r *http.Request) (int, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			http.Error(w, "gRPC requests only", http.StatusUnsupportedMediaType)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc+proto")
+		w.WriteHeader(http.StatusOK)
+		code, err := call(w, r)
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+		if err != nil {
+			w.Header().Set(http.TrailerPrefix+"Grpc-Message", grpcPercentEncode(err.Error()))
+		}
+	})
+}
+
+// call runs the method named by the request path and returns its status
+func (s *GRPCServer) call(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.URL.Path != grpcSolvePath {
+		return grpcUnimplemented, fmt.Errorf("unknown method %s", r.URL.Path)
+	}
+	data, err := readGRPCMessage(r.Body)
+	if err != nil {
+		return grpcInvalidArgument, err
+	}
+	var request SolveRequest
+	if err := request.UnmarshalProto(data); err != nil {
+		return grpcInvalidArgument, err
+	}
+	if request.Instance == nil {
+		return grpcInvalidArgument, errors.New("no instance given")
+	}
+	config, err := instanceConfig(request.Instance, func(base Config) (Config, error) {
+		return base, overlayConfig(request.Config, &base)
+	}, true)
+	if err != nil {
+		return grpcInvalidArgument, err
+	}
+	solver, err := NewSolverContext(r.Context(), request.Instance, config)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return grpcCancelled, err
+		}
+		return grpcInvalidArgument, err
+	}
+	solver.Tracer, solver.TraceParent = s.Tracer, SpanFromTraceParent(r.Header.Get("traceparent"))
+	defer solver.TraceRun()()
+	every := max(request.ProgressEvery, 1)
+	name := "grpc/" + strconv.FormatInt(s.calls.Add(1), 10)
+	defer s.Metrics.Finish(name)
+	solver.Events, solver.EventRun = s.Events, name
+
+	flusher, _ := w.(http.Flusher)
+	send := func(field int, encode func(*protoBuffer)) error {
+		var b protoBuffer
+		b.message(field, encode)
+		if err := writeGRPCMessage(w, b.data); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+	for !solver.Done() {
+		stats, err := solver.StepContext(r.Context())
+		if err != nil {
+			solver.publish(EventRunFinished, RunCancelled)
+			return grpcCancelled, err
+		}
+		s.Metrics.Observe(name, solver, stats)
+		if (stats.Iteration+1)%every == 0 || solver.Done() {
+			if err := send(1, func(b *protoBuffer) { encodeIterationStats(b, &stats) }); err != nil {
+				return grpcInternal, err
+			}
+		}
+	}
+	solution := solver.Solution()
+	// The progress messages already carried the history
+	solution.History = nil
+	if err := send(2, func(b *protoBuffer) { b.data = append(b.data, solution.MarshalProto()...) }); err != nil {
+		return grpcInternal, err
+	}
+	return grpcOK, nil
+}
+
+// readGRPCMessage reads one length-prefixed message. Compressed messages are
+// refused, as the service never advertises an encoding
+func readGRPCMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, fmt.Errorf("reading message: %w", err)
+	}
+	if prefix[0] != 0 {
+		return nil, errors.New("compressed messages are not supported")
+	}
+	n := binary.BigEndian.Uint32(prefix[1:])
+	if n > maxUploadBytes {
+		return nil, fmt.Errorf("message of %d bytes is too large", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("reading message: %w", err)
+	}
+	return data, nil
+}
+
+// writeGRPCMessage writes one uncompressed length-prefixed message
+func writeGRPCMessage(w io.Writer, data []byte) error {
+	prefix := [5]byte{}
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+	if _, err := w.Write(prefix[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// grpcPercentEncode escapes a status message as the grpc-message trailer requires
+func grpcPercentEncode(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		if c := message[i]; c >= 0x20 && c <= 0x7E && c != '%' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// grpcClient returns an HTTP client speaking HTTP/2 without TLS, as the
+// services of "serve -grpc" and "islands" do
+func grpcClient() *http.Client {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	return &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+}
+
+// grpcUnary calls the unary method at path on the server at address and
+// returns its reply, or an error carrying the status the server gave
+func grpcUnary(client *http.Client, address, path string, request []byte) ([]byte, error) {
+	var body bytes.Buffer
+	writeGRPCMessage(&body, request)
+	r, err := http.NewRequest(http.MethodPost, "http://"+address+path, &body)
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", "application/grpc+proto")
+	r.Header.Set("TE", "trailers")
+	response, err := client.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: HTTP status %s", path, response.Status)
+	}
+	reply, readErr := readGRPCMessage(response.Body)
+	// The status follows the reply in trailers, which are read with the body
+	io.Copy(io.Discard, response.Body)
+	status := response.Trailer.Get("Grpc-Status")
+	if status == "" {
+		status = response.Header.Get("Grpc-Status")
+	}
+	if code, _ := strconv.Atoi(status); status != "" && code != grpcOK {
+		message, _ := url.PathUnescape(response.Trailer.Get("Grpc-Message"))
+		return nil, fmt.Errorf("%s: status %d: %s", path, code, message)
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("%s: %w", path, readErr)
+	}
+	return reply, nil
+}
@@ -0,0 +1,20 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReload relays the signal asking a long run to reread its parameters,
+// SIGHUP, to c
+func notifyReload(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGHUP)
+}
+
+// notifyTerminate relays the signal asking the program to end, SIGTERM, to c
+func notifyTerminate(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGTERM)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// branchingLambda is the share of the range of a city's trails by which an
+// edge must beat the weakest one to count towards the branching factor
+const branchingLambda = 0.05
+
+// PheromoneDiversity measures how undecided the colony still is: the Shannon
+// entropy of each city's outgoing trails, normalized so that uniform trails
+// score 1 and a single dominant edge scores 0, averaged over the cities
+func (ac *AntColony) PheromoneDiversity() float64 {
+	n := len(ac.Cities)
+	if n < 3 {
+		return 0
+	}
+	if ac.sparse != nil && len(ac.Constraints.Clusters) == 0 {
+		return ac.sparse.diversity(n)
+	}
+	total := 0.0
+	for i := 0; i < n; i++ {
+		sum := 0.0
+		for j := 0; j < n; j++ {
+			if j != i {
+				sum += ac.pheromone(i, j)
+			}
+		}
+		if sum <= 0 {
+			total++
+			continue
+		}
+		entropy := 0.0
+		for j := 0; j < n; j++ {
+			if p := ac.pheromone(i, j) / sum; j != i && p > 0 {
+				entropy -= p * math.Log(p)
+			}
+		}
+		total += entropy / math.Log(float64(n-1))
+	}
+	return total / float64(n)
+}
+
+// BranchingFactor returns the mean lambda branching factor of the trails
+// (Gambardella and Dorigo, 1995): the number of edges out of a city whose
+// trail exceeds the weakest by more than branchingLambda of the range of the
+// city's trails, averaged over the cities. Uniform trails score n-1, and it
+// falls towards 2 on symmetric instances, 1 on others, as the colony stagnates
+func (ac *AntColony) BranchingFactor() float64 {
+	n := len(ac.Cities)
+	if n < 2 {
+		return 0
+	}
+	if ac.sparse != nil && len(ac.Constraints.Clusters) == 0 {
+		return ac.sparse.branching(n)
+	}
+	total := 0
+	for i := 0; i < n; i++ {
+		low, high := math.Inf(1), math.Inf(-1)
+		for j := 0; j < n; j++ {
+			if j != i {
+				low, high = math.Min(low, ac.pheromone(i, j)), math.Max(high, ac.pheromone(i, j))
+			}
+		}
+		threshold := low + branchingLambda*(high-low)
+		for j := 0; j < n; j++ {
+			if j != i && ac.pheromone(i, j) >= threshold {
+				total++
+			}
+		}
+	}
+	return float64(total) / float64(n)
+}
+
+// populationStats fills in the statistics of an iteration that describe its
+// whole population of ants, beyond the best, mean and worst tour lengths;
+// symmetric tells whether the distances are
+func (ac *AntColony) populationStats(stats *IterationStats, ants []*Ant, lengths []float64, symmetric bool) {
+	if len(lengths) == 0 {
+		return
+	}
+	stats.Median = median(lengths)
+	variance := 0.0
+	for _, length := range lengths {
+		variance += (length - stats.Mean) * (length - stats.Mean) / float64(len(lengths))
+	}
+	stats.StdDev = math.Sqrt(variance)
+	stats.Identical = ac.identicalTours(ants, symmetric)
+	stats.SharedEdges = ac.sharedEdges(ants, symmetric)
+}
+
+// sharedEdges returns the edges every ant's tour takes, in order; when
+// undirected, each edge is given from its lower city
+func (ac *AntColony) sharedEdges(ants []*Ant, undirected bool) [][2]int {
+	counts := make(map[[2]int]int)
+	for _, ant := range ants {
+		seen := make(map[[2]int]bool, len(ant.Tour))
+		ac.forEachEdge(ant.Tour, func(i, j int) {
+			if undirected && j < i {
+				i, j = j, i
+			}
+			if edge := [2]int{i, j}; !seen[edge] {
+				seen[edge] = true
+				counts[edge]++
+			}
+		})
+	}
+	var shared [][2]int
+	for edge, count := range counts {
+		if count == len(ants) {
+			shared = append(shared, edge)
+		}
+	}
+	slices.SortFunc(shared, func(a, b [2]int) int {
+		if a[0] != b[0] {
+			return a[0] - b[0]
+		}
+		return a[1] - b[1]
+	})
+	return shared
+}
+
+// identicalTours returns the share of the ants whose tour another ant built
+// too, compared by tourKey
+func (ac *AntColony) identicalTours(ants []*Ant, reversible bool) float64 {
+	if len(ants) == 0 {
+		return 0
+	}
+	counts := make(map[string]int, len(ants))
+	keys := make([]string, len(ants))
+	for k, ant := range ants {
+		keys[k] = ac.tourKey(ant.Tour, reversible)
+		counts[keys[k]]++
+	}
+	identical := 0
+	for _, key := range keys {
+		if counts[key] > 1 {
+			identical++
+		}
+	}
+	return float64(identical) / float64(len(ants))
+}
+
+// WriteHistoryCSV writes one row of statistics per iteration, with a header, for plotting convergence
+func WriteHistoryCSV(w io.Writer, history []IterationStats) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"iteration", "best", "mean", "median", "worst", "stddev", "best_so_far", "diversity", "branching", "identical", "shared_edges", "evaluations", "q0", "candidate_fallbacks", "trail_min", "trail_max", "clamped"})
+	format := func(x float64) string { return strconv.FormatFloat(x, 'g', -1, 64) }
+	for _, stats := range history {
+		cw.Write([]string{
+			strconv.Itoa(stats.Iteration),
+			format(stats.Best),
+			format(stats.Mean),
+			format(stats.Median),
+			format(stats.Worst),
+			format(stats.StdDev),
+			format(stats.BestSoFar),
+			format(stats.Diversity),
+			format(stats.Branching),
+			format(stats.Identical),
+			strconv.Itoa(len(stats.SharedEdges)),
+			strconv.Itoa(stats.Evaluations),
+			format(stats.Q0),
+			format(stats.CandidateFallbacks),
+			format(stats.TrailMin),
+			format(stats.TrailMax),
+			strconv.Itoa(stats.Clamped),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// htmlReportTemplate lays out a run report as a standalone page, the images inline
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>ACO run report{{with .Report.Instance.Name}}: {{.}}{{end}}</title>
+<style>
+  body { font-family: sans-serif; margin: 2em; color: #222; }
+  table { border-collapse: collapse; margin-bottom: 2em; }
+  th, td { text-align: left; padding: 0.2em 1em 0.2em 0; }
+  th { font-weight: normal; color: #666; }
+  figure { display: inline-block; margin: 0 1em 1em 0; vertical-align: top; }
+  figcaption { color: #666; }
+</style>
+</head>
+<body>
+<h1>ACO run report{{with .Report.Instance.Name}}: {{.}}{{end}}</h1>
+<table>
+{{range .Rows}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+<figure>{{.Tour}}<figcaption>Best tour</figcaption></figure>
+<figure>{{.Convergence}}<figcaption>Best and mean tour length per iteration</figcaption></figure>
+{{with .Heatmap}}<figure>{{.}}<figcaption>Final pheromone matrix, rows and columns in best tour order</figcaption></figure>{{end}}
+{{with .Backbone}}<figure>{{.}}<figcaption>Backbone: edges by how often iteration-best tours took them</figcaption></figure>{{end}}
+</body>
+</html>
+`))
+
+// WriteHTMLReport writes a run as a single HTML page holding its metadata,
+// best tour, convergence chart and, given the final colony state, its
+// pheromone heatmap, to share as one file
+func WriteHTMLReport(w io.Writer, instance *Instance, report *RunReport, state *ColonyState) error {
+	solution := report.Solution
+	config := solution.Config
+	variant := config.Variant
+	if variant == "" {
+		variant = AntSystem
+	}
+	rows := [][2]string{
+		{"Instance", instanceName(instance, report.Instance.Source)},
+		{"Cities", fmt.Sprint(report.Instance.Cities)},
+		{"Fingerprint", report.Instance.Fingerprint},
+		{"Variant", variant},
+		{"Parameters", fmt.Sprintf("ants %d, alpha %g, beta %g, rho %g, q %g, q0 %g, candidates %d",
+			config.NumAnts, config.Alpha, config.Beta, config.Rho, config.Q, config.Q0, config.Candidates)},
+		{"Iterations", fmt.Sprint(len(solution.History))},
+		{"Seed", fmt.Sprint(solution.Seed)},
+		{"Best tour length", fmt.Sprint(solution.Length)},
+	}
+	if optimum, known := KnownOptima[instance.Name]; known {
+		rows = append(rows, [2]string{"Gap to optimal", fmt.Sprintf("%.2f%% (optimum %g)", Gap(solution.Length, optimum), optimum)})
+	}
+	if len(solution.Backbone) > 0 {
+		common := 0
+		for _, e := range solution.Backbone {
+			if e.Frequency > backboneShare {
+				common++
+			}
+		}
+		top := solution.Backbone[:min(5, len(solution.Backbone))]
+		edges := make([]string, len(top))
+		for k, e := range top {
+			edges[k] = fmt.Sprintf("%s-%s (%.0f%%)", instance.Cities[e.From].Label(e.From), instance.Cities[e.To].Label(e.To), 100*e.Frequency)
+		}
+		rows = append(rows, [2]string{"Backbone", fmt.Sprintf("%d edges in over %.0f%% of iteration-best tours; most frequent %s",
+			common, 100*backboneShare, strings.Join(edges, ", "))})
+	}
+	rows = append(rows,
+		[2]string{"Time", fmt.Sprintf("%s (construction %s, update %s, local search %s; %s per iteration), after distances in %s and candidate lists in %s",
+			report.Timings.Total.Round(time.Millisecond), report.Timings.Construction.Round(time.Millisecond),
+			report.Timings.Update.Round(time.Millisecond), report.Timings.LocalSearch.Round(time.Millisecond),
+			report.IterationTimings.Total.Round(time.Microsecond), report.Timings.DistanceMatrix.Round(time.Millisecond),
+			report.Timings.CandidateLists.Round(time.Millisecond))},
+		[2]string{"Started", report.StartedAt.Format(time.RFC3339)},
+		[2]string{"Machine", fmt.Sprintf("%s, %s/%s, %d CPUs, %s", report.Machine.Hostname, report.Machine.OS,
+			report.Machine.Arch, report.Machine.CPUs, report.Machine.GoVersion)},
+	)
+
+	var tour, convergence, heatmap, backbone bytes.Buffer
+	if err := Render(&tour, instance.Cities, solution.Tour, RenderOptions{Format: "svg", Size: svgSize * 3 / 4, Closed: instance.ReturnToStart}); err != nil {
+		return err
+	}
+	series := []ConvergenceSeries{{Name: variant, History: solution.History}}
+	if err := RenderConvergence(&convergence, series, ChartOptions{Format: "svg", Mean: true}); err != nil {
+		return err
+	}
+	if state != nil {
+		var order []int
+		if len(state.BestTour) == len(instance.Cities) {
+			order = state.BestTour
+		}
+		options := RenderOptions{Format: "svg", Size: svgSize * 3 / 4}
+		if err := RenderPheromones(&heatmap, instance.Cities, state.Pheromones, order, PheromoneHeatmap, options); err != nil {
+			return err
+		}
+	}
+	if len(solution.Backbone) > 0 {
+		options := RenderOptions{Format: "svg", Size: svgSize * 3 / 4}
+		if err := RenderPheromones(&backbone, instance.Cities, solution.BackboneMatrix(len(instance.Cities)), nil, PheromoneTrails, options); err != nil {
+			return err
+		}
+	}
+	// The images are our own SVG, safe to embed as they are
+	return htmlReportTemplate.Execute(w, struct {
+		Report                               *RunReport
+		Rows                                 [][2]string
+		Tour, Convergence, Heatmap, Backbone template.HTML
+	}{report, rows, template.HTML(tour.String()), template.HTML(convergence.String()), template.HTML(heatmap.String()),
+		template.HTML(backbone.String())})
+}
+
+// reportCommand implements "report", which solves an instance and writes
+// the run as a shareable HTML page
+func reportCommand(args []string) error {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	logging := logFlags(flags)
+	auto := flags.Bool("auto", true, "without options in the instance, start from parameters recommended for its size and variant")
+	outPath := flags.String("o", "report.html", "write the HTML report to this `file`, - for stdout")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s report [flags] file\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Solves the instance and writes one HTML page with the run's metadata, best tour, convergence")
+		fmt.Fprintln(flags.Output(), "chart and pheromone heatmap.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("report: want exactly one instance file")
+	}
+	logEvery, err := logging()
+	if err != nil {
+		return err
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	config, err := instanceConfig(instance, parameters, *auto)
+	if err != nil {
+		return err
+	}
+	solver, err := NewSolver(instance, config)
+	if err != nil {
+		return err
+	}
+	solver.LogEvery = logEvery
+	startedAt := time.Now()
+	solver.Run()
+	report := NewRunReport(instance, flags.Arg(0), solver, startedAt)
+	return writeFile(*outPath, func(w io.Writer) error {
+		return WriteHTMLReport(w, instance, report, solver.State())
+	})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// loadTourFile reads a tour from a solution JSON file or from a TSPLIB, LKH or Concorde tour file
+func loadTourFile(path string) ([]int, error) {
+	solution, err := loadSolutionFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return solution.Tour, nil
+}
+
+// loadSolutionFile reads a solution JSON file, or a TSPLIB, LKH or Concorde
+// tour file as a solution of unknown length, zero
+func loadSolutionFile(path string) (*Solution, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		tour, err := LoadTour(path)
+		if err != nil {
+			return nil, err
+		}
+		return &Solution{Tour: tour}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var solution Solution
+	if err := json.Unmarshal(data, &solution); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &solution, nil
+}
+
+// improveCommand implements "improve", which polishes an existing tour with
+// local search without running the colony
+func improveCommand(args []string) error {
+	flags := flag.NewFlagSet("improve", flag.ExitOnError)
+	tourPath := flags.String("tour", "", "improve the tour in this solution JSON or TSPLIB/Concorde tour `file`")
+	instancePath := flags.String("instance", "", "the instance `file` the tour visits, instead of the argument")
+	methods := flags.String("method", TwoOptSearch, fmt.Sprintf("comma-separated local `searches` applied in turn, of %s", strings.Join(LocalSearches, ", ")))
+	budget := flags.Duration("time", 0, "stop with the tour improved so far after this long, 0 for no limit")
+	solutionPath := flags.String("solution", "", "write the improved tour as solution JSON to this `file`, - for stdout")
+	tourOutPath := flags.String("tourout", "", "write the improved tour as a TSPLIB tour to this `file`")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s improve -tour file [flags] instance\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Polishes a tour with the -method local searches, applying each in turn until none shortens it")
+		fmt.Fprintln(flags.Output(), "any more or -time runs out, for routes that need quick gains rather than a colony.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *instancePath == "" && flags.NArg() == 1 {
+		*instancePath = flags.Arg(0)
+	}
+	if *instancePath == "" || flags.NArg() > 1 || *tourPath == "" {
+		flags.Usage()
+		return fmt.Errorf("improve: want a -tour file and exactly one instance file")
+	}
+	var searches []string
+	for _, method := range strings.Split(*methods, ",") {
+		method = strings.TrimSpace(method)
+		if err := checkLocalSearch(method); err != nil || method == "" {
+			return fmt.Errorf("improve: unknown method %q, want some of %v", method, LocalSearches)
+		}
+		searches = append(searches, method)
+	}
+	instance, err := ReadInstance(*instancePath, "")
+	if err != nil {
+		return err
+	}
+	tour, err := loadTourFile(*tourPath)
+	if err != nil {
+		return err
+	}
+	if err := instance.ValidateTour(tour); err != nil {
+		return fmt.Errorf("%s: %w", *tourPath, err)
+	}
+	config := DefaultConfig()
+	if instance.Options != nil {
+		config = *instance.Options
+	}
+	solver, err := NewSolver(instance, config)
+	if err != nil {
+		return err
+	}
+	colony := solver.Colony
+	ctx := context.Background()
+	if *budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *budget)
+		defer cancel()
+	}
+	colony.ctx = ctx
+	start := time.Now()
+	before := colony.TourLength(tour)
+	improved, after := tour, before
+	gains := make(map[string]float64)
+	for shorter := true; shorter && ctx.Err() == nil; {
+		shorter = false
+		for _, method := range searches {
+			candidate := localSearches[method](colony, improved)
+			if length := colony.TourLength(candidate); length < after {
+				gains[method] += after - length
+				improved, after, shorter = candidate, length, true
+			}
+		}
+	}
+
+	out := os.Stdout
+	if *solutionPath == "-" {
+		out = os.Stderr
+	}
+	if hasNames(instance.Cities) {
+		fmt.Fprintln(out, "Improved tour:", FormatTour(instance.Cities, improved))
+	} else {
+		fmt.Fprintln(out, "Improved tour:", improved)
+	}
+	fmt.Fprintf(out, "Tour length: %g → %g (%.2f%% shorter)\n", before, after, 100*(before-after)/before)
+	for _, method := range searches {
+		fmt.Fprintf(out, "  %s: %g shorter\n", method, gains[method])
+	}
+	if ctx.Err() != nil {
+		fmt.Fprintf(out, "Stopped after %s, before the local searches ran out of moves\n", *budget)
+	} else {
+		fmt.Fprintf(out, "Local optimum reached in %s\n", time.Since(start).Round(time.Millisecond))
+	}
+	if *solutionPath != "" {
+		solution := &Solution{Tour: improved, Length: after, Config: config}
+		if err := writeJSON(*solutionPath, solution); err != nil {
+			return err
+		}
+	}
+	if *tourOutPath != "" {
+		return writeFile(*tourOutPath, func(w io.Writer) error {
+			return WriteTSPLIBTour(w, instance.Name, improved)
+		})
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Input formats understood by ReadInstance
+const (
+	FormatTSPLIB  = "tsplib"
+	FormatCSV     = "csv"
+	FormatJSON    = "json"
+	FormatGeoJSON = "geojson"
+	FormatGraph   = "graph"
+	FormatProto   = "proto"
+)
+
+// formatExtensions maps file extensions to the input format they usually hold
+var formatExtensions = map[string]string{
+	".tsp":     FormatTSPLIB,
+	".atsp":    FormatTSPLIB,
+	".csv":     FormatCSV,
+	".json":    FormatJSON,
+	".geojson": FormatGeoJSON,
+	".gr":      FormatGraph,
+	".dimacs":  FormatGraph,
+	".edges":   FormatGraph,
+	".pb":      FormatProto,
+}
+
+// readInput returns the contents of the file at path, "-" meaning standard input
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// scanNumbers reads the whitespace-separated numbers of r, as the OR-Library
+// and QAPLIB formats hold them
+func scanNumbers(r io.Reader) ([]float64, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	var values []float64
+	for scanner.Scan() {
+		v, err := strconv.ParseFloat(scanner.Text(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", scanner.Text())
+		}
+		values = append(values, v)
+	}
+	return values, scanner.Err()
+}
+
+// DetectFormat guesses the format of input data from the file name's
+// extension, falling back to its contents when the name says nothing, as for
+// standard input
+func DetectFormat(path string, data []byte) string {
+	if format, ok := formatExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return format
+	}
+	text := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(text, []byte("{")):
+		if bytes.Contains(text, []byte(`"FeatureCollection"`)) {
+			return FormatGeoJSON
+		}
+		return FormatJSON
+	case bytes.HasPrefix(text, []byte("NAME")), bytes.HasPrefix(text, []byte("TYPE")),
+		bytes.HasPrefix(text, []byte("DIMENSION")), bytes.HasPrefix(text, []byte("COMMENT")):
+		return FormatTSPLIB
+	case bytes.HasPrefix(text, []byte("p ")), bytes.HasPrefix(text, []byte("c ")):
+		return FormatGraph
+	}
+	return FormatCSV
+}
+
+// ReadInstance reads an instance in the given format, or a detected one if
+// format is empty, from the file at path or from standard input if path is "-".
+// Graphs become tours over their shortest-path distances
+func ReadInstance(path, format string) (*Instance, error) {
+	return ReadInstanceContext(context.Background(), path, format, nil)
+}
+
+// ReadGraph reads a DIMACS or edge-list graph from the file at path or from standard input if path is "-"
+func ReadGraph(path string) (*Digraph, error) {
+	data, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := LoadGraph(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", inputName(path), err)
+	}
+	return graph, nil
+}
+
+// parseInstance decodes data held in format
+func parseInstance(format string, data []byte) (*Instance, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case FormatTSPLIB:
+		tsplib, err := ParseTSPLIB(r)
+		if err != nil {
+			return nil, err
+		}
+		return tsplib.Instance(), nil
+	case FormatCSV:
+		return LoadInstanceCSV(r)
+	case FormatJSON:
+		instance := &Instance{}
+		start := time.Now()
+		if err := json.Unmarshal(data, instance); err != nil {
+			return nil, err
+		}
+		// Decoding is mostly the matrix, where there is one
+		if instance.Distances != nil {
+			instance.matrixTime = time.Since(start)
+		}
+		return instance, nil
+	case FormatGeoJSON:
+		cities, err := LoadCitiesGeoJSON(r)
+		if err != nil {
+			return nil, err
+		}
+		return &Instance{Cities: cities, Geographic: true}, nil
+	case FormatProto:
+		instance := &Instance{}
+		if err := instance.UnmarshalProto(data); err != nil {
+			return nil, err
+		}
+		return instance, nil
+	case FormatGraph:
+		graph, err := LoadGraph(r)
+		if err != nil {
+			return nil, err
+		}
+		return graph.Instance(), nil
+	}
+	return nil, fmt.Errorf("unknown input format %q", format)
+}
+
+// inputName names path in error messages
+func inputName(path string) string {
+	if path == "-" {
+		return "stdin"
+	}
+	return path
+}
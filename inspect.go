@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// PheromoneStats summarizes the trails a colony has learned, over the edges
+// between distinct cities, as ants see them
+type PheromoneStats struct {
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	// Initial is the level every trail started at
+	Initial float64 `json:"initial"`
+	// Lower and Upper are the trail limits of Max-Min Ant System or of
+	// Config.TrailMin and TrailMax, zero where there is none
+	Lower float64 `json:"lower,omitempty"`
+	Upper float64 `json:"upper,omitempty"`
+	// Diversity and Branching are PheromoneDiversity and BranchingFactor
+	Diversity float64 `json:"diversity"`
+	Branching float64 `json:"branching"`
+	// Touched counts the edges holding a trail of their own in sparse
+	// storage, the others sharing one level; it is every edge with dense trails
+	Touched int `json:"touched"`
+}
+
+// Pheromone returns the trail an ant at city i sees on the edge to city j:
+// the cluster-level trail for edges between clusters. Like the other
+// inspection methods it must not run during an iteration
+func (ac *AntColony) Pheromone(i, j int) (float64, error) {
+	n := len(ac.Cities)
+	if i < 0 || i >= n || j < 0 || j >= n {
+		return 0, fmt.Errorf("edge (%d, %d) out of range [0, %d)", i, j, n)
+	}
+	return ac.pheromone(i, j), nil
+}
+
+// PheromoneSnapshot returns a copy of the trails between every pair of
+// cities as ants see them, which later iterations leave as it is
+func (ac *AntColony) PheromoneSnapshot() [][]float64 {
+	n := len(ac.Cities)
+	snapshot := make([][]float64, n)
+	for i := range snapshot {
+		snapshot[i] = make([]float64, n)
+		for j := range snapshot[i] {
+			snapshot[i][j] = ac.pheromone(i, j)
+		}
+	}
+	return snapshot
+}
+
+// PheromoneStats summarizes the colony's trails
+func (ac *AntColony) PheromoneStats() PheromoneStats {
+	n := len(ac.Cities)
+	stats := PheromoneStats{
+		Min:       math.Inf(1),
+		Max:       math.Inf(-1),
+		Initial:   ac.initialPheromone,
+		Diversity: ac.PheromoneDiversity(),
+		Branching: ac.BranchingFactor(),
+		Touched:   n * (n - 1),
+	}
+	if ac.boundedTrails() {
+		stats.Lower = ac.minPheromone
+		if !math.IsInf(ac.maxPheromone, 1) {
+			stats.Upper = ac.maxPheromone
+		}
+	}
+	// add counts count edges of trail level
+	count, sum, squares := 0, 0.0, 0.0
+	add := func(level float64, edges int) {
+		if edges == 0 {
+			return
+		}
+		stats.Min, stats.Max = math.Min(stats.Min, level), math.Max(stats.Max, level)
+		count += edges
+		sum += float64(edges) * level
+		squares += float64(edges) * level * level
+	}
+	sparse := ac.sparse != nil && len(ac.Constraints.Clusters) == 0
+	if ac.sparse != nil {
+		rows := ac.sparse.rows(n)
+		stats.Touched = 0
+		for _, row := range rows {
+			stats.Touched += len(row)
+			for _, trail := range row {
+				if sparse {
+					add(trail, 1)
+				}
+			}
+		}
+		if sparse {
+			add(ac.sparse.level, n*(n-1)-stats.Touched)
+		}
+	}
+	if !sparse {
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				if i != j {
+					add(ac.pheromone(i, j), 1)
+				}
+			}
+		}
+	}
+	if count == 0 {
+		stats.Min, stats.Max = 0, 0
+		return stats
+	}
+	stats.Mean = sum / float64(count)
+	stats.StdDev = math.Sqrt(math.Max(squares/float64(count)-stats.Mean*stats.Mean, 0))
+	return stats
+}
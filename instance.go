@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Instance is a TSP instance as exchanged in JSON: the cities, an optional
+// explicit distance matrix, the constraints and the options to solve it with.
+// Geographic instances hold longitude in X and latitude in Y
+type Instance struct {
+	Name          string       `json:"name,omitempty"`
+	Cities        []*City      `json:"cities"`
+	Geographic    bool         `json:"geographic,omitempty"`
+	Distances     [][]float64  `json:"distances,omitempty"`
+	ReturnToStart bool         `json:"return_to_start,omitempty"`
+	Constraints   *Constraints `json:"constraints,omitempty"`
+	Options       *Config      `json:"options,omitempty"`
+	// Attributes holds matrices of edge attributes other than the cost, such
+	// as tolls or scenic scores, by name, for Config.EdgeWeights and
+	// multi-objective runs
+	Attributes map[string][][]float64 `json:"attributes,omitempty"`
+	// EdgeWeightType, if set, is the TSPLIB rule, such as EUC_2D or GEO, by
+	// which distances follow from the coordinates where there is no distance
+	// matrix; they are exact Euclidean ones without it
+	EdgeWeightType string `json:"edge_weight_type,omitempty"`
+
+	// matrixTime is the time spent building Distances as the instance was
+	// loaded, which solvers report as the time their distance matrix took
+	matrixTime time.Duration
+}
+
+// Validate checks that the instance is complete and its constraints are satisfiable
+func (inst *Instance) Validate() error {
+	n := len(inst.Cities)
+	if n == 0 {
+		return fmt.Errorf("instance has no cities")
+	}
+	for i, c := range inst.Cities {
+		if c == nil {
+			return fmt.Errorf("city %d is null", i)
+		}
+		if c.Metadata != nil && !json.Valid(c.Metadata) {
+			return fmt.Errorf("metadata of city %d is not valid JSON", i)
+		}
+	}
+	if inst.Distances != nil {
+		if len(inst.Distances) != n {
+			return fmt.Errorf("distance matrix has %d rows for %d cities", len(inst.Distances), n)
+		}
+		for i, row := range inst.Distances {
+			if len(row) != n {
+				return fmt.Errorf("distance matrix row %d has %d entries for %d cities", i, len(row), n)
+			}
+		}
+	}
+	if inst.EdgeWeightType != "" {
+		if inst.Distances != nil {
+			return fmt.Errorf("edge weight type %s and a distance matrix cannot be combined", inst.EdgeWeightType)
+		}
+		if _, err := tsplibDistance(inst.EdgeWeightType); err != nil {
+			return err
+		}
+	}
+	for name, matrix := range inst.Attributes {
+		if name == "" || name == LengthAttribute {
+			return fmt.Errorf("edge attribute name %q is reserved", name)
+		}
+		if len(matrix) != n {
+			return fmt.Errorf("edge attribute %s has %d rows for %d cities", name, len(matrix), n)
+		}
+		for i, row := range matrix {
+			if len(row) != n {
+				return fmt.Errorf("edge attribute %s row %d has %d entries for %d cities", name, i, len(row), n)
+			}
+		}
+	}
+	if inst.Constraints != nil {
+		if err := inst.Constraints.Validate(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metric returns the distance function between the instance's cities, by
+// its EdgeWeightType or Euclidean. It need not give zero from a city to
+// itself, which distance does
+func (inst *Instance) metric() func(a, b *City) float64 {
+	if distance, err := tsplibDistance(inst.EdgeWeightType); err == nil {
+		return distance
+	}
+	return (*City).Distance
+}
+
+// distance returns the distance from city i to city j, from the distance
+// matrix or by the metric between their coordinates
+func (inst *Instance) distance(i, j int) float64 {
+	switch {
+	case inst.Distances != nil:
+		return inst.Distances[i][j]
+	case i == j:
+		return 0
+	}
+	return inst.metric()(inst.Cities[i], inst.Cities[j])
+}
+
+// Fingerprint identifies the problem an instance poses: a SHA-256 of its
+// cities, distances and constraints, ignoring its name and options, so runs on
+// the same problem can be matched whatever file they came from
+func (inst *Instance) Fingerprint() string {
+	problem := *inst
+	problem.Name, problem.Options = "", nil
+	if problem.Distances != nil {
+		// JSON has no infinity, so unreachable pairs of graph instances hash as the largest float
+		problem.Distances = make([][]float64, len(inst.Distances))
+		for i, row := range inst.Distances {
+			problem.Distances[i] = make([]float64, len(row))
+			for j, d := range row {
+				problem.Distances[i][j] = min(d, math.MaxFloat64)
+			}
+		}
+	}
+	data, _ := json.Marshal(&problem)
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// UnmarshalJSON decodes an instance and validates it
+func (inst *Instance) UnmarshalJSON(data []byte) error {
+	type plain Instance
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*inst = Instance(decoded)
+	return inst.Validate()
+}
+
+// Instance converts a TSPLIB problem into a closed-tour instance using its
+// distances, or its edge weight type to compute them by
+func (t *TSPLIBInstance) Instance() *Instance {
+	inst := &Instance{Name: t.Name, Cities: t.Cities, Distances: t.Distances, ReturnToStart: true, matrixTime: t.matrixTime}
+	if t.Distances == nil {
+		inst.EdgeWeightType = t.EdgeWeightType
+	}
+	return inst
+}
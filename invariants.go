@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"runtime/debug"
+)
+
+// checkInvariants checks what must hold after every iteration, whatever the
+// instance and parameters: every ant's tour visits each city once, which it
+// cannot if selection ever picks a visited city, tour lengths are finite and
+// not negative, and no trail is negative or NaN
+func (ac *AntColony) checkInvariants(ants []*Ant) error {
+	n := len(ac.Cities)
+	for k, ant := range ants {
+		if err := validateTour(ant.Tour, n, nil, false); err != nil {
+			return fmt.Errorf("ant %d: %w", k, err)
+		}
+		if length := ac.TourLength(ant.Tour); length < 0 || math.IsNaN(length) || math.IsInf(length, 0) {
+			return fmt.Errorf("ant %d: tour length %g", k, length)
+		}
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if trail := ac.pheromone(i, j); trail < 0 || math.IsNaN(trail) {
+				return fmt.Errorf("trail %d-%d is %g", i, j, trail)
+			}
+		}
+	}
+	return nil
+}
+
+// FuzzCase is a random instance and configuration the fuzz command runs,
+// which -case runs again on its own
+type FuzzCase struct {
+	Distribution string `json:"distribution"`
+	Cities       int    `json:"cities"`
+	InstanceSeed int64  `json:"instance_seed"`
+	Config       Config `json:"config"`
+}
+
+// randomFuzzCase draws a case of up to maxCities cities, with parameters
+// anywhere in their valid ranges, running for iterations
+func randomFuzzCase(rng *rand.Rand, maxCities, iterations int) FuzzCase {
+	distributions := []string{UniformCities, ClusteredCities, GridCities}
+	c := FuzzCase{
+		Distribution: distributions[rng.Intn(len(distributions))],
+		Cities:       1 + rng.Intn(maxCities),
+		InstanceSeed: rng.Int63(),
+	}
+	config := DefaultConfig()
+	config.Variant = Variants[rng.Intn(len(Variants))]
+	config.NumAnts = 1 + rng.Intn(20)
+	config.Alpha = 3 * rng.Float64()
+	config.Beta = 5 * rng.Float64()
+	config.Rho = max(rng.Float64(), 0.01)
+	config.Q0 = rng.Float64()
+	config.Iterations = iterations
+	config.Seed = 1 + rng.Int63n(math.MaxInt32)
+	config.Workers = 1 + rng.Intn(4)
+	config.Candidates = rng.Intn(c.Cities + 1)
+	config.SparsePheromones = rng.Intn(2) == 0
+	config.Adaptive = rng.Intn(4) == 0
+	if !config.Adaptive && rng.Intn(4) == 0 {
+		config.Castes = "standard=0.8,greedy=0.1,random=0.1"
+	}
+	if config.Variant != MaxMinAntSystem && rng.Intn(4) == 0 {
+		config.TrailMin, config.TrailMax = rng.Float64(), 1+10*rng.Float64()
+	}
+	config.Placement = Placements[rng.Intn(len(Placements))]
+	config.StartCity = rng.Intn(c.Cities)
+	if rng.Intn(2) == 0 {
+		config.LocalSearch = true
+		config.Search = LocalSearches[rng.Intn(len(LocalSearches))]
+	}
+	if rng.Intn(2) == 0 {
+		config.Q0Schedule = Q0Schedules[rng.Intn(len(Q0Schedules))]
+		// The exponential schedule wants q0 below 1 at both ends
+		config.Q0, config.Q0Final = 0.99*config.Q0, 0.99*rng.Float64()
+	}
+	c.Config = config
+	return c
+}
+
+// run solves the case with invariants checked after every iteration,
+// returning the first broken one, or the panic of the solver as an error
+func (c FuzzCase) run() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	g, err := NewGenerator(c.Distribution, c.InstanceSeed)
+	if err != nil {
+		return err
+	}
+	solver, err := NewSolver(g.Instance(c.Cities), c.Config)
+	if err != nil {
+		return err
+	}
+	solver.CheckInvariants = true
+	_, err = solver.RunContext(context.Background())
+	return err
+}
+
+// fuzzCommand implements "fuzz", which solves random instances with random
+// parameters, checking the invariants of every iteration, and stops at the
+// first case that breaks one or panics, printing it as JSON for -case
+func fuzzCommand(args []string) error {
+	flags := flag.NewFlagSet("fuzz", flag.ExitOnError)
+	runs := flags.Int("runs", 200, "number of random cases to run")
+	seed := flags.Int64("seed", 1, "random `seed` drawing the cases; the same seed draws the same cases")
+	maxCities := flags.Int("max-cities", 30, "draw instances of up to this many cities")
+	iterations := flags.Int("iterations", 5, "run each case this many iterations")
+	casePath := flags.String("case", "", "run the case in this JSON `file`, printed by an earlier failure, instead of random ones")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s fuzz [flags]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Solves random instances with random parameters, checking after every iteration that the")
+		fmt.Fprintln(flags.Output(), "tours visit every city once with finite lengths and that no trail is negative or NaN.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *casePath != "" {
+		data, err := os.ReadFile(*casePath)
+		if err != nil {
+			return err
+		}
+		var c FuzzCase
+		if err := json.Unmarshal(data, &c); err != nil {
+			return fmt.Errorf("%s: %w", *casePath, err)
+		}
+		return c.run()
+	}
+	if *runs <= 0 || *maxCities <= 0 || *iterations <= 0 {
+		return fmt.Errorf("fuzz: -runs, -max-cities and -iterations must be positive")
+	}
+	rng := rand.New(rand.NewSource(*seed))
+	for r := 0; r < *runs; r++ {
+		c := randomFuzzCase(rng, *maxCities, *iterations)
+		if err := c.run(); err != nil {
+			data, _ := json.MarshalIndent(c, "", "  ")
+			fmt.Printf("%s\n", data)
+			return fmt.Errorf("fuzz: case %d of %d failed: %w", r+1, *runs, err)
+		}
+	}
+	fmt.Printf("%d cases passed\n", *runs)
+	return nil
+}
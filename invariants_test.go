@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// FuzzInvariants runs the random cases of the fuzz command, drawn from the
+// fuzzed seed, and fails on any invariant an iteration breaks
+func FuzzInvariants(f *testing.F) {
+	for seed := int64(1); seed <= 20; seed++ {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		c := randomFuzzCase(rand.New(rand.NewSource(seed)), 12, 3)
+		if err := c.run(); err != nil {
+			t.Fatalf("%+v: %v", c, err)
+		}
+	})
+}
+
+// FuzzParseTSPLIB checks that no TSPLIB file makes the parser panic, and that
+// the distances of the instances it accepts can be read
+func FuzzParseTSPLIB(f *testing.F) {
+	f.Add([]byte("NAME: tiny\nTYPE: TSP\nDIMENSION: 3\nEDGE_WEIGHT_TYPE: EUC_2D\nNODE_COORD_SECTION\n1 0 0\n2 3 0\n3 3 4\nEOF\n"))
+	f.Add([]byte("NAME: geo\nDIMENSION: 2\nEDGE_WEIGHT_TYPE: GEO\nNODE_COORD_SECTION\n1 38.24 20.42\n2 39.57 26.15\nEOF\n"))
+	f.Add([]byte("NAME: full\nDIMENSION: 3\nEDGE_WEIGHT_TYPE: EXPLICIT\nEDGE_WEIGHT_FORMAT: FULL_MATRIX\nEDGE_WEIGHT_SECTION\n0 1 2\n1 0 3\n2 3 0\nEOF\n"))
+	f.Add([]byte("NAME: upper\nDIMENSION: 4\nEDGE_WEIGHT_TYPE: EXPLICIT\nEDGE_WEIGHT_FORMAT: UPPER_ROW\nEDGE_WEIGHT_SECTION\n1 2 3\n4 5\n6\nEOF\n"))
+	f.Add([]byte("DIMENSION: 2\nEDGE_WEIGHT_TYPE: ATT\nNODE_COORD_SECTION\n1 6734 1453\n2 2233 10\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tsplib, err := ParseTSPLIB(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		inst := tsplib.Instance()
+		if inst.Validate() != nil || len(inst.Cities) > 100 {
+			return
+		}
+		for i := range inst.Cities {
+			for j := range inst.Cities {
+				inst.distance(i, j)
+			}
+		}
+	})
+}
+
+// FuzzParseInstance checks that no input in any format makes its parser
+// panic, nor checking the instances the parsers accept
+func FuzzParseInstance(f *testing.F) {
+	formats := []string{FormatCSV, FormatJSON, FormatGeoJSON, FormatProto, FormatGraph}
+	f.Add(uint8(0), []byte("name,x,y\na,0,0\nb,3,0\nc,3,4\n"))
+	f.Add(uint8(1), []byte(`{"cities":[{"x":0,"y":0},{"x":3,"y":0}],"return_to_start":true}`))
+	f.Add(uint8(1), []byte(`{"cities":[{"x":0,"y":0},{"x":1,"y":1}],"distances":[[0,2],[2,0]]}`))
+	f.Add(uint8(2), []byte(`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[13.4,52.5]},"properties":{"name":"Berlin"}}]}`))
+	f.Add(uint8(3), []byte{0x0a, 0x04, 0x11, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f})
+	f.Add(uint8(4), []byte("p sp 3 2\na 1 2 5\na 2 3 1\n"))
+	f.Fuzz(func(t *testing.T, format uint8, data []byte) {
+		inst, err := parseInstance(formats[int(format)%len(formats)], data)
+		if err != nil {
+			return
+		}
+		inst.Validate()
+	})
+}
+
+// FuzzConfigValidate checks that every configuration Validate accepts runs
+// without breaking an invariant, on a small instance and for a few iterations
+func FuzzConfigValidate(f *testing.F) {
+	f.Add(uint8(0), 10, 1.0, 2.0, 0.5, 100.0, 0.9)
+	f.Add(uint8(1), 1, 0.0, 0.0, 1.0, 1e-9, 0.0)
+	f.Add(uint8(2), 50, 10.0, 20.0, 0.01, 1e12, 1.0)
+	f.Add(uint8(3), 3, math.Inf(1), math.NaN(), -1.0, 0.0, 2.0)
+	g, err := NewGenerator(UniformCities, 1)
+	if err != nil {
+		f.Fatal(err)
+	}
+	instance := g.Instance(8)
+	f.Fuzz(func(t *testing.T, variant uint8, ants int, alpha, beta, rho, q, q0 float64) {
+		config := DefaultConfig()
+		config.Variant = Variants[int(variant)%len(Variants)]
+		config.NumAnts, config.Alpha, config.Beta, config.Rho, config.Q, config.Q0 = ants, alpha, beta, rho, q, q0
+		config.Iterations, config.Seed = 3, 1
+		if config.Validate() != nil || ants > 50 {
+			return
+		}
+		solver, err := NewSolver(instance, config)
+		if err != nil {
+			t.Fatalf("%+v: %v", config, err)
+		}
+		solver.CheckInvariants = true
+		if _, err := solver.RunContext(context.Background()); err != nil {
+			t.Fatalf("%+v: %v", config, err)
+		}
+	})
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Migration topologies of the island model
+const (
+	// TopologyRing sends every island the best tour of the one before it
+	TopologyRing = "ring"
+	// TopologyFull sends every island the best tour of all the others
+	TopologyFull = "full"
+)
+
+// Method paths of the aco.v1.Islands service
+const (
+	grpcJoinPath    = "/aco.v1.Islands/Join"
+	grpcMigratePath = "/aco.v1.Islands/Migrate"
+)
+
+// IslandAssignment is a decoded aco.v1.JoinResponse: what an island solves
+type IslandAssignment struct {
+	Island   int
+	Islands  int
+	Instance *Instance
+	Config   Config
+	// Interval is the number of iterations between migrations
+	Interval int
+}
+
+// MarshalProto encodes the assignment as an aco.v1.JoinResponse message
+func (a *IslandAssignment) MarshalProto() []byte {
+	var b protoBuffer
+	b.int(1, int64(a.Island))
+	b.int(2, int64(a.Islands))
+	b.message(3, func(b *protoBuffer) { b.data = append(b.data, a.Instance.MarshalProto()...) })
+	b.message(4, func(b *protoBuffer) { encodeConfig(b, &a.Config) })
+	b.int(5, int64(a.Interval))
+	return b.data
+}
+
+// UnmarshalProto decodes an aco.v1.JoinResponse message
+func (a *IslandAssignment) UnmarshalProto(data []byte) error {
+	*a = IslandAssignment{}
+	return protoDecode(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			a.Island = f.int()
+		case 2:
+			a.Islands = f.int()
+		case 3:
+			a.Instance = &Instance{}
+			return a.Instance.UnmarshalProto(f.data)
+		case 4:
+			return overlayConfig(f.data, &a.Config)
+		case 5:
+			a.Interval = f.int()
+		}
+		return nil
+	})
+}
+
+// Migrant is a best tour travelling between the coordinator and an island,
+// a decoded aco.v1.MigrateRequest or aco.v1.MigrateResponse
+type Migrant struct {
+	Island    int
+	Iteration int
+	Tour      []int
+	// Length is +Inf without a tour
+	Length float64
+	// Done tells the coordinator the island ran its last iteration
+	Done bool
+}
+
+// MarshalProto encodes the migrant as an aco.v1.MigrateRequest message,
+// whose first fields aco.v1.MigrateResponse shares
+func (m *Migrant) MarshalProto() []byte {
+	var b protoBuffer
+	b.int(1, int64(m.Island))
+	b.int(2, int64(m.Iteration))
+	b.ints(3, m.Tour)
+	b.double(4, m.Length)
+	b.bool(5, m.Done)
+	return b.data
+}
+
+// UnmarshalProto decodes an aco.v1.MigrateRequest or MigrateResponse message
+func (m *Migrant) UnmarshalProto(data []byte) error {
+	*m = Migrant{Length: math.Inf(1)}
+	return protoDecode(data, func(f protoField) error {
+		var err error
+		switch f.num {
+		case 1:
+			m.Island = f.int()
+		case 2:
+			m.Iteration = f.int()
+		case 3:
+			var tour []int
+			tour, err = f.ints()
+			m.Tour = append(m.Tour, tour...)
+		case 4:
+			m.Length = f.double()
+		case 5:
+			m.Done = f.bool()
+		}
+		return err
+	})
+}
+
+// IslandCoordinator serves the aco.v1.Islands service. It hands the instance
+// to the islands as they join and relays their best tours between them along
+// the topology, so independent colonies on several machines share what they
+// find while each keeps its own trails
+type IslandCoordinator struct {
+	Instance *Instance
+	Config   Config
+	Islands  int
+	Interval int
+	Topology string
+
+	mu       sync.Mutex
+	joined   int
+	best     []Migrant
+	finished chan struct{}
+}
+
+// NewIslandCoordinator prepares a coordinator of islands colonies
+func NewIslandCoordinator(instance *Instance, config Config, islands, interval int, topology string) (*IslandCoordinator, error) {
+	if islands < 1 {
+		return nil, fmt.Errorf("want at least one island, not %d", islands)
+	}
+	if interval < 1 {
+		return nil, fmt.Errorf("migration interval must be at least one iteration, not %d", interval)
+	}
+	if topology != TopologyRing && topology != TopologyFull {
+		return nil, fmt.Errorf("unknown topology %q, want %s or %s", topology, TopologyRing, TopologyFull)
+	}
+	best := make([]Migrant, islands)
+	for i := range best {
+		best[i] = Migrant{Island: i, Length: math.Inf(1)}
+	}
+	return &IslandCoordinator{Instance: instance, Config: config, Islands: islands, Interval: interval, Topology: topology,
+		best: best, finished: make(chan struct{})}, nil
+}
+
+// Handler returns the HTTP/2 handler of the service
+func (c *IslandCoordinator) Handler() http.Handler {
+	return grpcHandler(c.call)
+}
+
+// call runs the method named by the request path and returns its status
+func (c *IslandCoordinator) call(w http.ResponseWriter, r *http.Request) (int, error) {
+	data, err := readGRPCMessage(r.Body)
+	if err != nil {
+		return grpcInvalidArgument, err
+	}
+	var reply []byte
+	switch r.URL.Path {
+	case grpcJoinPath:
+		assignment, err := c.join()
+		if err != nil {
+			return grpcFailedPrecondition, err
+		}
+		reply = assignment.MarshalProto()
+	case grpcMigratePath:
+		var migrant Migrant
+		if err := migrant.UnmarshalProto(data); err != nil {
+			return grpcInvalidArgument, err
+		}
+		immigrant, err := c.migrate(migrant)
+		if err != nil {
+			return grpcInvalidArgument, err
+		}
+		reply = immigrant.MarshalProto()
+	default:
+		return grpcUnimplemented, fmt.Errorf("unknown method %s", r.URL.Path)
+	}
+	if err := writeGRPCMessage(w, reply); err != nil {
+		return grpcInternal, err
+	}
+	return grpcOK, nil
+}
+
+// join assigns the next island. Islands of a seeded run get seeds of their
+// own so they search apart
+func (c *IslandCoordinator) join() (*IslandAssignment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.joined == c.Islands {
+		return nil, fmt.Errorf("all %d islands have joined", c.Islands)
+	}
+	island := c.joined
+	c.joined++
+	config := c.Config
+	if config.Seed != 0 {
+		config.Seed += int64(island)
+	}
+	slog.Info("island joined", "island", island, "islands", c.Islands)
+	return &IslandAssignment{Island: island, Islands: c.Islands, Instance: c.Instance, Config: config, Interval: c.Interval}, nil
+}
+
+// migrate records the best tour of an island and returns the one it should
+// receive: its predecessor's in a ring, the best of the others when fully
+// connected, with an infinite length while they have none
+func (c *IslandCoordinator) migrate(migrant Migrant) (Migrant, error) {
+	if migrant.Island < 0 || migrant.Island >= c.Islands {
+		return Migrant{}, fmt.Errorf("no island %d", migrant.Island)
+	}
+	if len(migrant.Tour) > 0 {
+		if err := ValidateTour(migrant.Tour, len(c.Instance.Cities), c.Instance.Constraints); err != nil {
+			return Migrant{}, fmt.Errorf("island %d: %w", migrant.Island, err)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	own := &c.best[migrant.Island]
+	if migrant.Length < own.Length {
+		own.Tour, own.Length = migrant.Tour, migrant.Length
+		slog.Debug("island improved", "island", migrant.Island, "iteration", migrant.Iteration, "length", migrant.Length)
+	}
+	own.Iteration = migrant.Iteration
+	if migrant.Done && !own.Done {
+		own.Done = true
+		if c.countDone() == c.Islands {
+			close(c.finished)
+		}
+	}
+	immigrant := Migrant{Length: math.Inf(1)}
+	for _, other := range c.best {
+		if other.Island == migrant.Island {
+			continue
+		}
+		if c.Topology == TopologyRing && other.Island != (migrant.Island+c.Islands-1)%c.Islands {
+			continue
+		}
+		if other.Length < immigrant.Length {
+			immigrant = Migrant{Island: other.Island, Iteration: other.Iteration, Tour: other.Tour, Length: other.Length}
+		}
+	}
+	return immigrant, nil
+}
+
+// countDone returns the number of islands that ran their last iteration
+func (c *IslandCoordinator) countDone() int {
+	done := 0
+	for _, island := range c.best {
+		if island.Done {
+			done++
+		}
+	}
+	return done
+}
+
+// Wait blocks until every island is done or ctx ends, and returns the
+// solution of the island that found the shortest tour
+func (c *IslandCoordinator) Wait(ctx context.Context) (*Solution, int, error) {
+	select {
+	case <-c.finished:
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	best := c.best[0]
+	for _, island := range c.best[1:] {
+		if island.Length < best.Length {
+			best = island
+		}
+	}
+	config := c.Config
+	if config.Seed != 0 {
+		config.Seed += int64(best.Island)
+	}
+	return &Solution{Tour: best.Tour, Length: best.Length, Config: config, Seed: config.Seed}, best.Island, nil
+}
+
+// RunIsland joins the coordinator at address and runs the colony it assigns,
+// exchanging best tours with it every migration interval. Parameters are
+// applied over the assigned configuration, so an island can use its own
+// number of workers
+func RunIsland(address string, parameters func(Config) (Config, error)) (*Solution, error) {
+	client := grpcClient()
+	reply, err := grpcUnary(client, address, grpcJoinPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("joining %s: %w", address, err)
+	}
+	var assignment IslandAssignment
+	if err := assignment.UnmarshalProto(reply); err != nil {
+		return nil, fmt.Errorf("joining %s: %w", address, err)
+	}
+	config, err := parameters(assignment.Config)
+	if err != nil {
+		return nil, err
+	}
+	solver, err := NewSolver(assignment.Instance, config)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("joined", "island", assignment.Island, "islands", assignment.Islands,
+		"cities", len(assignment.Instance.Cities), "iterations", config.iterationLimit(), "interval", assignment.Interval)
+	for !solver.Done() {
+		stats := solver.Step()
+		done := solver.Done()
+		if (stats.Iteration+1)%max(assignment.Interval, 1) != 0 && !done {
+			continue
+		}
+		solution := solver.Solution()
+		migrant := Migrant{Island: assignment.Island, Iteration: stats.Iteration + 1, Tour: solution.Tour, Length: solution.Length, Done: done}
+		reply, err := grpcUnary(client, address, grpcMigratePath, migrant.MarshalProto())
+		if err != nil {
+			return nil, fmt.Errorf("migrating: %w", err)
+		}
+		var immigrant Migrant
+		if err := immigrant.UnmarshalProto(reply); err != nil {
+			return nil, fmt.Errorf("migrating: %w", err)
+		}
+		if len(immigrant.Tour) > 0 && !done {
+			taken, err := solver.Immigrate(immigrant.Tour)
+			if err != nil {
+				return nil, fmt.Errorf("tour from island %d: %w", immigrant.Island, err)
+			}
+			if taken {
+				slog.Debug("took tour", "from", immigrant.Island, "iteration", stats.Iteration+1, "length", immigrant.Length)
+			}
+		}
+	}
+	return solver.Solution(), nil
+}
+
+// islandsCommand implements "islands", which runs independent colonies on
+// several machines that exchange their best tours: one coordinator holding the
+// instance, and islands joining it
+func islandsCommand(args []string) error {
+	flags := flag.NewFlagSet("islands", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	logging := logFlags(flags)
+	auto := flags.Bool("auto", true, "without options in the instance, start from parameters recommended for its size and variant")
+	listen := flags.String("listen", "", "coordinate the islands solving file, serving them at this `address`, such as :9000")
+	join := flags.String("join", "", "run an island of the coordinator at this `address`, such as coordinator:9000")
+	islands := flags.Int("islands", 4, "with -listen, number of islands to wait for")
+	interval := flags.Int("migrate", 25, "with -listen, number of `iterations` between exchanges of best tours")
+	topology := flags.String("topology", TopologyRing, "with -listen, islands whose tours each receives: ring (the one before it) or full (the best of all)")
+	resultFormat := flags.String("format", "json", "print the result on stdout as json, csv or tour (space-separated cities)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s islands -listen address [-islands n] [-migrate n] [-topology ring|full] [flags] file\n       %s islands -join address [flags]\n\n", os.Args[0], os.Args[0])
+		fmt.Fprintln(flags.Output(), "The coordinator hands the instance to islands as they join, over the aco.v1.Islands gRPC service")
+		fmt.Fprintln(flags.Output(), "of proto/aco.proto, and relays their best tours every -migrate iterations. When all are done it")
+		fmt.Fprintln(flags.Output(), "prints the shortest tour. Parameter flags given to an island override the coordinator's.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if (*listen == "") == (*join == "") {
+		flags.Usage()
+		return fmt.Errorf("islands: want one of -listen and -join")
+	}
+	if (*listen != "") != (flags.NArg() == 1) || flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("islands: want exactly one instance file with -listen, and none with -join")
+	}
+	if err := checkResultFormat(*resultFormat); err != nil {
+		return err
+	}
+	if _, err := logging(); err != nil {
+		return err
+	}
+
+	if *join != "" {
+		solution, err := RunIsland(*join, parameters)
+		if err != nil {
+			return err
+		}
+		slog.Info("island done", "length", solution.Length)
+		return nil
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	config, err := instanceConfig(instance, parameters, *auto)
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	coordinator, err := NewIslandCoordinator(instance, config, *islands, *interval, *topology)
+	if err != nil {
+		return err
+	}
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	server := &http.Server{Addr: *listen, Handler: coordinator.Handler(), Protocols: &protocols, ReadHeaderTimeout: 10 * time.Second}
+	failed := make(chan error, 1)
+	go func() { failed <- server.ListenAndServe() }()
+	slog.Info("coordinating", "address", *listen, "islands", *islands, "topology", *topology, "cities", len(instance.Cities))
+	ctx, cancel := context.WithCancelCause(context.Background())
+	go func() {
+		if err := <-failed; err != http.ErrServerClosed {
+			cancel(err)
+		}
+	}()
+	solution, island, err := coordinator.Wait(ctx)
+	if err != nil {
+		return context.Cause(ctx)
+	}
+	// Let the last islands read the replies to their final migrations
+	shutdown, done := context.WithTimeout(context.Background(), 10*time.Second)
+	defer done()
+	server.Shutdown(shutdown)
+	slog.Info("islands done", "best island", island, "length", solution.Length)
+	return NewResult(instance.Cities, solution).Write(os.Stdout, *resultFormat)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Label returns the city's name, or its index if it has none
+func (c *City) Label(index int) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return strconv.Itoa(index)
+}
+
+// hasNames reports whether any of the cities is named
+func hasNames(cities []*City) bool {
+	for _, c := range cities {
+		if c.Name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMetadata reports whether any of the cities carries metadata
+func hasMetadata(cities []*City) bool {
+	for _, c := range cities {
+		if c.Metadata != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// tourMetadata lists the metadata of the cities of tour in visiting order,
+// null for cities without any, or returns nil if no city has metadata
+func tourMetadata(cities []*City, tour []int) []json.RawMessage {
+	if !hasMetadata(cities) {
+		return nil
+	}
+	metadata := make([]json.RawMessage, len(tour))
+	for i, city := range tour {
+		metadata[i] = cities[city].Metadata
+	}
+	return metadata
+}
+
+// FormatTour joins the labels of the cities of tour with arrows
+func FormatTour(cities []*City, tour []int) string {
+	labels := make([]string, len(tour))
+	for i, city := range tour {
+		labels[i] = cities[city].Label(city)
+	}
+	return strings.Join(labels, " → ")
+}
+
+// WriteItinerary prints one "From → To: distance" line per leg of tour,
+// including the leg back to the start of a closed tour
+func WriteItinerary(w io.Writer, cities []*City, distance func(i, j int) float64, tour []int, closed bool) error {
+	legs := len(tour) - 1
+	if closed && len(tour) > 1 {
+		legs = len(tour)
+	}
+	for i := 0; i < legs; i++ {
+		from, to := tour[i], tour[(i+1)%len(tour)]
+		if _, err := fmt.Fprintf(w, "%s → %s: %.1f\n", cities[from].Label(from), cities[to].Label(to), distance(from, to)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
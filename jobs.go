@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JobStore keeps the instances and runs of an API server in a directory, so
+// a restarted server still answers for finished runs and takes up the
+// queued and interrupted ones again. Instances are stored as aco.v1.Instance
+// messages in instances/<id>.pb, runs as JSON in runs/<id>.json, each
+// replaced whole on every change so a crash leaves the previous version
+type JobStore struct {
+	Dir string
+}
+
+// jobRecord is a run as the store keeps it
+type jobRecord struct {
+	ID         string    `json:"id"`
+	Instance   string    `json:"instance"`
+	Config     Config    `json:"config"`
+	State      string    `json:"state"`
+	Iteration  int       `json:"iteration"`
+	StartedAt  time.Time `json:"started_at,omitzero"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+	Solution   *Solution `json:"solution,omitempty"`
+}
+
+// OpenJobStore opens the store in dir, creating it if needed
+func OpenJobStore(dir string) (*JobStore, error) {
+	for _, sub := range []string{"instances", "runs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &JobStore{Dir: dir}, nil
+}
+
+// SaveInstance stores an uploaded instance
+func (js *JobStore) SaveInstance(id string, instance *Instance) error {
+	return js.replace(filepath.Join("instances", id+".pb"), instance.MarshalProto())
+}
+
+// SaveRun stores the state of a run
+func (js *JobStore) SaveRun(record *jobRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return js.replace(filepath.Join("runs", record.ID+".json"), data)
+}
+
+// replace writes data to name in the store through a temporary file, so
+// readers never see it half written
+func (js *JobStore) replace(name string, data []byte) error {
+	path := filepath.Join(js.Dir, name)
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Load reads the stored instances and runs
+func (js *JobStore) Load() (map[string]*Instance, []*jobRecord, error) {
+	instances := make(map[string]*Instance)
+	paths, err := filepath.Glob(filepath.Join(js.Dir, "instances", "*.pb"))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		instance := &Instance{}
+		if err := instance.UnmarshalProto(data); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		instances[strings.TrimSuffix(filepath.Base(path), ".pb")] = instance
+	}
+	var runs []*jobRecord
+	if paths, err = filepath.Glob(filepath.Join(js.Dir, "runs", "*.json")); err != nil {
+		return nil, nil, err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		record := &jobRecord{}
+		if err := json.Unmarshal(data, record); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		runs = append(runs, record)
+	}
+	sort.Slice(runs, func(a, b int) bool { return idLess(runs[a].ID, runs[b].ID) })
+	return instances, runs, nil
+}
+
+// record returns the run as the store keeps it
+func (run *apiRun) record() *jobRecord {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	return &jobRecord{
+		ID:         run.id,
+		Instance:   run.instance,
+		Config:     run.config,
+		State:      run.state,
+		Iteration:  run.iteration,
+		StartedAt:  run.startedAt,
+		FinishedAt: run.finishedAt,
+		Solution:   run.solution,
+	}
+}
+
+// Restore takes up the instances and runs of the server's store. Finished
+// runs are answered for as they ended; queued ones are queued again, and
+// those interrupted while running start over, with the same seed
+func (s *APIServer) Restore() error {
+	if s.Store == nil {
+		return errors.New("no job store to restore from")
+	}
+	instances, records, err := s.Store.Load()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	restarted, err := s.restore(instances, records)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for run, solver := range restarted {
+		go s.solve(run, solver)
+	}
+	return nil
+}
+
+// restore adds the stored instances and runs to the server and returns the
+// runs to solve again with their solvers; s.mu must be held
+func (s *APIServer) restore(instances map[string]*Instance, records []*jobRecord) (map[*apiRun]*Solver, error) {
+	for id, instance := range instances {
+		s.instances[id] = instance
+		s.lastID = max(s.lastID, idNumber(id))
+	}
+	restarted := make(map[*apiRun]*Solver)
+	for _, record := range records {
+		s.lastID = max(s.lastID, idNumber(record.ID))
+		run := &apiRun{id: record.ID, instance: record.Instance, config: record.Config,
+			state: record.State, iteration: record.Iteration, best: math.Inf(1),
+			startedAt: record.StartedAt, finishedAt: record.FinishedAt, solution: record.Solution}
+		run.ctx, run.cancel = context.WithCancel(context.Background())
+		if record.Solution != nil {
+			run.best = record.Solution.Length
+		}
+		s.runs[run.id] = run
+		if run.state != RunQueued && run.state != RunRunning {
+			continue
+		}
+		instance, ok := s.instances[record.Instance]
+		if !ok {
+			return nil, fmt.Errorf("run %s: no instance %q", record.ID, record.Instance)
+		}
+		solver, err := NewSolver(instance, record.Config)
+		if err != nil {
+			return nil, fmt.Errorf("run %s: %w", record.ID, err)
+		}
+		solver.Events, solver.EventRun = s.Events, "api/"+run.id
+		run.state, run.iteration, run.startedAt, run.solver = RunQueued, 0, time.Time{}, solver
+		restarted[run] = solver
+	}
+	return restarted, nil
+}
+
+// idNumber returns the number of an identifier handed out by newID, 0 for others
+func idNumber(id string) int {
+	n, _ := strconv.Atoi(id)
+	return n
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Operation is one processing step of a job on a machine
+type Operation struct {
+	Machine  int
+	Duration float64
+}
+
+// JobShop is a job-shop scheduling instance: every job is a sequence of
+// operations that must run in order, each machine processes one operation at
+// a time, and the objective is the makespan
+type JobShop struct {
+	Jobs        [][]Operation
+	NumMachines int
+
+	// offsets[j] is the global index of job j's first operation, opJob maps a
+	// global operation index back to its job
+	offsets []int
+	opJob   []int
+	numOps  int
+}
+
+// NewJobShop builds a job-shop instance from per-job operation lists
+func NewJobShop(jobs [][]Operation) (*JobShop, error) {
+	js := &JobShop{Jobs: jobs, offsets: make([]int, len(jobs))}
+	for j, ops := range jobs {
+		js.offsets[j] = js.numOps
+		js.numOps += len(ops)
+		for k, op := range ops {
+			js.opJob = append(js.opJob, j)
+			if op.Machine < 0 {
+				return nil, fmt.Errorf("job %d operation %d: negative machine %d", j, k, op.Machine)
+			}
+			if op.Duration < 0 {
+				return nil, fmt.Errorf("job %d operation %d: negative duration %g", j, k, op.Duration)
+			}
+			if op.Machine >= js.NumMachines {
+				js.NumMachines = op.Machine + 1
+			}
+		}
+	}
+	return js, nil
+}
+
+// NewFlowShop builds a flow-shop instance where every job visits machines
+// 0..m-1 in order, processingTimes[j][m] being job j's time on machine m
+func NewFlowShop(processingTimes [][]float64) (*JobShop, error) {
+	jobs := make([][]Operation, len(processingTimes))
+	for j, times := range processingTimes {
+		jobs[j] = make([]Operation, len(times))
+		for m, d := range times {
+			jobs[j][m] = Operation{Machine: m, Duration: d}
+		}
+	}
+	return NewJobShop(jobs)
+}
+
+// ParseJobShop reads an instance in the OR-Library format: a "jobs machines"
+// header followed by one line per job of "machine duration" pairs
+func ParseJobShop(r io.Reader) (*JobShop, error) {
+	var jobs [][]Operation
+	err := scanShop(r, func(line, numMachines int, values []int) error {
+		if len(values) != 2*numMachines {
+			return fmt.Errorf("line %d: got %d values, want %d machine/duration pairs", line, len(values), numMachines)
+		}
+		ops := make([]Operation, numMachines)
+		for k := range ops {
+			ops[k] = Operation{Machine: values[2*k], Duration: float64(values[2*k+1])}
+		}
+		jobs = append(jobs, ops)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewJobShop(jobs)
+}
+
+// ParseFlowShop reads a flow-shop instance: a "jobs machines" header followed
+// by one line per job of its processing times on machines 0..m-1
+func ParseFlowShop(r io.Reader) (*JobShop, error) {
+	var times [][]float64
+	err := scanShop(r, func(line, numMachines int, values []int) error {
+		if len(values) != numMachines {
+			return fmt.Errorf("line %d: got %d processing times, want %d", line, len(values), numMachines)
+		}
+		job := make([]float64, numMachines)
+		for m, v := range values {
+			job[m] = float64(v)
+		}
+		times = append(times, job)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewFlowShop(times)
+}
+
+// scanShop reads the integer lines of a shop instance, skipping blank lines
+// and # comments: the "jobs machines" header, then one line per job, which it
+// passes to job with the number of machines the header declares
+func scanShop(r io.Reader, job func(line, numMachines int, values []int) error) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	numJobs, numMachines, seen := -1, -1, 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		values := make([]int, len(fields))
+		for i, f := range fields {
+			v, err := strconv.Atoi(f)
+			if err != nil {
+				return fmt.Errorf("line %d: %q is not an integer", line, f)
+			}
+			values[i] = v
+		}
+		if numJobs < 0 {
+			if len(values) != 2 || values[0] < 0 || values[1] < 0 {
+				return fmt.Errorf("line %d: header must be \"jobs machines\"", line)
+			}
+			numJobs, numMachines = values[0], values[1]
+			continue
+		}
+		if err := job(line, numMachines, values); err != nil {
+			return err
+		}
+		seen++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if numJobs < 0 {
+		return fmt.Errorf("missing \"jobs machines\" header")
+	}
+	if seen != numJobs {
+		return fmt.Errorf("header declares %d jobs, found %d", numJobs, seen)
+	}
+	return nil
+}
+
+// Schedule decodes an operation sequence into start times (indexed by global
+// operation index) and the makespan, placing each operation as early as its job
+// and machine allow
+func (js *JobShop) Schedule(sequence []int) (starts []float64, makespan float64) {
+	starts = make([]float64, js.numOps)
+	jobReady := make([]float64, len(js.Jobs))
+	machineReady := make([]float64, js.NumMachines)
+	for _, id := range sequence {
+		job, k := js.operation(id)
+		op := js.Jobs[job][k]
+		start := math.Max(jobReady[job], machineReady[op.Machine])
+		starts[id] = start
+		jobReady[job] = start + op.Duration
+		machineReady[op.Machine] = start + op.Duration
+		makespan = math.Max(makespan, start+op.Duration)
+	}
+	return starts, makespan
+}
+
+// operation maps a global operation index to its job and position within the job
+func (js *JobShop) operation(id int) (job, k int) {
+	job = js.opJob[id]
+	return job, id - js.offsets[job]
+}
+
+// PheromoneShape places pheromone on (previous operation, next operation)
+// pairs, row 0 standing for the start of the sequence
+func (js *JobShop) PheromoneShape() (rows, cols int) {
+	return js.numOps + 1, js.numOps
+}
+
+// NewConstruction starts an empty operation sequence
+func (js *JobShop) NewConstruction() Construction {
+	return &jobShopConstruction{
+		shop:         js,
+		next:         make([]int, len(js.Jobs)),
+		jobReady:     make([]float64, len(js.Jobs)),
+		machineReady: make([]float64, js.NumMachines),
+	}
+}
+
+// jobShopConstruction appends one schedulable operation at a time: the next
+// unscheduled operation of some job, which keeps job precedence satisfied
+type jobShopConstruction struct {
+	shop         *JobShop
+	sequence     []int
+	next         []int
+	jobReady     []float64
+	machineReady []float64
+	makespan     float64
+}
+
+func (c *jobShopConstruction) Moves() []Move {
+	row := 0
+	if len(c.sequence) > 0 {
+		row = c.sequence[len(c.sequence)-1] + 1
+	}
+	// Giffler and Thompson: of the operations that could finish first, only
+	// those on its machine that start before it finishes are candidates, which
+	// keeps the schedule active
+	firstFinish, firstMachine := math.Inf(1), -1
+	for job, k := range c.next {
+		if k == len(c.shop.Jobs[job]) {
+			continue
+		}
+		op := c.shop.Jobs[job][k]
+		if finish := math.Max(c.jobReady[job], c.machineReady[op.Machine]) + op.Duration; finish < firstFinish {
+			firstFinish, firstMachine = finish, op.Machine
+		}
+	}
+	var moves []Move
+	for job, k := range c.next {
+		if k == len(c.shop.Jobs[job]) {
+			continue
+		}
+		op := c.shop.Jobs[job][k]
+		start := math.Max(c.jobReady[job], c.machineReady[op.Machine])
+		if op.Machine != firstMachine || start >= firstFinish {
+			continue
+		}
+		// Earliest completion time: operations that can finish soon are preferred
+		moves = append(moves, Move{Row: row, Col: c.shop.offsets[job] + k, Heuristic: 1 / (1 + start + op.Duration)})
+	}
+	return moves
+}
+
+func (c *jobShopConstruction) Apply(m Move) {
+	job, k := c.shop.operation(m.Col)
+	op := c.shop.Jobs[job][k]
+	finish := math.Max(c.jobReady[job], c.machineReady[op.Machine]) + op.Duration
+	c.jobReady[job] = finish
+	c.machineReady[op.Machine] = finish
+	c.makespan = math.Max(c.makespan, finish)
+	c.next[job]++
+	c.sequence = append(c.sequence, m.Col)
+}
+
+func (c *jobShopConstruction) Cost() float64 {
+	return c.makespan
+}
+
+func (c *jobShopConstruction) Solution() []int {
+	return append([]int(nil), c.sequence...)
+}
+
+// JobShopBenchmark is a bundled scheduling instance with its known optimal makespan
+type JobShopBenchmark struct {
+	Data    string
+	Optimum float64
+}
+
+// JobShopBenchmarks holds standard instances from the OR-Library for validation
+var JobShopBenchmarks = map[string]JobShopBenchmark{
+	// Fisher and Thompson 6x6
+	"ft06": {Optimum: 55, Data: `6 6
+2 1 0 3 1 6 3 7 5 3 4 6
+1 8 2 5 4 10 5 10 0 10 3 4
+2 5 3 4 5 8 0 9 1 1 4 7
+1 5 0 5 2 5 3 3 4 8 5 9
+2 9 1 3 4 5 5 4 0 3 3 1
+1 3 3 3 5 9 0 10 4 4 2 1
+`},
+	// Lawrence 10x5
+	"la01": {Optimum: 666, Data: `10 5
+1 21 0 53 4 95 3 55 2 34
+0 21 3 52 4 16 2 26 1 71
+3 39 4 98 1 42 2 31 0 12
+1 77 0 55 4 79 2 66 3 77
+0 83 3 34 2 64 1 19 4 37
+1 54 2 43 4 79 0 92 3 62
+3 69 4 77 1 87 2 87 0 93
+2 38 0 60 1 41 3 24 4 83
+3 17 1 49 4 25 0 44 2 98
+4 77 3 79 2 43 1 75 0 96
+`},
+}
+
+// jobShopCommand schedules a job-shop or flow-shop instance, from a file or
+// one of JobShopBenchmarks
+func jobShopCommand(args []string) error {
+	flags := flag.NewFlagSet("jobshop", flag.ExitOnError)
+	flow := flags.Bool("flow", false, "read the file as a flow shop: a \"jobs machines\" header and one line of processing times per job")
+	parameters := parameterFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s jobshop [flags] instance|benchmark\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Schedules the operations of an OR-Library job-shop file, a -flow flow-shop file or one of")
+		fmt.Fprintf(flags.Output(), "the benchmarks %s, minimizing the makespan, and prints the schedule\n", strings.Join(slices.Sorted(maps.Keys(JobShopBenchmarks)), ", "))
+		fmt.Fprintln(flags.Output(), "per machine, with the gap to the optimum for benchmarks. Of the parameters only -ants,")
+		fmt.Fprintln(flags.Output(), "-alpha, -beta, -rho, -q, -iterations and -seed apply.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("jobshop: want exactly one instance file or benchmark")
+	}
+	config, err := parameters(DefaultConfig())
+	if err != nil {
+		return err
+	}
+	var js *JobShop
+	benchmark, isBenchmark := JobShopBenchmarks[flags.Arg(0)]
+	if isBenchmark {
+		js, err = ParseJobShop(strings.NewReader(benchmark.Data))
+	} else {
+		js, err = readShop(flags.Arg(0), *flow)
+	}
+	if err != nil {
+		return err
+	}
+	sequence, makespan, err := solveProblem(js, config)
+	if err != nil {
+		return err
+	}
+	if sequence == nil {
+		return fmt.Errorf("jobshop: no schedule found")
+	}
+	starts, _ := js.Schedule(sequence)
+	order := make([]int, len(sequence))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		ja, ka := js.operation(a)
+		jb, kb := js.operation(b)
+		return cmp.Or(cmp.Compare(js.Jobs[ja][ka].Machine, js.Jobs[jb][kb].Machine), cmp.Compare(starts[a], starts[b]))
+	})
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MACHINE\tJOB\tOPERATION\tSTART\tEND")
+	for _, id := range order {
+		job, k := js.operation(id)
+		op := js.Jobs[job][k]
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%g\t%g\n", op.Machine, job, k, starts[id], starts[id]+op.Duration)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Println("Best makespan:", makespan)
+	if isBenchmark {
+		fmt.Printf("Optimum: %g (gap %.2f%%)\n", benchmark.Optimum, 100*(makespan-benchmark.Optimum)/benchmark.Optimum)
+	}
+	return nil
+}
+
+// readShop reads a job-shop file, or a flow-shop file if flow is set
+func readShop(path string, flow bool) (*JobShop, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	parse := ParseJobShop
+	if flow {
+		parse = ParseFlowShop
+	}
+	js, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return js, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// jobShopGap is how far above the optimum TestJobShopBenchmarks lets a
+// schedule end
+const jobShopGap = 0.15
+
+// TestJobShopBenchmarks checks that seeded colonies schedule every benchmark
+// feasibly and within jobShopGap of its optimal makespan
+func TestJobShopBenchmarks(t *testing.T) {
+	for name, benchmark := range JobShopBenchmarks {
+		t.Run(name, func(t *testing.T) {
+			js, err := ParseJobShop(strings.NewReader(benchmark.Data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for seed := int64(1); seed <= 3; seed++ {
+				config := DefaultConfig()
+				config.NumAnts, config.Iterations, config.Rho, config.Seed = 20, 100, 0.1, seed
+				sequence, makespan, err := solveProblem(js, config)
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkSchedule(t, js, sequence, makespan)
+				if makespan < benchmark.Optimum || makespan > benchmark.Optimum*(1+jobShopGap) {
+					t.Errorf("seed %d: makespan %g, want within %g%% above the optimum %g", seed, makespan, 100*jobShopGap, benchmark.Optimum)
+				}
+			}
+		})
+	}
+}
+
+// TestFlowShop checks that a flow-shop file is read with every job visiting
+// the machines in order, and scheduled feasibly
+func TestFlowShop(t *testing.T) {
+	js, err := ParseFlowShop(strings.NewReader("# jobs machines\n4 3\n3 2 4\n1 4 2\n2 2 3\n5 1 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j, ops := range js.Jobs {
+		for m, op := range ops {
+			if op.Machine != m {
+				t.Fatalf("job %d operation %d on machine %d", j, m, op.Machine)
+			}
+		}
+	}
+	config := DefaultConfig()
+	config.Seed = 1
+	sequence, makespan, err := solveProblem(js, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSchedule(t, js, sequence, makespan)
+
+	for _, input := range []string{"", "2 2\n1 2\n", "1 2\n1 2 3\n", "1 x\n"} {
+		if _, err := ParseFlowShop(strings.NewReader(input)); err == nil {
+			t.Errorf("%q: no error", input)
+		}
+	}
+}
+
+// checkSchedule fails t unless sequence holds every operation of js once,
+// and the schedule it decodes to runs each job's operations in order, never
+// overlaps two operations on a machine and ends at makespan
+func checkSchedule(t *testing.T, js *JobShop, sequence []int, makespan float64) {
+	t.Helper()
+	if err := ValidateTour(sequence, js.numOps, nil); err != nil {
+		t.Fatalf("sequence %v: %v", sequence, err)
+	}
+	starts, _ := js.Schedule(sequence)
+	type interval struct{ start, end float64 }
+	machines := make([][]interval, js.NumMachines)
+	end := 0.0
+	for j, ops := range js.Jobs {
+		ready := 0.0
+		for k, op := range ops {
+			start := starts[js.offsets[j]+k]
+			if start < ready {
+				t.Errorf("job %d operation %d starts at %g, before its predecessor ends at %g", j, k, start, ready)
+			}
+			ready = start + op.Duration
+			end = math.Max(end, ready)
+			machines[op.Machine] = append(machines[op.Machine], interval{start, ready})
+		}
+	}
+	for m, intervals := range machines {
+		sort.Slice(intervals, func(a, b int) bool { return intervals[a].start < intervals[b].start })
+		for k := 1; k < len(intervals); k++ {
+			if intervals[k].start < intervals[k-1].end {
+				t.Errorf("machine %d runs %v and %v at once", m, intervals[k-1], intervals[k])
+			}
+		}
+	}
+	if end != makespan {
+		t.Errorf("schedule ends at %g, reported makespan %g", end, makespan)
+	}
+}
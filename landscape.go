@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// Fitness-distance correlations beyond which Jones and Forrest (1995) call a
+// landscape straightforward for search, and below whose negative it misleads
+const fdcThreshold = 0.15
+
+// LandscapeAnalysis describes how hard an instance is for search, from tours
+// sampled on it and a reference tour, as short as is known
+type LandscapeAnalysis struct {
+	Samples int `json:"samples"`
+	// LocalOptima tells whether the samples are random tours improved by
+	// 2-opt rather than random tours
+	LocalOptima     bool    `json:"local_optima"`
+	ReferenceLength float64 `json:"reference_length"`
+	// MeanGap is the mean excess of the samples' lengths over the reference,
+	// in percent
+	MeanGap float64 `json:"mean_gap"`
+	// MeanDistance is the mean bond distance of the samples to the reference,
+	// the share of its edges they lack, and PairDistance the mean between
+	// two samples
+	MeanDistance float64 `json:"mean_distance"`
+	PairDistance float64 `json:"pair_distance"`
+	// FDC is the fitness-distance correlation, between the samples' lengths
+	// and their distances to the reference: near 1, shorter tours share more
+	// of its edges and guide search towards it
+	FDC float64 `json:"fdc"`
+	// Autocorrelation is the correlation between the lengths of tours one
+	// random 2-opt move apart along a random walk, and CorrelationLength
+	// -1/ln|Autocorrelation|, the number of moves over which lengths stay
+	// related, or 0 when undefined; the longer, the smoother the landscape
+	Autocorrelation   float64 `json:"autocorrelation"`
+	CorrelationLength float64 `json:"correlation_length"`
+	WalkLength        int     `json:"walk_length"`
+	// Difficulty classifies the FDC as straightforward, difficult or misleading
+	Difficulty string `json:"difficulty"`
+}
+
+// AnalyzeLandscape samples random tours of the colony's instance, improved
+// by 2-opt if localOptima, and measures them against reference; a sample
+// shorter than the reference replaces it. The autocorrelation comes from a
+// random walk of walk 2-opt moves. Constraints are not considered
+func AnalyzeLandscape(ac *AntColony, reference []int, samples, walk int, localOptima bool, rng *rand.Rand) *LandscapeAnalysis {
+	n := len(ac.Cities)
+	undirected := ac.symmetric()
+	tours := make([][]int, samples)
+	lengths := make([]float64, samples)
+	for k := range tours {
+		tours[k] = rng.Perm(n)
+		if localOptima {
+			tours[k] = ac.TwoOpt(tours[k])
+		}
+		lengths[k] = ac.TourLength(tours[k])
+	}
+	analysis := &LandscapeAnalysis{Samples: samples, LocalOptima: localOptima, WalkLength: walk}
+	analysis.ReferenceLength = ac.TourLength(reference)
+	for k, length := range lengths {
+		if length < analysis.ReferenceLength {
+			reference, analysis.ReferenceLength = tours[k], length
+		}
+	}
+
+	distances := make([]float64, samples)
+	for k, tour := range tours {
+		distances[k] = ac.bondDistance(tour, reference, undirected)
+		analysis.MeanGap += Gap(lengths[k], analysis.ReferenceLength) / float64(samples)
+	}
+	analysis.MeanDistance, _ = meanStddev(distances)
+	pairs := 0
+	for a := range tours {
+		for b := a + 1; b < len(tours); b++ {
+			analysis.PairDistance += ac.bondDistance(tours[a], tours[b], undirected)
+			pairs++
+		}
+	}
+	if pairs > 0 {
+		analysis.PairDistance /= float64(pairs)
+	}
+	analysis.FDC = correlation(lengths, distances)
+	switch {
+	case analysis.FDC >= fdcThreshold:
+		analysis.Difficulty = "straightforward"
+	case analysis.FDC > -fdcThreshold:
+		analysis.Difficulty = "difficult"
+	default:
+		analysis.Difficulty = "misleading"
+	}
+
+	// Walk by reversing random segments, recording the length after each move
+	tour := rng.Perm(n)
+	steps := make([]float64, walk+1)
+	steps[0] = ac.TourLength(tour)
+	for t := 1; t <= walk; t++ {
+		i, j := rng.Intn(n), rng.Intn(n)
+		if i > j {
+			i, j = j, i
+		}
+		for ; i < j; i, j = i+1, j-1 {
+			tour[i], tour[j] = tour[j], tour[i]
+		}
+		steps[t] = ac.TourLength(tour)
+	}
+	if walk > 0 {
+		analysis.Autocorrelation = correlation(steps[:walk], steps[1:])
+		if r := math.Abs(analysis.Autocorrelation); r > 0 && r < 1 {
+			analysis.CorrelationLength = -1 / math.Log(r)
+		}
+	}
+	return analysis
+}
+
+// bondDistance returns the share of the edges of tour b that tour a does not
+// take, 0 for the same tour and 1 for tours without a common edge
+func (ac *AntColony) bondDistance(a, b []int, undirected bool) float64 {
+	edge := func(i, j int) [2]int {
+		if undirected && j < i {
+			i, j = j, i
+		}
+		return [2]int{i, j}
+	}
+	edges := make(map[[2]int]bool, len(b))
+	ac.forEachEdge(b, func(i, j int) { edges[edge(i, j)] = true })
+	if len(edges) == 0 {
+		return 0
+	}
+	shared := 0
+	ac.forEachEdge(a, func(i, j int) {
+		if edges[edge(i, j)] {
+			shared++
+		}
+	})
+	return 1 - float64(shared)/float64(len(edges))
+}
+
+// correlation returns the Pearson correlation of xs and ys, 0 if either is constant
+func correlation(xs, ys []float64) float64 {
+	mx, sx := meanStddev(xs)
+	my, sy := meanStddev(ys)
+	if sx == 0 || sy == 0 || len(xs) < 2 {
+		return 0
+	}
+	covariance := 0.0
+	for k := range xs {
+		covariance += (xs[k] - mx) * (ys[k] - my)
+	}
+	return covariance / float64(len(xs)-1) / (sx * sy)
+}
+
+// landscapeCommand implements "landscape", which reports fitness-distance
+// correlation and autocorrelation of an instance
+func landscapeCommand(args []string) error {
+	flags := flag.NewFlagSet("landscape", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	samples := flags.Int("samples", 100, "number of tours to sample")
+	walk := flags.Int("walk", 1000, "number of random 2-opt moves to measure autocorrelation over")
+	local := flags.Bool("local", true, "improve the sampled tours by 2-opt, to analyse the local optima search meets")
+	tourPath := flags.String("tour", "", "measure against this best-known tour `file` instead of the colony's best tour")
+	format := flags.String("format", "text", "print the analysis as text or json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s landscape [flags] file\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Samples tours of the instance and compares their lengths with their bond distances to a")
+		fmt.Fprintln(flags.Output(), "best-known tour, found by running the colony with the parameters given unless -tour is given.")
+		fmt.Fprintln(flags.Output(), "Positive fitness-distance correlation means shorter tours look more like the best one, which")
+		fmt.Fprintln(flags.Output(), "guides search; a long correlation length means a smooth landscape.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("landscape: want exactly one instance file")
+	}
+	if *samples < 2 || *walk < 0 {
+		return errors.New("landscape: want at least two -samples and a -walk that is not negative")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("landscape: unknown format %q, want text or json", *format)
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	if instance.Constraints != nil && !instance.Constraints.empty() {
+		return errors.New("landscape: instances with constraints are not supported")
+	}
+	config, err := instanceConfig(instance, parameters, true)
+	if err != nil {
+		return err
+	}
+	solver, err := NewSolver(instance, config)
+	if err != nil {
+		return err
+	}
+	var reference []int
+	if *tourPath != "" {
+		if reference, err = loadTourFile(*tourPath); err == nil {
+			err = instance.ValidateTour(reference)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", *tourPath, err)
+		}
+	} else {
+		reference = solver.Run().Tour
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	analysis := AnalyzeLandscape(solver.Colony, reference, *samples, *walk, *local, rand.New(rand.NewSource(seed)))
+	if *format == "json" {
+		return writeJSON("-", analysis)
+	}
+
+	kind := "random tours"
+	if analysis.LocalOptima {
+		kind = "2-opt local optima"
+	}
+	fmt.Printf("%s: %d cities, %d %s against a reference tour of length %.6g\n",
+		instanceName(instance, flags.Arg(0)), len(instance.Cities), analysis.Samples, kind, analysis.ReferenceLength)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "mean gap\t%.2f%%\n", analysis.MeanGap)
+	fmt.Fprintf(tw, "mean distance to reference\t%.3f\n", analysis.MeanDistance)
+	fmt.Fprintf(tw, "mean distance between samples\t%.3f\n", analysis.PairDistance)
+	fmt.Fprintf(tw, "fitness-distance correlation\t%.3f (%s)\n", analysis.FDC, analysis.Difficulty)
+	fmt.Fprintf(tw, "autocorrelation\t%.3f (correlation length %.3g over %d moves)\n",
+		analysis.Autocorrelation, analysis.CorrelationLength, analysis.WalkLength)
+	return tw.Flush()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"slices"
+	"sync"
+)
+
+// LengthCacheStats counts the lookups of a colony's tour length cache
+type LengthCacheStats struct {
+	Hits    int `json:"hits"`
+	Misses  int `json:"misses"`
+	Entries int `json:"entries"`
+	// HitRate is the share of lookups that found the length cached
+	HitRate float64 `json:"hit_rate"`
+}
+
+// cachedLength is the length of a tour, kept with the tour in canonical form
+// to tell it from another of the same hash
+type cachedLength struct {
+	tour   []int
+	length float64
+}
+
+// lengthCache memoizes the lengths of up to size tours, keyed by the hash of
+// their canonical form, so that a tour read from another city or in the
+// other direction hits too. The oldest entry makes way for a new one. It
+// only pays off where lengths cost more than reading a tour, as with
+// time-dependent costs, or where the same tours come back often
+type lengthCache struct {
+	mu      sync.Mutex
+	entries map[uint64]cachedLength
+	// order holds the keys in the order they came in, next the slot of the oldest
+	order        []uint64
+	next         int
+	hits, misses int
+}
+
+// newLengthCache returns a cache of up to size tours
+func newLengthCache(size int) *lengthCache {
+	return &lengthCache{entries: make(map[uint64]cachedLength, size), order: make([]uint64, 0, size)}
+}
+
+// length returns the length of tour, computing it only on a miss
+func (c *lengthCache) length(ac *AntColony, tour []int) float64 {
+	canonical := ac.CanonicalTour(tour)
+	key := hashTour(canonical)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && slices.Equal(entry.tour, canonical) {
+		c.hits++
+		c.mu.Unlock()
+		return entry.length
+	}
+	c.misses++
+	c.mu.Unlock()
+	// Measured from the canonical form, a tour has one length however read
+	length := ac.tourLength(canonical)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, taken := c.entries[key]; !taken {
+		if len(c.order) < cap(c.order) {
+			c.order = append(c.order, key)
+		} else {
+			delete(c.entries, c.order[c.next])
+			c.order[c.next] = key
+			c.next = (c.next + 1) % len(c.order)
+		}
+	}
+	c.entries[key] = cachedLength{tour: canonical, length: length}
+	return length
+}
+
+// reset empties the cache, whose lengths no longer hold once the costs change
+func (c *lengthCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.order, c.next = c.order[:0], 0
+}
+
+// hashTour returns the FNV-1a hash of a tour's cities
+func hashTour(tour []int) uint64 {
+	const prime = 1099511628211
+	hash := uint64(14695981039346656037)
+	for _, city := range tour {
+		for v := uint64(city); ; v >>= 8 {
+			hash ^= v & 0xff
+			hash *= prime
+			if v < 0x100 {
+				break
+			}
+		}
+		// Separate the cities, so that their bytes cannot run together
+		hash ^= 0xff
+		hash *= prime
+	}
+	return hash
+}
+
+// LengthCacheStats returns the lookups of the colony's tour length cache so
+// far, and false if it has none
+func (ac *AntColony) LengthCacheStats() (LengthCacheStats, bool) {
+	if ac.lengths == nil {
+		return LengthCacheStats{}, false
+	}
+	c := ac.lengths
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := LengthCacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries)}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		stats.HitRate = float64(c.hits) / float64(lookups)
+	}
+	return stats, true
+}
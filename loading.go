@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// LoadReading is the stage of loading an instance as LoadProgress reports it
+// while the input is read
+const LoadReading = "reading"
+
+// LoadProgress is how far loading an instance has come: Done of Total bytes
+// read while reading. Total is zero where it is unknown, as for standard input
+type LoadProgress struct {
+	Stage string `json:"stage"`
+	Done  int64  `json:"done"`
+	Total int64  `json:"total,omitempty"`
+}
+
+// LoadProgressFunc hears how far loading an instance has come
+type LoadProgressFunc func(LoadProgress)
+
+// detectBytes is how much of input without a telling name DetectFormat sees
+const detectBytes = 4096
+
+// loadReportInterval is how often a LoadProgressFunc hears of progress
+const loadReportInterval = 100 * time.Millisecond
+
+// loadReporter passes progress on to a LoadProgressFunc, at most every
+// loadReportInterval apart from the end of a stage. Its methods do nothing
+// on a nil reporter, which a load without a LoadProgressFunc gets
+type loadReporter struct {
+	progress LoadProgressFunc
+	reported time.Time
+}
+
+// newLoadReporter returns a reporter to progress, nil if it is nil
+func newLoadReporter(progress LoadProgressFunc) *loadReporter {
+	if progress == nil {
+		return nil
+	}
+	return &loadReporter{progress: progress}
+}
+
+// report passes progress on if it ends its stage or the last report is old enough
+func (l *loadReporter) report(stage string, done, total int64) {
+	if l == nil {
+		return
+	}
+	if now := time.Now(); done == total || now.Sub(l.reported) >= loadReportInterval {
+		l.reported = now
+		l.progress(LoadProgress{Stage: stage, Done: done, Total: total})
+	}
+}
+
+// progressReader counts the bytes read through it for a loadReporter, and
+// fails with ctx's error once ctx is done, which stops any parser reading it
+type progressReader struct {
+	ctx         context.Context
+	r           io.Reader
+	read, total int64
+	reporter    *loadReporter
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if err == io.EOF {
+		p.reporter.report(LoadReading, p.read, p.read)
+	} else {
+		p.reporter.report(LoadReading, p.read, p.total)
+	}
+	return n, err
+}
+
+// ReadInstanceContext is ReadInstance, parsing TSPLIB and CSV input as it
+// streams in rather than holding the whole file, telling progress how far it
+// has come, and giving up with ctx's error once ctx is done
+func ReadInstanceContext(ctx context.Context, path, format string, progress LoadProgressFunc) (*Instance, error) {
+	var f *os.File
+	var total int64
+	if path == "-" {
+		f = os.Stdin
+	} else {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			total = info.Size()
+		}
+	}
+	reporter := newLoadReporter(progress)
+	r := bufio.NewReaderSize(&progressReader{ctx: ctx, r: f, total: total, reporter: reporter}, detectBytes)
+	if format == "" {
+		// The start of the input tells its format where the name does not
+		head, err := r.Peek(detectBytes)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+		format = DetectFormat(path, head)
+	}
+	instance, err := streamInstance(ctx, format, r, reporter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", inputName(path), err)
+	}
+	return instance, nil
+}
+
+// streamInstance decodes r held in format, streaming the formats that allow
+// it and reading the others whole for parseInstance
+func streamInstance(ctx context.Context, format string, r io.Reader, reporter *loadReporter) (*Instance, error) {
+	switch format {
+	case FormatTSPLIB:
+		tsplib, err := ParseTSPLIB(r)
+		if err != nil {
+			return nil, err
+		}
+		return tsplib.Instance(), nil
+	case FormatCSV:
+		return LoadInstanceCSV(r)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseInstance(format, data)
+}
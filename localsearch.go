@@ -0,0 +1,187 @@
+package main
+
+import "slices"
+
+// TwoOpt shortens tour by reversing segments for as long as some reversal
+// helps, and returns the improved copy. Reversals that would break the
+// colony's constraints are skipped. Static symmetric distances are compared
+// edge by edge; time-dependent or asymmetric costs re-evaluate the whole tour.
+// Once the colony's context is done it returns the tour improved so far
+func (ac *AntColony) TwoOpt(tour []int) []int {
+	t := slices.Clone(tour)
+	n := len(t)
+	exact := ac.TimeCost == nil && ac.symmetric()
+	constrained := !ac.Constraints.empty()
+	d := ac.distance
+	length := ac.TourLength(t)
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n-1; i++ {
+			if ac.cancelled() {
+				return t
+			}
+			for j := i + 1; j < n; j++ {
+				if exact {
+					// Reversing t[i..j] swaps the edges into and out of the segment
+					delta := 0.0
+					prev, hasPrev := 0, i > 0
+					if hasPrev {
+						prev = t[i-1]
+					} else if ac.ReturnToStart && j < n-1 {
+						prev, hasPrev = t[n-1], true
+					}
+					next, hasNext := 0, j < n-1
+					if hasNext {
+						next = t[j+1]
+					} else if ac.ReturnToStart && i > 0 {
+						next, hasNext = t[0], true
+					}
+					if hasPrev {
+						delta += d(prev, t[j]) - d(prev, t[i])
+					}
+					if hasNext {
+						delta += d(t[i], next) - d(t[j], next)
+					}
+					// Written as a negation so that NaN from unreachable pairs is rejected
+					if !(delta < -1e-9) {
+						continue
+					}
+				}
+				slices.Reverse(t[i : j+1])
+				candidate := length
+				if !exact {
+					candidate = ac.TourLength(t)
+				}
+				if (!exact && candidate >= length) || (constrained && ValidateTour(t, n, ac.Constraints) != nil) {
+					slices.Reverse(t[i : j+1])
+					continue
+				}
+				length = candidate
+				improved = true
+			}
+		}
+	}
+	return t
+}
+
+// orOptMaxSegment is the longest segment Or-opt moves
+const orOptMaxSegment = 3
+
+// OrOpt shortens tour by moving segments of up to orOptMaxSegment cities,
+// either way round, to another place in the tour for as long as some move
+// helps, and returns the improved copy. Like TwoOpt it skips moves that would
+// break the colony's constraints, compares static symmetric distances edge by
+// edge and returns the tour improved so far once the colony's context is done
+func (ac *AntColony) OrOpt(tour []int) []int {
+	t := slices.Clone(tour)
+	length := ac.TourLength(t)
+	for improved := true; improved && !ac.cancelled(); {
+		t, length, improved = ac.orOptMove(t, length)
+	}
+	return t
+}
+
+// orOptMove makes the first Or-opt move that shortens tour t of the given
+// length, reporting whether it found one
+func (ac *AntColony) orOptMove(t []int, length float64) ([]int, float64, bool) {
+	n := len(t)
+	exact := ac.TimeCost == nil && ac.symmetric()
+	constrained := !ac.Constraints.empty()
+	closed := ac.ReturnToStart
+	// cost is the cost of the edge from a to b, nothing if either is missing
+	cost := func(a, b int) float64 {
+		if a < 0 || b < 0 {
+			return 0
+		}
+		return ac.distance(a, b)
+	}
+	for k := 1; k <= orOptMaxSegment && k < n; k++ {
+		for i := 0; i+k <= n; i++ {
+			if ac.cancelled() {
+				return t, length, false
+			}
+			segment := t[i : i+k]
+			rest := append(slices.Clone(t[:i]), t[i+k:]...)
+			m := len(rest)
+			prev, next := -1, -1
+			if i > 0 {
+				prev = t[i-1]
+			} else if closed {
+				prev = t[n-1]
+			}
+			if i+k < n {
+				next = t[i+k]
+			} else if closed {
+				next = t[0]
+			}
+			removal := cost(prev, next) - cost(prev, segment[0]) - cost(segment[k-1], next)
+			if prev < 0 || next < 0 {
+				removal = -cost(prev, segment[0]) - cost(segment[k-1], next)
+			}
+			last := m
+			if closed {
+				// Before the first city and after the last is the same place
+				last = m - 1
+			}
+			for p := 0; p <= last; p++ {
+				a, b := -1, -1
+				if p > 0 {
+					a = rest[p-1]
+				} else if closed {
+					a = rest[m-1]
+				}
+				if p < m {
+					b = rest[p]
+				}
+				for _, reversed := range []bool{false, true} {
+					if (reversed && k == 1) || (!reversed && p == i) {
+						continue
+					}
+					first, end := segment[0], segment[k-1]
+					if reversed {
+						first, end = end, first
+					}
+					if exact {
+						delta := removal + cost(a, first) + cost(end, b)
+						if a >= 0 && b >= 0 {
+							delta -= cost(a, b)
+						}
+						// Written as a negation so that NaN from unreachable pairs is rejected
+						if !(delta < -1e-9) {
+							continue
+						}
+					}
+					moved := slices.Clone(segment)
+					if reversed {
+						slices.Reverse(moved)
+					}
+					candidate := slices.Concat(rest[:p], moved, rest[p:])
+					candidateLength := ac.TourLength(candidate)
+					if !(candidateLength < length) || (constrained && ValidateTour(candidate, n, ac.Constraints) != nil) {
+						continue
+					}
+					return candidate, candidateLength, true
+				}
+			}
+		}
+	}
+	return t, length, false
+}
+
+// symmetric reports whether every distance is the same in both directions,
+// as those by a metric are
+func (ac *AntColony) symmetric() bool {
+	for i := range ac.DistanceMatrix {
+		for j := range i {
+			if ac.DistanceMatrix[i][j] != ac.DistanceMatrix[j][i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// empty reports whether there are no constraints at all
+func (c *Constraints) empty() bool {
+	return len(c.Precedences) == 0 && len(c.ForbiddenEdges) == 0 && len(c.RequiredEdges) == 0 && len(c.Clusters) == 0 && len(c.Segments) == 0
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// logFlags registers -log-level and -log-every on flags and returns a function
+// that installs the logger they select as slog's default, writing text records
+// to standard error, and returns the interval between progress records
+func logFlags(flags *flag.FlagSet) func() (int, error) {
+	level := flags.String("log-level", "info", "log records at this `level` and above: debug, info, warn or error")
+	every := flags.Int("log-every", 0, "log progress every `n` iterations, 0 for none")
+	return func() (int, error) {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(*level)); err != nil {
+			return 0, fmt.Errorf("-log-level: %w", err)
+		}
+		if *every < 0 {
+			return 0, fmt.Errorf("-log-every must not be negative")
+		}
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: l})))
+		return *every, nil
+	}
+}
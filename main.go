@@ -1,29 +1,60 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
+	"os"
+	"runtime/debug"
+	"sync"
 	"time"
 )
 
-// City represents a city with coordinates
+// City represents a city with coordinates, Z is left at zero for planar instances.
+// ServiceTime is spent at the city on each visit and counts toward the tour objective
 type City struct {
-	X float64
-	Y float64
+	// Name labels the city in printed tours, its index is used if empty
+	Name        string  `json:"name,omitempty"`
+	X           float64 `json:"x"`
+	Y           float64 `json:"y"`
+	Z           float64 `json:"z,omitempty"`
+	ServiceTime float64 `json:"service_time,omitempty"`
+	// Metadata is any JSON value of the caller's, such as an order id, an
+	// address or a payload, which the solver never reads and every export of
+	// a tour carries along with the city, so results need no joining back
+	// against their source
+	Metadata json.RawMessage `json:"metadata,omitempty"`
 }
 
 // Distance calculates the Euclidean distance between two cities
 func (c *City) Distance(other *City) float64 {
 	dx := c.X - other.X
 	dy := c.Y - other.Y
-	return math.Sqrt(dx*dx + dy*dy)
+	dz := c.Z - other.Z
+	// The conversions round each square, keeping compilers from fusing the
+	// sum into multiply-adds that would make distances differ across machines
+	return math.Sqrt(float64(dx*dx) + float64(dy*dy) + float64(dz*dz))
 }
 
 // Ant represents an ant agent
 type Ant struct {
 	Tour    []int
 	Visited map[int]bool
+	Elapsed float64
+	Weights []float64
+
+	// rng drives the ant's own choices, so ants can move in parallel and still
+	// follow from the colony's seed, which seed is drawn from
+	rng  *rand.Rand
+	seed int64
+	// params holds the ant's own parameters in self-adaptive colonies, nil otherwise
+	params *antParameters
+	// listMoves counts the ant's choices of a next city from a candidate
+	// list, and fallbacks those of them that had to consider every city
+	listMoves, fallbacks int
 }
 
 // AntColony represents an ant colony
@@ -36,156 +67,552 @@ type AntColony struct {
 	Cities         []*City
 	Pheromones     [][]float64
 	DistanceMatrix [][]float64
+	Constraints    *Constraints
+	ChangeStrategy ChangeStrategy
+	Smoothing      float64
+	TimeCost       TimeDependentCost
+	Stochastic     *StochasticCosts
+	Objectives     [][][]float64
+	// ClusterPheromones holds the trails between clusters in clustered instances
+	ClusterPheromones [][]float64
+	// ReturnToStart closes tours with an edge from the last city back to the first
+	ReturnToStart bool
+	// Variant selects the ACO algorithm, Ant System if empty
+	Variant string
+	// Q0 is the probability an Ant Colony System ant takes the most attractive edge
+	Q0 float64
+	// Workers is the number of ants building tours at once; Ant Colony System
+	// ants always move one at a time since each updates the trails as it goes
+	Workers int
+	// Adaptive lets each ant carry its own alpha, beta and q0, evolved by the
+	// quality of the tours built with them
+	Adaptive bool
+	// Castes, if set, splits the ants into castes choosing by parameters of
+	// their own
+	Castes []Caste
+	// CandidateList limits each move to that many nearest cities while any of
+	// them is allowed, 0 for no limit
+	CandidateList int
+	// Heuristic, if set, replaces the inverse edge cost as the desirability
+	// of moves outside multi-objective runs
+	Heuristic HeuristicFunc
+	// Objective, if set, scores tours in place of their length for the
+	// trails and the best tour
+	Objective Objective
+	// Vehicle, if set, runs the tours, for objectives and solutions to cost
+	// them by
+	Vehicle *VehicleProfile
+	// Attributes holds matrices of edge attributes other than the cost, by
+	// name, for objectives and heuristics to read
+	Attributes map[string][][]float64
+	// objectiveNames names the attributes SetObjectives made the Objectives
+	// from, for them to follow cities added and removed
+	objectiveNames []string
+	// owned is set once the colony has copied what it shared with its
+	// instance, for AddCity and RemoveCity to change
+	owned bool
+	// Placement decides the ants' start cities, one of Placements; empty
+	// means PlacementRandom. StartCity is the start of PlacementFixed
+	Placement string
+	StartCity int
+
+	rng *rand.Rand
+	// source is the source of rng, kept to record its draws
+	source                     rand.Source64
+	initialPheromone           float64
+	minPheromone, maxPheromone float64
+	adaptive                   []antParameters
+	// trailBounds keeps the trails of a variant other than MMAS within
+	// minPheromone and maxPheromone, and clamped counts the trails the last
+	// update brought back within the bounds
+	trailBounds bool
+	clamped     int
+	// casteParams holds the parameters of every ant's caste, nil for standard ants
+	casteParams []*antParameters
+	// nearest[i] lists the CandidateList cities nearest to i, nearest first
+	nearest [][]int
+	// busy sums the time every worker spent building tours, and matrixTime
+	// and listTime the time spent computing distances and sorting candidate lists
+	busy, matrixTime, listTime time.Duration
+	// ctx, if set, cuts short candidate lists, construction and local search
+	// once done; the solver sets it for the iteration in progress
+	ctx context.Context
+	// costFloor replaces zero costs in the heuristic, which duplicate cities
+	// would otherwise make infinite; the solver sets it from the distances
+	costFloor float64
+	// sparse, if set, holds the city-level trails in place of Pheromones,
+	// which is then nil
+	sparse *sparseTrails
+	// lengths, if set, memoizes the lengths of tours
+	lengths *lengthCache
+	// metric computes the distance between two cities where DistanceMatrix,
+	// nil in sparse colonies, holds none
+	metric func(a, b *City) float64
 }
 
 // NewAntColony initializes a new ant colony
 func NewAntColony(numAnts int, alpha, beta, rho, q float64, cities []*City) *AntColony {
+	colony, _ := NewAntColonyContext(context.Background(), numAnts, alpha, beta, rho, q, cities)
+	return colony
+}
+
+// NewAntColonyContext is NewAntColony, giving up building the distance matrix
+// with ctx's error once ctx is done
+func NewAntColonyContext(ctx context.Context, numAnts int, alpha, beta, rho, q float64, cities []*City) (*AntColony, error) {
+	return newAntColony(ctx, numAnts, alpha, beta, rho, q, cities, nil, nil, false)
+}
+
+// newAntColony is NewAntColonyContext, keeping the trails in a sparseTrails
+// rather than a matrix if sparse is set. Given distances, it takes them as
+// the distance matrix rather than computing one from the coordinates by
+// metric, Euclidean if nil; sparse colonies keep no matrix, computing each
+// distance as it is needed
+func newAntColony(ctx context.Context, numAnts int, alpha, beta, rho, q float64, cities []*City, distances [][]float64,
+	metric func(a, b *City) float64, sparse bool) (*AntColony, error) {
+	if metric == nil {
+		metric = (*City).Distance
+	}
 	colony := &AntColony{
-		NumAnts:        numAnts,
-		Alpha:          alpha,
-		Beta:           beta,
-		Rho:            rho,
-		Q:              q,
-		Cities:         cities,
-		Pheromones:     make([][]float64, len(cities)),
-		DistanceMatrix: make([][]float64, len(cities)),
-	}
-	for i := range colony.Pheromones {
-		colony.Pheromones[i] = make([]float64, len(cities))
+		NumAnts:     numAnts,
+		Alpha:       alpha,
+		Beta:        beta,
+		Rho:         rho,
+		Q:           q,
+		Cities:      cities,
+		Constraints: &Constraints{},
+		metric:      metric,
+	}
+	colony.Seed(time.Now().UnixNano())
+	if sparse {
+		colony.sparse = newSparseTrails(0)
+	} else {
+		colony.Pheromones = make([][]float64, len(cities))
+		for i := range colony.Pheromones {
+			colony.Pheromones[i] = make([]float64, len(cities))
+		}
+	}
+	if distances != nil || sparse {
+		colony.DistanceMatrix = distances
+		return colony, nil
 	}
+	start := time.Now()
+	colony.DistanceMatrix = make([][]float64, len(cities))
 	for i := range colony.DistanceMatrix {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		colony.DistanceMatrix[i] = make([]float64, len(cities))
 		for j := range colony.DistanceMatrix[i] {
-			colony.DistanceMatrix[i][j] = cities[i].Distance(cities[j])
+			if i != j {
+				colony.DistanceMatrix[i][j] = metric(cities[i], cities[j])
+			}
 		}
 	}
-	return colony
+	colony.matrixTime = time.Since(start)
+	return colony, nil
+}
+
+// distance returns the distance from city i to city j, from the distance
+// matrix or, without one, by the colony's metric
+func (ac *AntColony) distance(i, j int) float64 {
+	if ac.DistanceMatrix != nil {
+		return ac.DistanceMatrix[i][j]
+	}
+	if i == j {
+		return 0
+	}
+	return ac.metric(ac.Cities[i], ac.Cities[j])
+}
+
+// distanceMatrix returns the distance matrix, computing every distance of a
+// colony that keeps none
+func (ac *AntColony) distanceMatrix() [][]float64 {
+	if ac.DistanceMatrix != nil {
+		return ac.DistanceMatrix
+	}
+	n := len(ac.Cities)
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+		for j := range matrix[i] {
+			matrix[i][j] = ac.distance(i, j)
+		}
+	}
+	return matrix
+}
+
+// cancelled reports whether the colony's context is done, which leaves the
+// tours of the iteration in progress unfinished
+func (ac *AntColony) cancelled() bool {
+	if ac.ctx == nil {
+		return false
+	}
+	select {
+	case <-ac.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// Seed makes the colony's random decisions reproducible
+func (ac *AntColony) Seed(seed int64) {
+	ac.source = rand.NewSource(seed).(rand.Source64)
+	ac.rng = rand.New(ac.source)
 }
 
 // InitializeAnts initializes ants with random starting cities
 func (ac *AntColony) InitializeAnts() []*Ant {
 	ants := make([]*Ant, ac.NumAnts)
+	if ac.Adaptive && len(ac.adaptive) != ac.NumAnts {
+		ac.initAdaptiveParameters()
+	}
+	if len(ac.Castes) > 0 && len(ac.casteParams) != ac.NumAnts {
+		ac.assignCastes()
+	}
+	ac.prepareCandidateLists()
+	starts := ac.placeAnts()
 	for i := range ants {
-		ants[i] = &Ant{
-			Tour:    make([]int, len(ac.Cities)),
-			Visited: make(map[int]bool),
+		seed := ac.rng.Int63()
+		if starts == nil {
+			ants[i] = ac.newAnt(ac.startCity(), seed)
+		} else {
+			ants[i] = ac.newAnt(starts[i], seed)
+		}
+		if ac.Adaptive {
+			ants[i].params = &ac.adaptive[i]
+		} else if len(ac.Castes) > 0 {
+			ants[i].params = ac.casteParams[i]
 		}
-		startCity := rand.Intn(len(ac.Cities))
-		ants[i].Tour[0] = startCity
-		ants[i].Visited[startCity] = true
 	}
 	return ants
 }
 
-// NextCity selects the next city for an ant to visit based on pheromone trails and heuristic information
+// newAnt places an ant on startCity, making its choices from seed
+func (ac *AntColony) newAnt(startCity int, seed int64) *Ant {
+	ant := &Ant{
+		Tour:    make([]int, 0, len(ac.Cities)),
+		Visited: make(map[int]bool),
+		rng:     rand.New(rand.NewSource(seed)),
+		seed:    seed,
+	}
+	ant.Tour = append(ant.Tour, startCity)
+	ant.Visited[startCity] = true
+	ant.Elapsed = ac.Cities[startCity].ServiceTime
+	return ant
+}
+
+// startCity picks a random city that may begin a tour under the colony's constraints
+func (ac *AntColony) startCity() int {
+	starts := ac.startCandidates()
+	return starts[ac.rng.Intn(len(starts))]
+}
+
+// allowed reports whether the ant may move to city next
+func (ac *AntColony) allowed(ant *Ant, city int) bool {
+	currentCity := ant.Tour[len(ant.Tour)-1]
+	return !ant.Visited[city] &&
+		ac.Constraints.Allows(ant.Visited, city) &&
+		ac.Constraints.AllowsEdge(currentCity, city) &&
+		ac.Constraints.AllowsCluster(ant.Visited, currentCity, city)
+}
+
+// candidates lists the cities the ant may move to next. A pending required
+// edge is always followed; otherwise cities whose required edges would be
+// broken by entering them are avoided unless nothing else is left. An ant
+// boxed in by forbidden edges may still cross one rather than stall, leaving
+// the violation for ValidateTour to report
+func (ac *AntColony) candidates(ant *Ant) []int {
+	currentCity := ant.Tour[len(ant.Tour)-1]
+	for _, partner := range ac.Constraints.requiredPartners(currentCity) {
+		if ac.allowed(ant, partner) {
+			return []int{partner}
+		}
+	}
+	var preferred, fallback []int
+	for i := range ac.Cities {
+		if !ac.allowed(ant, i) {
+			continue
+		}
+		fallback = append(fallback, i)
+		if ac.Constraints.canEnter(ant.Visited, currentCity, i) {
+			preferred = append(preferred, i)
+		}
+	}
+	if len(preferred) > 0 {
+		return preferred
+	}
+	if len(fallback) > 0 {
+		return fallback
+	}
+	for i := range ac.Cities {
+		if !ant.Visited[i] && ac.Constraints.Allows(ant.Visited, i) {
+			fallback = append(fallback, i)
+		}
+	}
+	return fallback
+}
+
+// NextCity selects the next city for an ant to visit based on pheromone trails
+// and heuristic information. It returns an unvisited city whenever there is
+// one, breaking the constraints if they allow none, for ValidateTour to
+// report, and -1 only once the ant has visited every city
 func (ac *AntColony) NextCity(ant *Ant) int {
 	currentCity := ant.Tour[len(ant.Tour)-1]
-	pheromones := ac.Pheromones[currentCity]
-	heuristic := make([]float64, len(ac.Cities))
-	sum := 0.0
-	for i, city := range ac.Cities {
-		if !ant.Visited[i] {
-			heuristic[i] = 1 / ac.DistanceMatrix[currentCity][i]
-			sum += math.Pow(pheromones[i], ac.Alpha) * math.Pow(heuristic[i], ac.Beta)
-		}
-	}
-	roulette := rand.Float64() * sum
-	cumulativeProbability := 0.0
-	for i, city := range ac.Cities {
-		if !ant.Visited[i] {
-			cumulativeProbability += math.Pow(pheromones[i], ac.Alpha) * math.Pow(heuristic[i], ac.Beta)
-			if cumulativeProbability >= roulette {
+	candidates := ac.nearCandidates(ant, currentCity)
+	if len(candidates) == 0 {
+		for i := range ac.Cities {
+			if !ant.Visited[i] {
 				return i
 			}
 		}
+		return -1
+	}
+	alpha, beta, q0 := ac.Alpha, ac.Beta, 0.0
+	if ac.Variant == AntColonySystem {
+		q0 = ac.Q0
+	}
+	if ant.params != nil {
+		alpha, beta, q0 = ant.params.Alpha, ant.params.Beta, ant.params.Q0
 	}
-	// This should not happen
-	return -1
+	weights := make([]float64, len(candidates))
+	selectionWeights(weights, alpha, beta, func(k int) (float64, float64) {
+		return ac.pheromone(currentCity, candidates[k]), ac.heuristic(ant, currentCity, candidates[k])
+	})
+	rng := ant.rng
+	if rng == nil {
+		rng = ac.rng
+	}
+	var choice int
+	if (ac.Variant == AntColonySystem || ant.params != nil) && rng.Float64() < q0 {
+		choice = bestWeighted(weights)
+	} else if choice = rouletteSelect(rng, weights); choice < 0 {
+		// Rounding left the cumulative sum short of the draw, or NaN weights
+		// of unreachable cities spoilt it
+		choice = bestWeighted(weights)
+	}
+	return candidates[choice]
+}
+
+// heuristic returns the desirability of the ant moving from city from to city to:
+// the inverse edge cost, or the inverse of the ant's weighted sum of objective
+// costs in multi-objective runs
+func (ac *AntColony) heuristic(ant *Ant, from, to int) float64 {
+	if ant.Weights == nil {
+		if ac.Heuristic != nil {
+			return ac.Heuristic(ac, ant, from, to)
+		}
+		return 1 / max(ac.edgeCost(from, to, ant.Elapsed), ac.costFloor)
+	}
+	cost := 0.0
+	for k, objective := range ac.Objectives {
+		cost += ant.Weights[k] * objective[from][to]
+	}
+	return 1 / max(cost, ac.costFloor)
 }
 
-// AntsMove performs the movement of all ants
+// AntsMove performs the movement of all ants, spread over the colony's workers.
+// Once the colony's context is done, ants stop where they are
 func (ac *AntColony) AntsMove(ants []*Ant) {
+	move := func(ant *Ant) {
+		for len(ant.Tour) < len(ac.Cities) && !ac.cancelled() {
+			currentCity, nextCity := ant.Tour[len(ant.Tour)-1], ac.NextCity(ant)
+			ac.visit(ant, nextCity)
+			if ac.Variant == AntColonySystem {
+				ac.blendTrail(currentCity, nextCity, acsLocalEvaporation, ac.initialPheromone)
+			}
+		}
+	}
+	if ac.Workers <= 1 || ac.Variant == AntColonySystem {
+		start := time.Now()
+		for _, ant := range ants {
+			move(ant)
+		}
+		ac.busy += time.Since(start)
+		return
+	}
+	if len(ac.Constraints.Clusters) > 0 {
+		ac.ensureClusterPheromones()
+	}
+	queue := make(chan *Ant)
+	var wg sync.WaitGroup
+	busy := make([]time.Duration, min(ac.Workers, len(ants)))
+	// A panic building a tour is kept, not left to kill the program, and
+	// raised again below on the caller's goroutine where it can be recovered
+	panics := make([]*workerPanic, len(busy))
+	for w := range busy {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ant := range queue {
+				if panics[w] != nil {
+					continue
+				}
+				start := time.Now()
+				panics[w] = moveRecovering(move, ant)
+				busy[w] += time.Since(start)
+			}
+		}()
+	}
 	for _, ant := range ants {
-		for len(ant.Tour) < len(ac.Cities) {
-			nextCity := ac.NextCity(ant)
-			ant.Tour = append(ant.Tour, nextCity)
-			ant.Visited[nextCity] = true
+		queue <- ant
+	}
+	close(queue)
+	wg.Wait()
+	for _, b := range busy {
+		ac.busy += b
+	}
+	for _, p := range panics {
+		if p != nil {
+			panic(*p)
 		}
 	}
 }
 
+// workerPanic is a panic raised while a worker goroutine built a tour, with
+// the stack it was raised on
+type workerPanic struct {
+	Value any
+	Stack []byte
+}
+
+func (p workerPanic) String() string {
+	return fmt.Sprintf("%v (in a worker goroutine)\n%s", p.Value, p.Stack)
+}
+
+// moveRecovering moves the ant with move, returning the panic it raised if
+// any
+func moveRecovering(move func(*Ant), ant *Ant) (p *workerPanic) {
+	defer func() {
+		if r := recover(); r != nil {
+			p = &workerPanic{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	move(ant)
+	return nil
+}
+
+// visit moves the ant on to city, serving it after travelling there
+func (ac *AntColony) visit(ant *Ant, city int) {
+	ant.Elapsed += ac.edgeCost(ant.Tour[len(ant.Tour)-1], city, ant.Elapsed) + ac.Cities[city].ServiceTime
+	ant.Tour = append(ant.Tour, city)
+	ant.Visited[city] = true
+}
+
 // UpdatePheromones updates the pheromone trails based on the tours of the ants
 func (ac *AntColony) UpdatePheromones(ants []*Ant) {
+	ac.evaporate()
+	for _, ant := range ants {
+		ac.deposit(ant.Tour, ac.Q/max(ac.Score(ant.Tour), ac.costFloor))
+	}
+}
+
+// evaporate reduces every pheromone trail by the evaporation rate
+func (ac *AntColony) evaporate() {
+	if ac.sparse != nil {
+		ac.sparse.update(func(trail float64) float64 { return trail * (1 - ac.Rho) })
+	}
 	for i := range ac.Pheromones {
 		for j := range ac.Pheromones[i] {
 			ac.Pheromones[i][j] *= (1 - ac.Rho)
 		}
 	}
-	for _, ant := range ants {
-		tourLength := ac.TourLength(ant.Tour)
-		for i := 0; i < len(ant.Tour)-1; i++ {
-			fromCity := ant.Tour[i]
-			toCity := ant.Tour[i+1]
-			ac.Pheromones[fromCity][toCity] += ac.Q / tourLength
-			ac.Pheromones[toCity][fromCity] += ac.Q / tourLength
+	for i := range ac.ClusterPheromones {
+		for j := range ac.ClusterPheromones[i] {
+			ac.ClusterPheromones[i][j] *= (1 - ac.Rho)
 		}
 	}
 }
 
-// TourLength calculates the total length of a tour
+// deposit adds amount of pheromone to both directions of every edge of tour
+func (ac *AntColony) deposit(tour []int, amount float64) {
+	ac.forEachEdge(tour, func(i, j int) {
+		*ac.trail(i, j) += amount
+		*ac.trail(j, i) += amount
+	})
+}
+
+// forEachEdge calls f with the cities at both ends of every edge of tour,
+// including the edge back to the start of a closed tour
+func (ac *AntColony) forEachEdge(tour []int, f func(i, j int)) {
+	edges := len(tour) - 1
+	if ac.ReturnToStart {
+		edges = len(tour)
+	}
+	for k := 0; k < edges; k++ {
+		f(tour[k], tour[(k+1)%len(tour)])
+	}
+}
+
+// TourLength calculates the total length of a tour including service times,
+// departing each city as soon as its service ends.
+// With stochastic costs the length is the robust estimate over sampled costs
 func (ac *AntColony) TourLength(tour []int) float64 {
-	length := 0.0
+	if ac.Stochastic != nil {
+		return ac.RobustLength(tour)
+	}
+	if ac.lengths != nil {
+		return ac.lengths.length(ac, tour)
+	}
+	return ac.tourLength(tour)
+}
+
+// tourLength computes the length of a tour, cached or not
+func (ac *AntColony) tourLength(tour []int) float64 {
+	if len(tour) == 0 {
+		return 0
+	}
+	length := ac.Cities[tour[0]].ServiceTime
 	for i := 0; i < len(tour)-1; i++ {
 		fromCity := tour[i]
 		toCity := tour[i+1]
-		length += ac.DistanceMatrix[fromCity][toCity]
+		length += ac.edgeCost(fromCity, toCity, length) + ac.Cities[toCity].ServiceTime
+	}
+	if ac.ReturnToStart {
+		length += ac.edgeCost(tour[len(tour)-1], tour[0], length)
 	}
 	return length
 }
 
-func main() {
-	// Seed random number generator
-	rand.Seed(time.Now().UnixNano())
-
-	// Create cities
-	cities := []*City{
-		{X: 0, Y: 0},
-		{X: 1, Y: 1},
-		{X: 2, Y: 2},
-		{X: 3, Y: 3},
-		{X: 4, Y: 4},
+// serviceTime sums the service times of the cities on tour
+func (ac *AntColony) serviceTime(tour []int) float64 {
+	total := 0.0
+	for _, city := range tour {
+		total += ac.Cities[city].ServiceTime
 	}
+	return total
+}
 
-	// Set ACO parameters
-	numAnts := 10
-	alpha := 1.0
-	beta := 2.0
-	rho := 0.5
-	q := 100.0
-
-	// Create ant colony
-	colony := NewAntColony(numAnts, alpha, beta, rho, q, cities)
-
-	// Run ACO algorithm
-	iterations := 100
-	for i := 0; i < iterations; i++ {
-		ants := colony.InitializeAnts()
-		colony.AntsMove(ants)
-		colony.UpdatePheromones(ants)
+// writeFile creates the file at path and fills it with write, writing to
+// standard output instead if path is "-"
+func writeFile(path string, write func(w io.Writer) error) error {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
 	}
+	return err
+}
 
-	// Find best tour
-	bestTour := make([]int, len(cities))
-	bestTourLength := math.Inf(1)
-	for _, ant := range colony.InitializeAnts() {
-		tourLength := colony.TourLength(ant.Tour)
-		if tourLength < bestTourLength {
-			bestTourLength = tourLength
-			copy(bestTour, ant.Tour)
+// writeJSON writes v as indented JSON to the file at path, or standard output if path is "-"
+func writeJSON(path string, v any) error {
+	return writeFile(path, func(w io.Writer) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err == nil {
+			_, err = w.Write(append(data, '\n'))
 		}
-	}
+		return err
+	})
+}
 
-	// Print results
-	fmt.Println("Best tour:", bestTour)
-	fmt.Println("Best tour length:", bestTourLength)
+// fatal reports err and exits
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
 }
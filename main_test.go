@@ -0,0 +1,133 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"testing/quick"
+)
+
+// randomColony builds a small colony from a seed, with cities that may share
+// a place, parameters and trails up to extremes that strain the selection
+// weights, and forbidden edges, to stand for any state a run may reach
+func randomColony(seed int64) *AntColony {
+	rng := rand.New(rand.NewSource(seed))
+	n := 2 + rng.Intn(14)
+	cities := make([]*City, n)
+	for i := range cities {
+		cities[i] = &City{X: float64(rng.Intn(10)), Y: float64(rng.Intn(10))}
+	}
+	extremes := []float64{0, 1e-300, 1e-12, 0.5, 1, 3, 50, 1e12, 1e300}
+	pick := func() float64 { return extremes[rng.Intn(len(extremes))] }
+	ac := NewAntColony(n, pick(), pick(), 0.5, 100, cities)
+	ac.Seed(seed)
+	if rng.Intn(2) == 0 {
+		ac.Variant, ac.Q0 = AntColonySystem, rng.Float64()
+	}
+	if rng.Intn(2) == 0 {
+		ac.CandidateList = 1 + rng.Intn(n)
+		ac.prepareCandidateLists()
+	}
+	for i := range ac.Pheromones {
+		for j := range ac.Pheromones[i] {
+			ac.Pheromones[i][j] = pick()
+		}
+	}
+	ac.Constraints = &Constraints{}
+	for k := rng.Intn(n); k > 0; k-- {
+		ac.Constraints.AddForbiddenEdge(rng.Intn(n), rng.Intn(n))
+	}
+	return ac
+}
+
+// TestNextCity checks over random colonies that NextCity always moves an ant
+// on to a city it has not visited, one the constraints allow whenever there
+// is one, so that every tour visits each city once
+func TestNextCity(t *testing.T) {
+	property := func(seed int64) bool {
+		ac := randomColony(seed)
+		n := len(ac.Cities)
+		ant := ac.newAnt(ac.startCity(), seed)
+		for len(ant.Tour) < n {
+			allowed := slices.ContainsFunc(ac.candidates(ant), func(city int) bool { return ac.allowed(ant, city) })
+			next := ac.NextCity(ant)
+			if next < 0 || next >= n || ant.Visited[next] {
+				t.Logf("seed %d: moved from %v to %d", seed, ant.Tour, next)
+				return false
+			}
+			if allowed && !ac.allowed(ant, next) {
+				t.Logf("seed %d: moved from %v to %d across a forbidden edge", seed, ant.Tour, next)
+				return false
+			}
+			ac.visit(ant, next)
+		}
+		if err := ValidateTour(ant.Tour, n, nil); err != nil {
+			t.Logf("seed %d: %v", seed, err)
+			return false
+		}
+		return ac.NextCity(ant) == -1
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestBestWeighted checks that the fallback of roulette selection picks a
+// weight no other beats, whatever NaN or infinite weights there are
+func TestBestWeighted(t *testing.T) {
+	specials := []float64{math.NaN(), math.Inf(1), 0, math.SmallestNonzeroFloat64}
+	property := func(weights []float64, mask []uint8) bool {
+		for k := range weights {
+			if k < len(mask) && mask[k]%3 == 0 {
+				weights[k] = specials[int(mask[k]/3)%len(specials)]
+			}
+		}
+		best := bestWeighted(weights)
+		if len(weights) == 0 {
+			return best == -1
+		}
+		if best < 0 || best >= len(weights) {
+			return false
+		}
+		for _, w := range weights {
+			if w > weights[best] || math.IsNaN(weights[best]) && !math.IsNaN(w) {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestAntsMoveWorkerPanic checks that a panic building a tour on a worker
+// goroutine reaches the goroutine that stepped the solver, where a crash
+// report can recover it, rather than killing the program
+func TestAntsMoveWorkerPanic(t *testing.T) {
+	g, err := NewGenerator(UniformCities, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.Seed, config.Workers = 1, 4
+	solver, err := NewSolver(g.Instance(20), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.Colony.TimeCost = func(i, j int, t float64) float64 {
+		if t > 100 {
+			panic("edge cost out of range")
+		}
+		return solver.Colony.distance(i, j)
+	}
+	defer func() {
+		p, ok := recover().(workerPanic)
+		if !ok || p.Value != "edge cost out of range" || len(p.Stack) == 0 {
+			t.Errorf("recovered %#v, want the worker's panic with its stack", p)
+		}
+	}()
+	solver.Step()
+	t.Error("step returned despite the panic")
+}
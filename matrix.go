@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Distance matrix file formats
+const (
+	// MatrixCSV holds a row of comma-separated distances per city
+	MatrixCSV = "csv"
+	// MatrixBinary holds matrixMagic, the number of cities as a little-endian
+	// uint64 and the distances row by row as little-endian float64s
+	MatrixBinary = "binary"
+)
+
+// matrixMagic starts binary distance matrix files
+const matrixMagic = "ACODIST1"
+
+// matrixFormat returns the distance matrix format of the file at path:
+// binary for a .bin file and CSV otherwise
+func matrixFormat(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".bin") {
+		return MatrixBinary
+	}
+	return MatrixCSV
+}
+
+// instanceDistances returns the distance matrix of an instance: its own, or
+// the distances between its cities' coordinates by its metric
+func instanceDistances(inst *Instance) [][]float64 {
+	if inst.Distances != nil {
+		return inst.Distances
+	}
+	metric := inst.metric()
+	distances := make([][]float64, len(inst.Cities))
+	for i, a := range inst.Cities {
+		distances[i] = make([]float64, len(inst.Cities))
+		for j, b := range inst.Cities {
+			if i != j {
+				distances[i][j] = metric(a, b)
+			}
+		}
+	}
+	return distances
+}
+
+// WriteMatrix writes a distance matrix in format, MatrixCSV or MatrixBinary
+func WriteMatrix(w io.Writer, distances [][]float64, format string) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case MatrixCSV:
+		var line []byte
+		for _, row := range distances {
+			line = line[:0]
+			for j, d := range row {
+				if j > 0 {
+					line = append(line, ',')
+				}
+				line = strconv.AppendFloat(line, d, 'g', -1, 64)
+			}
+			bw.Write(append(line, '\n'))
+		}
+	case MatrixBinary:
+		bw.WriteString(matrixMagic)
+		bw.Write(binary.LittleEndian.AppendUint64(nil, uint64(len(distances))))
+		var row []byte
+		for _, distances := range distances {
+			row = row[:0]
+			for _, d := range distances {
+				row = binary.LittleEndian.AppendUint64(row, math.Float64bits(d))
+			}
+			bw.Write(row)
+		}
+	default:
+		return fmt.Errorf("unknown matrix format %q, want %s or %s", format, MatrixCSV, MatrixBinary)
+	}
+	return bw.Flush()
+}
+
+// ReadMatrix reads a square distance matrix written by WriteMatrix or by any
+// system writing rows of comma-separated numbers, telling the formats apart
+// by matrixMagic. Distances may be infinite, for pairs with no route, but not
+// negative or NaN
+func ReadMatrix(r io.Reader) ([][]float64, error) {
+	br := bufio.NewReader(r)
+	if head, err := br.Peek(len(matrixMagic)); err == nil && bytes.Equal(head, []byte(matrixMagic)) {
+		return readMatrixBinary(br)
+	}
+	reader := csv.NewReader(br)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	var distances [][]float64
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		// The first row tells the size of the matrix
+		if distances == nil {
+			if err := checkMatrixMemory(len(record)); err != nil {
+				return nil, err
+			}
+		}
+		row := make([]float64, len(record))
+		for j, field := range record {
+			if row[j], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil || row[j] < 0 || math.IsNaN(row[j]) {
+				return nil, fmt.Errorf("line %d: distance %q is not a non-negative number", line, field)
+			}
+		}
+		distances = append(distances, row)
+	}
+	if len(distances) == 0 {
+		return nil, fmt.Errorf("no distances")
+	}
+	if len(distances[0]) != len(distances) {
+		return nil, fmt.Errorf("matrix has %d rows of %d distances, want a square one", len(distances), len(distances[0]))
+	}
+	return distances, nil
+}
+
+// readMatrixBinary reads a MatrixBinary matrix
+func readMatrixBinary(r io.Reader) ([][]float64, error) {
+	header := make([]byte, len(matrixMagic)+8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("binary matrix header: %w", err)
+	}
+	n := binary.LittleEndian.Uint64(header[len(matrixMagic):])
+	if n == 0 || n > math.MaxInt32 {
+		return nil, fmt.Errorf("binary matrix of %d cities", n)
+	}
+	if err := checkMatrixMemory(int(n)); err != nil {
+		return nil, err
+	}
+	distances := make([][]float64, n)
+	row := make([]byte, 8*n)
+	for i := range distances {
+		if _, err := io.ReadFull(r, row); err != nil {
+			return nil, fmt.Errorf("binary matrix row %d: %w", i, err)
+		}
+		distances[i] = make([]float64, n)
+		for j := range distances[i] {
+			d := math.Float64frombits(binary.LittleEndian.Uint64(row[8*j:]))
+			if d < 0 || math.IsNaN(d) {
+				return nil, fmt.Errorf("binary matrix row %d: distance %g is not a non-negative number", i, d)
+			}
+			distances[i][j] = d
+		}
+	}
+	return distances, nil
+}
+
+// ReadMatrixFile reads a distance matrix from the file at path, or from
+// standard input if path is "-"
+func ReadMatrixFile(path string) ([][]float64, error) {
+	data, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+	distances, err := ReadMatrix(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", inputName(path), err)
+	}
+	return distances, nil
+}
+
+// setMatrix makes distances the distance matrix of inst, in place of any
+// its edge weight type gives; inst must have as many cities as the matrix
+// has rows
+func (inst *Instance) setMatrix(distances [][]float64) error {
+	if len(distances) != len(inst.Cities) {
+		return fmt.Errorf("distance matrix has %d rows for %d cities", len(distances), len(inst.Cities))
+	}
+	inst.Distances, inst.EdgeWeightType = distances, ""
+	return nil
+}
+
+// matrixCommand implements "matrix", which writes the distance matrix of an
+// instance, as solve would compute it, for later runs to read with -matrix,
+// or attaches a matrix, computed once or by another system, to an instance
+func matrixCommand(args []string) error {
+	flags := flag.NewFlagSet("matrix", flag.ExitOnError)
+	outPath := flags.String("o", "-", "write to this `file`, - for stdout")
+	format := flags.String("format", "", "write the matrix as csv or binary, by default binary for a .bin -o file and csv otherwise")
+	rounding := flags.String("round", RoundNone, fmt.Sprintf("round the distances, one of %s", strings.Join(RoundingModes, ", ")))
+	attachPath := flags.String("attach", "", "instead, read the distance matrix in this CSV or binary `file` and write the instance with it as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s matrix [flags] instance\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Writes the distance matrix of an instance, for solve -matrix to read instead of computing it")
+		fmt.Fprintln(flags.Output(), "again, or with -attach makes a JSON instance of one and a matrix from elsewhere.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("matrix: want exactly one instance file")
+	}
+	if *format == "" {
+		*format = matrixFormat(*outPath)
+	}
+	if *format != MatrixCSV && *format != MatrixBinary {
+		return fmt.Errorf("matrix: unknown format %q, want %s or %s", *format, MatrixCSV, MatrixBinary)
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	if *attachPath != "" {
+		distances, err := ReadMatrixFile(*attachPath)
+		if err != nil {
+			return err
+		}
+		if err := instance.setMatrix(distances); err != nil {
+			return fmt.Errorf("%s: %w", *attachPath, err)
+		}
+		return writeJSON(*outPath, instance)
+	}
+	if instance, _, err = Preprocess(instance, Preprocessing{Round: *rounding}); err != nil {
+		return err
+	}
+	return writeFile(*outPath, func(w io.Writer) error {
+		return WriteMatrix(w, instanceDistances(instance), *format)
+	})
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// MemoryEstimate is the memory a run takes for its largest structures, in
+// bytes. The matrices grow with the square of the cities, the rest with the
+// cities alone
+type MemoryEstimate struct {
+	// DistanceMatrix holds the cost of every edge as a float64, none with
+	// sparse trails, whose colonies compute distances as they need them
+	DistanceMatrix int64 `json:"distance_matrix"`
+	// Pheromones holds the trail of every edge as a float64, only the
+	// touched ones with sparse trails, which the estimate takes to be none
+	Pheromones int64 `json:"pheromones"`
+	// CandidateLists holds every city's nearest neighbours
+	CandidateLists int64 `json:"candidate_lists"`
+	// Ants holds the tours and visited cities of an iteration's ants
+	Ants  int64 `json:"ants"`
+	Total int64 `json:"total"`
+}
+
+// antBytesPerCity is about what an ant takes per city, for its tour and the
+// entry of the city in its visited set
+const antBytesPerCity = 32
+
+// matrixBytes is the memory a matrix of float64 between that many cities
+// takes: 8 bytes an entry, and a slice header a row
+func matrixBytes(cities int) int64 {
+	n := int64(cities)
+	return n*n*8 + n*24
+}
+
+// EstimateMemory estimates the memory a run of cfg takes on an instance of
+// that many cities
+func EstimateMemory(cities int, cfg Config) MemoryEstimate {
+	n := int64(cities)
+	matrix := matrixBytes(cities)
+	estimate := MemoryEstimate{Ants: int64(cfg.NumAnts) * n * antBytesPerCity}
+	if !cfg.SparsePheromones {
+		estimate.DistanceMatrix = matrix
+		estimate.Pheromones = matrix
+	}
+	if cfg.Candidates > 0 {
+		estimate.CandidateLists = n*min(int64(cfg.Candidates), max(n-1, 0))*8 + n*24
+	}
+	estimate.Total = estimate.DistanceMatrix + estimate.Pheromones + estimate.CandidateLists + estimate.Ants
+	return estimate
+}
+
+// availableMemory returns the memory the process may still take: the least
+// of its Go memory limit, what its cgroup allows beyond its use and what the
+// system has available. It reports false where it can tell none of them
+func availableMemory() (int64, bool) {
+	available := int64(math.MaxInt64)
+	// A negative limit only reads the current one, MaxInt64 if none was set
+	if limit := debug.SetMemoryLimit(-1); limit < available {
+		available = limit
+	}
+	if limit, ok := readMemoryFile("/sys/fs/cgroup/memory.max"); ok {
+		used, _ := readMemoryFile("/sys/fs/cgroup/memory.current")
+		available = min(available, max(limit-used, 0))
+	} else if limit, ok := readMemoryFile("/sys/fs/cgroup/memory/memory.limit_in_bytes"); ok {
+		used, _ := readMemoryFile("/sys/fs/cgroup/memory/memory.usage_in_bytes")
+		available = min(available, max(limit-used, 0))
+	}
+	if free, ok := memAvailable(); ok {
+		available = min(available, free)
+	}
+	return available, available != math.MaxInt64
+}
+
+// readMemoryFile reads a number of bytes from a cgroup file, reporting
+// false if there is none or it says "max"
+func readMemoryFile(path string) (int64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	return n, err == nil
+}
+
+// memAvailable reads MemAvailable from /proc/meminfo, reporting false
+// where there is none
+func memAvailable() (int64, bool) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "MemAvailable:" && fields[2] == "kB" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			return kb << 10, err == nil
+		}
+	}
+	return 0, false
+}
+
+// checkMemory reports an error, before anything is allocated, if that many
+// colonies of cfg on inst would take more memory than cfg.MemoryLimit
+// allows, or than is available if it is zero, naming what would save memory.
+// A distance matrix the instance came with is already in memory, and shared
+func checkMemory(inst *Instance, cfg Config, colonies int) error {
+	limit := cfg.MemoryLimit
+	if limit < 0 {
+		return nil
+	}
+	if limit == 0 {
+		var ok bool
+		if limit, ok = availableMemory(); !ok {
+			return nil
+		}
+	}
+	cities := len(inst.Cities)
+	estimate := EstimateMemory(cities, cfg)
+	if inst.Distances != nil {
+		estimate.Total -= estimate.DistanceMatrix
+		estimate.DistanceMatrix = 0
+	}
+	need := estimate.Total * int64(colonies)
+	if need <= limit {
+		return nil
+	}
+	var hints []string
+	if !cfg.SparsePheromones {
+		saved := (estimate.DistanceMatrix + estimate.Pheromones) * int64(colonies)
+		hints = append(hints, fmt.Sprintf("-sparse-pheromones saves %s", formatBytes(saved)))
+	}
+	if cfg.Candidates > 0 {
+		hints = append(hints, fmt.Sprintf("dropping -candidates saves %s", formatBytes(estimate.CandidateLists*int64(colonies))))
+	}
+	if colonies > 1 {
+		hints = append(hints, "fewer -restarts run fewer colonies at once")
+	}
+	message := fmt.Sprintf("%d cities need about %s, more than the %s available", cities, formatBytes(need), formatBytes(limit))
+	if len(hints) > 0 {
+		message += "; " + strings.Join(hints, ", ")
+	}
+	if estimate.DistanceMatrix > 0 {
+		message += fmt.Sprintf(" (the distance matrix alone takes %s)", formatBytes(estimate.DistanceMatrix))
+	}
+	return errors.New(message)
+}
+
+// checkMatrixMemory reports an error, before a loader allocates a distance
+// matrix between that many cities, if it would take more memory than is
+// available. Config.MemoryLimit plays no part, as a matrix that does not fit
+// cannot be loaded at all
+func checkMatrixMemory(cities int) error {
+	available, ok := availableMemory()
+	if !ok {
+		return nil
+	}
+	if need := matrixBytes(cities); need > available {
+		return fmt.Errorf("a distance matrix of %d cities needs about %s, more than the %s available", cities, formatBytes(need), formatBytes(available))
+	}
+	return nil
+}
+
+// formatBytes writes a number of bytes in binary units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, prefix := float64(n)/unit, 0
+	for value >= unit && prefix < 5 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTPE"[prefix])
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Metrics gathers the progress of the runs a server makes and exposes it in
+// the Prometheus text format. Counters cover every run since the server
+// started; gauges describe the runs still going, labelled by run. A nil
+// *Metrics records nothing, so servers can call it unconditionally
+type Metrics struct {
+	mu           sync.Mutex
+	iterations   int64
+	improvements int64
+	finished     int64
+	timings      Timings
+	runs         map[string]*runMetrics
+}
+
+// runMetrics is what Metrics keeps about a run in progress
+type runMetrics struct {
+	best, entropy float64
+	workers       int
+	timings       Timings
+}
+
+// NewMetrics returns empty metrics
+func NewMetrics() *Metrics {
+	return &Metrics{runs: make(map[string]*runMetrics)}
+}
+
+// Observe records an iteration the solver of run has just made
+func (m *Metrics) Observe(run string, solver *Solver, stats IterationStats) {
+	if m == nil {
+		return
+	}
+	timings := solver.Timings()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.runs[run]
+	if !ok {
+		r = &runMetrics{best: math.Inf(1)}
+		m.runs[run] = r
+	}
+	m.iterations++
+	if stats.BestSoFar < r.best {
+		m.improvements++
+	}
+	// Add what the run spent since its last iteration to the totals
+	m.timings.Construction += timings.Construction - r.timings.Construction
+	m.timings.Update += timings.Update - r.timings.Update
+	m.timings.LocalSearch += timings.LocalSearch - r.timings.LocalSearch
+	m.timings.Total += timings.Total - r.timings.Total
+	m.timings.WorkerBusy += timings.WorkerBusy - r.timings.WorkerBusy
+	r.best, r.entropy, r.workers, r.timings = stats.BestSoFar, stats.Diversity, max(solver.Config.Workers, 1), timings
+}
+
+// Finish forgets the gauges of a run that has ended
+func (m *Metrics) Finish(run string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.runs[run]; ok {
+		delete(m.runs, run)
+		m.finished++
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+// Iterations per second are rate(aco_iterations_total[1m]), and worker
+// utilization is rate(aco_worker_busy_seconds_total) divided by
+// rate(aco_construction_seconds_total) times aco_workers
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	value := func(name, labels string, v float64) {
+		fmt.Fprintf(bw, "%s%s %s\n", name, labels, strconv.FormatFloat(v, 'g', -1, 64))
+	}
+
+	metric("aco_iterations_total", "counter", "Iterations completed by all runs.")
+	value("aco_iterations_total", "", float64(m.iterations))
+	metric("aco_improvements_total", "counter", "Iterations that shortened their run's best tour.")
+	value("aco_improvements_total", "", float64(m.improvements))
+	metric("aco_runs_finished_total", "counter", "Runs that have ended.")
+	value("aco_runs_finished_total", "", float64(m.finished))
+	for _, c := range []struct {
+		name, help string
+		seconds    float64
+	}{
+		{"aco_iteration_seconds_total", "Time spent in iterations.", m.timings.Total.Seconds()},
+		{"aco_construction_seconds_total", "Time spent building tours.", m.timings.Construction.Seconds()},
+		{"aco_update_seconds_total", "Time spent updating trails.", m.timings.Update.Seconds()},
+		{"aco_local_search_seconds_total", "Time spent improving tours by local search.", m.timings.LocalSearch.Seconds()},
+		{"aco_worker_busy_seconds_total", "Time workers spent building tours, summed over workers.", m.timings.WorkerBusy.Seconds()},
+	} {
+		metric(c.name, "counter", c.help)
+		value(c.name, "", c.seconds)
+	}
+	metric("aco_runs_active", "gauge", "Runs in progress.")
+	value("aco_runs_active", "", float64(len(m.runs)))
+
+	ids := make([]string, 0, len(m.runs))
+	for id := range m.runs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, g := range []struct {
+		name, help string
+		get        func(*runMetrics) float64
+	}{
+		{"aco_best_length", "Length of the run's best tour so far.", func(r *runMetrics) float64 { return r.best }},
+		{"aco_pheromone_entropy", "Normalized entropy of the run's pheromone trails, 1 when uniform and 0 when converged.", func(r *runMetrics) float64 { return r.entropy }},
+		{"aco_workers", "Workers building the run's tours.", func(r *runMetrics) float64 { return float64(r.workers) }},
+	} {
+		metric(g.name, "gauge", g.help)
+		for _, id := range ids {
+			value(g.name, fmt.Sprintf("{run=%q}", id), g.get(m.runs[id]))
+		}
+	}
+	metric("go_goroutines", "gauge", "Number of goroutines that currently exist.")
+	value("go_goroutines", "", float64(runtime.NumGoroutine()))
+	bw.Flush()
+}
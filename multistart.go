@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MultiStart runs independent colonies on an instance at once, differing only
+// in their seed, and keeps the best: the simplest way to put spare cores to
+// use, as restarts from scratch escape the region one colony converged on
+type MultiStart struct {
+	Solvers []*Solver
+}
+
+// NewMultiStart prepares k solvers configured by cfg with consecutive seeds
+// from cfg.Seed, or from the current time if it is zero
+func NewMultiStart(ctx context.Context, inst *Instance, cfg Config, k int) (*MultiStart, error) {
+	first := cfg.Seed
+	if first == 0 {
+		first = time.Now().UnixNano()
+	}
+	// Each solver only checks its own memory against what the earlier ones left
+	if err := checkMemory(inst, cfg, k); err != nil {
+		return nil, err
+	}
+	m := &MultiStart{Solvers: make([]*Solver, k)}
+	for r := range m.Solvers {
+		config := cfg
+		config.Seed = first + int64(r)
+		solver, err := NewSolverContext(ctx, inst, config)
+		if err != nil {
+			return nil, err
+		}
+		m.Solvers[r] = solver
+	}
+	return m, nil
+}
+
+// RunContext runs every solver to the end of its iterations, all at once, or
+// until ctx is done, and returns the one with the shortest tour, with ctx's
+// error if it stopped them early
+func (m *MultiStart) RunContext(ctx context.Context) (*Solver, error) {
+	errs := make([]error, len(m.Solvers))
+	var wg sync.WaitGroup
+	for r, solver := range m.Solvers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[r] = solver.RunContext(ctx)
+		}()
+	}
+	wg.Wait()
+	best := m.Solvers[0]
+	for _, solver := range m.Solvers[1:] {
+		if solver.bestLength < best.bestLength {
+			best = solver
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return best, err
+		}
+	}
+	return best, nil
+}
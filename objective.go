@@ -0,0 +1,104 @@
+package main
+
+import "fmt"
+
+// Objective scores the tours of a colony in place of their length: the
+// solver keeps the tour of lowest score as its best, and the trails reward
+// tours by the inverse of their scores, which must therefore be positive.
+// The heuristic still guides ants by edge cost, so an objective far from the
+// length may want a heuristic of its own
+type Objective interface {
+	Score(ac *AntColony, tour []int) float64
+}
+
+// Score returns the score of a tour under the colony's Objective, its length
+// without one
+func (ac *AntColony) Score(tour []int) float64 {
+	if ac.Objective == nil {
+		return ac.TourLength(tour)
+	}
+	return ac.Objective.Score(ac, tour)
+}
+
+// score returns the score of a tour under objective, its length if it is nil
+func score(objective Objective, ac *AntColony, tour []int) float64 {
+	if objective == nil {
+		return ac.TourLength(tour)
+	}
+	return objective.Score(ac, tour)
+}
+
+// WeightedObjective scores a tour by a weighted sum of its length and of the
+// values its edges carry in Attributes, matrices over the cities such as
+// tolls or risks; Weights[k] weighs Attributes[k]
+type WeightedObjective struct {
+	Length     float64
+	Attributes [][][]float64
+	Weights    []float64
+}
+
+// Score returns the weighted sum, counting the closing edge of closed tours
+func (o *WeightedObjective) Score(ac *AntColony, tour []int) float64 {
+	total := 0.0
+	if o.Length != 0 {
+		total = o.Length * ac.TourLength(tour)
+	}
+	ac.forEachEdge(tour, func(i, j int) {
+		for k, attribute := range o.Attributes {
+			total += o.Weights[k] * attribute[i][j]
+		}
+	})
+	return total
+}
+
+// PenaltyObjective adds Penalty to the score of Base for every constraint
+// a tour breaks, which ants only do where the constraints leave them no
+// other city, so that such tours lose out to those keeping to them
+type PenaltyObjective struct {
+	Base    Objective
+	Penalty float64
+}
+
+// Score returns the penalized score
+func (o *PenaltyObjective) Score(ac *AntColony, tour []int) float64 {
+	return score(o.Base, ac, tour) + o.Penalty*float64(ac.Constraints.Violations(tour, ac.ReturnToStart))
+}
+
+// MaximizeObjective makes the colony seek the highest score of Base rather
+// than the lowest, scoring tours by its inverse, so that trails reward tours
+// in proportion to their score of Base
+type MaximizeObjective struct {
+	Base Objective
+}
+
+// Score returns the inverse of the score of Base
+func (o *MaximizeObjective) Score(ac *AntColony, tour []int) float64 {
+	return 1 / score(o.Base, ac, tour)
+}
+
+// configObjective returns the objective cfg asks for of the colony, nil for
+// the length. Edge weights and a time or cost model each replace the length,
+// so they are refused together, as Validate does, rather than one dropped
+func (ac *AntColony) configObjective(cfg Config) (Objective, error) {
+	var objective Objective
+	if cfg.EdgeWeights != "" {
+		weighted, err := ac.weightedObjective(cfg.EdgeWeights)
+		if err != nil {
+			return nil, err
+		}
+		objective = weighted
+	}
+	if cfg.CostModel == CostTime || cfg.CostModel == CostMoney {
+		if objective != nil {
+			return nil, fmt.Errorf("edge weights and cost model %s cannot be combined", cfg.CostModel)
+		}
+		objective = &VehicleObjective{Vehicle: ac.Vehicle, Model: cfg.CostModel}
+	}
+	if cfg.Penalty > 0 {
+		objective = &PenaltyObjective{Base: objective, Penalty: cfg.Penalty}
+	}
+	if cfg.Maximize {
+		objective = &MaximizeObjective{Base: objective}
+	}
+	return objective, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// KnownOptima maps TSPLIB instance names to their proven optimal tour lengths
+var KnownOptima = map[string]float64{
+	"burma14":   3323,
+	"ulysses16": 6859,
+	"gr17":      2085,
+	"ulysses22": 7013,
+	"gr24":      1272,
+	"fri26":     937,
+	"bays29":    2020,
+	"att48":     10628,
+	"eil51":     426,
+	"berlin52":  7542,
+	"st70":      675,
+	"eil76":     538,
+	"pr76":      108159,
+	"rat99":     1211,
+	"kroA100":   21282,
+	"kroB100":   22141,
+	"kroC100":   20749,
+	"kroD100":   21294,
+	"kroE100":   22068,
+	"rd100":     7910,
+	"eil101":    629,
+	"lin105":    14379,
+	"pr107":     44303,
+	"pr124":     59030,
+	"bier127":   118282,
+	"ch130":     6110,
+	"pr136":     96772,
+	"pr144":     58537,
+	"ch150":     6528,
+	"kroA150":   26524,
+	"kroB150":   26130,
+	"pr152":     73682,
+	"u159":      42080,
+	"rat195":    2323,
+	"d198":      15780,
+	"kroA200":   29368,
+	"kroB200":   29437,
+	"ts225":     126643,
+	"tsp225":    3916,
+	"pr226":     80369,
+	"gil262":    2378,
+	"pr264":     49135,
+	"a280":      2579,
+	"pr299":     48191,
+	"lin318":    42029,
+	"fl417":     11861,
+	"pr439":     107217,
+	"pcb442":    50778,
+	"d493":      35002,
+	"att532":    27686,
+	"u574":      36905,
+	"rat783":    8806,
+	"pr1002":    259045,
+}
+
+// Gap returns how far length is above optimum, as a percentage of the optimum
+func Gap(length, optimum float64) float64 {
+	return 100 * (length - optimum) / optimum
+}
+
+// TSPLIBTour is a tour read from a TSPLIB .tour or .opt.tour file, with cities numbered from zero
+type TSPLIBTour struct {
+	Name      string
+	Comment   string
+	Dimension int
+	Tour      []int
+}
+
+// LoadTSPLIBTour reads a TSPLIB tour from the file at path
+func LoadTSPLIBTour(path string) (*TSPLIBTour, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tour, err := ParseTSPLIBTour(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tour, nil
+}
+
+// ParseTSPLIBTour reads the TOUR_SECTION of a TSPLIB tour file, which lists
+// 1-based node ids terminated by -1
+func ParseTSPLIBTour(r io.Reader) (*TSPLIBTour, error) {
+	t := &TSPLIBTour{}
+	inSection := false
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if text == "EOF" {
+			break
+		}
+		if text == "TOUR_SECTION" {
+			inSection = true
+			continue
+		}
+		if !inSection {
+			key, value, ok := strings.Cut(text, ":")
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown keyword %q", line, text)
+			}
+			switch strings.TrimSpace(key) {
+			case "NAME":
+				t.Name = strings.TrimSpace(value)
+			case "COMMENT":
+				t.Comment = strings.TrimSpace(value)
+			case "DIMENSION":
+				n, err := strconv.Atoi(strings.TrimSpace(value))
+				if err != nil || n <= 0 {
+					return nil, fmt.Errorf("line %d: invalid DIMENSION %q", line, value)
+				}
+				t.Dimension = n
+			}
+			continue
+		}
+		for _, field := range strings.Fields(text) {
+			id, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: node id %q is not an integer", line, field)
+			}
+			if id == -1 {
+				inSection = false
+				break
+			}
+			if id < 1 {
+				return nil, fmt.Errorf("line %d: node id %d must be positive", line, id)
+			}
+			t.Tour = append(t.Tour, id-1)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(t.Tour) == 0 {
+		return nil, fmt.Errorf("missing TOUR_SECTION")
+	}
+	if t.Dimension > 0 && len(t.Tour) != t.Dimension {
+		return nil, fmt.Errorf("tour lists %d nodes, DIMENSION is %d", len(t.Tour), t.Dimension)
+	}
+	return t, nil
+}
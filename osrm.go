@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DistanceProvider supplies the travel cost between every pair of cities,
+// for instances whose real costs are not straight-line distances
+type DistanceProvider interface {
+	Matrix(cities []*City) ([][]float64, error)
+}
+
+// OSRMProvider fetches road travel times or distances from the table service
+// of an OSRM server, or of a compatible API such as the Mapbox Matrix API, for
+// cities holding longitude in X and latitude in Y. Unreachable pairs cost +Inf
+type OSRMProvider struct {
+	// BaseURL is the server address, such as http://router.project-osrm.org
+	// or https://api.mapbox.com/directions-matrix/v1/mapbox for Mapbox
+	BaseURL string
+	// Profile is the routing profile, "driving" if empty
+	Profile string
+	// Metric is "duration" in seconds (the default) or "distance" in metres
+	Metric string
+	// AccessToken is sent as the access_token parameter when set, as Mapbox requires
+	AccessToken string
+	// CacheDir, when set, keeps each fetched matrix in a file named after the
+	// request so that repeated runs on the same points make no requests
+	CacheDir string
+	Client   *http.Client
+}
+
+// osrmTable is the response of the table service
+type osrmTable struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Durations [][]*float64 `json:"durations"`
+	Distances [][]*float64 `json:"distances"`
+}
+
+// Matrix returns the cost of travelling between every pair of cities
+func (p *OSRMProvider) Matrix(cities []*City) ([][]float64, error) {
+	if err := checkGeographic(cities); err != nil {
+		return nil, err
+	}
+	metric := p.Metric
+	if metric == "" {
+		metric = "duration"
+	}
+	if metric != "duration" && metric != "distance" {
+		return nil, fmt.Errorf("osrm: unknown metric %q, want duration or distance", metric)
+	}
+	requestURL := p.tableURL(cities, metric)
+
+	var cachePath string
+	if p.CacheDir != "" {
+		sum := sha256.Sum256([]byte(requestURL))
+		cachePath = filepath.Join(p.CacheDir, "osrm-"+hex.EncodeToString(sum[:16])+".json")
+		if data, err := os.ReadFile(cachePath); err == nil {
+			return decodeOSRMTable(data, metric, len(cities))
+		}
+	}
+	data, err := p.fetch(requestURL)
+	if err != nil {
+		return nil, err
+	}
+	matrix, err := decodeOSRMTable(data, metric, len(cities))
+	if err != nil {
+		return nil, err
+	}
+	if cachePath != "" {
+		if err := os.MkdirAll(p.CacheDir, 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(cachePath, data, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return matrix, nil
+}
+
+// tableURL builds the table request for cities; the access token is added
+// when fetching so it stays out of cache file names
+func (p *OSRMProvider) tableURL(cities []*City, metric string) string {
+	profile := p.Profile
+	if profile == "" {
+		profile = "driving"
+	}
+	coordinates := make([]string, len(cities))
+	for i, c := range cities {
+		coordinates[i] = strconv.FormatFloat(c.X, 'f', 6, 64) + "," + strconv.FormatFloat(c.Y, 'f', 6, 64)
+	}
+	base := strings.TrimSuffix(p.BaseURL, "/")
+	if !strings.Contains(base, "directions-matrix") {
+		base += "/table/v1"
+	}
+	return fmt.Sprintf("%s/%s/%s?annotations=%s", base, profile, strings.Join(coordinates, ";"), metric)
+}
+
+// fetch performs the request and returns the response body
+func (p *OSRMProvider) fetch(requestURL string) ([]byte, error) {
+	if p.AccessToken != "" {
+		requestURL += "&access_token=" + url.QueryEscape(p.AccessToken)
+	}
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: time.Minute}
+	}
+	resp, err := client.Get(requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("osrm: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("osrm: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var table osrmTable
+		if json.Unmarshal(data, &table) == nil && table.Message != "" {
+			return nil, fmt.Errorf("osrm: %s: %s", resp.Status, table.Message)
+		}
+		return nil, fmt.Errorf("osrm: %s", resp.Status)
+	}
+	return data, nil
+}
+
+// decodeOSRMTable extracts the n×n matrix of metric from a table response
+func decodeOSRMTable(data []byte, metric string, n int) ([][]float64, error) {
+	var table osrmTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("osrm: %w", err)
+	}
+	if table.Code != "Ok" {
+		return nil, fmt.Errorf("osrm: %s: %s", table.Code, table.Message)
+	}
+	rows := table.Durations
+	if metric == "distance" {
+		rows = table.Distances
+	}
+	if len(rows) != n {
+		return nil, fmt.Errorf("osrm: table has %d rows for %d cities", len(rows), n)
+	}
+	matrix := make([][]float64, n)
+	for i, row := range rows {
+		if len(row) != n {
+			return nil, fmt.Errorf("osrm: table row %d has %d entries for %d cities", i, len(row), n)
+		}
+		matrix[i] = make([]float64, n)
+		for j, v := range row {
+			matrix[i][j] = math.Inf(1)
+			if v != nil {
+				matrix[i][j] = *v
+			}
+		}
+	}
+	return matrix, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import "slices"
+
+// ParetoSolution is a tour together with its value under every objective
+type ParetoSolution struct {
+	Tour   []int
+	Scores []float64
+}
+
+// dominates reports whether scores a are no worse than b in every objective and better in at least one
+func dominates(a, b []float64) bool {
+	better := false
+	for k := range a {
+		if a[k] > b[k] {
+			return false
+		}
+		if a[k] < b[k] {
+			better = true
+		}
+	}
+	return better
+}
+
+// ParetoArchive keeps the non-dominated solutions seen so far
+type ParetoArchive struct {
+	Solutions []ParetoSolution
+}
+
+// Add inserts s unless an archived solution dominates or equals it, evicting
+// the solutions s dominates, and reports whether s was kept
+func (pa *ParetoArchive) Add(s ParetoSolution) bool {
+	for _, other := range pa.Solutions {
+		if dominates(other.Scores, s.Scores) || slices.Equal(other.Scores, s.Scores) {
+			return false
+		}
+	}
+	kept := pa.Solutions[:0]
+	for _, other := range pa.Solutions {
+		if !dominates(s.Scores, other.Scores) {
+			kept = append(kept, other)
+		}
+	}
+	pa.Solutions = append(kept, s)
+	return true
+}
+
+// ObjectiveScores sums every objective's edge costs along tour, the closing
+// edge of closed tours included
+func (ac *AntColony) ObjectiveScores(tour []int) []float64 {
+	scores := make([]float64, len(ac.Objectives))
+	edges := len(tour) - 1
+	if ac.ReturnToStart {
+		edges = len(tour)
+	}
+	for k, objective := range ac.Objectives {
+		for i := 0; i < edges; i++ {
+			scores[k] += objective[tour[i]][tour[(i+1)%len(tour)]]
+		}
+	}
+	return scores
+}
+
+// RunMultiObjective optimizes all of the colony's Objectives at once and returns
+// the non-dominated tours found. Each ant weighs the objectives randomly when
+// choosing edges, and only tours not dominated within their iteration deposit
+// pheromone, sharing Q equally
+func (ac *AntColony) RunMultiObjective(iterations int) []ParetoSolution {
+	// Normalize objectives by their mean edge cost so the random weights are comparable
+	scales := make([]float64, len(ac.Objectives))
+	for k, objective := range ac.Objectives {
+		sum, count := 0.0, 0
+		for i := range objective {
+			for j := range objective[i] {
+				if i != j {
+					sum += objective[i][j]
+					count++
+				}
+			}
+		}
+		scales[k] = 1
+		if sum > 0 {
+			scales[k] = float64(count) / sum
+		}
+	}
+
+	archive := &ParetoArchive{}
+	for it := 0; it < iterations; it++ {
+		ants := ac.InitializeAnts()
+		for _, ant := range ants {
+			ant.Weights = make([]float64, len(ac.Objectives))
+			total := 0.0
+			for k := range ant.Weights {
+				ant.Weights[k] = ac.rng.Float64()
+				total += ant.Weights[k]
+			}
+			for k := range ant.Weights {
+				ant.Weights[k] = ant.Weights[k] / total * scales[k]
+			}
+		}
+		ac.AntsMove(ants)
+
+		front := &ParetoArchive{}
+		for _, ant := range ants {
+			front.Add(ParetoSolution{Tour: ant.Tour, Scores: ac.ObjectiveScores(ant.Tour)})
+		}
+		ac.evaporate()
+		for _, s := range front.Solutions {
+			ac.deposit(s.Tour, ac.Q/float64(len(front.Solutions)))
+			archive.Add(s)
+		}
+	}
+	return archive.Solutions
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// TestRunMultiObjective checks that the archive of a multi-objective run holds
+// valid tours scored right, none of which dominates another
+func TestRunMultiObjective(t *testing.T) {
+	g, err := NewGenerator(UniformCities, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := g.Instance(10)
+	// Tolls unrelated to the distance, so that the objectives conflict
+	rng := rand.New(rand.NewSource(1))
+	toll := make([][]float64, 10)
+	for i := range toll {
+		toll[i] = make([]float64, 10)
+		for j := range i {
+			toll[i][j] = float64(rng.Intn(100))
+			toll[j][i] = toll[i][j]
+		}
+	}
+	inst.Attributes = map[string][][]float64{"toll": toll}
+
+	config := DefaultConfig()
+	config.Seed = 2
+	solver, err := NewSolver(inst, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := solver.Colony.SetObjectives(LengthAttribute, "toll"); err != nil {
+		t.Fatal(err)
+	}
+	archive := solver.Colony.RunMultiObjective(30)
+	if len(archive) < 2 {
+		t.Fatalf("archive of %d tours, want a front of several for conflicting objectives", len(archive))
+	}
+	for i, a := range archive {
+		if err := inst.ValidateTour(a.Tour); err != nil {
+			t.Fatalf("tour %d: %v", i, err)
+		}
+		length := solver.Colony.TourLength(a.Tour)
+		if scores := solver.Colony.ObjectiveScores(a.Tour); !slices.Equal(scores, a.Scores) || math.Abs(scores[0]-length) > 1e-9*length {
+			t.Errorf("tour %d scored %v, of length %g and objectives %v", i, a.Scores, length, scores)
+		}
+		for j, b := range archive {
+			if i != j && (dominates(a.Scores, b.Scores) || slices.Equal(a.Scores, b.Scores)) {
+				t.Errorf("tour %d %v dominates or equals tour %d %v", i, a.Scores, j, b.Scores)
+			}
+		}
+	}
+}
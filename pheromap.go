@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+)
+
+// Pheromone rendering modes
+const (
+	// PheromoneTrails draws every edge over the city map, thicker and more
+	// opaque the more pheromone it carries
+	PheromoneTrails = "trails"
+	// PheromoneHeatmap draws the pheromone matrix as a grid of shaded cells
+	PheromoneHeatmap = "heatmap"
+)
+
+// pheromoneThreshold is the share of the strongest trail below which trails
+// are left out of overlays, which would otherwise draw every pair of cities
+const pheromoneThreshold = 0.02
+
+// RenderPheromones draws a pheromone matrix as a trail overlay over the
+// cities or as a heatmap. In a heatmap, rows and columns follow order, such
+// as the best tour, which shows a converged colony as a band along the
+// diagonal; nil keeps the cities' order
+func RenderPheromones(w io.Writer, cities []*City, pheromones [][]float64, order []int, mode string, options RenderOptions) error {
+	if options.Size == 0 {
+		options.Size = svgSize
+	}
+	if options.Size <= 2*svgMargin {
+		return fmt.Errorf("image size %d is too small", options.Size)
+	}
+	if len(pheromones) != len(cities) {
+		return fmt.Errorf("pheromone matrix has %d rows for %d cities", len(pheromones), len(cities))
+	}
+	strongest := strongestTrail(pheromones)
+	switch mode {
+	case "", PheromoneTrails:
+		return renderTrails(w, cities, pheromones, strongest, options)
+	case PheromoneHeatmap:
+		if order == nil {
+			order = make([]int, len(cities))
+			for i := range order {
+				order[i] = i
+			}
+		}
+		return renderHeatmap(w, pheromones, order, strongest, options)
+	}
+	return fmt.Errorf("unknown pheromone mode %q, want trails or heatmap", mode)
+}
+
+// strongestTrail returns the most pheromone on any edge, or 1 if there is none
+func strongestTrail(pheromones [][]float64) float64 {
+	strongest := 0.0
+	for i := range pheromones {
+		for j := range pheromones[i] {
+			if i != j {
+				strongest = math.Max(strongest, pheromones[i][j])
+			}
+		}
+	}
+	if strongest <= 0 {
+		return 1
+	}
+	return strongest
+}
+
+// trailEdge is an edge of a trail overlay with its share of the strongest trail
+type trailEdge struct {
+	i, j     int
+	strength float64
+}
+
+// trailEdges returns the edges carrying at least threshold of the strongest
+// trail, averaging the two directions, weakest first
+func trailEdges(pheromones [][]float64, strongest, threshold float64) []trailEdge {
+	var edges []trailEdge
+	for i := range pheromones {
+		for j := i + 1; j < len(pheromones); j++ {
+			if s := (pheromones[i][j] + pheromones[j][i]) / 2 / strongest; s >= threshold {
+				edges = append(edges, trailEdge{i, j, math.Min(s, 1)})
+			}
+		}
+	}
+	sort.Slice(edges, func(a, b int) bool { return edges[a].strength < edges[b].strength })
+	return edges
+}
+
+// renderTrails draws the overlay, weakest trails first so strong ones end up on top
+func renderTrails(w io.Writer, cities []*City, pheromones [][]float64, strongest float64, options RenderOptions) error {
+	threshold := options.Threshold
+	if threshold == 0 {
+		threshold = pheromoneThreshold
+	}
+	edges := trailEdges(pheromones, strongest, threshold)
+	project := newProjection(cities, options.Size)
+
+	if options.Format == "png" {
+		img := newCanvas(options.Size, options.Size)
+		for _, e := range edges {
+			x0, y0 := project.point(cities[e.i])
+			x1, y1 := project.point(cities[e.j])
+			blendLine(img, x0, y0, x1, y1, routeColor, e.strength)
+		}
+		for _, c := range cities {
+			x, y := project.point(c)
+			fillCircle(img, x, y, 3, cityColor)
+		}
+		return png.Encode(w, img)
+	}
+	if options.Format != "" && options.Format != "svg" {
+		return fmt.Errorf("unknown image format %q, want svg or png", options.Format)
+	}
+	bw := bufio.NewWriter(w)
+	size := options.Size
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", size, size, size, size)
+	fmt.Fprintln(bw, `<rect width="100%" height="100%" fill="white"/>`)
+	for _, e := range edges {
+		x0, y0 := project.point(cities[e.i])
+		x1, y1 := project.point(cities[e.j])
+		fmt.Fprintf(bw, "<line x1=\"%.2f\" y1=\"%.2f\" x2=\"%.2f\" y2=\"%.2f\" stroke=\"steelblue\" stroke-width=\"%.2f\" stroke-opacity=\"%.3f\"/>\n",
+			x0, y0, x1, y1, 0.5+3*e.strength, e.strength)
+	}
+	for _, c := range cities {
+		x, y := project.point(c)
+		fmt.Fprintf(bw, "<circle cx=\"%.2f\" cy=\"%.2f\" r=\"3\" fill=\"firebrick\"/>\n", x, y)
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// heatColor shades a cell from white for no pheromone to dark red for the strongest trail
+func heatColor(strength float64) color.RGBA {
+	s := math.Min(math.Max(strength, 0), 1)
+	return color.RGBA{uint8(255 - 100*s), uint8(255 * (1 - s)), uint8(255 * (1 - s)), 0xff}
+}
+
+// renderHeatmap draws the matrix with rows and columns in the given order
+func renderHeatmap(w io.Writer, pheromones [][]float64, order []int, strongest float64, options RenderOptions) error {
+	n := len(order)
+	cell := float64(options.Size-2*svgMargin) / float64(max(n, 1))
+	strength := func(r, c int) float64 {
+		if r == c {
+			return 0
+		}
+		return pheromones[order[r]][order[c]] / strongest
+	}
+	if options.Format == "png" {
+		img := newCanvas(options.Size, options.Size)
+		for py := svgMargin; py < options.Size-svgMargin; py++ {
+			r := min(int(float64(py-svgMargin)/cell), n-1)
+			for px := svgMargin; px < options.Size-svgMargin; px++ {
+				c := min(int(float64(px-svgMargin)/cell), n-1)
+				img.SetRGBA(px, py, heatColor(strength(r, c)))
+			}
+		}
+		return png.Encode(w, img)
+	}
+	if options.Format != "" && options.Format != "svg" {
+		return fmt.Errorf("unknown image format %q, want svg or png", options.Format)
+	}
+	bw := bufio.NewWriter(w)
+	size := options.Size
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" shape-rendering=\"crispEdges\">\n", size, size, size, size)
+	fmt.Fprintln(bw, `<rect width="100%" height="100%" fill="white"/>`)
+	for r := 0; r < n; r++ {
+		for c := 0; c < n; c++ {
+			// White cells are the background already
+			if s := strength(r, c); s > 0 {
+				col := heatColor(s)
+				fmt.Fprintf(bw, "<rect x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=\"#%02x%02x%02x\"/>\n",
+					svgMargin+float64(c)*cell, svgMargin+float64(r)*cell, cell, cell, col.R, col.G, col.B)
+			}
+		}
+	}
+	fmt.Fprintf(bw, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"none\" stroke=\"black\"/>\n",
+		svgMargin, svgMargin, size-2*svgMargin, size-2*svgMargin)
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// blendLine draws a line over the image with the given opacity
+func blendLine(img *image.RGBA, x0, y0, x1, y1 float64, c color.RGBA, opacity float64) {
+	steps := int(math.Ceil(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))))
+	for s := 0; s <= steps; s++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(s) / float64(steps)
+		}
+		x, y := int(math.Round(x0+t*(x1-x0))), int(math.Round(y0+t*(y1-y0)))
+		if !(image.Point{x, y}).In(img.Rect) {
+			continue
+		}
+		old := img.RGBAAt(x, y)
+		mix := func(a, b uint8) uint8 { return uint8(float64(a)*(1-opacity) + float64(b)*opacity) }
+		img.SetRGBA(x, y, color.RGBA{mix(old.R, c.R), mix(old.G, c.G), mix(old.B, c.B), 0xff})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Ways of placing the ants on their start cities at every iteration
+const (
+	// PlacementRandom starts every ant from a city drawn at random
+	PlacementRandom = "random"
+	// PlacementPerCity starts the ants from distinct cities, drawn in a
+	// random order, until every city has an ant, then from distinct cities again
+	PlacementPerCity = "per-city"
+	// PlacementGrid lays a grid of about as many cells as ants over the
+	// cities and starts each ant from a random city of another cell, so every
+	// region gets its share of ants
+	PlacementGrid = "grid"
+	// PlacementFixed starts every ant from Config.StartCity
+	PlacementFixed = "fixed"
+)
+
+// Placements lists the ways of placing ants
+var Placements = []string{PlacementRandom, PlacementPerCity, PlacementGrid, PlacementFixed}
+
+// checkPlacement reports an error for unknown placements; empty means PlacementRandom
+func checkPlacement(placement string) error {
+	if placement != "" && !slices.Contains(Placements, placement) {
+		return fmt.Errorf("unknown placement %q, want one of %v", placement, Placements)
+	}
+	return nil
+}
+
+// startCandidates lists the cities a tour may begin at under the colony's
+// constraints, those that may only end it among them only if none other may
+func (ac *AntColony) startCandidates() []int {
+	var starts, fallback []int
+	for i := range ac.Cities {
+		if ac.Constraints.Allows(nil, i) {
+			fallback = append(fallback, i)
+			// A city in the middle of a required chain cannot start an open tour
+			if len(ac.Constraints.requiredPartners(i)) <= 1 {
+				starts = append(starts, i)
+			}
+		}
+	}
+	if len(starts) == 0 {
+		return fallback
+	}
+	return starts
+}
+
+// checkStartCity reports an error if the colony places its ants on a fixed
+// city no tour may start from
+func (ac *AntColony) checkStartCity() error {
+	if ac.Placement != PlacementFixed {
+		return nil
+	}
+	if ac.StartCity < 0 || ac.StartCity >= len(ac.Cities) {
+		return fmt.Errorf("start city %d out of range [0, %d)", ac.StartCity, len(ac.Cities))
+	}
+	if !slices.Contains(ac.startCandidates(), ac.StartCity) {
+		return fmt.Errorf("start city %d cannot begin a tour under the constraints", ac.StartCity)
+	}
+	return nil
+}
+
+// placeAnts returns the start cities of the iteration's ants, nil for random
+// placement, whose draws InitializeAnts interleaves with the ants' seeds
+func (ac *AntColony) placeAnts() []int {
+	switch ac.Placement {
+	case PlacementPerCity:
+		candidates := ac.startCandidates()
+		starts := make([]int, 0, ac.NumAnts)
+		for len(starts) < ac.NumAnts {
+			for _, k := range ac.rng.Perm(len(candidates)) {
+				starts = append(starts, candidates[k])
+			}
+		}
+		return starts[:ac.NumAnts]
+	case PlacementGrid:
+		cells := ac.gridCells(ac.startCandidates())
+		order := ac.rng.Perm(len(cells))
+		starts := make([]int, ac.NumAnts)
+		for i := range starts {
+			cell := cells[order[i%len(cells)]]
+			starts[i] = cell[ac.rng.Intn(len(cell))]
+		}
+		return starts
+	case PlacementFixed:
+		starts := make([]int, ac.NumAnts)
+		for i := range starts {
+			starts[i] = ac.StartCity
+		}
+		return starts
+	}
+	return nil
+}
+
+// gridCells groups cities by the cell of a square grid of about as many
+// cells as ants over their bounding box, dropping empty cells
+func (ac *AntColony) gridCells(cities []int) [][]int {
+	side := int(math.Ceil(math.Sqrt(float64(ac.NumAnts))))
+	low := City{X: math.Inf(1), Y: math.Inf(1)}
+	high := City{X: math.Inf(-1), Y: math.Inf(-1)}
+	for _, i := range cities {
+		c := ac.Cities[i]
+		low.X, low.Y = min(low.X, c.X), min(low.Y, c.Y)
+		high.X, high.Y = max(high.X, c.X), max(high.Y, c.Y)
+	}
+	// cell returns the grid index of x in [from, to], all in one cell if the range is empty
+	cell := func(x, from, to float64) int {
+		if to <= from {
+			return 0
+		}
+		return min(int(float64(side)*(x-from)/(to-from)), side-1)
+	}
+	index := make(map[int]int)
+	var cells [][]int
+	for _, i := range cities {
+		c := ac.Cities[i]
+		key := cell(c.Y, low.Y, high.Y)*side + cell(c.X, low.X, high.X)
+		k, ok := index[key]
+		if !ok {
+			k = len(cells)
+			index[key] = k
+			cells = append(cells, nil)
+		}
+		cells[k] = append(cells[k], i)
+	}
+	return cells
+}
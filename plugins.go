@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Plugins extend the solver and the CLI by name without changes to this
+// code: a Go file added to the build, usually behind a build tag, registers
+// variants, heuristics or local searches from its init function, and they
+// are then selected like the built-in ones with -variant, -heuristic and
+// -search or the matching config fields. For example
+//
+//	//go:build myplugin
+//
+//	package main
+//
+//	func init() {
+//		RegisterHeuristic("sqrt", func(ac *AntColony, ant *Ant, from, to int) float64 {
+//			return 1 / math.Sqrt(ac.edgeCost(from, to, ant.Elapsed))
+//		})
+//	}
+//
+// built with go build -tags myplugin. Registration is not synchronized and
+// must be over before solvers start, which init functions guarantee.
+
+// VariantRule is the pheromone rule of a variant registered with RegisterVariant
+type VariantRule struct {
+	// InitialPheromone returns the level trails start at from the length of
+	// a nearest-neighbour tour; nil starts them where Ant System does
+	InitialPheromone func(ac *AntColony, length float64) float64
+	// UpdateTrails updates the trails after an iteration, as
+	// AntColony.UpdateTrails does for the built-in variants
+	UpdateTrails func(ac *AntColony, ants []*Ant, lengths []float64, best []int, bestLength float64)
+}
+
+// HeuristicFunc returns the desirability of the ant moving from city from to
+// city to, which ants weigh against the trail with beta
+type HeuristicFunc func(ac *AntColony, ant *Ant, from, to int) float64
+
+// LocalSearchFunc returns an improved copy of a tour built by the colony's ants
+type LocalSearchFunc func(ac *AntColony, tour []int) []int
+
+// Heuristics and local searches known by name, selected by Config.Heuristic and Config.Search
+const (
+	// DistanceHeuristic prefers cities in inverse proportion to the cost of reaching them
+	DistanceHeuristic = "distance"
+	// WeightedHeuristic prefers cities by the cost of reaching them weighted
+	// with edge attributes as Config.EdgeWeights asks
+	WeightedHeuristic = "weighted"
+	// TwoOptSearch is the 2-opt local search of AntColony.TwoOpt
+	TwoOptSearch = "2opt"
+	// OrOptSearch is the Or-opt local search of AntColony.OrOpt
+	OrOptSearch = "oropt"
+)
+
+// Heuristics lists the names of the heuristics, built-in and registered
+var Heuristics = []string{DistanceHeuristic, WeightedHeuristic}
+
+// LocalSearches lists the names of the local searches, built-in and registered
+var LocalSearches = []string{TwoOptSearch, OrOptSearch}
+
+var (
+	variantRules  = map[string]VariantRule{}
+	heuristics    = map[string]HeuristicFunc{WeightedHeuristic: weightedHeuristic}
+	localSearches = map[string]LocalSearchFunc{
+		TwoOptSearch: (*AntColony).TwoOpt,
+		OrOptSearch:  (*AntColony).OrOpt,
+	}
+)
+
+// RegisterVariant adds a variant using rule under name, which must be new.
+// Its ants build tours as Ant System's do
+func RegisterVariant(name string, rule VariantRule) {
+	if rule.UpdateTrails == nil {
+		panic(fmt.Sprintf("RegisterVariant %q: no UpdateTrails", name))
+	}
+	Variants = register(Variants, "variant", name)
+	variantRules[name] = rule
+}
+
+// RegisterHeuristic adds a heuristic under name, which must be new
+func RegisterHeuristic(name string, heuristic HeuristicFunc) {
+	if heuristic == nil {
+		panic(fmt.Sprintf("RegisterHeuristic %q: nil heuristic", name))
+	}
+	Heuristics = register(Heuristics, "heuristic", name)
+	heuristics[name] = heuristic
+}
+
+// RegisterLocalSearch adds a local search under name, which must be new
+func RegisterLocalSearch(name string, search LocalSearchFunc) {
+	if search == nil {
+		panic(fmt.Sprintf("RegisterLocalSearch %q: nil local search", name))
+	}
+	LocalSearches = register(LocalSearches, "local search", name)
+	localSearches[name] = search
+}
+
+// register appends name to the names of a kind of plugin, panicking if it
+// is empty or taken, since that is a mistake in the program itself
+func register(names []string, kind, name string) []string {
+	if name == "" {
+		panic(fmt.Sprintf("registering a %s without a name", kind))
+	}
+	if slices.Contains(names, name) {
+		panic(fmt.Sprintf("registering %s %q twice", kind, name))
+	}
+	return append(names, name)
+}
+
+// checkHeuristic reports an error for unknown heuristic names; empty means DistanceHeuristic
+func checkHeuristic(heuristic string) error {
+	if heuristic != "" && !slices.Contains(Heuristics, heuristic) {
+		return fmt.Errorf("unknown heuristic %q, want one of %v", heuristic, Heuristics)
+	}
+	return nil
+}
+
+// checkLocalSearch reports an error for unknown local search names; empty means TwoOptSearch
+func checkLocalSearch(search string) error {
+	if search != "" && !slices.Contains(LocalSearches, search) {
+		return fmt.Errorf("unknown local search %q, want one of %v", search, LocalSearches)
+	}
+	return nil
+}
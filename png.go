@@ -0,0 +1,117 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+// Colours of rendered images, matching the SVG renderer's
+var (
+	routeColor = color.RGBA{0x46, 0x82, 0xb4, 0xff} // steelblue
+	cityColor  = color.RGBA{0xb2, 0x22, 0x22, 0xff} // firebrick
+	labelColor = color.RGBA{0x00, 0x00, 0x00, 0xff}
+)
+
+// renderPNG draws the tour as a PNG image
+func renderPNG(w io.Writer, cities []*City, tour []int, options RenderOptions) error {
+	return png.Encode(w, drawTour(cities, tour, options))
+}
+
+// drawTour draws the cities and the tour through them on a new image
+func drawTour(cities []*City, tour []int, options RenderOptions) *image.RGBA {
+	img := newCanvas(options.Size, options.Size)
+	project := newProjection(cities, options.Size)
+	route := tourRoute(cities, tour, options.Closed)
+	for k := 1; k < len(route); k++ {
+		x0, y0 := project.point(route[k-1])
+		x1, y1 := project.point(route[k])
+		drawLine(img, x0, y0, x1, y1, routeColor)
+	}
+	labelled := options.labelled(cities)
+	for i, c := range cities {
+		x, y := project.point(c)
+		fillCircle(img, x, y, 3, cityColor)
+		if labelled {
+			drawText(img, int(x)+5, int(y)-12, c.Label(i), labelColor)
+		}
+	}
+	return img
+}
+
+// newCanvas returns a white image of the given size
+func newCanvas(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	return img
+}
+
+// drawLine draws a one pixel wide line, stepping along its longer axis
+func drawLine(img *image.RGBA, x0, y0, x1, y1 float64, c color.RGBA) {
+	steps := int(math.Ceil(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))))
+	for s := 0; s <= steps; s++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(s) / float64(steps)
+		}
+		img.SetRGBA(int(math.Round(x0+t*(x1-x0))), int(math.Round(y0+t*(y1-y0))), c)
+	}
+}
+
+// fillCircle draws a filled disc of radius r pixels centred on (x, y)
+func fillCircle(img *image.RGBA, x, y, r float64, c color.RGBA) {
+	for py := int(y - r); py <= int(y+r); py++ {
+		for px := int(x - r); px <= int(x+r); px++ {
+			if dx, dy := float64(px)-x, float64(py)-y; dx*dx+dy*dy <= r*r {
+				img.SetRGBA(px, py, c)
+			}
+		}
+	}
+}
+
+// glyphs is a 3×5 pixel font: each row of a glyph is three bits, the most
+// significant on the left. Lower case letters are drawn as upper case and
+// characters without a glyph as a blank
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7}, '1': {2, 6, 2, 2, 7}, '2': {7, 1, 7, 4, 7}, '3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1}, '5': {7, 4, 7, 1, 7}, '6': {7, 4, 7, 5, 7}, '7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7}, '9': {7, 5, 7, 1, 7},
+	'A': {2, 5, 7, 5, 5}, 'B': {6, 5, 6, 5, 6}, 'C': {3, 4, 4, 4, 3}, 'D': {6, 5, 5, 5, 6},
+	'E': {7, 4, 6, 4, 7}, 'F': {7, 4, 6, 4, 4}, 'G': {3, 4, 5, 5, 3}, 'H': {5, 5, 7, 5, 5},
+	'I': {7, 2, 2, 2, 7}, 'J': {1, 1, 1, 5, 2}, 'K': {5, 5, 6, 5, 5}, 'L': {4, 4, 4, 4, 7},
+	'M': {5, 7, 7, 5, 5}, 'N': {6, 5, 5, 5, 5}, 'O': {2, 5, 5, 5, 2}, 'P': {6, 5, 6, 4, 4},
+	'Q': {2, 5, 5, 6, 3}, 'R': {6, 5, 6, 5, 5}, 'S': {3, 4, 2, 1, 6}, 'T': {7, 2, 2, 2, 2},
+	'U': {5, 5, 5, 5, 7}, 'V': {5, 5, 5, 5, 2}, 'W': {5, 5, 7, 7, 5}, 'X': {5, 5, 2, 5, 5},
+	'Y': {5, 5, 2, 2, 2}, 'Z': {7, 1, 2, 4, 7},
+	'-': {0, 0, 7, 0, 0}, '_': {0, 0, 0, 0, 7}, '.': {0, 0, 0, 0, 2}, ',': {0, 0, 0, 2, 4},
+	':': {0, 2, 0, 2, 0}, '/': {1, 1, 2, 4, 4}, '%': {5, 1, 2, 4, 5}, '+': {0, 2, 7, 2, 0},
+	'(': {1, 2, 2, 2, 1}, ')': {4, 2, 2, 2, 4}, '=': {0, 7, 0, 7, 0},
+}
+
+// glyphScale is the size in pixels of a font pixel
+const glyphScale = 2
+
+// drawText writes text with its top left corner at (x, y) in the built-in font
+func drawText(img *image.RGBA, x, y int, text string, c color.RGBA) {
+	for _, r := range strings.ToUpper(text) {
+		glyph := glyphs[r]
+		for row, bits := range glyph {
+			for col := 0; col < 3; col++ {
+				if bits&(4>>col) == 0 {
+					continue
+				}
+				for dy := 0; dy < glyphScale; dy++ {
+					for dx := 0; dx < glyphScale; dx++ {
+						img.SetRGBA(x+col*glyphScale+dx, y+row*glyphScale+dy, c)
+					}
+				}
+			}
+		}
+		x += 4 * glyphScale
+	}
+}
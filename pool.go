@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Errors of SolverPool.Solve besides those of the solve itself
+var (
+	// ErrPoolFull is returned when the most solves the pool queues are
+	// already waiting
+	ErrPoolFull = errors.New("solver pool: queue is full")
+	// ErrPoolClosed is returned once the pool is closed, also to solves
+	// that were still waiting for a slot
+	ErrPoolClosed = errors.New("solver pool: closed")
+)
+
+// SolverPool runs the solves of many callers at once, such as the requests
+// of a web service embedding the solver, a bounded number of them at a time.
+// The others wait for a slot in the order they came, each bounded by its own
+// context, so a caller that gives up leaves the queue at once. It is safe
+// for concurrent use
+type SolverPool struct {
+	// Metrics, if set, records the progress of the solves as "pool/1",
+	// "pool/2", ...
+	Metrics *Metrics
+	// Events, if set, receives the events of the solves, named like their metrics
+	Events RunListener
+
+	size, maxQueue int
+
+	mu      sync.Mutex
+	running int
+	// waiting holds a channel per queued solve, oldest first, which receives
+	// nil when the solve gets a slot or ErrPoolClosed
+	waiting []chan error
+	closed  bool
+	lastID  int
+	stats   PoolStats
+}
+
+// PoolStats sums up the solves a SolverPool has made since it was created
+type PoolStats struct {
+	Size    int `json:"size"`
+	Running int `json:"running"`
+	Queued  int `json:"queued"`
+	// MaxQueued is the most solves that ever waited at once
+	MaxQueued int `json:"max_queued"`
+	// Submitted counts every call of Solve; Rejected those that found the
+	// pool full or closed, Abandoned those whose context was done before
+	// they got a slot
+	Submitted int `json:"submitted"`
+	Rejected  int `json:"rejected"`
+	Abandoned int `json:"abandoned"`
+	// Completed, Cancelled and Failed count the solves that ran to the end,
+	// were stopped by their context while running, or could not start, such
+	// as for an invalid configuration
+	Completed int `json:"completed"`
+	Cancelled int `json:"cancelled"`
+	Failed    int `json:"failed"`
+	// Evaluations is the number of tours the solves built, all together
+	Evaluations int `json:"evaluations"`
+	// WaitSeconds and SolveSeconds sum the time solves spent waiting for a
+	// slot and running
+	WaitSeconds  float64 `json:"wait_seconds"`
+	SolveSeconds float64 `json:"solve_seconds"`
+}
+
+// NewSolverPool returns a pool running up to size solves at once, one per
+// CPU if size is 0, with up to maxQueue more waiting, or any number if
+// maxQueue is 0
+func NewSolverPool(size, maxQueue int) *SolverPool {
+	if size <= 0 {
+		size = runtime.NumCPU()
+	}
+	return &SolverPool{size: size, maxQueue: max(maxQueue, 0)}
+}
+
+// Solve solves inst with cfg as soon as a slot is free and returns the best
+// tour found. ctx bounds both the wait and the solve: a solve whose ctx is
+// done while it waits returns ctx's error and no solution, and one whose ctx
+// is done while it runs returns the best tour so far with ctx's error
+func (p *SolverPool) Solve(ctx context.Context, inst *Instance, cfg Config, options ...SolverOption) (*Solution, error) {
+	queued := time.Now()
+	id, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer p.release()
+	started := time.Now()
+	solver, err := NewSolverContext(ctx, inst, cfg, options...)
+	if err != nil {
+		p.record(func(s *PoolStats) {
+			s.Failed++
+			s.WaitSeconds += started.Sub(queued).Seconds()
+		})
+		return nil, err
+	}
+	name := "pool/" + strconv.Itoa(id)
+	defer p.Metrics.Finish(name)
+	solver.Events, solver.EventRun = p.Events, name
+	solution, err := p.run(ctx, name, solver)
+	p.record(func(s *PoolStats) {
+		if err != nil {
+			s.Cancelled++
+		} else {
+			s.Completed++
+		}
+		s.Evaluations += solution.Evaluations
+		s.WaitSeconds += started.Sub(queued).Seconds()
+		s.SolveSeconds += time.Since(started).Seconds()
+	})
+	return solution, err
+}
+
+// run runs a solver to the end, or until ctx is done
+func (p *SolverPool) run(ctx context.Context, name string, solver *Solver) (*Solution, error) {
+	defer solver.TraceRun()()
+	for !solver.Done() {
+		stats, err := solver.StepContext(ctx)
+		if err != nil {
+			solver.publish(EventRunFinished, RunCancelled)
+			return solver.Solution(), err
+		}
+		p.Metrics.Observe(name, solver, stats)
+	}
+	return solver.Solution(), nil
+}
+
+// acquire waits for a slot and returns the number of the solve it is for
+func (p *SolverPool) acquire(ctx context.Context) (int, error) {
+	p.mu.Lock()
+	p.stats.Submitted++
+	switch {
+	case p.closed:
+		p.stats.Rejected++
+		p.mu.Unlock()
+		return 0, ErrPoolClosed
+	case p.running < p.size && len(p.waiting) == 0:
+		p.running++
+		p.lastID++
+		id := p.lastID
+		p.mu.Unlock()
+		return id, nil
+	case p.maxQueue > 0 && len(p.waiting) >= p.maxQueue:
+		p.stats.Rejected++
+		p.mu.Unlock()
+		return 0, ErrPoolFull
+	}
+	ready := make(chan error, 1)
+	p.waiting = append(p.waiting, ready)
+	p.stats.MaxQueued = max(p.stats.MaxQueued, len(p.waiting))
+	p.mu.Unlock()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			return 0, err
+		}
+	case <-ctx.Done():
+		p.mu.Lock()
+		if k := slices.Index(p.waiting, ready); k >= 0 {
+			p.waiting = slices.Delete(p.waiting, k, k+1)
+			p.stats.Abandoned++
+			p.mu.Unlock()
+			return 0, ctx.Err()
+		}
+		p.mu.Unlock()
+		// A slot came at the same time, or the pool closed
+		if err := <-ready; err != nil {
+			return 0, err
+		}
+		p.release()
+		p.record(func(s *PoolStats) { s.Abandoned++ })
+		return 0, ctx.Err()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastID++
+	return p.lastID, nil
+}
+
+// release hands the slot of a finished solve to the oldest waiting one, or
+// frees it
+func (p *SolverPool) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.waiting) > 0 {
+		ready := p.waiting[0]
+		p.waiting = p.waiting[1:]
+		ready <- nil
+		return
+	}
+	p.running--
+}
+
+// record updates the pool's statistics
+func (p *SolverPool) record(update func(*PoolStats)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	update(&p.stats)
+}
+
+// Stats returns the pool's statistics so far
+func (p *SolverPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Size, stats.Running, stats.Queued = p.size, p.running, len(p.waiting)
+	return stats
+}
+
+// Close makes the solves still waiting, and any made from now on, fail with
+// ErrPoolClosed. Solves already running go on; their callers' contexts stop
+// them
+func (p *SolverPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, ready := range p.waiting {
+		ready <- ErrPoolClosed
+	}
+	p.stats.Rejected += len(p.waiting)
+	p.waiting = nil
+}
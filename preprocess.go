@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// Ways of rounding the distances of a preprocessed instance
+const (
+	RoundNone    = "none"
+	RoundNearest = "nint"
+	RoundCeil    = "ceil"
+	RoundFloor   = "floor"
+)
+
+// RoundingModes lists the ways of rounding preprocessed distances
+var RoundingModes = []string{RoundNone, RoundNearest, RoundCeil, RoundFloor}
+
+// checkRoundingMode reports an error for unknown rounding modes; empty means RoundNone
+func checkRoundingMode(mode string) error {
+	if mode != "" && !slices.Contains(RoundingModes, mode) {
+		return fmt.Errorf("unknown rounding %q, want one of %v", mode, RoundingModes)
+	}
+	return nil
+}
+
+// earthRadius is the mean radius of the Earth in kilometres
+const earthRadius = 6371.0088
+
+// Preprocessing configures the transformations applied to raw input before
+// solving, in the order of the fields. Duplicate cities are merged afterwards
+// by the -duplicates handling of solve
+type Preprocessing struct {
+	// Project maps longitude and latitude to kilometres east and north of the
+	// cities' centre, by an equirectangular projection, so that Euclidean
+	// distances approximate those on the ground for regional instances
+	Project bool
+	// Normalize moves the cities' lower corner to the origin and shrinks
+	// them into the unit cube
+	Normalize bool
+	// Scale multiplies the coordinates, 0 or 1 for none
+	Scale float64
+	// Round rounds the distances to whole numbers, one of RoundingModes
+	Round string
+}
+
+// CoordinateMap is the map a preprocessing step applied to the coordinates of
+// every city: x becomes (x - OffsetX) * ScaleX, and likewise y and z
+type CoordinateMap struct {
+	OffsetX float64 `json:"offset_x"`
+	OffsetY float64 `json:"offset_y"`
+	OffsetZ float64 `json:"offset_z"`
+	ScaleX  float64 `json:"scale_x"`
+	ScaleY  float64 `json:"scale_y"`
+	ScaleZ  float64 `json:"scale_z"`
+}
+
+// apply maps the coordinates of c in place
+func (m CoordinateMap) apply(c *City) {
+	c.X = (c.X - m.OffsetX) * m.ScaleX
+	c.Y = (c.Y - m.OffsetY) * m.ScaleY
+	c.Z = (c.Z - m.OffsetZ) * m.ScaleZ
+}
+
+// Restore returns the coordinates c had before the map
+func (m CoordinateMap) Restore(c City) City {
+	c.X = c.X/m.ScaleX + m.OffsetX
+	c.Y = c.Y/m.ScaleY + m.OffsetY
+	c.Z = c.Z/m.ScaleZ + m.OffsetZ
+	return c
+}
+
+// PreprocessStep records a transformation of the input in a run report, with
+// what it takes to map results back to the original data
+type PreprocessStep struct {
+	// Step is project, normalize, scale, round or merge
+	Step string `json:"step"`
+	// Map is the map of the coordinates of the project, normalize and scale
+	// steps. The latter two scale a distance matrix, and so tour lengths, by
+	// ScaleX as well
+	Map *CoordinateMap `json:"map,omitempty"`
+	// Rounding is the rounding mode of the round step
+	Rounding string `json:"rounding,omitempty"`
+	// Groups are the original cities of each city of the merge step, the
+	// first of each group standing for it
+	Groups [][]int `json:"groups,omitempty"`
+}
+
+// RestoreCity maps a city of a preprocessed instance back to the original
+// coordinates by undoing the coordinate maps of steps, last first
+func RestoreCity(steps []PreprocessStep, c City) City {
+	for _, step := range slices.Backward(steps) {
+		if step.Map != nil {
+			c = step.Map.Restore(c)
+		}
+	}
+	return c
+}
+
+// Preprocess returns a copy of an instance transformed as p asks, and the
+// steps it took, none if p asks for nothing. City indices stay the same, so
+// tours of the copy are tours of the original
+func Preprocess(inst *Instance, p Preprocessing) (*Instance, []PreprocessStep, error) {
+	if err := checkRoundingMode(p.Round); err != nil {
+		return nil, nil, err
+	}
+	if p.Scale < 0 || math.IsNaN(p.Scale) || math.IsInf(p.Scale, 0) {
+		return nil, nil, fmt.Errorf("coordinate scale %g must be positive", p.Scale)
+	}
+	prepared := *inst
+	prepared.Cities = make([]*City, len(inst.Cities))
+	for i, c := range inst.Cities {
+		city := *c
+		prepared.Cities[i] = &city
+	}
+	// Distances by an edge weight type follow from the coordinates as they
+	// were read, so they are kept as a matrix before any step changes them
+	transforms := p.Project || p.Normalize || p.Scale != 0 && p.Scale != 1 || p.Round != "" && p.Round != RoundNone
+	if transforms && prepared.EdgeWeightType != "" {
+		start := time.Now()
+		prepared.Distances, prepared.EdgeWeightType = instanceDistances(inst), ""
+		prepared.matrixTime = time.Since(start)
+	}
+	var steps []PreprocessStep
+	// transform applies a coordinate map, scaling a distance matrix by
+	// distanceScale unless it is 1
+	transform := func(step string, m CoordinateMap, distanceScale float64) {
+		for _, c := range prepared.Cities {
+			m.apply(c)
+		}
+		if prepared.Distances != nil && distanceScale != 1 {
+			prepared.Distances = mapDistances(prepared.Distances, func(d float64) float64 { return d * distanceScale })
+		}
+		// The coordinates hold longitude and latitude no longer
+		prepared.Geographic = false
+		steps = append(steps, PreprocessStep{Step: step, Map: &m})
+	}
+
+	if p.Project {
+		if !inst.Geographic {
+			return nil, nil, fmt.Errorf("projection needs cities with latitude and longitude")
+		}
+		if err := checkGeographic(inst.Cities); err != nil {
+			return nil, nil, err
+		}
+		low, high := cityBounds(prepared.Cities)
+		lon, lat := (low.X+high.X)/2, (low.Y+high.Y)/2
+		perDegree := earthRadius * math.Pi / 180
+		transform("project", CoordinateMap{
+			OffsetX: lon, OffsetY: lat,
+			ScaleX: perDegree * math.Cos(lat*math.Pi/180), ScaleY: perDegree, ScaleZ: 1,
+		}, 1)
+	}
+	if p.Normalize {
+		low, high := cityBounds(prepared.Cities)
+		extent := max(high.X-low.X, high.Y-low.Y, high.Z-low.Z)
+		scale := 1.0
+		if extent > 0 {
+			scale = 1 / extent
+		}
+		transform("normalize", CoordinateMap{OffsetX: low.X, OffsetY: low.Y, OffsetZ: low.Z, ScaleX: scale, ScaleY: scale, ScaleZ: scale}, scale)
+	}
+	if p.Scale != 0 && p.Scale != 1 {
+		transform("scale", CoordinateMap{ScaleX: p.Scale, ScaleY: p.Scale, ScaleZ: p.Scale}, p.Scale)
+	}
+	if p.Round != "" && p.Round != RoundNone {
+		round := map[string]func(float64) float64{RoundNearest: nint, RoundCeil: math.Ceil, RoundFloor: math.Floor}[p.Round]
+		if prepared.Distances == nil {
+			// Rounded distances no longer follow from the coordinates
+			prepared.Distances = make([][]float64, len(prepared.Cities))
+			for i, a := range prepared.Cities {
+				prepared.Distances[i] = make([]float64, len(prepared.Cities))
+				for j, b := range prepared.Cities {
+					prepared.Distances[i][j] = round(a.Distance(b))
+				}
+			}
+		} else {
+			prepared.Distances = mapDistances(prepared.Distances, round)
+		}
+		steps = append(steps, PreprocessStep{Step: "round", Rounding: p.Round})
+	}
+	if len(steps) == 0 {
+		return inst, nil, nil
+	}
+	return &prepared, steps, nil
+}
+
+// cityBounds returns the lower and upper corners of the box holding cities
+func cityBounds(cities []*City) (low, high City) {
+	low = City{X: math.Inf(1), Y: math.Inf(1), Z: math.Inf(1)}
+	high = City{X: math.Inf(-1), Y: math.Inf(-1), Z: math.Inf(-1)}
+	for _, c := range cities {
+		low.X, low.Y, low.Z = min(low.X, c.X), min(low.Y, c.Y), min(low.Z, c.Z)
+		high.X, high.Y, high.Z = max(high.X, c.X), max(high.Y, c.Y), max(high.Z, c.Z)
+	}
+	return low, high
+}
+
+// mapDistances returns a copy of a distance matrix with f applied to every entry
+func mapDistances(distances [][]float64, f func(float64) float64) [][]float64 {
+	mapped := make([][]float64, len(distances))
+	for i, row := range distances {
+		mapped[i] = make([]float64, len(row))
+		for j, d := range row {
+			mapped[i][j] = f(d)
+		}
+	}
+	return mapped
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// initialPheromone is the trail level every pheromone entry of a ProblemColony starts with
+const initialPheromone = 1.0
+
+// Move is a single construction step: the pheromone entry it reads and reinforces,
+// and the problem-specific heuristic desirability of taking it
+type Move struct {
+	Row       int
+	Col       int
+	Heuristic float64
+	// Option tells apart moves that share a pheromone entry, such as parallel
+	// arcs, for Apply; problems with one move per entry leave it 0
+	Option int
+}
+
+// Problem describes a combinatorial problem that ants solve by extending a
+// partial solution one move at a time
+type Problem interface {
+	// PheromoneShape returns the dimensions of the pheromone matrix
+	PheromoneShape() (rows, cols int)
+	// NewConstruction returns an empty partial solution for one ant
+	NewConstruction() Construction
+}
+
+// Construction is one ant's partial solution to a Problem
+type Construction interface {
+	// Moves lists the feasible extensions of the partial solution, none once it is complete
+	Moves() []Move
+	// Apply extends the partial solution with m
+	Apply(m Move)
+	// Cost returns the objective value to minimize, +Inf for an infeasible solution
+	Cost() float64
+	// Solution returns the finished solution in the problem's own encoding
+	Solution() []int
+}
+
+// ProblemColony runs the Ant System on any Problem
+type ProblemColony struct {
+	NumAnts    int
+	Alpha      float64
+	Beta       float64
+	Rho        float64
+	Q          float64
+	Problem    Problem
+	Pheromones [][]float64
+
+	rng *rand.Rand
+}
+
+// NewProblemColony initializes a new colony for problem
+func NewProblemColony(numAnts int, alpha, beta, rho, q float64, problem Problem) *ProblemColony {
+	rows, cols := problem.PheromoneShape()
+	colony := &ProblemColony{
+		NumAnts:    numAnts,
+		Alpha:      alpha,
+		Beta:       beta,
+		Rho:        rho,
+		Q:          q,
+		Problem:    problem,
+		Pheromones: make([][]float64, rows),
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for i := range colony.Pheromones {
+		colony.Pheromones[i] = make([]float64, cols)
+		for j := range colony.Pheromones[i] {
+			colony.Pheromones[i][j] = initialPheromone
+		}
+	}
+	return colony
+}
+
+// Seed makes the colony's random decisions reproducible
+func (pc *ProblemColony) Seed(seed int64) {
+	pc.rng = rand.New(rand.NewSource(seed))
+}
+
+// Construct builds one complete solution, returning it with the moves taken
+func (pc *ProblemColony) Construct() (Construction, []Move) {
+	construction := pc.Problem.NewConstruction()
+	var taken []Move
+	for {
+		moves := construction.Moves()
+		if len(moves) == 0 {
+			return construction, taken
+		}
+		weights := make([]float64, len(moves))
+		selectionWeights(weights, pc.Alpha, pc.Beta, func(k int) (float64, float64) {
+			return pc.Pheromones[moves[k].Row][moves[k].Col], moves[k].Heuristic
+		})
+		choice := rouletteSelect(pc.rng, weights)
+		if choice < 0 {
+			choice = bestWeighted(weights)
+		}
+		construction.Apply(moves[choice])
+		taken = append(taken, moves[choice])
+	}
+}
+
+// Iterate lets every ant construct a solution, updates the pheromones and
+// returns the best solution of the iteration
+func (pc *ProblemColony) Iterate() ([]int, float64) {
+	for i := range pc.Pheromones {
+		for j := range pc.Pheromones[i] {
+			pc.Pheromones[i][j] *= (1 - pc.Rho)
+		}
+	}
+	var best []int
+	bestCost := math.Inf(1)
+	// Every ant builds on the trails the iteration started with; deposits are
+	// laid once all have finished
+	costs := make([]float64, pc.NumAnts)
+	moves := make([][]Move, pc.NumAnts)
+	for a := range costs {
+		var construction Construction
+		construction, moves[a] = pc.Construct()
+		costs[a] = construction.Cost()
+		if costs[a] < bestCost {
+			bestCost = costs[a]
+			best = construction.Solution()
+		}
+	}
+	for a, cost := range costs {
+		if math.IsInf(cost, 1) || cost <= 0 {
+			continue
+		}
+		for _, m := range moves[a] {
+			pc.Pheromones[m.Row][m.Col] += pc.Q / cost
+		}
+	}
+	return best, bestCost
+}
+
+// Run performs the given number of iterations and returns the best solution found
+func (pc *ProblemColony) Run(iterations int) ([]int, float64) {
+	var best []int
+	bestCost := math.Inf(1)
+	for i := 0; i < iterations; i++ {
+		solution, cost := pc.Iterate()
+		if cost < bestCost {
+			bestCost = cost
+			best = solution
+		}
+	}
+	return best, bestCost
+}
+
+// solveProblem runs a colony with the parameters of config on problem, seeded
+// with config.Seed unless it is 0, and returns the best solution it finds, nil
+// if no ant found a feasible one
+func solveProblem(problem Problem, config Config) ([]int, float64, error) {
+	if err := config.Validate(); err != nil {
+		return nil, 0, err
+	}
+	colony := NewProblemColony(config.NumAnts, config.Alpha, config.Beta, config.Rho, config.Q, problem)
+	if config.Seed != 0 {
+		colony.Seed(config.Seed)
+	}
+	best, cost := colony.Run(config.Iterations)
+	return best, cost, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// progressWindow is the number of iterations the improvement rate is measured over
+const progressWindow = 100
+
+// ProgressBar redraws a single terminal line with the progress of a run: a
+// bar, the best length so far, how fast it is still improving, the pheromone
+// diversity and the estimated time left
+type ProgressBar struct {
+	w      io.Writer
+	total  int
+	width  int
+	start  time.Time
+	drawn  time.Time
+	recent []float64
+}
+
+// NewProgressBar starts a progress bar for a run of total iterations
+func NewProgressBar(w io.Writer, total int) *ProgressBar {
+	return &ProgressBar{w: w, total: total, width: 30, start: time.Now()}
+}
+
+// Update records an iteration's statistics and redraws the line, at most ten
+// times a second apart from the final iteration
+func (p *ProgressBar) Update(stats IterationStats) {
+	p.recent = append(p.recent, stats.BestSoFar)
+	if len(p.recent) > progressWindow+1 {
+		p.recent = p.recent[1:]
+	}
+	done := stats.Iteration + 1
+	now := time.Now()
+	if done < p.total && now.Sub(p.drawn) < 100*time.Millisecond {
+		return
+	}
+	p.drawn = now
+
+	fraction := float64(done) / float64(max(p.total, 1))
+	filled := min(int(fraction*float64(p.width)), p.width)
+	elapsed := now.Sub(p.start)
+	eta := time.Duration(float64(elapsed) / fraction * (1 - fraction))
+	fmt.Fprintf(p.w, "\r\033[K[%s%s] %3.0f%% %d/%d  best %.6g  improving %.2f%%/%d it  diversity %.3f  ETA %s",
+		strings.Repeat("#", filled), strings.Repeat(".", p.width-filled),
+		100*fraction, done, p.total, stats.BestSoFar, p.improvement(), progressWindow,
+		stats.Diversity, eta.Round(time.Second))
+}
+
+// improvement returns the percentage by which the best length fell over the
+// last progressWindow iterations, or as many as have run
+func (p *ProgressBar) improvement() float64 {
+	first, last := p.recent[0], p.recent[len(p.recent)-1]
+	if first <= 0 || first == last {
+		return 0
+	}
+	return 100 * (first - last) / first
+}
+
+// Done ends the progress line so later output starts on a fresh line
+func (p *ProgressBar) Done() {
+	fmt.Fprintln(p.w)
+}
+
+// LoadProgressLine returns a LoadProgressFunc redrawing a single terminal
+// line with how far loading an instance has come, ending the line once a
+// stage is done
+func LoadProgressLine(w io.Writer) LoadProgressFunc {
+	return func(p LoadProgress) {
+		done, total := fmt.Sprint(p.Done), fmt.Sprint(p.Total)
+		if p.Stage == LoadReading {
+			done, total = formatBytes(p.Done), formatBytes(p.Total)
+		}
+		if p.Total <= 0 {
+			fmt.Fprintf(w, "\r\033[K%s %s", p.Stage, done)
+			return
+		}
+		fmt.Fprintf(w, "\r\033[K%s %3.0f%% %s/%s", p.Stage, 100*float64(p.Done)/float64(p.Total), done, total)
+		if p.Done >= p.Total {
+			fmt.Fprintln(w)
+		}
+	}
+}
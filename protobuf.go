@@ -0,0 +1,867 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Protocol buffer wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+var errProtoTruncated = errors.New("protobuf: truncated message")
+
+// protoBuffer appends fields in the protocol buffer wire format. Scalars equal
+// to their zero value are omitted, as proto3 does
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) tag(field, wire int) {
+	b.data = binary.AppendUvarint(b.data, uint64(field)<<3|uint64(wire))
+}
+
+func (b *protoBuffer) int(field int, v int64) {
+	if v != 0 {
+		b.tag(field, protoVarint)
+		b.data = binary.AppendUvarint(b.data, uint64(v))
+	}
+}
+
+func (b *protoBuffer) bool(field int, v bool) {
+	if v {
+		b.int(field, 1)
+	}
+}
+
+func (b *protoBuffer) double(field int, v float64) {
+	if v != 0 {
+		b.tag(field, protoFixed64)
+		b.data = binary.LittleEndian.AppendUint64(b.data, math.Float64bits(v))
+	}
+}
+
+func (b *protoBuffer) string(field int, v string) {
+	if v != "" {
+		b.bytes(field, []byte(v))
+	}
+}
+
+func (b *protoBuffer) bytes(field int, v []byte) {
+	b.tag(field, protoBytes)
+	b.data = binary.AppendUvarint(b.data, uint64(len(v)))
+	b.data = append(b.data, v...)
+}
+
+// message writes the embedded message built by encode
+func (b *protoBuffer) message(field int, encode func(*protoBuffer)) {
+	var sub protoBuffer
+	encode(&sub)
+	b.bytes(field, sub.data)
+}
+
+func (b *protoBuffer) doubles(field int, vs []float64) {
+	if len(vs) == 0 {
+		return
+	}
+	packed := make([]byte, 0, 8*len(vs))
+	for _, v := range vs {
+		packed = binary.LittleEndian.AppendUint64(packed, math.Float64bits(v))
+	}
+	b.bytes(field, packed)
+}
+
+func (b *protoBuffer) ints(field int, vs []int) {
+	if len(vs) == 0 {
+		return
+	}
+	var packed []byte
+	for _, v := range vs {
+		packed = binary.AppendUvarint(packed, uint64(int64(v)))
+	}
+	b.bytes(field, packed)
+}
+
+func (b *protoBuffer) matrix(field int, rows [][]float64) {
+	for _, row := range rows {
+		b.message(field, func(b *protoBuffer) { b.doubles(1, row) })
+	}
+}
+
+// protoField is one decoded field; numeric values of every width are held in value
+type protoField struct {
+	num, wire int
+	value     uint64
+	data      []byte
+}
+
+func (f protoField) int() int        { return int(int64(f.value)) }
+func (f protoField) int64() int64    { return int64(f.value) }
+func (f protoField) bool() bool      { return f.value != 0 }
+func (f protoField) double() float64 { return math.Float64frombits(f.value) }
+func (f protoField) string() string  { return string(f.data) }
+
+// doubles returns a repeated double field, packed or not
+func (f protoField) doubles() ([]float64, error) {
+	if f.wire == protoFixed64 {
+		return []float64{f.double()}, nil
+	}
+	if f.wire != protoBytes || len(f.data)%8 != 0 {
+		return nil, fmt.Errorf("protobuf: field %d is not a packed double", f.num)
+	}
+	vs := make([]float64, len(f.data)/8)
+	for i := range vs {
+		vs[i] = math.Float64frombits(binary.LittleEndian.Uint64(f.data[8*i:]))
+	}
+	return vs, nil
+}
+
+// ints returns a repeated integer field, packed or not
+func (f protoField) ints() ([]int, error) {
+	if f.wire == protoVarint {
+		return []int{f.int()}, nil
+	}
+	var vs []int
+	for data := f.data; len(data) > 0; {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errProtoTruncated
+		}
+		vs = append(vs, int(int64(v)))
+		data = data[n:]
+	}
+	return vs, nil
+}
+
+// row decodes a Row message
+func (f protoField) row() ([]float64, error) {
+	var row []float64
+	err := protoDecode(f.data, func(f protoField) error {
+		if f.num != 1 {
+			return nil
+		}
+		vs, err := f.doubles()
+		row = append(row, vs...)
+		return err
+	})
+	return row, err
+}
+
+// protoDecode calls visit with each field of a message, skipping nothing so
+// visit can ignore field numbers it does not know
+func protoDecode(data []byte, visit func(protoField) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errProtoTruncated
+		}
+		data = data[n:]
+		f := protoField{num: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case protoVarint:
+			if f.value, n = binary.Uvarint(data); n <= 0 {
+				return errProtoTruncated
+			}
+			data = data[n:]
+		case protoFixed64:
+			if len(data) < 8 {
+				return errProtoTruncated
+			}
+			f.value, data = binary.LittleEndian.Uint64(data), data[8:]
+		case protoFixed32:
+			if len(data) < 4 {
+				return errProtoTruncated
+			}
+			f.value, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case protoBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return errProtoTruncated
+			}
+			f.data, data = data[n:n+int(length)], data[n+int(length):]
+		default:
+			return fmt.Errorf("protobuf: unsupported wire type %d", f.wire)
+		}
+		if err := visit(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeConfig(b *protoBuffer, c *Config) {
+	b.int(1, int64(c.NumAnts))
+	b.double(2, c.Alpha)
+	b.double(3, c.Beta)
+	b.double(4, c.Rho)
+	b.double(5, c.Q)
+	b.int(6, int64(c.Iterations))
+	b.int(7, c.Seed)
+	b.string(8, c.Variant)
+	b.double(9, c.Q0)
+	b.int(10, int64(c.Workers))
+	b.bool(11, c.LocalSearch)
+	b.bool(12, c.Adaptive)
+	b.int(13, int64(c.Candidates))
+	b.string(14, c.Heuristic)
+	b.string(15, c.Search)
+	b.int(16, int64(c.RestartStagnation))
+	b.double(17, c.RestartBranching)
+	b.double(18, c.RestartEntropy)
+	b.string(19, c.RestartAction)
+	b.int(20, int64(c.Alternatives))
+	b.string(21, c.SeedHeuristic)
+	b.int(22, int64(c.Evaluations))
+	b.bool(23, c.SparsePheromones)
+	b.string(24, c.Placement)
+	b.int(25, int64(c.StartCity))
+	b.string(26, c.Q0Schedule)
+	b.double(27, c.Q0Final)
+	b.double(28, c.CandidateFallbacks)
+	b.int(29, c.MemoryLimit)
+	b.int(30, int64(c.LengthCache))
+	b.double(31, c.Penalty)
+	b.bool(32, c.Maximize)
+	b.string(33, c.EdgeWeights)
+	b.int(34, int64(c.RestartArchive))
+	b.double(35, c.RestartStrength)
+	b.string(36, c.Castes)
+	b.double(37, c.TrailMin)
+	b.double(38, c.TrailMax)
+	b.string(39, c.Vehicle)
+	b.string(40, c.CostModel)
+	b.string(41, c.CostNoise)
+	b.string(42, c.RushHour)
+}
+
+func decodeConfig(data []byte) (*Config, error) {
+	c := &Config{}
+	return c, overlayConfig(data, c)
+}
+
+// overlayConfig sets the fields of c present in an aco.v1.Config message,
+// which proto3 leaves out when zero, and keeps the others
+func overlayConfig(data []byte, c *Config) error {
+	return protoDecode(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			c.NumAnts = f.int()
+		case 2:
+			c.Alpha = f.double()
+		case 3:
+			c.Beta = f.double()
+		case 4:
+			c.Rho = f.double()
+		case 5:
+			c.Q = f.double()
+		case 6:
+			c.Iterations = f.int()
+		case 7:
+			c.Seed = f.int64()
+		case 8:
+			c.Variant = f.string()
+		case 9:
+			c.Q0 = f.double()
+		case 10:
+			c.Workers = f.int()
+		case 11:
+			c.LocalSearch = f.bool()
+		case 12:
+			c.Adaptive = f.bool()
+		case 13:
+			c.Candidates = f.int()
+		case 14:
+			c.Heuristic = f.string()
+		case 15:
+			c.Search = f.string()
+		case 16:
+			c.RestartStagnation = f.int()
+		case 17:
+			c.RestartBranching = f.double()
+		case 18:
+			c.RestartEntropy = f.double()
+		case 19:
+			c.RestartAction = f.string()
+		case 20:
+			c.Alternatives = f.int()
+		case 21:
+			c.SeedHeuristic = f.string()
+		case 22:
+			c.Evaluations = f.int()
+		case 23:
+			c.SparsePheromones = f.bool()
+		case 24:
+			c.Placement = f.string()
+		case 25:
+			c.StartCity = f.int()
+		case 26:
+			c.Q0Schedule = f.string()
+		case 27:
+			c.Q0Final = f.double()
+		case 28:
+			c.CandidateFallbacks = f.double()
+		case 29:
+			c.MemoryLimit = f.int64()
+		case 30:
+			c.LengthCache = f.int()
+		case 31:
+			c.Penalty = f.double()
+		case 32:
+			c.Maximize = f.bool()
+		case 33:
+			c.EdgeWeights = f.string()
+		case 34:
+			c.RestartArchive = f.int()
+		case 35:
+			c.RestartStrength = f.double()
+		case 36:
+			c.Castes = f.string()
+		case 37:
+			c.TrailMin = f.double()
+		case 38:
+			c.TrailMax = f.double()
+		case 39:
+			c.Vehicle = f.string()
+		case 40:
+			c.CostModel = f.string()
+		case 41:
+			c.CostNoise = f.string()
+		case 42:
+			c.RushHour = f.string()
+		}
+		return nil
+	})
+}
+
+func encodeConstraints(b *protoBuffer, c *Constraints) {
+	for _, p := range c.Precedences {
+		b.message(1, func(b *protoBuffer) {
+			b.int(1, int64(p.Before))
+			b.int(2, int64(p.After))
+		})
+	}
+	edges := func(field int, edges [][2]int) {
+		for _, e := range edges {
+			b.message(field, func(b *protoBuffer) {
+				b.int(1, int64(e[0]))
+				b.int(2, int64(e[1]))
+			})
+		}
+	}
+	edges(2, c.ForbiddenEdges)
+	edges(3, c.RequiredEdges)
+	for _, cluster := range c.Clusters {
+		b.message(4, func(b *protoBuffer) { b.ints(1, cluster) })
+	}
+	for _, segment := range c.Segments {
+		b.message(5, func(b *protoBuffer) { b.ints(1, segment) })
+	}
+}
+
+func decodeConstraints(data []byte) (*Constraints, error) {
+	c := &Constraints{}
+	return c, protoDecode(data, func(f protoField) error {
+		switch f.num {
+		case 1, 2, 3:
+			var pair [2]int
+			if err := protoDecode(f.data, func(g protoField) error {
+				if g.num == 1 || g.num == 2 {
+					pair[g.num-1] = g.int()
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			switch f.num {
+			case 1:
+				c.AddPrecedence(pair[0], pair[1])
+			case 2:
+				c.AddForbiddenEdge(pair[0], pair[1])
+			case 3:
+				c.AddRequiredEdge(pair[0], pair[1])
+			}
+		case 4, 5:
+			var cities []int
+			if err := protoDecode(f.data, func(g protoField) error {
+				if g.num != 1 {
+					return nil
+				}
+				more, err := g.ints()
+				cities = append(cities, more...)
+				return err
+			}); err != nil {
+				return err
+			}
+			if f.num == 4 {
+				c.Clusters = append(c.Clusters, cities)
+			} else {
+				c.Segments = append(c.Segments, cities)
+			}
+		}
+		return nil
+	})
+}
+
+// encodeCity writes the fields of an aco.v1.City message
+func encodeCity(b *protoBuffer, c *City) {
+	b.double(1, c.X)
+	b.double(2, c.Y)
+	b.double(3, c.Z)
+	b.double(4, c.ServiceTime)
+	b.string(5, c.Name)
+	b.string(6, string(c.Metadata))
+}
+
+// decodeCity decodes an aco.v1.City message
+func decodeCity(data []byte) (*City, error) {
+	c := &City{}
+	err := protoDecode(data, func(g protoField) error {
+		switch g.num {
+		case 1:
+			c.X = g.double()
+		case 2:
+			c.Y = g.double()
+		case 3:
+			c.Z = g.double()
+		case 4:
+			c.ServiceTime = g.double()
+		case 5:
+			c.Name = g.string()
+		case 6:
+			c.Metadata = json.RawMessage(g.string())
+		}
+		return nil
+	})
+	return c, err
+}
+
+// MarshalProto encodes the instance as an aco.v1.Instance message
+func (inst *Instance) MarshalProto() []byte {
+	var b protoBuffer
+	b.string(1, inst.Name)
+	for _, c := range inst.Cities {
+		b.message(2, func(b *protoBuffer) { encodeCity(b, c) })
+	}
+	b.bool(3, inst.Geographic)
+	b.matrix(4, inst.Distances)
+	b.bool(5, inst.ReturnToStart)
+	if inst.Constraints != nil {
+		b.message(6, func(b *protoBuffer) { encodeConstraints(b, inst.Constraints) })
+	}
+	if inst.Options != nil {
+		b.message(7, func(b *protoBuffer) { encodeConfig(b, inst.Options) })
+	}
+	names := make([]string, 0, len(inst.Attributes))
+	for name := range inst.Attributes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		b.message(8, func(b *protoBuffer) {
+			b.string(1, name)
+			b.matrix(2, inst.Attributes[name])
+		})
+	}
+	b.string(9, inst.EdgeWeightType)
+	return b.data
+}
+
+// UnmarshalProto decodes an aco.v1.Instance message and validates it
+func (inst *Instance) UnmarshalProto(data []byte) error {
+	*inst = Instance{}
+	err := protoDecode(data, func(f protoField) error {
+		var err error
+		switch f.num {
+		case 1:
+			inst.Name = f.string()
+		case 2:
+			var c *City
+			c, err = decodeCity(f.data)
+			inst.Cities = append(inst.Cities, c)
+		case 3:
+			inst.Geographic = f.bool()
+		case 4:
+			var row []float64
+			row, err = f.row()
+			inst.Distances = append(inst.Distances, row)
+		case 5:
+			inst.ReturnToStart = f.bool()
+		case 6:
+			inst.Constraints, err = decodeConstraints(f.data)
+		case 7:
+			inst.Options, err = decodeConfig(f.data)
+		case 8:
+			var name string
+			var matrix [][]float64
+			err = protoDecode(f.data, func(g protoField) error {
+				switch g.num {
+				case 1:
+					name = g.string()
+				case 2:
+					row, err := g.row()
+					if err != nil {
+						return err
+					}
+					matrix = append(matrix, row)
+				}
+				return nil
+			})
+			if inst.Attributes == nil {
+				inst.Attributes = make(map[string][][]float64)
+			}
+			inst.Attributes[name] = matrix
+		case 9:
+			inst.EdgeWeightType = f.string()
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return inst.Validate()
+}
+
+// MarshalProto encodes the solution as an aco.v1.Solution message
+func (s *Solution) MarshalProto() []byte {
+	var b protoBuffer
+	b.ints(1, s.Tour)
+	b.double(2, s.Length)
+	for _, stats := range s.History {
+		b.message(3, func(b *protoBuffer) { encodeIterationStats(b, &stats) })
+	}
+	b.message(4, func(b *protoBuffer) { encodeConfig(b, &s.Config) })
+	b.int(5, s.Seed)
+	for _, restart := range s.Restarts {
+		b.message(6, func(b *protoBuffer) {
+			b.int(1, int64(restart.Iteration))
+			b.string(2, restart.Reason)
+			b.double(3, restart.Value)
+		})
+	}
+	for _, alternative := range s.Alternatives {
+		b.message(7, func(b *protoBuffer) {
+			b.ints(1, alternative.Tour)
+			b.double(2, alternative.Length)
+		})
+	}
+	for _, edge := range s.Backbone {
+		b.message(8, func(b *protoBuffer) {
+			b.int(1, int64(edge.From))
+			b.int(2, int64(edge.To))
+			b.double(3, edge.Frequency)
+		})
+	}
+	b.int(9, int64(s.Evaluations))
+	for _, reload := range s.Reloads {
+		b.message(10, func(b *protoBuffer) {
+			b.int(1, int64(reload.Iteration))
+			b.message(2, func(b *protoBuffer) { encodeConfig(b, &reload.Config) })
+		})
+	}
+	b.int(11, int64(s.Candidates))
+	b.double(12, s.Score)
+	for _, metadata := range s.Metadata {
+		if metadata == nil {
+			metadata = json.RawMessage("null")
+		}
+		b.bytes(13, metadata)
+	}
+	for _, route := range s.Routes {
+		b.message(14, func(b *protoBuffer) {
+			b.string(1, route.Vehicle)
+			b.int(2, int64(route.Stops))
+			b.double(3, route.Distance)
+			b.double(4, route.DrivingHours)
+			b.double(5, route.ServiceHours)
+			b.double(6, route.FixedCost)
+			b.double(7, route.DistanceCost)
+			b.double(8, route.TimeCost)
+			b.double(9, route.Total)
+		})
+	}
+	return b.data
+}
+
+func encodeIterationStats(b *protoBuffer, stats *IterationStats) {
+	b.int(1, int64(stats.Iteration))
+	b.double(2, stats.Best)
+	b.double(3, stats.Mean)
+	b.double(4, stats.Worst)
+	b.double(5, stats.BestSoFar)
+	b.double(6, stats.Diversity)
+	b.double(7, stats.Branching)
+	b.double(8, stats.Identical)
+	b.double(9, stats.Median)
+	b.double(10, stats.StdDev)
+	for _, edge := range stats.SharedEdges {
+		b.message(11, func(b *protoBuffer) {
+			b.int(1, int64(edge[0]))
+			b.int(2, int64(edge[1]))
+		})
+	}
+	b.int(12, int64(stats.Evaluations))
+	b.double(13, stats.Q0)
+	b.double(14, stats.CandidateFallbacks)
+	b.double(15, stats.TrailMin)
+	b.double(16, stats.TrailMax)
+	b.int(17, int64(stats.Clamped))
+}
+
+// UnmarshalProto decodes an aco.v1.Solution message
+func (s *Solution) UnmarshalProto(data []byte) error {
+	*s = Solution{}
+	return protoDecode(data, func(f protoField) error {
+		var err error
+		switch f.num {
+		case 1:
+			var tour []int
+			tour, err = f.ints()
+			s.Tour = append(s.Tour, tour...)
+		case 2:
+			s.Length = f.double()
+		case 3:
+			var stats IterationStats
+			err = protoDecode(f.data, func(g protoField) error {
+				switch g.num {
+				case 1:
+					stats.Iteration = g.int()
+				case 2:
+					stats.Best = g.double()
+				case 3:
+					stats.Mean = g.double()
+				case 4:
+					stats.Worst = g.double()
+				case 5:
+					stats.BestSoFar = g.double()
+				case 6:
+					stats.Diversity = g.double()
+				case 7:
+					stats.Branching = g.double()
+				case 8:
+					stats.Identical = g.double()
+				case 9:
+					stats.Median = g.double()
+				case 10:
+					stats.StdDev = g.double()
+				case 11:
+					var edge [2]int
+					if err := protoDecode(g.data, func(h protoField) error {
+						if h.num == 1 || h.num == 2 {
+							edge[h.num-1] = h.int()
+						}
+						return nil
+					}); err != nil {
+						return err
+					}
+					stats.SharedEdges = append(stats.SharedEdges, edge)
+				case 12:
+					stats.Evaluations = g.int()
+				case 13:
+					stats.Q0 = g.double()
+				case 14:
+					stats.CandidateFallbacks = g.double()
+				case 15:
+					stats.TrailMin = g.double()
+				case 16:
+					stats.TrailMax = g.double()
+				case 17:
+					stats.Clamped = g.int()
+				}
+				return nil
+			})
+			s.History = append(s.History, stats)
+		case 4:
+			var config *Config
+			if config, err = decodeConfig(f.data); err == nil {
+				s.Config = *config
+			}
+		case 5:
+			s.Seed = f.int64()
+		case 6:
+			var restart Restart
+			err = protoDecode(f.data, func(g protoField) error {
+				switch g.num {
+				case 1:
+					restart.Iteration = g.int()
+				case 2:
+					restart.Reason = g.string()
+				case 3:
+					restart.Value = g.double()
+				}
+				return nil
+			})
+			s.Restarts = append(s.Restarts, restart)
+		case 7:
+			var alternative AlternativeTour
+			err = protoDecode(f.data, func(g protoField) error {
+				switch g.num {
+				case 1:
+					tour, err := g.ints()
+					alternative.Tour = append(alternative.Tour, tour...)
+					return err
+				case 2:
+					alternative.Length = g.double()
+				}
+				return nil
+			})
+			s.Alternatives = append(s.Alternatives, alternative)
+		case 8:
+			var edge BackboneEdge
+			err = protoDecode(f.data, func(g protoField) error {
+				switch g.num {
+				case 1:
+					edge.From = g.int()
+				case 2:
+					edge.To = g.int()
+				case 3:
+					edge.Frequency = g.double()
+				}
+				return nil
+			})
+			s.Backbone = append(s.Backbone, edge)
+		case 9:
+			s.Evaluations = f.int()
+		case 10:
+			var reload Reload
+			err = protoDecode(f.data, func(g protoField) error {
+				switch g.num {
+				case 1:
+					reload.Iteration = g.int()
+				case 2:
+					config, err := decodeConfig(g.data)
+					if err != nil {
+						return err
+					}
+					reload.Config = *config
+				}
+				return nil
+			})
+			s.Reloads = append(s.Reloads, reload)
+		case 11:
+			s.Candidates = f.int()
+		case 12:
+			s.Score = f.double()
+		case 13:
+			s.Metadata = append(s.Metadata, json.RawMessage(f.string()))
+		case 14:
+			var route RouteCost
+			err = protoDecode(f.data, func(g protoField) error {
+				switch g.num {
+				case 1:
+					route.Vehicle = g.string()
+				case 2:
+					route.Stops = g.int()
+				case 3:
+					route.Distance = g.double()
+				case 4:
+					route.DrivingHours = g.double()
+				case 5:
+					route.ServiceHours = g.double()
+				case 6:
+					route.FixedCost = g.double()
+				case 7:
+					route.DistanceCost = g.double()
+				case 8:
+					route.TimeCost = g.double()
+				case 9:
+					route.Total = g.double()
+				}
+				return nil
+			})
+			s.Routes = append(s.Routes, route)
+		}
+		return err
+	})
+}
+
+// MarshalProto encodes the state as an aco.v1.ColonyState message
+func (cs *ColonyState) MarshalProto() []byte {
+	var b protoBuffer
+	b.message(1, func(b *protoBuffer) { encodeConfig(b, &cs.Config) })
+	b.int(2, cs.Seed)
+	b.int(3, int64(cs.Iteration))
+	b.matrix(4, cs.Pheromones)
+	b.matrix(5, cs.ClusterPheromones)
+	b.ints(6, cs.BestTour)
+	b.double(7, cs.BestLength)
+	for _, c := range cs.Cities {
+		b.message(8, func(b *protoBuffer) { encodeCity(b, c) })
+	}
+	if p := cs.Provenance; p != nil {
+		b.message(9, func(b *protoBuffer) {
+			b.string(1, p.Fingerprint)
+			b.string(2, p.Instance)
+			b.message(3, func(b *protoBuffer) { encodeConfig(b, &p.Config) })
+			b.string(4, p.Version)
+			b.string(5, p.Arithmetic)
+		})
+	}
+	return b.data
+}
+
+// UnmarshalProto decodes an aco.v1.ColonyState message
+func (cs *ColonyState) UnmarshalProto(data []byte) error {
+	*cs = ColonyState{}
+	return protoDecode(data, func(f protoField) error {
+		var err error
+		switch f.num {
+		case 1:
+			var config *Config
+			if config, err = decodeConfig(f.data); err == nil {
+				cs.Config = *config
+			}
+		case 2:
+			cs.Seed = f.int64()
+		case 3:
+			cs.Iteration = f.int()
+		case 4, 5:
+			var row []float64
+			if row, err = f.row(); f.num == 4 {
+				cs.Pheromones = append(cs.Pheromones, row)
+			} else {
+				cs.ClusterPheromones = append(cs.ClusterPheromones, row)
+			}
+		case 6:
+			var tour []int
+			tour, err = f.ints()
+			cs.BestTour = append(cs.BestTour, tour...)
+		case 7:
+			cs.BestLength = f.double()
+		case 8:
+			var c *City
+			c, err = decodeCity(f.data)
+			cs.Cities = append(cs.Cities, c)
+		case 9:
+			p := &Provenance{}
+			err = protoDecode(f.data, func(g protoField) error {
+				switch g.num {
+				case 1:
+					p.Fingerprint = g.string()
+				case 2:
+					p.Instance = g.string()
+				case 3:
+					config, err := decodeConfig(g.data)
+					if err != nil {
+						return err
+					}
+					p.Config = *config
+				case 4:
+					p.Version = g.string()
+				case 5:
+					p.Arithmetic = g.string()
+				}
+				return nil
+			})
+			cs.Provenance = p
+		}
+		return err
+	})
+}
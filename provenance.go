@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+)
+
+// Provenance records exactly how a run was produced: what it solved, with
+// which parameters and seed, by which build of the program on which
+// arithmetic. Runs of equal provenance produce the same results
+type Provenance struct {
+	// Fingerprint is a SHA-256 of the other fields, to tell at a glance
+	// whether two results were produced alike
+	Fingerprint string `json:"fingerprint"`
+	// Instance is the fingerprint of the instance solved
+	Instance string `json:"instance"`
+	// Config holds the parameters the run started with, its seed drawn from
+	// the clock if it had none; Solution.Reloads lists later changes
+	Config Config `json:"config"`
+	// Version is the module version of the program and the revision it was
+	// built from, with +dirty for uncommitted changes
+	Version string `json:"version"`
+	// Arithmetic describes the floating-point arithmetic of the build, on
+	// which lengths, and so the choices of ants, may depend in the last bits
+	Arithmetic string `json:"arithmetic"`
+}
+
+// Provenance returns the provenance of the solver's run
+func (s *Solver) Provenance() Provenance {
+	p := Provenance{
+		Instance:   s.instanceFingerprint(),
+		Config:     s.startConfig,
+		Version:    codeVersion(),
+		Arithmetic: arithmeticMode(),
+	}
+	p.Config.Seed = s.seed
+	// The protocol buffer encoding keeps every float exactly, NaN and
+	// infinities included, where JSON fails on them
+	var b protoBuffer
+	b.string(1, p.Instance)
+	b.message(2, func(b *protoBuffer) { encodeConfig(b, &p.Config) })
+	b.string(3, p.Version)
+	b.string(4, p.Arithmetic)
+	sum := sha256.Sum256(b.data)
+	p.Fingerprint = "sha256:" + hex.EncodeToString(sum[:])
+	return p
+}
+
+// codeVersion names the build of the program from the information Go
+// records in binaries built from a module, "unknown" without it
+func codeVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	if version == "" {
+		version = "(devel)"
+	}
+	settings := make(map[string]string)
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	// Pseudo-versions of modules fetched by revision already hold it
+	if revision := settings["vcs.revision"]; revision != "" && version == "(devel)" {
+		version += " " + revision[:min(12, len(revision))]
+		if settings["vcs.modified"] == "true" {
+			version += "+dirty"
+		}
+	}
+	return version
+}
+
+// fusingArchitectures are those on which Go fuses x*y + z into one rounding,
+// which amd64 does too from GOAMD64=v3 on
+var fusingArchitectures = []string{"arm64", "loong64", "ppc64", "ppc64le", "riscv64", "s390x"}
+
+// arithmeticMode describes the floating-point arithmetic of the build: the
+// architecture, its feature level where Go records one, and whether
+// multiply-adds are fused
+func arithmeticMode() string {
+	level := ""
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			// GOAMD64, GOARM64 and the like
+			if setting.Key == "GO"+strings.ToUpper(runtime.GOARCH) {
+				level = "/" + setting.Value
+			}
+		}
+	}
+	fused := slices.Contains(fusingArchitectures, runtime.GOARCH) || (runtime.GOARCH == "amd64" && level >= "/v3")
+	mode := "unfused"
+	if fused {
+		mode = "fused multiply-add"
+	}
+	return fmt.Sprintf("float64 %s%s, %s", runtime.GOARCH, level, mode)
+}
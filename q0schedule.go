@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Schedules of the Ant Colony System exploitation probability q0 over a run
+const (
+	// Q0Constant keeps q0 at Config.Q0 throughout
+	Q0Constant = "constant"
+	// Q0Linear moves q0 in equal steps from Config.Q0 at the first iteration
+	// to Config.Q0Final at the last
+	Q0Linear = "linear"
+	// Q0Exponential shrinks or grows the exploration probability 1 - q0 by
+	// the same factor every iteration, from Config.Q0 to Config.Q0Final, so
+	// that exploration ends slowly as q0 nears 1
+	Q0Exponential = "exponential"
+)
+
+// Q0Schedules lists the schedules of q0
+var Q0Schedules = []string{Q0Constant, Q0Linear, Q0Exponential}
+
+// checkQ0Schedule reports an error for unknown schedules of q0; empty means Q0Constant
+func checkQ0Schedule(schedule string) error {
+	if schedule != "" && !slices.Contains(Q0Schedules, schedule) {
+		return fmt.Errorf("unknown q0 schedule %q, want one of %v", schedule, Q0Schedules)
+	}
+	return nil
+}
+
+// scheduledQ0 returns the q0 of the 0-based iteration under the config's
+// schedule. Iterations beyond the config's limit, as continued runs take,
+// keep the final q0
+func (c *Config) scheduledQ0(iteration int) float64 {
+	last := c.iterationLimit() - 1
+	if c.Q0Schedule == "" || c.Q0Schedule == Q0Constant || last <= 0 {
+		return c.Q0
+	}
+	t := math.Min(float64(iteration)/float64(last), 1)
+	if c.Q0Schedule == Q0Exponential {
+		return 1 - (1-c.Q0)*math.Pow((1-c.Q0Final)/(1-c.Q0), t)
+	}
+	return c.Q0 + (c.Q0Final-c.Q0)*t
+}
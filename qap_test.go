@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// qap7 is a QAPLIB-format instance small enough to solve by enumeration
+const qap7 = `7
+
+ 0 3 0 2 5 0 1
+ 3 0 4 0 0 2 0
+ 0 4 0 6 1 0 3
+ 2 0 6 0 0 4 2
+ 5 0 1 0 0 3 0
+ 0 2 0 4 3 0 5
+ 1 0 3 2 0 5 0
+
+ 0 1 2 3 1 2 3
+ 1 0 1 2 2 1 2
+ 2 1 0 1 3 2 1
+ 3 2 1 0 4 3 2
+ 1 2 3 4 0 1 2
+ 2 1 2 3 1 0 1
+ 3 2 1 2 2 1 0
+`
+
+// qapGap is how far above the optimum TestQAPOptimum lets a run end
+const qapGap = 0.02
+
+// TestQAPOptimum checks that seeded colonies end within qapGap of the optimal
+// assignment of a QAPLIB instance, found by trying every permutation, and that
+// one of them reaches it
+func TestQAPOptimum(t *testing.T) {
+	qap, err := ParseQAPLIB(strings.NewReader(qap7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	optimum := bruteForceQAP(qap)
+
+	reached := false
+	for seed := int64(1); seed <= 5; seed++ {
+		config := DefaultConfig()
+		config.NumAnts, config.Iterations, config.Rho, config.Seed = 20, 200, 0.1, seed
+		assignment, cost, err := solveProblem(qap, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateTour(assignment, len(qap.Flow), nil); err != nil {
+			t.Fatalf("seed %d: assignment %v is not a permutation: %v", seed, assignment, err)
+		}
+		if got := qap.Cost(assignment); got != cost {
+			t.Errorf("seed %d: assignment %v costs %g, reported %g", seed, assignment, got, cost)
+		}
+		if cost > optimum*(1+qapGap) {
+			t.Errorf("seed %d: best cost %g, more than %g%% above the optimum %g", seed, cost, 100*qapGap, optimum)
+		}
+		reached = reached || cost == optimum
+	}
+	if !reached {
+		t.Errorf("no seed reached the optimum %g", optimum)
+	}
+}
+
+// TestParseQAPLIBErrors checks that malformed instances are refused
+func TestParseQAPLIBErrors(t *testing.T) {
+	for _, input := range []string{"", "2\n0 1\n1 0\n0 1\n", "2.5\n", "0\n", "2\n0 x\n1 0\n0 1\n1 0\n"} {
+		if _, err := ParseQAPLIB(strings.NewReader(input)); err == nil {
+			t.Errorf("%q: no error", input)
+		}
+	}
+}
+
+// bruteForceQAP returns the least cost of any assignment of qap
+func bruteForceQAP(qap *QAP) float64 {
+	n := len(qap.Flow)
+	assignment := make([]int, n)
+	for i := range assignment {
+		assignment[i] = i
+	}
+	best := qap.Cost(assignment)
+	var permute func(k int)
+	permute = func(k int) {
+		if k == n {
+			best = min(best, qap.Cost(assignment))
+			return
+		}
+		for i := k; i < n; i++ {
+			assignment[k], assignment[i] = assignment[i], assignment[k]
+			permute(k + 1)
+			assignment[k], assignment[i] = assignment[i], assignment[k]
+		}
+	}
+	permute(0)
+	return best
+}
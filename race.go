@@ -0,0 +1,218 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Race evaluates candidate configurations F-Race style: every surviving
+// candidate is run on one block, an instance and a seed, at a time, and once
+// FirstTest blocks are in, candidates whose ranks are significantly worse than
+// the best's are dropped after each block, so poor candidates stop consuming
+// runs early
+type Race struct {
+	Instances  []*Instance
+	Candidates []Config
+	// Seeds is the number of seeds per instance, bounding the race at
+	// Seeds×len(Instances) blocks; the first is Config.Seed of each candidate, or 1
+	Seeds int
+	// FirstTest is the number of blocks before candidates may be dropped
+	FirstTest int
+	// Significance is the level of the Friedman test, such as 0.05
+	Significance float64
+	// Parallel is the number of candidates run at once, one if zero
+	Parallel int
+	// Budget stops each run after the iteration that exhausts it, none if zero
+	Budget time.Duration
+}
+
+// RaceResult is the outcome of a race
+type RaceResult struct {
+	// Survivors holds the indices of the candidates still in the race at the end
+	Survivors []int
+	// Lengths holds each candidate's best length on each block it was run on,
+	// NaN for blocks after it was dropped
+	Lengths [][]float64
+	// Instance holds the index of each block's instance
+	Instance []int
+	// Runs counts the solver runs made
+	Runs int
+}
+
+// Run races the candidates
+func (r *Race) Run() (*RaceResult, error) {
+	result := &RaceResult{Lengths: make([][]float64, len(r.Candidates))}
+	alive := make([]int, len(r.Candidates))
+	for c := range alive {
+		alive[c] = c
+	}
+	for block := 0; block < r.Seeds*len(r.Instances) && len(alive) > 1; block++ {
+		k, seed := block%len(r.Instances), int64(block/len(r.Instances))
+		result.Instance = append(result.Instance, k)
+		lengths := make([]float64, len(r.Candidates))
+		for c := range lengths {
+			lengths[c] = math.NaN()
+		}
+		errs := make([]error, len(r.Candidates))
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < min(max(r.Parallel, 1), len(alive)); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for c := range jobs {
+					config := r.Candidates[c]
+					if config.Seed == 0 {
+						config.Seed = 1
+					}
+					config.Seed += seed
+					experiment := &Experiment{Instance: r.Instances[k], Config: config, Seeds: 1, Budget: r.Budget}
+					stats, err := experiment.Run()
+					if err != nil {
+						errs[c] = err
+						continue
+					}
+					lengths[c] = stats.Mean
+				}
+			}()
+		}
+		for _, c := range alive {
+			jobs <- c
+		}
+		close(jobs)
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+		for c := range r.Candidates {
+			result.Lengths[c] = append(result.Lengths[c], lengths[c])
+		}
+		result.Runs += len(alive)
+
+		// Every instance is seen before the first test, so survivors have a result on each
+		if block+1 >= max(r.FirstTest, len(r.Instances)) {
+			before := len(alive)
+			alive = r.eliminate(alive, result.Lengths)
+			slog.Debug("race block", "block", block+1, "survivors", len(alive), "dropped", before-len(alive))
+		}
+	}
+	result.Survivors = alive
+	return result, nil
+}
+
+// eliminate applies the Friedman test to the blocks all alive candidates ran
+// on and, if their ranks differ significantly, drops those whose rank sum
+// exceeds the best's by more than the critical difference
+func (r *Race) eliminate(alive []int, lengths [][]float64) []int {
+	k, b := float64(len(alive)), float64(len(lengths[alive[0]]))
+	rankSums := make([]float64, len(alive))
+	ranks := make([]float64, len(alive))
+	for block := range lengths[alive[0]] {
+		values := make([]float64, len(alive))
+		for i, c := range alive {
+			values[i] = lengths[c][block]
+		}
+		rankWithTies(values, ranks)
+		for i := range rankSums {
+			rankSums[i] += ranks[i]
+		}
+	}
+	statistic := -3 * b * (k + 1)
+	for _, sum := range rankSums {
+		statistic += 12 / (b * k * (k + 1)) * sum * sum
+	}
+	if chiSquareSurvival(statistic, k-1) >= r.Significance {
+		return alive
+	}
+	best := math.Inf(1)
+	for _, sum := range rankSums {
+		best = math.Min(best, sum)
+	}
+	// The rank sum difference of two candidates has standard deviation
+	// sqrt(b k (k+1) / 6) under the null hypothesis
+	critical := normalQuantile(1-r.Significance/2) * math.Sqrt(b*k*(k+1)/6)
+	var survivors []int
+	for i, c := range alive {
+		if rankSums[i]-best <= critical {
+			survivors = append(survivors, c)
+		}
+	}
+	return survivors
+}
+
+// rankWithTies stores the 1-based rank of each value in ranks, tied values sharing their mean rank
+func rankWithTies(values, ranks []float64) {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+	for i := 0; i < len(order); {
+		j := i
+		for j < len(order) && values[order[j]] == values[order[i]] {
+			j++
+		}
+		for m := i; m < j; m++ {
+			ranks[order[m]] = float64(i+j+1) / 2
+		}
+		i = j
+	}
+}
+
+// chiSquareSurvival returns the probability that a chi-square variable with
+// df degrees of freedom exceeds x
+func chiSquareSurvival(x, df float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	return 1 - regularizedGammaP(df/2, x/2)
+}
+
+// regularizedGammaP returns the regularized lower incomplete gamma function
+// P(a, x), by its series for small x and its continued fraction otherwise
+func regularizedGammaP(a, x float64) float64 {
+	lgamma, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a*math.Log(x) - lgamma)
+	if x < a+1 {
+		sum, term := 1/a, 1/a
+		for n := 1; n < 500 && math.Abs(term) > math.Abs(sum)*1e-15; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+		}
+		return sum * prefix
+	}
+	// Lentz's method for the continued fraction of Q(a, x)
+	const tiny = 1e-300
+	b := x + 1 - a
+	c, d := 1/tiny, 1/b
+	h := d
+	for n := 1; n < 500; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return 1 - prefix*h
+}
+
+// normalQuantile returns the p-quantile of the standard normal distribution
+func normalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
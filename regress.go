@@ -0,0 +1,161 @@
+package main
+
+import (
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"text/tabwriter"
+)
+
+// GoldenCase is a fixed-seed run on a built-in benchmark instance and the
+// length of the best tour it found when it was recorded
+type GoldenCase struct {
+	Instance string  `json:"instance"`
+	Config   Config  `json:"config"`
+	Length   float64 `json:"length"`
+}
+
+// GoldenFile holds the cases "regress" checks, and by how much, relative to
+// the recorded length, a run may fall short of its case before it regresses
+type GoldenFile struct {
+	Tolerance float64      `json:"tolerance"`
+	Cases     []GoldenCase `json:"cases"`
+}
+
+// goldenIterations is how long the runs of a new golden file are: long
+// enough for the variants to part ways, short enough to check in seconds
+const goldenIterations = 100
+
+// defaultGoldenCases returns the cases of a new golden file: every variant
+// with its default parameters and seed 1 on every instance of the classic
+// suite, lengths yet to be recorded
+func defaultGoldenCases() []GoldenCase {
+	var cases []GoldenCase
+	for _, name := range BenchmarkSuites["classic"] {
+		for _, variant := range Variants {
+			config := DefaultConfig()
+			config.Variant, config.Seed, config.Iterations = variant, 1, goldenIterations
+			cases = append(cases, GoldenCase{Instance: name, Config: config})
+		}
+	}
+	return cases
+}
+
+// goldenData is benchmarks/golden.json as it was when the program was built,
+// the cases "regress" checks unless given another file
+//
+//go:embed benchmarks/golden.json
+var goldenData []byte
+
+// readGoldenFile reads a golden file, the built-in one if path is empty, and
+// an empty one if update is set and there is none yet
+func readGoldenFile(path string, update bool) (*GoldenFile, error) {
+	data := goldenData
+	var err error
+	if path != "" {
+		data, err = os.ReadFile(path)
+	}
+	if errors.Is(err, fs.ErrNotExist) && update {
+		return &GoldenFile{Tolerance: 0.01, Cases: defaultGoldenCases()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	golden := &GoldenFile{}
+	if err := json.Unmarshal(data, golden); err != nil {
+		return nil, fmt.Errorf("%s: %w", cmp.Or(path, "built-in golden file"), err)
+	}
+	return golden, nil
+}
+
+// runGoldenCase solves a case again and returns the length of its best tour
+func runGoldenCase(c GoldenCase) (float64, error) {
+	data, err := benchmarkFiles.ReadFile("benchmarks/" + c.Instance + ".tsp")
+	if err != nil {
+		return 0, fmt.Errorf("no built-in instance %s", c.Instance)
+	}
+	instance, err := parseInstance(FormatTSPLIB, data)
+	if err != nil {
+		return 0, err
+	}
+	solver, err := NewSolver(instance, c.Config)
+	if err != nil {
+		return 0, fmt.Errorf("%s %s: %w", c.Instance, c.Config.Variant, err)
+	}
+	return solver.Run().Length, nil
+}
+
+// regressCommand implements "regress", which runs the fixed-seed cases of a
+// golden file again and fails if any finds a tour longer than recorded by
+// more than the file's tolerance, so changes to the solver cannot cost tour
+// quality unnoticed. Shorter tours pass, and -update records them
+func regressCommand(args []string) error {
+	flags := flag.NewFlagSet("regress", flag.ExitOnError)
+	goldenPath := flags.String("golden", "", "read the cases and their recorded lengths from this JSON `file`, the copy of benchmarks/golden.json built into the program if empty")
+	update := flags.Bool("update", false, "record the lengths the cases find now in the -golden file, creating it with a case per variant and classic instance if missing")
+	tolerance := flags.Float64("tolerance", 0, "let tours be this `share` longer than recorded, the golden file's tolerance if 0")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s regress [flags]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Runs the fixed-seed cases of a golden file on the built-in instances and fails if a tour")
+		fmt.Fprintln(flags.Output(), "is longer than recorded by more than the tolerance. Without -golden it checks the cases of")
+		fmt.Fprintln(flags.Output(), "benchmarks/golden.json built into the program, so it runs from any directory; -update")
+		fmt.Fprintln(flags.Output(), "needs -golden, such as -golden benchmarks/golden.json from the source tree, to write to.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *tolerance < 0 {
+		return fmt.Errorf("regress: -tolerance must not be negative")
+	}
+	if *update && *goldenPath == "" {
+		return fmt.Errorf("regress: -update needs -golden, the file to write to")
+	}
+	golden, err := readGoldenFile(*goldenPath, *update)
+	if err != nil {
+		return err
+	}
+	if *tolerance > 0 {
+		golden.Tolerance = *tolerance
+	}
+
+	regressed := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INSTANCE\tVARIANT\tSEED\tRECORDED\tLENGTH\tCHANGE\tSTATUS")
+	for k, c := range golden.Cases {
+		length, err := runGoldenCase(c)
+		if err != nil {
+			return err
+		}
+		status, change := "ok", "-"
+		if c.Length > 0 {
+			change = fmt.Sprintf("%+.2f%%", 100*(length-c.Length)/c.Length)
+			switch {
+			case length > c.Length*(1+golden.Tolerance):
+				status = "REGRESSED"
+				regressed++
+			case length < c.Length:
+				status = "better"
+			}
+		} else {
+			status = "new"
+		}
+		if *update {
+			golden.Cases[k].Length = length
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.6g\t%.6g\t%s\t%s\n", c.Instance, c.Config.Variant, c.Config.Seed, c.Length, length, change, status)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if *update {
+		return writeJSON(*goldenPath, golden)
+	}
+	if regressed > 0 {
+		return fmt.Errorf("regress: %d of %d cases found tours more than %g%% longer than recorded", regressed, len(golden.Cases), 100*golden.Tolerance)
+	}
+	return nil
+}
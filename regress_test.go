@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestGolden runs the fixed-seed cases of the built-in golden file, as
+// "regress" does, and fails those whose tours come out longer than recorded
+// by more than the file's tolerance
+func TestGolden(t *testing.T) {
+	golden, err := readGoldenFile("", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range golden.Cases {
+		t.Run(fmt.Sprintf("%s/%s/%d", c.Instance, c.Config.Variant, c.Config.Seed), func(t *testing.T) {
+			t.Parallel()
+			if testing.Short() && c.Instance != "eil51" {
+				t.Skip("only eil51 in short mode")
+			}
+			length, err := runGoldenCase(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if length > c.Length*(1+golden.Tolerance) {
+				t.Errorf("tour of length %g, recorded %g", length, c.Length)
+			}
+		})
+	}
+}
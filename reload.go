@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Reload records a change of parameters in the middle of a run
+type Reload struct {
+	// Iteration is the first iteration run with the new parameters
+	Iteration int    `json:"iteration"`
+	Config    Config `json:"config"`
+}
+
+// ParameterUpdate lists the parameters a running solver can change, nil for
+// those to keep
+type ParameterUpdate struct {
+	Rho         *float64 `json:"rho,omitempty"`
+	Beta        *float64 `json:"beta,omitempty"`
+	Q0          *float64 `json:"q0,omitempty"`
+	LocalSearch *bool    `json:"local_search,omitempty"`
+	Search      *string  `json:"search,omitempty"`
+}
+
+// Apply returns cfg with the update's parameters set
+func (u ParameterUpdate) Apply(cfg Config) Config {
+	if u.Rho != nil {
+		cfg.Rho = *u.Rho
+	}
+	if u.Beta != nil {
+		cfg.Beta = *u.Beta
+	}
+	if u.Q0 != nil {
+		cfg.Q0 = *u.Q0
+	}
+	if u.LocalSearch != nil {
+		cfg.LocalSearch = *u.LocalSearch
+	}
+	if u.Search != nil {
+		cfg.Search = *u.Search
+	}
+	return cfg
+}
+
+// Reload changes the parameters of a run in progress to those of cfg that
+// may change mid-run, rho, beta, q0 and the local search, ignoring the
+// others, from the next iteration on. It may be called while another
+// goroutine runs the solver; the solution lists the changes. Self-adaptive
+// ants keep evolving their own beta and q0
+func (s *Solver) Reload(cfg Config) error {
+	update := s.Config
+	update.Rho, update.Beta, update.Q0 = cfg.Rho, cfg.Beta, cfg.Q0
+	update.LocalSearch, update.Search = cfg.LocalSearch, cfg.Search
+	if err := update.Validate(); err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	s.reload = &update
+	return nil
+}
+
+// applyReload puts into effect the parameters Reload last set, if any
+func (s *Solver) applyReload() {
+	s.reloadMu.Lock()
+	update := s.reload
+	s.reload = nil
+	s.reloadMu.Unlock()
+	if update == nil || *update == s.Config {
+		return
+	}
+	s.Config = *update
+	s.Colony.Rho, s.Colony.Beta, s.Colony.Q0 = update.Rho, update.Beta, update.Q0
+	if s.Colony.Variant == MaxMinAntSystem && !math.IsInf(s.bestLength, 1) {
+		// The trail limits follow from rho
+		s.Colony.setPheromoneBounds(s.bestLength)
+		s.Colony.clampPheromones()
+	}
+	s.reloads = append(s.reloads, Reload{Iteration: s.iteration, Config: *update})
+	s.logger().Info("reloaded parameters", "iteration", s.iteration, "rho", update.Rho, "beta", update.Beta,
+		"q0", update.Q0, "local_search", update.LocalSearch, "search", update.Search)
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// replHelp lists the commands the REPL understands
+const replHelp = `Commands:
+  add x y [name]     add a city
+  remove i           remove city i
+  cities             list the cities
+  set param value    change ants, alpha, beta, rho, q, q0, variant or seed
+  params             show the parameters
+  run [n]            run n more iterations, 10 by default
+  best               print the best tour so far
+  plot file          draw the best tour as an SVG image in file
+  reset              forget the trails and best tour, keeping the cities
+  load file          replace the cities with those of an instance file
+  help               show this list
+  quit               leave
+`
+
+// replCommand implements "repl", an interactive session that edits an
+// instance and its parameters and runs the colony a few iterations at a time
+func replCommand(args []string) error {
+	flags := flag.NewFlagSet("repl", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s repl [flags] [file]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Starts an interactive session, with the cities of file if given. Type help for the commands.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	r := &repl{instance: &Instance{}, out: os.Stdout}
+	config := DefaultConfig()
+	if flags.NArg() > 0 {
+		instance, err := ReadInstance(flags.Arg(0), "")
+		if err != nil {
+			return err
+		}
+		r.instance = instance
+		if instance.Options != nil {
+			config = *instance.Options
+		}
+	}
+	config, err := parameters(config)
+	if err != nil {
+		return err
+	}
+	if config.Seed == 0 {
+		config.Seed = 1
+	}
+	r.config = config
+	return r.serve(os.Stdin)
+}
+
+// replParameters lists the parameters "set" may change, named like the flags
+var replParameters = []string{"ants", "alpha", "beta", "rho", "q", "q0", "variant", "seed"}
+
+// repl holds the state of an interactive session. The solver is built on the
+// first run and edited in step with the cities and parameters after that, so
+// its trails carry over; only reset and load drop it
+type repl struct {
+	instance *Instance
+	config   Config
+	solver   *Solver
+	out      io.Writer
+}
+
+// serve reads commands from in until it ends or the user quits
+func (r *repl) serve(in io.Reader) error {
+	fmt.Fprintf(r.out, "%d cities. Type help for the commands.\n", len(r.instance.Cities))
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(r.out, "aco> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(r.out)
+			return scanner.Err()
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return nil
+		}
+		if err := r.execute(fields[0], fields[1:]); err != nil {
+			fmt.Fprintln(r.out, "error:", err)
+		}
+	}
+}
+
+// execute runs one command
+func (r *repl) execute(name string, args []string) error {
+	switch name {
+	case "help":
+		fmt.Fprint(r.out, replHelp)
+	case "add":
+		return r.add(args)
+	case "remove":
+		return r.remove(args)
+	case "cities":
+		for i, c := range r.instance.Cities {
+			fmt.Fprintf(r.out, "%4d  %-12s %g %g\n", i, c.Label(i), c.X, c.Y)
+		}
+	case "set":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: set param value")
+		}
+		return r.set(args[0], args[1])
+	case "params":
+		c := r.config
+		fmt.Fprintf(r.out, "ants %d  alpha %g  beta %g  rho %g  q %g  q0 %g  variant %s  seed %d\n",
+			c.NumAnts, c.Alpha, c.Beta, c.Rho, c.Q, c.Q0, c.Variant, c.Seed)
+	case "run":
+		n := 10
+		if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n <= 0 {
+				return fmt.Errorf("run: want a positive number of iterations")
+			}
+		}
+		return r.run(n)
+	case "best":
+		if r.solver == nil {
+			return fmt.Errorf("nothing has run yet")
+		}
+		solution := r.solver.Solution()
+		fmt.Fprintln(r.out, "Best tour:", FormatTour(r.instance.Cities, solution.Tour))
+		fmt.Fprintln(r.out, "Best tour length:", solution.Length)
+	case "plot":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: plot file")
+		}
+		var tour []int
+		if r.solver != nil {
+			tour = r.solver.Solution().Tour
+		}
+		return writeFile(args[0], func(w io.Writer) error {
+			return WriteTourSVG(w, r.instance.Cities, tour, r.instance.ReturnToStart)
+		})
+	case "reset":
+		r.solver = nil
+	case "load":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: load file")
+		}
+		instance, err := ReadInstance(args[0], "")
+		if err != nil {
+			return err
+		}
+		r.instance, r.solver = instance, nil
+		fmt.Fprintf(r.out, "%d cities.\n", len(instance.Cities))
+	default:
+		return fmt.Errorf("unknown command %q, type help for the list", name)
+	}
+	return nil
+}
+
+// add appends a city, to the running colony too, whose new edges start at
+// the mean trail
+func (r *repl) add(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("usage: add x y [name]")
+	}
+	if r.instance.Distances != nil {
+		return fmt.Errorf("cannot add cities to an instance with an explicit distance matrix")
+	}
+	x, errX := strconv.ParseFloat(args[0], 64)
+	y, errY := strconv.ParseFloat(args[1], 64)
+	if errX != nil || errY != nil {
+		return fmt.Errorf("add: coordinates must be numbers")
+	}
+	city := &City{X: x, Y: y}
+	if len(args) == 3 {
+		city.Name = args[2]
+	}
+	// The colony copies the instance's cities before it first changes them
+	if r.solver != nil {
+		r.solver.AddCity(city)
+	}
+	r.instance.Cities = append(r.instance.Cities, city)
+	// The new city's edges carry no attributes
+	for name, matrix := range r.instance.Attributes {
+		for k := range matrix {
+			matrix[k] = append(matrix[k], 0)
+		}
+		r.instance.Attributes[name] = append(matrix, make([]float64, len(r.instance.Cities)))
+	}
+	fmt.Fprintf(r.out, "city %d added\n", len(r.instance.Cities)-1)
+	return nil
+}
+
+// remove deletes a city, with its row and column of an explicit distance
+// matrix or edge attributes and the constraints involving it, from the
+// running colony too
+func (r *repl) remove(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: remove i")
+	}
+	i, err := strconv.Atoi(args[0])
+	if err != nil || i < 0 || i >= len(r.instance.Cities) {
+		return fmt.Errorf("remove: no city %q", args[0])
+	}
+	if r.solver != nil {
+		if err := r.solver.RemoveCity(i); err != nil {
+			return err
+		}
+	}
+	r.instance.Cities = slices.Delete(r.instance.Cities, i, i+1)
+	if r.instance.Distances != nil {
+		r.instance.Distances = removeRowCol(r.instance.Distances, i)
+	}
+	for name, matrix := range r.instance.Attributes {
+		r.instance.Attributes[name] = removeRowCol(matrix, i)
+	}
+	if r.instance.Constraints != nil {
+		r.instance.Constraints.removeCity(i)
+	}
+	return nil
+}
+
+// set changes a parameter, taking effect on the next run. A new variant
+// starts the trails again at its own initial level, keeping the best tour,
+// and a new seed draws the colony's choices afresh
+func (r *repl) set(param, value string) error {
+	config := r.config
+	field, ok := configFields(&config)[param]
+	if !ok || !slices.Contains(replParameters, param) {
+		return fmt.Errorf("unknown parameter %q, want one of %v", param, replParameters)
+	}
+	if err := setConfigField(field, value); err != nil {
+		return fmt.Errorf("%s: %w", param, err)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	r.config = config
+	if r.solver == nil {
+		return nil
+	}
+	solver, colony := r.solver, r.solver.Colony
+	colony.NumAnts, colony.Alpha, colony.Beta, colony.Rho, colony.Q, colony.Q0 =
+		config.NumAnts, config.Alpha, config.Beta, config.Rho, config.Q, config.Q0
+	switch param {
+	case "seed":
+		colony.Seed(config.Seed)
+		solver.seed = config.Seed
+	case "variant":
+		colony.Variant = config.Variant
+		colony.InitializePheromones()
+		if colony.Variant == MaxMinAntSystem && !math.IsInf(solver.bestLength, 1) {
+			colony.setPheromoneBounds(solver.bestLength)
+			colony.clampPheromones()
+		}
+		fmt.Fprintln(r.out, "trails restarted")
+	}
+	solver.Config = config
+	return nil
+}
+
+// run performs n more iterations, building the solver first if needed
+func (r *repl) run(n int) error {
+	if r.solver == nil {
+		solver, err := NewSolver(r.instance, r.config)
+		if err != nil {
+			return err
+		}
+		r.solver = solver
+	}
+	var stats IterationStats
+	for range n {
+		stats = r.solver.Step()
+	}
+	fmt.Fprintf(r.out, "iteration %d: best %.6g, mean %.6g, diversity %.3f\n",
+		stats.Iteration+1, stats.BestSoFar, stats.Mean, stats.Diversity)
+	return nil
+}
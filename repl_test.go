@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestReplEdits adds and removes cities and changes the variant and seed
+// between runs, and checks that the session keeps its solver, whose best
+// tour goes on visiting every city
+func TestReplEdits(t *testing.T) {
+	g, err := NewGenerator(UniformCities, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.Seed = 1
+	r := &repl{instance: g.Instance(10), config: config, out: io.Discard}
+	commands := []string{"run 5", "add 10 20 depot", "run 2", "remove 3", "set variant mmas", "run 2", "set seed 9", "add 500 500", "run 2"}
+	var solver *Solver
+	for _, command := range commands {
+		fields := strings.Fields(command)
+		if err := r.execute(fields[0], fields[1:]); err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+		if solver == nil {
+			solver = r.solver
+		} else if r.solver != solver {
+			t.Fatalf("%s dropped the solver", command)
+		}
+		if n := len(r.instance.Cities); len(solver.Colony.Cities) != n {
+			t.Fatalf("%s: colony of %d cities, instance of %d", command, len(solver.Colony.Cities), n)
+		}
+		if err := ValidateTour(solver.Solution().Tour, len(r.instance.Cities), nil); err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+	}
+	if solver.Colony.Variant != MaxMinAntSystem || solver.Config.Seed != 9 {
+		t.Errorf("variant %s and seed %d, want mmas and 9", solver.Colony.Variant, solver.Config.Seed)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+)
+
+// RandomLog records the draws a colony makes from its random number
+// generator, in order. Every random decision of a run follows from them: the
+// ants make their choices from seeds drawn there. Replaying the draws
+// reproduces the run exactly, and tells when a changed program takes another
+// path than the recorded run, which makes a failing run debuggable
+type RandomLog struct {
+	Seed  int64    `json:"seed"`
+	Draws []uint64 `json:"draws"`
+}
+
+// recordingSource passes the draws of a source through, appending them to a log
+type recordingSource struct {
+	mu     sync.Mutex
+	source rand.Source64
+	log    *RandomLog
+}
+
+func (r *recordingSource) Int63() int64 {
+	return int64(r.Uint64() & (1<<63 - 1))
+}
+
+func (r *recordingSource) Uint64() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	x := r.source.Uint64()
+	r.log.Draws = append(r.log.Draws, x)
+	return x
+}
+
+func (r *recordingSource) Seed(seed int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.source.Seed(seed)
+}
+
+// RandomReplay feeds a colony the draws of a RandomLog in place of its
+// random number generator
+type RandomReplay struct {
+	mu   sync.Mutex
+	log  *RandomLog
+	next int
+	// overrun takes over once the recorded draws run out
+	overrun rand.Source64
+}
+
+func (r *RandomReplay) Int63() int64 {
+	return int64(r.Uint64() & (1<<63 - 1))
+}
+
+func (r *RandomReplay) Uint64() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next < len(r.log.Draws) {
+		r.next++
+		return r.log.Draws[r.next-1]
+	}
+	if r.overrun == nil {
+		r.overrun = rand.NewSource(r.log.Seed).(rand.Source64)
+	}
+	r.next++
+	return r.overrun.Uint64()
+}
+
+// Seed does nothing: the replay decides the draws
+func (r *RandomReplay) Seed(int64) {}
+
+// Err reports whether the run so far drew more or fewer numbers than the
+// recorded one, a sign it took another path. Call it once the run is over
+func (r *RandomReplay) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next != len(r.log.Draws) {
+		return fmt.Errorf("the run made %d random draws where the recorded one made %d, so it took another path", r.next, len(r.log.Draws))
+	}
+	return nil
+}
+
+// RecordRandom records every draw the colony makes from now on, returning the
+// log they go to. Call it before the first iteration
+func (s *Solver) RecordRandom() *RandomLog {
+	log := &RandomLog{Seed: s.seed}
+	s.Colony.source = &recordingSource{source: s.Colony.source, log: log}
+	s.Colony.rng = rand.New(s.Colony.source)
+	return log
+}
+
+// ReplayRandom makes the colony draw the numbers of log, recorded by
+// RecordRandom on a solver configured alike, seed included, in place of its
+// own. Call it before the first iteration
+func (s *Solver) ReplayRandom(log *RandomLog) *RandomReplay {
+	replay := &RandomReplay{log: log}
+	s.Colony.source = replay
+	s.Colony.rng = rand.New(replay)
+	return replay
+}
+
+// LoadRandomLog reads a log written as JSON by "solve -record"
+func LoadRandomLog(path string) (*RandomLog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	log := &RandomLog{}
+	if err := json.Unmarshal(data, log); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return log, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+)
+
+// InstanceSummary identifies the instance a run was made on
+type InstanceSummary struct {
+	Name        string `json:"name,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Cities      int    `json:"cities"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// MachineInfo describes the machine a run was made on, for comparing timings
+type MachineInfo struct {
+	Hostname  string `json:"hostname,omitempty"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	CPUs      int    `json:"cpus"`
+	GoVersion string `json:"go_version"`
+}
+
+// RunReport records everything about a run needed to reproduce it and compare it with others
+type RunReport struct {
+	StartedAt time.Time       `json:"started_at"`
+	Instance  InstanceSummary `json:"instance"`
+	Solution  *Solution       `json:"solution"`
+	// Provenance records how the run was produced, to trace the result back to
+	Provenance Provenance `json:"provenance"`
+	// Preprocessing lists the transformations of the input, in order, the
+	// solution's lengths being those of the transformed instance
+	Preprocessing []PreprocessStep `json:"preprocessing,omitempty"`
+	Timings       Timings          `json:"timings"`
+	// LengthCache counts the lookups of the tour length cache, if the run had one
+	LengthCache *LengthCacheStats `json:"length_cache,omitempty"`
+	// IterationTimings is the mean time an iteration spent in each phase
+	IterationTimings Timings     `json:"iteration_timings"`
+	Machine          MachineInfo `json:"machine"`
+}
+
+// NewRunReport collects the report of a solver's run on inst, read from source
+func NewRunReport(inst *Instance, source string, solver *Solver, startedAt time.Time) *RunReport {
+	hostname, _ := os.Hostname()
+	timings := solver.Timings()
+	report := &RunReport{
+		StartedAt: startedAt,
+		Instance: InstanceSummary{
+			Name:        inst.Name,
+			Source:      source,
+			Cities:      len(inst.Cities),
+			Fingerprint: inst.Fingerprint(),
+		},
+		Solution:         solver.Solution(),
+		Provenance:       solver.Provenance(),
+		Timings:          timings,
+		IterationTimings: timings.PerIteration(),
+		Machine: MachineInfo{
+			Hostname:  hostname,
+			OS:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+			CPUs:      runtime.NumCPU(),
+			GoVersion: runtime.Version(),
+		},
+	}
+	if stats, ok := solver.Colony.LengthCacheStats(); ok {
+		report.LengthCache = &stats
+	}
+	return report
+}
+
+// WriteTimings prints the time spent in setup and in each phase of the
+// iterations, in all, per iteration and as a share of the whole, so that
+// tuning can start where the time goes
+func WriteTimings(w io.Writer, t Timings) error {
+	whole := t.DistanceMatrix + t.CandidateLists + t.Total
+	mean := t.PerIteration()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "phase\ttotal\tper iteration\tshare")
+	row := func(phase string, total time.Duration, perIteration string) {
+		share := 0.0
+		if whole > 0 {
+			share = 100 * float64(total) / float64(whole)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.1f%%\n", phase, total.Round(time.Microsecond), perIteration, share)
+	}
+	row("distance matrix", t.DistanceMatrix, "-")
+	row("candidate lists", t.CandidateLists, "-")
+	row("construction", t.Construction, mean.Construction.Round(time.Microsecond).String())
+	row("local search", t.LocalSearch, mean.LocalSearch.Round(time.Microsecond).String())
+	row("pheromone update", t.Update, mean.Update.Round(time.Microsecond).String())
+	row("iterations", t.Total, mean.Total.Round(time.Microsecond).String())
+	return tw.Flush()
+}
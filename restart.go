@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Restart actions, selected by Config.RestartAction
+const (
+	// RestartReset sets every trail back to the level the colony started at,
+	// or to the upper trail bound under MMAS
+	RestartReset = "reset"
+	// RestartSmooth pulls every trail restartSmoothing of the way towards the
+	// strongest one, which keeps the order of the trails but flattens it
+	// (pheromone trail smoothing, Stützle and Hoos)
+	RestartSmooth = "smooth"
+	// RestartBest resets the trails and lays the best tour so far on them
+	RestartBest = "best"
+)
+
+// RestartActions lists the names of the restart actions
+var RestartActions = []string{RestartReset, RestartSmooth, RestartBest}
+
+// Reasons for restarts
+const (
+	RestartStagnation = "stagnation"
+	RestartBranching  = "branching"
+	RestartEntropy    = "entropy"
+)
+
+const (
+	// restartSmoothing is how far RestartSmooth pulls trails towards the strongest
+	restartSmoothing = 0.5
+	// restartGrace is the number of iterations after a restart during which
+	// the branching factor and entropy cannot trigger another, as the
+	// trails of RestartBest start out dominated by a single tour
+	restartGrace = 10
+)
+
+// Restart records a restart of the trails during a run
+type Restart struct {
+	// Iteration is the iteration after which the trails were restarted
+	Iteration int `json:"iteration"`
+	// Reason is the indicator that triggered the restart, one of
+	// RestartStagnation, RestartBranching and RestartEntropy
+	Reason string `json:"reason"`
+	// Value is the indicator's value: iterations without improvement, or the
+	// branching factor or pheromone diversity
+	Value float64 `json:"value"`
+}
+
+// checkRestartAction reports an error for unknown restart actions; empty means RestartReset
+func checkRestartAction(action string) error {
+	if action != "" && !slices.Contains(RestartActions, action) {
+		return fmt.Errorf("unknown restart action %q, want one of %v", action, RestartActions)
+	}
+	return nil
+}
+
+// restartReason returns why the colony should restart after an iteration
+// with these statistics, with the value of the indicator, or "" if it
+// should go on
+func (s *Solver) restartReason(stats IterationStats) (string, float64) {
+	since := s.iteration - s.lastRestart
+	stagnant := s.iteration - max(s.lastImprovement, s.lastRestart)
+	switch {
+	case s.Config.RestartStagnation > 0 && stagnant >= s.Config.RestartStagnation:
+		return RestartStagnation, float64(stagnant)
+	case since < restartGrace:
+		return "", 0
+	case stats.Branching < s.Config.RestartBranching:
+		return RestartBranching, stats.Branching
+	case stats.Diversity < s.Config.RestartEntropy:
+		return RestartEntropy, stats.Diversity
+	}
+	return "", 0
+}
+
+// injectArchive lays archived tours on the trails, each as much as strength
+// times Q over its length, 1 if strength is zero; bounded trails, as under
+// MMAS, stay within their bounds
+func (ac *AntColony) injectArchive(tours []AlternativeTour, strength float64) {
+	if len(tours) == 0 {
+		return
+	}
+	if strength == 0 {
+		strength = 1
+	}
+	for _, t := range tours {
+		ac.deposit(t.Tour, strength*ac.Q/max(t.Length, ac.costFloor))
+	}
+	if ac.boundedTrails() {
+		ac.clampPheromones()
+	}
+}
+
+// restartTrails applies a restart action to the trails; best is the best
+// tour so far
+func (ac *AntColony) restartTrails(action string, best []int, bestLength float64) {
+	level := ac.initialPheromone
+	if ac.Variant == MaxMinAntSystem {
+		level = ac.maxPheromone
+	}
+	if ac.sparse != nil {
+		if action == RestartSmooth {
+			strongest := ac.sparse.max()
+			ac.sparse.update(func(trail float64) float64 { return trail + restartSmoothing*(strongest-trail) })
+		} else {
+			ac.sparse.fill(level)
+		}
+	}
+	for _, matrix := range [][][]float64{ac.Pheromones, ac.ClusterPheromones} {
+		strongest := math.Inf(-1)
+		for i := range matrix {
+			for j := range matrix[i] {
+				strongest = math.Max(strongest, matrix[i][j])
+			}
+		}
+		for i := range matrix {
+			for j := range matrix[i] {
+				if action == RestartSmooth {
+					matrix[i][j] += restartSmoothing * (strongest - matrix[i][j])
+				} else {
+					matrix[i][j] = level
+				}
+			}
+		}
+	}
+	if action == RestartBest && len(best) > 0 && !math.IsInf(bestLength, 0) {
+		ac.deposit(best, ac.Q/bestLength)
+		if ac.boundedTrails() {
+			ac.clampPheromones()
+		}
+	}
+}
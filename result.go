@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ResultFormats lists the formats Result.Write accepts
+var ResultFormats = []string{"json", "csv", "tour"}
+
+// checkResultFormat reports an error for unknown result formats; empty means none
+func checkResultFormat(format string) error {
+	if format != "" && !slices.Contains(ResultFormats, format) {
+		return fmt.Errorf("unknown result format %q, want one of %v", format, ResultFormats)
+	}
+	return nil
+}
+
+// Result is the final outcome of a run in the compact form printed for scripts
+type Result struct {
+	Tour    []int    `json:"tour"`
+	Names   []string `json:"names,omitempty"`
+	Length  *float64 `json:"length"`
+	Optimum *float64 `json:"optimum,omitempty"`
+	// Gap is the percentage by which Length exceeds Optimum
+	Gap  *float64 `json:"gap,omitempty"`
+	Seed int64    `json:"seed"`
+	// Metadata holds the metadata of the visited cities in tour order, when
+	// any city has some
+	Metadata []json.RawMessage `json:"metadata,omitempty"`
+
+	cities []*City
+}
+
+// NewResult summarizes a solution over cities; a missing tour has a null length
+func NewResult(cities []*City, solution *Solution) *Result {
+	r := &Result{Tour: solution.Tour, Seed: solution.Seed, cities: cities}
+	if !math.IsInf(solution.Length, 0) {
+		length := solution.Length
+		r.Length = &length
+	}
+	if hasNames(cities) {
+		r.Names = make([]string, len(solution.Tour))
+		for i, city := range solution.Tour {
+			r.Names[i] = cities[city].Label(city)
+		}
+	}
+	r.Metadata = tourMetadata(cities, solution.Tour)
+	return r
+}
+
+// SetOptimum records the optimal length and the gap to it
+func (r *Result) SetOptimum(optimum float64) {
+	r.Optimum = &optimum
+	if r.Length != nil {
+		gap := Gap(*r.Length, optimum)
+		r.Gap = &gap
+	}
+}
+
+// Write prints the result as "json", one object on a line; "csv", a row per
+// visited city with its coordinates and any metadata as JSON; or "tour", the
+// cities separated by spaces
+func (r *Result) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case "csv":
+		cw := csv.NewWriter(w)
+		header := []string{"position", "city", "name", "x", "y"}
+		if r.Metadata != nil {
+			header = append(header, "metadata")
+		}
+		cw.Write(header)
+		for i, city := range r.Tour {
+			c := r.cities[city]
+			record := []string{
+				strconv.Itoa(i),
+				strconv.Itoa(city),
+				c.Name,
+				strconv.FormatFloat(c.X, 'g', -1, 64),
+				strconv.FormatFloat(c.Y, 'g', -1, 64),
+			}
+			if r.Metadata != nil {
+				record = append(record, string(c.Metadata))
+			}
+			cw.Write(record)
+		}
+		cw.Flush()
+		return cw.Error()
+	case "tour":
+		cities := make([]string, len(r.Tour))
+		for i, city := range r.Tour {
+			cities[i] = strconv.Itoa(city)
+		}
+		_, err := fmt.Fprintln(w, strings.Join(cities, " "))
+		return err
+	}
+	return checkResultFormat(format)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RunTimeDistribution measures how long independent runs take to reach a
+// target length, the empirical run-time distribution by which Hoos and
+// Stützle compare stochastic local search algorithms: runs differ only in
+// their seed, and each goes on until it reaches the target or a cap
+type RunTimeDistribution struct {
+	Instance *Instance
+	// Config is the configuration of the first run, whose Iterations, or
+	// Evaluations if set, caps every run; Config.Seed 0 starts from seed 1
+	Config Config
+	Runs   int
+	Target float64
+	// Timeout caps the time of each run, none if zero
+	Timeout time.Duration
+	// Parallel is the number of runs at once, one if zero. Parallel runs
+	// compete for the processor, which lengthens their times
+	Parallel int
+	Logger   *slog.Logger
+	LogEvery int
+}
+
+// RTDRun is one run of a run-time distribution
+type RTDRun struct {
+	Seed    int64 `json:"seed"`
+	Reached bool  `json:"reached"`
+	// Iterations, Evaluations and Time are what the run took to reach the
+	// target, or ran for in all if it did not
+	Iterations  int           `json:"iterations"`
+	Evaluations int           `json:"evaluations"`
+	Time        time.Duration `json:"time_ns"`
+	Length      float64       `json:"length"`
+}
+
+// Run performs the runs and returns them ordered by the time they took,
+// those that reached the target first
+func (d *RunTimeDistribution) Run() ([]RTDRun, error) {
+	first := d.Config.Seed
+	if first == 0 {
+		first = 1
+	}
+	solvers := make([]*Solver, d.Runs)
+	for r := range solvers {
+		config := d.Config
+		config.Seed = first + int64(r)
+		solver, err := NewSolver(d.Instance, config)
+		if err != nil {
+			return nil, err
+		}
+		logger := d.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		solver.Logger = logger.With("seed", config.Seed)
+		solver.LogEvery = d.LogEvery
+		solvers[r] = solver
+	}
+	runs := make([]RTDRun, d.Runs)
+	run := func(r int) {
+		solver := solvers[r]
+		runs[r] = RTDRun{Seed: solver.Config.Seed, Length: math.Inf(1)}
+		start := time.Now()
+		for !solver.Done() {
+			stats := solver.Step()
+			runs[r].Iterations++
+			runs[r].Evaluations = stats.Evaluations
+			runs[r].Length = stats.BestSoFar
+			if stats.BestSoFar <= d.Target {
+				runs[r].Reached = true
+				break
+			}
+			if d.Timeout > 0 && time.Since(start) >= d.Timeout {
+				break
+			}
+		}
+		runs[r].Time = time.Since(start)
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(d.Parallel, 1), d.Runs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				run(r)
+			}
+		}()
+	}
+	for r := range solvers {
+		jobs <- r
+	}
+	close(jobs)
+	wg.Wait()
+	sort.SliceStable(runs, func(a, b int) bool {
+		if runs[a].Reached != runs[b].Reached {
+			return runs[a].Reached
+		}
+		return runs[a].Time < runs[b].Time
+	})
+	return runs, nil
+}
+
+// WriteRTDCSV writes a row per run, with a header, in the order Run returns
+// them; probability is the share of all runs that reached the target within
+// the run's time, which plotted against seconds is the distribution
+func WriteRTDCSV(w io.Writer, runs []RTDRun) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"seed", "reached", "iterations", "evaluations", "seconds", "length", "probability"})
+	format := func(x float64) string { return strconv.FormatFloat(x, 'g', -1, 64) }
+	for k, run := range runs {
+		probability := ""
+		if run.Reached {
+			probability = format(float64(k+1) / float64(len(runs)))
+		}
+		cw.Write([]string{
+			strconv.FormatInt(run.Seed, 10),
+			strconv.FormatBool(run.Reached),
+			strconv.Itoa(run.Iterations),
+			strconv.Itoa(run.Evaluations),
+			format(run.Time.Seconds()),
+			format(run.Length),
+			probability,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// rtdCommand implements "rtd", which measures the run-time distribution of
+// reaching a target length on an instance
+func rtdCommand(args []string) error {
+	flags := flag.NewFlagSet("rtd", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	logging := logFlags(flags)
+	runs := flags.Int("runs", 25, "number of runs, with seeds -seed, -seed+1, ... (1, 2, ... by default)")
+	target := flags.Float64("target", 0, "run until a tour of at most this `length`")
+	gap := flags.Float64("gap", 0, "without -target, run until a tour this many `percent` above the known optimum")
+	timeout := flags.Duration("timeout", 0, "give up a run after this long, 0 for no limit; -iterations or -evaluations caps runs too")
+	parallel := flags.Int("parallel", 1, "number of runs at once, which then compete for the processor")
+	outPath := flags.String("o", "-", "write the runs as CSV to this `file`, - for stdout")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s rtd [flags] file\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Runs the colony until it reaches the target, over and over, and writes the time and iterations")
+		fmt.Fprintln(flags.Output(), "each run needed, fastest first, with the share of runs done by then: the empirical run-time")
+		fmt.Fprintln(flags.Output(), "distribution. Runs that hit the -iterations, -evaluations or -timeout cap come last, without a share.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("rtd: want exactly one instance file")
+	}
+	if *runs <= 0 {
+		return fmt.Errorf("rtd: -runs must be positive")
+	}
+	logEvery, err := logging()
+	if err != nil {
+		return err
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	goal := *target
+	if goal == 0 {
+		optimum, known := KnownOptima[instance.Name]
+		if !known {
+			return fmt.Errorf("rtd: no known optimum for %s, give a -target", instanceName(instance, flags.Arg(0)))
+		}
+		goal = optimum * (1 + *gap/100)
+	}
+	config, err := instanceConfig(instance, parameters, true)
+	if err != nil {
+		return err
+	}
+	distribution := &RunTimeDistribution{
+		Instance: instance,
+		Config:   config,
+		Runs:     *runs,
+		Target:   goal,
+		Timeout:  *timeout,
+		Parallel: *parallel,
+		LogEvery: logEvery,
+	}
+	results, err := distribution.Run()
+	if err != nil {
+		return err
+	}
+	if err := writeFile(*outPath, func(w io.Writer) error { return WriteRTDCSV(w, results) }); err != nil {
+		return err
+	}
+
+	var times []float64
+	var iterations, evaluations []float64
+	for _, run := range results {
+		if run.Reached {
+			times = append(times, run.Time.Seconds())
+			iterations = append(iterations, float64(run.Iterations))
+			evaluations = append(evaluations, float64(run.Evaluations))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d of %d runs reached %g", len(times), *runs, goal)
+	if len(times) > 0 {
+		fmt.Fprintf(os.Stderr, ", in a median %s, %g iterations and %g tours", time.Duration(median(times)*float64(time.Second)).Round(time.Millisecond), median(iterations), median(evaluations))
+	}
+	fmt.Fprintln(os.Stderr)
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Conventions for the length of a tour. Published benchmark results mostly
+// round every edge the way TSPLIB does, so lengths scored otherwise cannot be
+// compared with them
+const (
+	// ScoreMatrix takes the instance's own costs, those the colony solves
+	ScoreMatrix = "matrix"
+	// ScoreExact takes the exact Euclidean distances between the cities
+	ScoreExact = "exact"
+	// ScoreNearest rounds every Euclidean edge to the nearest integer, as the
+	// TSPLIB EUC_2D and EUC_3D types do
+	ScoreNearest = "nint"
+	// ScoreCeil rounds every Euclidean edge up, as the TSPLIB CEIL_2D type does
+	ScoreCeil = "ceil"
+)
+
+// ScoringConventions lists the conventions tours can be scored by
+var ScoringConventions = []string{ScoreMatrix, ScoreExact, ScoreNearest, ScoreCeil}
+
+// checkScoringConvention reports an error for unknown scoring conventions
+func checkScoringConvention(convention string) error {
+	if !slices.Contains(ScoringConventions, convention) {
+		return fmt.Errorf("unknown scoring convention %q, want one of %v", convention, ScoringConventions)
+	}
+	return nil
+}
+
+// ScoreTour returns the length of a tour of the instance under a convention:
+// the cost of its edges, the closing one included if it returns to start,
+// plus the service times of its cities. Every convention but ScoreMatrix
+// measures edges between the cities' coordinates, ignoring a distance matrix,
+// so an instance read from a TSPLIB file of EUC_2D type scores its exact
+// length under ScoreExact and its published one under ScoreNearest
+func ScoreTour(inst *Instance, tour []int, convention string) (float64, error) {
+	if err := checkScoringConvention(convention); err != nil {
+		return 0, err
+	}
+	if err := inst.ValidateTour(tour); err != nil {
+		return 0, err
+	}
+	if convention != ScoreMatrix && !located(inst.Cities) {
+		return 0, fmt.Errorf("scoring by %s needs cities with coordinates, not only distances", convention)
+	}
+	cost := func(i, j int) float64 {
+		switch convention {
+		case ScoreMatrix:
+			return inst.distance(i, j)
+		case ScoreNearest:
+			return nint(inst.Cities[i].Distance(inst.Cities[j]))
+		case ScoreCeil:
+			return math.Ceil(inst.Cities[i].Distance(inst.Cities[j]))
+		}
+		return inst.Cities[i].Distance(inst.Cities[j])
+	}
+	length := 0.0
+	for k, city := range tour {
+		length += inst.Cities[city].ServiceTime
+		if k > 0 {
+			length += cost(tour[k-1], city)
+		}
+	}
+	if inst.ReturnToStart && len(tour) > 1 {
+		length += cost(tour[len(tour)-1], tour[0])
+	}
+	return length, nil
+}
+
+// located reports whether the cities are anywhere but all at one point, as
+// those of instances given only by their distances are
+func located(cities []*City) bool {
+	for _, c := range cities {
+		if c.X != cities[0].X || c.Y != cities[0].Y || c.Z != cities[0].Z {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreCommand implements "score", which reports the length of a tour under
+// every convention asked for
+func scoreCommand(args []string) error {
+	flags := flag.NewFlagSet("score", flag.ExitOnError)
+	tourPath := flags.String("tour", "", "score the tour in this solution JSON or TSPLIB/Concorde tour `file`")
+	conventions := flags.String("convention", strings.Join(ScoringConventions, ","), fmt.Sprintf("comma-separated scoring `conventions`, of %s", strings.Join(ScoringConventions, ", ")))
+	format := flags.String("format", "text", "print the lengths as text or json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s score -tour file [flags] instance\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Reports the length of a tour under each convention: the instance's own costs, exact Euclidean")
+		fmt.Fprintln(flags.Output(), "distances, or distances rounded to the nearest integer or up, as TSPLIB benchmarks are scored.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 || *tourPath == "" {
+		flags.Usage()
+		return fmt.Errorf("score: want a -tour file and exactly one instance file")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("score: unknown format %q, want text or json", *format)
+	}
+	var names []string
+	for _, name := range strings.Split(*conventions, ",") {
+		name = strings.TrimSpace(name)
+		if err := checkScoringConvention(name); err != nil {
+			return fmt.Errorf("score: %w", err)
+		}
+		names = append(names, name)
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	tour, err := loadTourFile(*tourPath)
+	if err != nil {
+		return err
+	}
+	if err := instance.ValidateTour(tour); err != nil {
+		return fmt.Errorf("%s: %w", *tourPath, err)
+	}
+	// Conventions the instance cannot be scored by are reported, not fatal
+	lengths := make(map[string]float64)
+	var unscored []error
+	for _, name := range names {
+		length, err := ScoreTour(instance, tour, name)
+		if err != nil {
+			unscored = append(unscored, err)
+			continue
+		}
+		lengths[name] = length
+	}
+	if len(lengths) == 0 {
+		return errors.Join(unscored...)
+	}
+	if *format == "json" {
+		data, err := json.MarshalIndent(lengths, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "convention\tlength")
+		for _, name := range names {
+			if length, ok := lengths[name]; ok {
+				fmt.Fprintf(tw, "%s\t%g\n", name, length)
+			}
+		}
+		tw.Flush()
+	}
+	for _, err := range unscored {
+		fmt.Fprintln(os.Stderr, "score:", err)
+	}
+	return nil
+}
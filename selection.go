@@ -0,0 +1,99 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Selection weights outside [minSafeWeight, maxSafeWeight] may have lost
+// their precision to underflow, or sum to infinity
+const (
+	minSafeWeight = 0x1p-1022
+	maxSafeWeight = 1e300
+)
+
+// attractiveness combines a pheromone level and heuristic value into a selection weight
+func attractiveness(pheromone, heuristic, alpha, beta float64) float64 {
+	return math.Pow(pheromone, alpha) * math.Pow(heuristic, beta)
+}
+
+// logAttractiveness is the logarithm of attractiveness, which stays in range
+// where large exponents make math.Pow overflow or underflow
+func logAttractiveness(pheromone, heuristic, alpha, beta float64) float64 {
+	power := func(x, exponent float64) float64 {
+		if exponent == 0 {
+			return 0
+		}
+		return exponent * math.Log(x)
+	}
+	return power(pheromone, alpha) + power(heuristic, beta)
+}
+
+// selectionWeights fills weights with the attractiveness of each option,
+// given its pheromone level and heuristic value by option. When any weight
+// leaves the safe range it starts over in log space, subtracting the largest
+// logarithm before exponentiating, which keeps the ratios between the weights
+// as far as they can be represented
+func selectionWeights(weights []float64, alpha, beta float64, option func(k int) (pheromone, heuristic float64)) {
+	safe := true
+	for k := range weights {
+		pheromone, heuristic := option(k)
+		weights[k] = attractiveness(pheromone, heuristic, alpha, beta)
+		safe = safe && weights[k] >= minSafeWeight && weights[k] <= maxSafeWeight
+	}
+	if safe {
+		return
+	}
+	top := math.Inf(-1)
+	for k := range weights {
+		pheromone, heuristic := option(k)
+		weights[k] = logAttractiveness(pheromone, heuristic, alpha, beta)
+		if weights[k] > top {
+			top = weights[k]
+		}
+	}
+	for k, w := range weights {
+		switch {
+		case math.IsInf(top, 1):
+			// Infinitely attractive options share the choice between them
+			weights[k] = 0
+			if w == top {
+				weights[k] = 1
+			}
+		case math.IsNaN(w) || math.IsInf(top, -1):
+			weights[k] = 0
+		default:
+			weights[k] = math.Exp(w - top)
+		}
+	}
+}
+
+// bestWeighted returns the index of the largest weight, the first of equals,
+// NaN weights losing to all others; -1 only for no weights
+func bestWeighted(weights []float64) int {
+	best := -1
+	for k, w := range weights {
+		if best < 0 || w > weights[best] || (math.IsNaN(weights[best]) && !math.IsNaN(w)) {
+			best = k
+		}
+	}
+	return best
+}
+
+// rouletteSelect picks an index with probability proportional to its weight,
+// returning -1 if rounding leaves the wheel short
+func rouletteSelect(rng *rand.Rand, weights []float64) int {
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+	roulette := rng.Float64() * sum
+	cumulativeProbability := 0.0
+	for i, w := range weights {
+		cumulativeProbability += w
+		if cumulativeProbability >= roulette {
+			return i
+		}
+	}
+	return -1
+}
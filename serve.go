@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// serveCommand implements "serve", which runs the solver behind a web
+// server: a live dashboard of one instance, a REST API solving uploaded
+// instances, a gRPC service, or several of them on their own addresses
+func serveCommand(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	logging := logFlags(flags)
+	auto := flags.Bool("auto", true, "without options in the instance, start from parameters recommended for its size and variant")
+	webAddr := flags.String("web", "", "serve a live dashboard of the run on file at this `address`, such as :8080")
+	delay := flags.Duration("delay", 0, "with -web, wait this long between iterations so fast runs can be followed")
+	maxUpdates := flags.Float64("max-updates", dashboardMaxUpdates, "with -web, send at most this many iteration updates a second, batching the iterations between them; new best tours are sent at once, 0 sends every iteration")
+	apiAddr := flags.String("api", "", "serve the REST API at this `address`, such as :8081")
+	jobsDir := flags.String("jobs", "", "with -api, keep instances and runs in this `directory`, resuming queued and interrupted runs on restart")
+	dbPath := flags.String("db", "", "with -api, archive the report of every finished run in this JSON Lines `file`, listed by \"runs list\"")
+	maxRuns := flags.Int("maxruns", 0, "with -api, number of runs solved at once, later ones queued; 0 for one per CPU")
+	metricsAddr := flags.String("metrics", "", "serve Prometheus metrics of the runs at /metrics on this `address`")
+	otlpEndpoint := flags.String("otlp", "", "with -api and -grpc, send OpenTelemetry spans of the runs to the OTLP/HTTP collector at this `url`, $OTEL_EXPORTER_OTLP_ENDPOINT if empty")
+	events := eventFlags(flags)
+	grpcAddr := flags.String("grpc", "", "serve the aco.v1.Solver gRPC service of proto/aco.proto over cleartext HTTP/2 at this `address`")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s serve [-web address file] [-api address] [-grpc address] [-metrics address] [flags]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "With -web, solves the instance in file, streaming every iteration to the dashboard's browsers over")
+		fmt.Fprintln(flags.Output(), "a WebSocket. The parameter panel restarts the run with new parameters. Scripts can follow the same")
+		fmt.Fprintln(flags.Output(), "events as Server-Sent Events from /events.")
+		fmt.Fprintln(flags.Output(), "With -api, accepts instances on POST /instances and runs on POST /runs, polled at /runs/{id},")
+		fmt.Fprintln(flags.Output(), "retuned mid-run with PATCH /runs/{id}/parameters, cancelled with POST /runs/{id}/cancel and")
+		fmt.Fprintln(flags.Output(), "fetched from /runs/{id}/solution. With -jobs, they outlive the server: queued runs and those it")
+		fmt.Fprintln(flags.Output(), "was solving start again when it restarts.")
+		fmt.Fprintln(flags.Output(), "With -grpc, streams the progress and solution of every Solve call until the client cancels it.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *webAddr == "" && *apiAddr == "" && *grpcAddr == "" {
+		flags.Usage()
+		return fmt.Errorf("serve: want at least one of -web, -api and -grpc")
+	}
+	if (*webAddr != "") != (flags.NArg() == 1) || flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("serve: want exactly one instance file with -web, and none without")
+	}
+	if _, err := logging(); err != nil {
+		return err
+	}
+
+	listener, err := events()
+	if err != nil {
+		return err
+	}
+	if listener != nil {
+		defer listener.Close()
+	}
+	var servers []*http.Server
+	tracer := NewTracerFromEnv(*otlpEndpoint)
+	tracer.FlushEvery(5 * time.Second)
+	var metrics *Metrics
+	if *metricsAddr != "" {
+		metrics = NewMetrics()
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics)
+		servers = append(servers, &http.Server{Addr: *metricsAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second})
+		slog.Info("serving metrics", "address", *metricsAddr)
+	}
+	if *webAddr != "" {
+		instance, err := ReadInstance(flags.Arg(0), "")
+		if err != nil {
+			return err
+		}
+		config, err := instanceConfig(instance, parameters, *auto)
+		if err != nil {
+			return err
+		}
+		dashboard := NewDashboard(instance, config)
+		dashboard.Delay = *delay
+		dashboard.MaxUpdates = *maxUpdates
+		dashboard.Metrics, dashboard.Events = metrics, listener
+		if err := dashboard.Start(config); err != nil {
+			return err
+		}
+		servers = append(servers, &http.Server{Addr: *webAddr, Handler: dashboard.Handler(), ReadHeaderTimeout: 10 * time.Second})
+		slog.Info("serving dashboard", "address", *webAddr, "cities", len(instance.Cities))
+	}
+	if *apiAddr != "" {
+		api := NewAPIServer(*maxRuns)
+		api.Metrics, api.Tracer, api.Events = metrics, tracer, listener
+		if *dbPath != "" {
+			api.Archive = &RunArchive{Path: *dbPath}
+		}
+		if *jobsDir != "" {
+			if api.Store, err = OpenJobStore(*jobsDir); err != nil {
+				return err
+			}
+			if err := api.Restore(); err != nil {
+				return err
+			}
+		}
+		servers = append(servers, &http.Server{Addr: *apiAddr, Handler: api.Handler(), ReadHeaderTimeout: 10 * time.Second})
+		slog.Info("serving API", "address", *apiAddr, "maxruns", api.MaxRuns)
+	}
+	if *grpcAddr != "" {
+		// gRPC clients speak HTTP/2 without TLS from the first byte
+		var protocols http.Protocols
+		protocols.SetUnencryptedHTTP2(true)
+		server := &http.Server{Addr: *grpcAddr, Handler: (&GRPCServer{Metrics: metrics, Tracer: tracer, Events: listener}).Handler(), Protocols: &protocols, ReadHeaderTimeout: 10 * time.Second}
+		servers = append(servers, server)
+		slog.Info("serving gRPC", "address", *grpcAddr)
+	}
+	failed := make(chan error, len(servers))
+	for _, server := range servers {
+		go func() { failed <- server.ListenAndServe() }()
+	}
+	return <-failed
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Arc is a directed, weighted edge that also consumes a resource when traversed
+type Arc struct {
+	To       int
+	Weight   float64
+	Resource float64
+}
+
+// Digraph is a directed graph stored as adjacency lists of outgoing arcs
+type Digraph struct {
+	Arcs [][]Arc
+}
+
+// NewDigraph creates a digraph with n nodes and no arcs
+func NewDigraph(n int) *Digraph {
+	return &Digraph{Arcs: make([][]Arc, n)}
+}
+
+// AddArc adds an arc from node from to node to
+func (g *Digraph) AddArc(from, to int, weight, resource float64) error {
+	n := len(g.Arcs)
+	if from < 0 || from >= n || to < 0 || to >= n {
+		return fmt.Errorf("arc %d->%d: node out of range [0, %d)", from, to, n)
+	}
+	if weight < 0 {
+		return fmt.Errorf("arc %d->%d: negative weight %g", from, to, weight)
+	}
+	g.Arcs[from] = append(g.Arcs[from], Arc{To: to, Weight: weight, Resource: resource})
+	return nil
+}
+
+// ConstrainedPath asks for the cheapest simple path from Source to Target that
+// uses at most MaxHops arcs and at most ResourceBudget of the arcs' resource;
+// a non-positive limit means unconstrained
+type ConstrainedPath struct {
+	Graph          *Digraph
+	Source         int
+	Target         int
+	MaxHops        int
+	ResourceBudget float64
+}
+
+// PheromoneShape places pheromone on (from, to) node pairs
+func (cp *ConstrainedPath) PheromoneShape() (rows, cols int) {
+	return len(cp.Graph.Arcs), len(cp.Graph.Arcs)
+}
+
+// NewConstruction places the ant on the source node
+func (cp *ConstrainedPath) NewConstruction() Construction {
+	visited := make([]bool, len(cp.Graph.Arcs))
+	visited[cp.Source] = true
+	return &pathConstruction{instance: cp, path: []int{cp.Source}, visited: visited}
+}
+
+// pathConstruction walks outgoing arcs until the target is reached or the ant is stuck
+type pathConstruction struct {
+	instance *ConstrainedPath
+	path     []int
+	visited  []bool
+	weight   float64
+	resource float64
+	// offers holds the arcs offered by the last Moves call, by Move.Option
+	offers []Arc
+}
+
+// Moves offers every feasible arc out of the current node that no other arc
+// to the same node beats: the cheapest one, and under a resource budget also
+// the heavier ones that use less of it, since they may leave room for a
+// cheaper path further on
+func (c *pathConstruction) Moves() []Move {
+	current := c.path[len(c.path)-1]
+	if current == c.instance.Target {
+		return nil
+	}
+	if c.instance.MaxHops > 0 && len(c.path)-1 >= c.instance.MaxHops {
+		return nil
+	}
+	budget := c.instance.ResourceBudget > 0
+	var feasible []Arc
+	for _, a := range c.instance.Graph.Arcs[current] {
+		if c.visited[a.To] {
+			continue
+		}
+		if budget && c.resource+a.Resource > c.instance.ResourceBudget {
+			continue
+		}
+		feasible = append(feasible, a)
+	}
+	// dominates reports whether a is at least as good as b on weight and,
+	// under a budget, resource; of equal arcs the first is kept
+	dominates := func(a, b Arc, first bool) bool {
+		if a.Weight > b.Weight || budget && a.Resource > b.Resource {
+			return false
+		}
+		return first || a.Weight < b.Weight || budget && a.Resource < b.Resource
+	}
+	c.offers = c.offers[:0]
+	var moves []Move
+	for k, a := range feasible {
+		dominated := false
+		for l, b := range feasible {
+			if l != k && b.To == a.To && dominates(b, a, l < k) {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			moves = append(moves, Move{Row: current, Col: a.To, Heuristic: 1 / (1 + a.Weight), Option: len(c.offers)})
+			c.offers = append(c.offers, a)
+		}
+	}
+	return moves
+}
+
+func (c *pathConstruction) Apply(m Move) {
+	arc := c.offers[m.Option]
+	c.path = append(c.path, m.Col)
+	c.visited[m.Col] = true
+	c.weight += arc.Weight
+	c.resource += arc.Resource
+}
+
+// Cost is the path weight, +Inf if the ant got stuck before reaching the target
+func (c *pathConstruction) Cost() float64 {
+	if c.path[len(c.path)-1] != c.instance.Target {
+		return math.Inf(1)
+	}
+	return c.weight
+}
+
+func (c *pathConstruction) Solution() []int {
+	return append([]int(nil), c.path...)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestConstrainedPathResources checks that the resources of DIMACS arcs are
+// read and kept within the budget, and that a seeded colony finds the same
+// path every time. The cheap arcs 1->2->4 use up the budget, leaving the
+// dearer 1->3->4
+func TestConstrainedPathResources(t *testing.T) {
+	graph, err := LoadGraph(strings.NewReader("p sp 4 5\na 1 2 1 5\na 2 4 1 5\na 1 3 2 1\na 3 4 2 1\ne 2 3 9\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arc := graph.Arcs[0][0]; arc.Resource != 5 {
+		t.Fatalf("arc %+v, want resource 5", arc)
+	}
+	if arc := graph.Arcs[1][1]; arc.To != 2 || arc.Resource != 0 {
+		t.Fatalf("edge arc %+v, want to 2 of no resource", arc)
+	}
+	config := DefaultConfig()
+	config.Seed = 4
+	for _, c := range []struct {
+		budget float64
+		path   []int
+		cost   float64
+	}{
+		{0, []int{0, 1, 3}, 2},
+		{6, []int{0, 2, 3}, 4},
+	} {
+		var first []int
+		for range 2 {
+			path, cost, err := solveProblem(&ConstrainedPath{Graph: graph, Source: 0, Target: 3, ResourceBudget: c.budget}, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(path, c.path) || cost != c.cost {
+				t.Errorf("budget %g: path %v of cost %g, want %v of cost %g", c.budget, path, cost, c.path, c.cost)
+			}
+			if first != nil && !slices.Equal(path, first) {
+				t.Errorf("budget %g: seed %d found %v, then %v", c.budget, config.Seed, first, path)
+			}
+			first = path
+		}
+	}
+}
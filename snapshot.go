@@ -0,0 +1,202 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Snapshot is a compact record of a run after an iteration: its statistics,
+// the parameters in effect, a summary of the trails and the edges of the best
+// tour so far. Snapshots of many runs make a dataset for learning which
+// parameters work, or when a run has stopped paying off, from run traces
+type Snapshot struct {
+	// Run names the run, such as the instance and seed
+	Run       string         `json:"run"`
+	Iteration int            `json:"iteration"`
+	Variant   string         `json:"variant"`
+	Cities    int            `json:"cities"`
+	Ants      int            `json:"ants"`
+	Alpha     float64        `json:"alpha"`
+	Beta      float64        `json:"beta"`
+	Rho       float64        `json:"rho"`
+	Stats     IterationStats `json:"stats"`
+	// SinceImprovement is the number of iterations since the best tour last
+	// got shorter, and Restarts the restarts of the trails so far
+	SinceImprovement int `json:"since_improvement"`
+	Restarts         int `json:"restarts"`
+	// TrailMean, TrailStdDev, TrailMin and TrailMax summarize the trails of
+	// every edge, and BestTrailMean those of the best tour's edges
+	TrailMean     float64 `json:"trail_mean"`
+	TrailStdDev   float64 `json:"trail_stddev"`
+	TrailMin      float64 `json:"trail_min"`
+	TrailMax      float64 `json:"trail_max"`
+	BestTrailMean float64 `json:"best_trail_mean"`
+	// BestEdges are the edges of the best tour so far, from their lower city
+	// on symmetric instances
+	BestEdges [][2]int `json:"best_edges"`
+}
+
+// Snapshot returns a snapshot of the run after its last iteration, whose
+// statistics are stats. It reads every trail, so it takes as long as an
+// update of the full matrix
+func (s *Solver) Snapshot(stats IterationStats) Snapshot {
+	ac := s.Colony
+	snapshot := Snapshot{
+		Run:              s.EventRun,
+		Iteration:        stats.Iteration,
+		Variant:          cmp.Or(ac.Variant, AntSystem),
+		Cities:           len(ac.Cities),
+		Ants:             ac.NumAnts,
+		Alpha:            ac.Alpha,
+		Beta:             ac.Beta,
+		Rho:              ac.Rho,
+		Stats:            stats,
+		SinceImprovement: s.iteration - s.lastImprovement,
+		Restarts:         len(s.restarts),
+		TrailMin:         math.Inf(1),
+		TrailMax:         math.Inf(-1),
+	}
+	n := len(ac.Cities)
+	sum, squares, edges := 0.0, 0.0, 0
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+			trail := ac.pheromone(i, j)
+			sum += trail
+			squares += trail * trail
+			edges++
+			snapshot.TrailMin = math.Min(snapshot.TrailMin, trail)
+			snapshot.TrailMax = math.Max(snapshot.TrailMax, trail)
+		}
+	}
+	if edges == 0 {
+		snapshot.TrailMin, snapshot.TrailMax = 0, 0
+	} else {
+		snapshot.TrailMean = sum / float64(edges)
+		snapshot.TrailStdDev = math.Sqrt(max(squares/float64(edges)-snapshot.TrailMean*snapshot.TrailMean, 0))
+	}
+	if len(s.bestTour) > 0 {
+		tour := ac.CanonicalTour(s.bestTour)
+		snapshot.BestEdges = tourEdges(tour, ac.ReturnToStart, !ac.symmetric())
+		for _, e := range snapshot.BestEdges {
+			snapshot.BestTrailMean += ac.pheromone(e[0], e[1]) / float64(len(snapshot.BestEdges))
+		}
+	}
+	return snapshot
+}
+
+// snapshotHeader names the columns SnapshotWriter writes
+var snapshotHeader = []string{"run", "iteration", "evaluations", "variant", "cities", "ants", "alpha", "beta", "rho", "q0",
+	"best", "mean", "worst", "stddev", "best_so_far", "since_improvement", "restarts", "diversity", "branching", "identical",
+	"shared_edges", "trail_mean", "trail_stddev", "trail_min", "trail_max", "best_trail_mean", "best_edges"}
+
+// SnapshotWriter writes snapshots as CSV rows, one per snapshot, for any
+// number of solvers at once. Best tour edges are written "i-j", separated by
+// spaces. Write errors are kept for Close to return
+type SnapshotWriter struct {
+	mu     sync.Mutex
+	cw     *csv.Writer
+	closer io.Closer
+	err    error
+}
+
+// NewSnapshotWriter writes snapshots to w, after a header unless header is false
+func NewSnapshotWriter(w io.Writer, header bool) *SnapshotWriter {
+	sw := &SnapshotWriter{cw: csv.NewWriter(w)}
+	if header {
+		sw.cw.Write(snapshotHeader)
+	}
+	return sw
+}
+
+// OpenSnapshotFile appends snapshots to the file at path, creating it with a
+// header if it is new or empty, so that the runs of many commands gather in
+// one dataset; "-" writes them to standard output
+func OpenSnapshotFile(path string) (*SnapshotWriter, error) {
+	if path == "-" {
+		return NewSnapshotWriter(os.Stdout, true), nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	sw := NewSnapshotWriter(f, info.Size() == 0)
+	sw.closer = f
+	return sw, nil
+}
+
+// Write adds a snapshot to the dataset
+func (sw *SnapshotWriter) Write(snapshot Snapshot) {
+	format := func(x float64) string { return strconv.FormatFloat(x, 'g', -1, 64) }
+	edges := make([]string, len(snapshot.BestEdges))
+	for k, e := range snapshot.BestEdges {
+		edges[k] = strconv.Itoa(e[0]) + "-" + strconv.Itoa(e[1])
+	}
+	stats := snapshot.Stats
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.err != nil {
+		return
+	}
+	sw.err = sw.cw.Write([]string{
+		snapshot.Run,
+		strconv.Itoa(snapshot.Iteration),
+		strconv.Itoa(stats.Evaluations),
+		snapshot.Variant,
+		strconv.Itoa(snapshot.Cities),
+		strconv.Itoa(snapshot.Ants),
+		format(snapshot.Alpha),
+		format(snapshot.Beta),
+		format(snapshot.Rho),
+		format(stats.Q0),
+		format(stats.Best),
+		format(stats.Mean),
+		format(stats.Worst),
+		format(stats.StdDev),
+		format(stats.BestSoFar),
+		strconv.Itoa(snapshot.SinceImprovement),
+		strconv.Itoa(snapshot.Restarts),
+		format(stats.Diversity),
+		format(stats.Branching),
+		format(stats.Identical),
+		strconv.Itoa(len(stats.SharedEdges)),
+		format(snapshot.TrailMean),
+		format(snapshot.TrailStdDev),
+		format(snapshot.TrailMin),
+		format(snapshot.TrailMax),
+		format(snapshot.BestTrailMean),
+		strings.Join(edges, " "),
+	})
+}
+
+// Close flushes the snapshots written, closes the file OpenSnapshotFile
+// opened, and returns the first error writing them
+func (sw *SnapshotWriter) Close() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.cw.Flush()
+	err := sw.err
+	if err == nil {
+		err = sw.cw.Error()
+	}
+	if sw.closer != nil {
+		if closeErr := sw.closer.Close(); err == nil {
+			err = closeErr
+		}
+		sw.closer = nil
+	}
+	return err
+}
@@ -0,0 +1,665 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// solveCommand implements "solve", which runs the colony on one instance and
+// writes the best tour in the requested formats
+func solveCommand(args []string) error {
+	flags := flag.NewFlagSet("solve", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	auto := flags.Bool("auto", true, "without options in the instance, start from parameters recommended for its size and variant rather than the fixed defaults")
+	logging := logFlags(flags)
+	throttling := throttleFlags(flags)
+	tsplibPath := flags.String("tsplib", "", "solve the TSPLIB `file` instead of the built-in example")
+	csvPath := flags.String("csv", "", "solve the cities in this CSV `file` of id,x,y or name,lat,lon rows")
+	instancePath := flags.String("instance", "", "solve the JSON instance in this `file`")
+	geoJSONPath := flags.String("geojson", "", "solve the Point features of this GeoJSON `file`")
+	graphPath := flags.String("graph", "", "tour the nodes of this DIMACS or edge-list graph `file` along its arcs")
+	source := flags.Int("source", 0, "with -graph and -target, find a path from this 0-based `node`")
+	target := flags.Int("target", -1, "with -graph, find a shortest path to this 0-based `node` instead of a tour")
+	maxHops := flags.Int("maxhops", 0, "limit the shortest path to this many arcs, 0 for no limit")
+	budget := flags.Float64("budget", 0, "limit the shortest path's total arc resource, the value after an arc's weight in the graph file, 0 for no limit")
+	osrmURL := flags.String("osrm", "", "use road costs from the OSRM-compatible table service at this `url` for geographic cities")
+	osrmProfile := flags.String("profile", "driving", "with -osrm, the routing `profile`")
+	osrmMetric := flags.String("metric", "duration", "with -osrm, minimize road duration or distance")
+	osrmCache := flags.String("cache", "", "with -osrm, cache fetched matrices in this `directory`")
+	matrixPath := flags.String("matrix", "", "read the distances between the cities from this CSV or binary `file`, as written by the matrix command")
+	optTourPath := flags.String("opttour", "", "report the gap to the optimal tour in this TSPLIB, LKH or Concorde tour `file`")
+	solutionPath := flags.String("solution", "", "write the solution as JSON to this `file`, - for stdout")
+	dbPath := flags.String("db", "", "archive the run report in this JSON Lines `file`, listed by \"runs list\"")
+	statePath := flags.String("state", "", "write the final pheromone trails and best tour to this `file`, as protobuf if it ends in .pb, JSON otherwise")
+	crashPath := flags.String("crash", "aco-crash.json", "on a panic or SIGTERM, write the best tour so far to this JSON `file` before exiting, empty for none")
+	recordPath := flags.String("record", "", "record every random draw of the run to this JSON `file`, for -replay")
+	replayPath := flags.String("replay", "", "replay the random draws recorded in this `file` by -record, with its seed, to reproduce that run and tell where it diverges")
+	warmPath := flags.String("warm", "", "start from the trails in this state `file`, written by -state on an earlier version of the instance, matching cities by name or coordinates")
+	warmSmoothing := flags.Float64("warm-smoothing", 0.25, "with -warm, pull the carried-over trails this `share` of the way towards their mean")
+	historyPath := flags.String("history", "", "write per-iteration tour lengths and diversity indicators as CSV to this `file`, - for stdout")
+	snapshotsPath := flags.String("snapshots", "", "append per-iteration snapshots of the statistics, parameters, trail summary and best tour edges as CSV to this dataset `file`, - for stdout")
+	snapshotEvery := flags.Int("snapshot-every", 1, "with -snapshots, snapshot every this many iterations and after the last")
+	showTimings := flags.Bool("timings", false, "print the time spent computing distances, sorting candidate lists, building tours, in local search and updating trails, in all and per iteration")
+	reportPath := flags.String("output", "", "write a JSON run report with the solution, timings and machine to this `file`, - for stdout")
+	geoJSONOutPath := flags.String("geojsonout", "", "write the best tour as a GeoJSON LineString to this `file`")
+	gpxPath := flags.String("gpx", "", "write the best tour of a geographic instance as a GPX track to this `file`")
+	tspOutPath := flags.String("tspout", "", "write the instance as a TSPLIB problem for Concorde or LKH to this `file`")
+	lkhParPath := flags.String("lkhpar", "", "with -tspout, write an LKH parameter file solving it to this `file`")
+	scale := flags.Float64("scale", 1, "with -tspout, multiply distances by this `factor` before rounding them to integers")
+	tourOutPath := flags.String("tourout", "", "write the best tour as a TSPLIB tour to this `file`")
+	quiet := flags.Bool("quiet", false, "print nothing but the result on stdout, as JSON unless -format says otherwise")
+	resultFormat := flags.String("format", "", "print the result on stdout as json, csv or tour (space-separated cities) instead of text")
+	dir := flags.String("dir", "", "solve every instance file in this `directory` instead of a single instance")
+	outDir := flags.String("out", "results", "with -dir, write a report per instance and summary.csv to this `directory`")
+	parallel := flags.Int("parallel", 0, "with -dir, number of files to solve at once, 0 for one per core -max-cores allows")
+	showProgress := flags.Bool("progress", false, "show a live progress bar with the best length, improvement rate, diversity and time left on stderr")
+	textMap := flags.String("map", "", "draw the cities and best tour as text in the terminal at the end of the run (end), or redrawn as it improves (live)")
+	asciiMap := flags.Bool("ascii", false, "with -map, draw in plain ASCII rather than Unicode")
+	otlpEndpoint := flags.String("otlp", "", "send OpenTelemetry spans of the run to the OTLP/HTTP collector at this `url`, $OTEL_EXPORTER_OTLP_ENDPOINT if empty")
+	events := eventFlags(flags)
+	timeout := flags.Duration("timeout", 0, "stop after this long with the best tour so far, 0 for no limit; an interrupt stops the run likewise")
+	project := flags.Bool("project", false, "solve geographic cities projected to kilometres east and north of their centre rather than on raw degrees")
+	normalize := flags.Bool("normalize", false, "move the cities into the unit square before solving, after -project")
+	coordScale := flags.Float64("scale-coords", 1, "multiply the coordinates, and any distance matrix, by this `factor` before solving, after -normalize")
+	rounding := flags.String("round", RoundNone, fmt.Sprintf("round the distances before solving, after -scale-coords, one of %s", strings.Join(RoundingModes, ", ")))
+	edgeAttributesPath := flags.String("edge-attributes", "", "read attributes of edges, such as tolls, for -edge-weights from this CSV `file` of from,to,name... rows")
+	objectives := flags.String("objectives", "", "minimize these comma-separated edge attributes, length among them, at once and print the tours none of the others beats in every one")
+	duplicates := flags.String("duplicates", DuplicatesFloor, "handle cities at zero distance by a floor on the costs the heuristic inverts, or merge them, visiting each group at once")
+	restarts := flags.Int("restarts", 1, "run this many colonies with consecutive seeds at once, within the same -timeout, and keep the best tour")
+	remote := flags.String("remote", "", "move the ants on the construction workers (aco worker) at these comma-separated `addresses`")
+	kmlPath := flags.String("kml", "", "write the best tour of a geographic instance as a KML path to this `file`")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s solve [flags] [file | -]\n       %s solve -dir directory [-out directory] [flags]\n\n", os.Args[0], os.Args[0])
+		fmt.Fprintln(flags.Output(), "Solves the instance in file, or read from stdin if file is -, detecting its format.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *quiet && *resultFormat == "" {
+		*resultFormat = "json"
+	}
+	if err := checkResultFormat(*resultFormat); err != nil {
+		return err
+	}
+	if *textMap != "" && *textMap != "end" && *textMap != "live" {
+		return fmt.Errorf("unknown -map %q, want end or live", *textMap)
+	}
+	if err := checkDuplicateMode(*duplicates); err != nil {
+		return err
+	}
+	if err := checkRoundingMode(*rounding); err != nil {
+		return err
+	}
+	logEvery, err := logging()
+	if err != nil {
+		return err
+	}
+	throttle, err := throttling()
+	if err != nil {
+		return err
+	}
+
+	if *snapshotEvery < 1 {
+		return fmt.Errorf("-snapshot-every must be at least 1")
+	}
+	var snapshots *SnapshotWriter
+	if *snapshotsPath != "" {
+		if snapshots, err = OpenSnapshotFile(*snapshotsPath); err != nil {
+			return err
+		}
+		defer snapshots.Close()
+	}
+
+	if *dir != "" {
+		err := solveDirectory(*dir, *outDir, *parallel, func(instance *Instance) (Config, error) {
+			return instanceConfig(instance, parameters, *auto)
+		}, func(s *Solver) {
+			s.LogEvery, s.Throttle = logEvery, throttle
+			s.Snapshots, s.SnapshotEvery = snapshots, *snapshotEvery
+		})
+		if snapshots != nil {
+			if closeErr := snapshots.Close(); err == nil && closeErr != nil {
+				err = fmt.Errorf("%s: %w", *snapshotsPath, closeErr)
+			}
+		}
+		return err
+	}
+
+	// Create cities, from the named input file or standard input if given
+	instance := &Instance{Cities: []*City{
+		{X: 0, Y: 0},
+		{X: 1, Y: 1},
+		{X: 2, Y: 2},
+		{X: 3, Y: 3},
+		{X: 4, Y: 4},
+	}}
+	inputPath, format := flags.Arg(0), ""
+	for _, input := range []struct{ path, format string }{
+		{*tsplibPath, FormatTSPLIB},
+		{*csvPath, FormatCSV},
+		{*geoJSONPath, FormatGeoJSON},
+		{*graphPath, FormatGraph},
+		{*instancePath, FormatJSON},
+	} {
+		if input.path != "" {
+			inputPath, format = input.path, input.format
+			break
+		}
+	}
+	if format == FormatGraph && *target >= 0 {
+		graph, err := ReadGraph(inputPath)
+		if err != nil {
+			return err
+		}
+		config, err := parameters(DefaultConfig())
+		if err != nil {
+			return err
+		}
+		return solvePath(graph, *source, *target, *maxHops, *budget, config)
+	}
+	if inputPath != "" {
+		// Loading a large instance takes a while, which an interrupt cuts short
+		loadCtx, stopLoading := signal.NotifyContext(context.Background(), os.Interrupt)
+		var progress LoadProgressFunc
+		if *showProgress {
+			progress = LoadProgressLine(os.Stderr)
+		}
+		instance, err = ReadInstanceContext(loadCtx, inputPath, format, progress)
+		stopLoading()
+		if err != nil {
+			return err
+		}
+		slog.Debug("read instance", "path", inputName(inputPath), "name", instance.Name, "cities", len(instance.Cities))
+	}
+
+	if *osrmURL != "" {
+		if !instance.Geographic {
+			return fmt.Errorf("-osrm needs cities with latitude and longitude")
+		}
+		provider := &OSRMProvider{
+			BaseURL:     *osrmURL,
+			Profile:     *osrmProfile,
+			Metric:      *osrmMetric,
+			AccessToken: os.Getenv("MAPBOX_ACCESS_TOKEN"),
+			CacheDir:    *osrmCache,
+		}
+		start := time.Now()
+		distances, err := provider.Matrix(instance.Cities)
+		if err != nil {
+			return err
+		}
+		instance.Distances, instance.matrixTime = distances, time.Since(start)
+	}
+	if *matrixPath != "" {
+		start := time.Now()
+		distances, err := ReadMatrixFile(*matrixPath)
+		if err != nil {
+			return err
+		}
+		if err := instance.setMatrix(distances); err != nil {
+			return fmt.Errorf("%s: %w", *matrixPath, err)
+		}
+		instance.matrixTime = time.Since(start)
+	}
+	if *edgeAttributesPath != "" {
+		if err := LoadEdgeAttributesFile(*edgeAttributesPath, instance); err != nil {
+			return err
+		}
+	}
+
+	// Preprocess the raw input, keeping it for the exports and the steps to
+	// map the results back with
+	raw, steps := instance, []PreprocessStep(nil)
+	instance, steps, err = Preprocess(instance, Preprocessing{Project: *project, Normalize: *normalize, Scale: *coordScale, Round: *rounding})
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		slog.Debug("preprocessed", "step", step.Step)
+	}
+
+	// Set ACO parameters, flags given on the command line overriding the instance's options
+	config, err := instanceConfig(instance, parameters, *auto)
+	if err != nil {
+		return err
+	}
+
+	if *objectives != "" {
+		return solveMultiObjective(instance, config, *objectives)
+	}
+
+	// Solve merged duplicates as one city, keeping the instance to map the
+	// solution back to
+	original, groups := instance, duplicateGroups(instance)
+	if len(groups) < len(instance.Cities) {
+		slog.Info("duplicate cities", "cities", len(instance.Cities), "distinct", len(groups), "handling", *duplicates)
+		if *duplicates == DuplicatesMerge {
+			if instance, groups, err = MergeDuplicates(instance); err != nil {
+				return err
+			}
+			steps = append(steps, PreprocessStep{Step: "merge", Groups: groups})
+		}
+	}
+	merged := instance != original
+
+	if *restarts < 1 {
+		return fmt.Errorf("-restarts must be at least 1")
+	}
+	if *restarts > 1 && (*showProgress || *textMap == "live" || *remote != "" || *recordPath != "" || *replayPath != "") {
+		return fmt.Errorf("-restarts cannot be combined with -progress, -map live, -remote, -record or -replay")
+	}
+	var recorded *RandomLog
+	if *replayPath != "" {
+		if recorded, err = LoadRandomLog(*replayPath); err != nil {
+			return err
+		}
+		config.Seed = recorded.Seed
+	}
+
+	// Run ACO algorithm, keeping the best tour found, until done, interrupted
+	// or out of time
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	multi, err := NewMultiStart(ctx, instance, config, *restarts)
+	if err != nil {
+		return err
+	}
+	solver := multi.Solvers[0]
+	tracer := NewTracerFromEnv(*otlpEndpoint)
+	listener, err := events()
+	if err != nil {
+		return err
+	}
+	// Keep the best tour where a crash or SIGTERM cannot lose it
+	if *crashPath != "" {
+		recorder := NewCrashRecorder(*crashPath)
+		if listener == nil {
+			listener = recorder
+		} else {
+			listener = RunListeners{listener, recorder}
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				recorder.Write(fmt.Sprint("panic: ", r), debug.Stack())
+				panic(r)
+			}
+		}()
+		terminate := make(chan os.Signal, 1)
+		notifyTerminate(terminate)
+		defer func() {
+			signal.Stop(terminate)
+			close(terminate)
+		}()
+		go func() {
+			if sig, ok := <-terminate; ok {
+				recorder.Write("signal: "+sig.String(), nil)
+				os.Exit(128 + 15)
+			}
+		}()
+	}
+	var previous *ColonyState
+	if *warmPath != "" {
+		if previous, err = LoadColonyState(*warmPath); err != nil {
+			return err
+		}
+	}
+	// Name the runs by the instance and seed, which tell how to reproduce them
+	runName := instance.Name
+	if runName == "" {
+		runName = "solve"
+	}
+	for _, s := range multi.Solvers {
+		seed := s.Solution().Seed
+		s.LogEvery, s.Tracer, s.Events, s.Throttle = logEvery, tracer, listener, throttle
+		s.Snapshots, s.SnapshotEvery = snapshots, *snapshotEvery
+		s.EventRun = fmt.Sprintf("%s/%d", runName, seed)
+		if *restarts > 1 {
+			s.Logger = slog.Default().With("seed", seed)
+		}
+		if previous != nil {
+			matched, err := s.WarmStart(previous, *warmSmoothing)
+			if err != nil {
+				return fmt.Errorf("%s: %w", *warmPath, err)
+			}
+			if s == solver {
+				slog.Info("warm start", "matched", matched, "cities", len(instance.Cities), "previous", len(previous.Cities))
+			}
+		}
+	}
+	var replay *RandomReplay
+	switch {
+	case *replayPath != "":
+		replay = solver.ReplayRandom(recorded)
+	case *recordPath != "":
+		recorded = solver.RecordRandom()
+	}
+	if *remote != "" {
+		if solver.Remote, err = NewRemoteConstruction(strings.Split(*remote, ","), instance, config); err != nil {
+			return err
+		}
+	}
+	// On SIGHUP, read the parameters again, the -config file and ACO_*
+	// variables still overridden by flags, and change those that may change
+	// mid-run
+	hangup := make(chan os.Signal, 1)
+	notifyReload(hangup)
+	defer func() {
+		signal.Stop(hangup)
+		close(hangup)
+	}()
+	go func(instance *Instance) {
+		for range hangup {
+			updated, err := instanceConfig(instance, parameters, *auto)
+			for _, s := range multi.Solvers {
+				if err == nil {
+					err = s.Reload(updated)
+				}
+			}
+			if err != nil {
+				slog.Error("reloading parameters", "err", err)
+			}
+		}
+	}(instance)
+	endTrace := solver.TraceRun()
+	slog.Debug("solving", "variant", config.Variant, "ants", config.NumAnts, "iterations", config.iterationLimit(), "seed", solver.Solution().Seed)
+	startedAt := time.Now()
+	var solution *Solution
+	switch {
+	case *restarts > 1:
+		solver, err = multi.RunContext(ctx)
+		solution = solver.Solution()
+		slog.Info("best of restarts", "restarts", *restarts, "seed", solution.Seed, "length", solution.Length)
+	case *textMap == "live":
+		live := NewLiveMap(os.Stderr, instance.Cities, instance.ReturnToStart, *asciiMap, config.iterationLimit())
+		for !solver.Done() {
+			stats, err := solver.StepContext(ctx)
+			if err != nil {
+				break
+			}
+			live.Update(solver, stats)
+		}
+	case *showProgress:
+		progress := NewProgressBar(os.Stderr, config.iterationLimit())
+		for !solver.Done() {
+			stats, err := solver.StepContext(ctx)
+			if err != nil {
+				break
+			}
+			progress.Update(stats)
+		}
+		progress.Done()
+	}
+	if solution == nil {
+		solution, err = solver.RunContext(ctx)
+	}
+	if err != nil && len(solution.Tour) == 0 {
+		return fmt.Errorf("stopped before the first tour: %w", err)
+	} else if err != nil {
+		slog.Warn("stopped early", "reason", err, "iterations", len(solution.History))
+	}
+	if replay != nil {
+		if err := replay.Err(); err != nil {
+			slog.Warn("replay diverged", "reason", err)
+		}
+	}
+	if *recordPath != "" {
+		if err := writeJSON(*recordPath, recorded); err != nil {
+			return err
+		}
+	}
+	stop()
+	endTrace()
+	if err := solver.Tracer.Flush(); err != nil {
+		return err
+	}
+	if solver.Events != nil {
+		if err := solver.Events.Close(); err != nil {
+			return err
+		}
+	}
+	colony := solver.Colony
+	if merged {
+		solution = ExpandSolution(solution, groups)
+		if colony, err = newAntColony(context.Background(), config.NumAnts, config.Alpha, config.Beta, config.Rho, config.Q,
+			original.Cities, original.Distances, original.metric(), false); err != nil {
+			return err
+		}
+		colony.ReturnToStart = original.ReturnToStart
+	}
+	// Lengths stay those of the preprocessed instance, the cities those of
+	// the input
+	instance = raw
+	solution.Metadata = tourMetadata(instance.Cities, solution.Tour)
+
+	// Compare with the optimum from the given tour file or the registry of known optima
+	optimum, known := 0.0, false
+	if *optTourPath != "" {
+		optTour, err := LoadTour(*optTourPath)
+		if err == nil {
+			err = instance.ValidateTour(optTour)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", *optTourPath, err)
+		}
+		optimum, known = colony.TourLength(optTour), true
+	} else if instance.Name != "" {
+		optimum, known = KnownOptima[instance.Name]
+	}
+
+	// Print results, to stderr when stdout carries a structured export, and
+	// not at all in quiet mode
+	out := io.Writer(os.Stdout)
+	for _, path := range []string{*solutionPath, *reportPath, *historyPath, *snapshotsPath, *geoJSONOutPath, *gpxPath, *kmlPath} {
+		if path == "-" || *resultFormat != "" {
+			out = os.Stderr
+		}
+	}
+	if *quiet {
+		out = io.Discard
+	}
+	if hasNames(instance.Cities) && len(solution.Tour) > 0 {
+		fmt.Fprintln(out, "Best tour:", FormatTour(instance.Cities, solution.Tour))
+		WriteItinerary(out, instance.Cities, colony.distance, solution.Tour, instance.ReturnToStart)
+	} else {
+		fmt.Fprintln(out, "Best tour:", solution.Tour)
+	}
+	fmt.Fprintln(out, "Best tour length:", solution.Length)
+	if solver.Colony.Objective != nil {
+		fmt.Fprintln(out, "Best tour score:", solution.Score)
+	}
+	if err := WriteRouteCosts(out, solution.Routes); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Run fingerprint:", solver.Provenance().Fingerprint)
+	if config.Adaptive {
+		alpha, beta, q0 := solver.Colony.AdaptedParameters()
+		fmt.Fprintf(out, "Adapted parameters: mean alpha %.3g, beta %.3g, q0 %.3g\n", alpha, beta, q0)
+	}
+	if config.Candidates > 0 && len(solution.History) > 0 {
+		fallbacks := 0.0
+		for _, stats := range solution.History {
+			fallbacks += stats.CandidateFallbacks / float64(len(solution.History))
+		}
+		fmt.Fprintf(out, "Candidate lists: %d cities, %.2f%% of moves fell back to all cities\n", solution.Candidates, 100*fallbacks)
+	}
+	if sizes := solver.Colony.CasteSizes(); sizes != nil {
+		castes := make([]string, len(sizes))
+		for k, size := range sizes {
+			castes[k] = fmt.Sprintf("%d %s", size, solver.Colony.Castes[k].Name)
+		}
+		fmt.Fprintf(out, "Castes: %s ants\n", strings.Join(castes, ", "))
+	}
+	if stats, ok := solver.Colony.LengthCacheStats(); ok {
+		fmt.Fprintf(out, "Length cache: %.1f%% of %d lookups hit, %d tours cached\n",
+			100*stats.HitRate, stats.Hits+stats.Misses, stats.Entries)
+	}
+	if *showTimings {
+		if err := WriteTimings(out, solver.Timings()); err != nil {
+			return err
+		}
+	}
+	if known {
+		fmt.Fprintln(out, "Optimal tour length:", optimum)
+		fmt.Fprintf(out, "Gap to optimal: %.2f%%\n", Gap(solution.Length, optimum))
+	}
+	if *textMap == "end" {
+		width, height := terminalSize()
+		if err := WriteTextMap(out, instance.Cities, solution.Tour, instance.ReturnToStart, width, height-4, *asciiMap); err != nil {
+			return err
+		}
+	}
+	if *resultFormat != "" {
+		result := NewResult(instance.Cities, solution)
+		if known {
+			result.SetOptimum(optimum)
+		}
+		if err := result.Write(os.Stdout, *resultFormat); err != nil {
+			return err
+		}
+	}
+
+	if *solutionPath != "" {
+		if err := writeJSON(*solutionPath, solution); err != nil {
+			return err
+		}
+	}
+	if *statePath != "" {
+		if err := WriteColonyState(*statePath, solver.State()); err != nil {
+			return err
+		}
+	}
+	if snapshots != nil {
+		if err := snapshots.Close(); err != nil {
+			return fmt.Errorf("%s: %w", *snapshotsPath, err)
+		}
+	}
+	if *historyPath != "" {
+		if err := writeFile(*historyPath, func(w io.Writer) error {
+			return WriteHistoryCSV(w, solution.History)
+		}); err != nil {
+			return err
+		}
+	}
+	report := NewRunReport(instance, inputPath, solver, startedAt)
+	report.Solution = solution
+	report.Preprocessing = steps
+	if *reportPath != "" {
+		if err := writeJSON(*reportPath, report); err != nil {
+			return err
+		}
+	}
+	if *dbPath != "" {
+		archive := &RunArchive{Path: *dbPath}
+		if err := archive.Append(report); err != nil {
+			return err
+		}
+	}
+	if *tspOutPath != "" {
+		if err := writeFile(*tspOutPath, func(w io.Writer) error {
+			return WriteTSPLIB(w, instance, colony.distance, *scale)
+		}); err != nil {
+			return err
+		}
+		if *lkhParPath != "" {
+			if err := writeFile(*lkhParPath, func(w io.Writer) error {
+				return WriteLKHParameters(w, *tspOutPath, strings.TrimSuffix(*tspOutPath, filepath.Ext(*tspOutPath))+".tour")
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	if *tourOutPath != "" {
+		if err := writeFile(*tourOutPath, func(w io.Writer) error {
+			return WriteTSPLIBTour(w, instance.Name, solution.Tour)
+		}); err != nil {
+			return err
+		}
+	}
+	exports := []struct {
+		path  string
+		write func(w io.Writer, cities []*City, solution *Solution, closed bool) error
+	}{
+		{*geoJSONOutPath, WriteTourGeoJSON},
+		{*gpxPath, WriteTourGPX},
+		{*kmlPath, WriteTourKML},
+	}
+	for _, export := range exports {
+		if export.path == "" {
+			continue
+		}
+		if export.path != *geoJSONOutPath && !instance.Geographic {
+			return fmt.Errorf("%s: GPX and KML need cities with latitude and longitude", export.path)
+		}
+		if err := writeFile(export.path, func(w io.Writer) error {
+			return export.write(w, instance.Cities, solution, instance.ReturnToStart)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// solvePath runs the colony on a constrained shortest path problem and prints the best path
+// solveMultiObjective minimizes the comma-separated edge attributes of
+// instance at once and prints the Pareto archive, a line per tour with its
+// score under every objective
+func solveMultiObjective(instance *Instance, config Config, objectives string) error {
+	var names []string
+	for _, name := range strings.Split(objectives, ",") {
+		names = append(names, strings.TrimSpace(name))
+	}
+	if len(names) < 2 {
+		return fmt.Errorf("-objectives needs at least two edge attributes, got %q", objectives)
+	}
+	solver, err := NewSolver(instance, config)
+	if err != nil {
+		return err
+	}
+	if err := solver.Colony.SetObjectives(names...); err != nil {
+		return err
+	}
+	archive := solver.Colony.RunMultiObjective(config.Iterations)
+	slices.SortFunc(archive, func(a, b ParetoSolution) int { return slices.Compare(a.Scores, b.Scores) })
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(names, "\t"))+"\tTOUR")
+	for _, s := range archive {
+		for _, score := range s.Scores {
+			fmt.Fprintf(tw, "%g\t", score)
+		}
+		fmt.Fprintln(tw, s.Tour)
+	}
+	return tw.Flush()
+}
+
+func solvePath(graph *Digraph, source, target, maxHops int, budget float64, config Config) error {
+	n := len(graph.Arcs)
+	if source < 0 || source >= n || target >= n {
+		return fmt.Errorf("source and target must be nodes in [0, %d)", n)
+	}
+	problem := &ConstrainedPath{Graph: graph, Source: source, Target: target, MaxHops: maxHops, ResourceBudget: budget}
+	path, cost, err := solveProblem(problem, config)
+	if err != nil {
+		return err
+	}
+	if path == nil {
+		return fmt.Errorf("no feasible path from %d to %d found", source, target)
+	}
+	fmt.Println("Best path:", path)
+	fmt.Println("Best path length:", cost)
+	return nil
+}
@@ -0,0 +1,754 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IterationStats summarizes the tours built in one iteration
+type IterationStats struct {
+	Iteration int     `json:"iteration"`
+	Best      float64 `json:"best"`
+	Mean      float64 `json:"mean"`
+	Median    float64 `json:"median"`
+	Worst     float64 `json:"worst"`
+	// StdDev is the standard deviation of the lengths of the ants' tours
+	StdDev    float64 `json:"stddev"`
+	BestSoFar float64 `json:"best_so_far"`
+	// Diversity is the pheromone diversity, the normalized entropy of the
+	// trails, after the iteration's update
+	Diversity float64 `json:"diversity"`
+	// Branching is the lambda branching factor after the iteration's update
+	Branching float64 `json:"branching"`
+	// Identical is the share of the iteration's ants that built the same
+	// tour as another; it nears 1 when the colony has stagnated
+	Identical float64 `json:"identical"`
+	// SharedEdges lists the edges in the tours of all the iteration's ants,
+	// from their lower city on symmetric instances. The history CSV only
+	// gives their number
+	SharedEdges [][2]int `json:"shared_edges,omitempty"`
+	// Evaluations is the number of tours the ants built up to and including
+	// the iteration
+	Evaluations int `json:"evaluations"`
+	// Q0 is the exploitation probability of the iteration, the mean of the
+	// ants' own under self-adaptation; zero for variants without one
+	Q0 float64 `json:"q0,omitempty"`
+	// CandidateFallbacks is the share of the ants' moves for which their
+	// candidate list held no city they could move to, zero without lists
+	CandidateFallbacks float64 `json:"candidate_fallbacks,omitempty"`
+	// TrailMin and TrailMax are the bounds the trails were kept within after
+	// the iteration's update, those of MMAS or Config.TrailMin and TrailMax,
+	// zero where there is none. Clamped is the number of trails the update
+	// took beyond them and clamping brought back
+	TrailMin float64 `json:"trail_min,omitempty"`
+	TrailMax float64 `json:"trail_max,omitempty"`
+	Clamped  int     `json:"clamped,omitempty"`
+}
+
+// Solution is the outcome of a run together with everything needed to reproduce it
+type Solution struct {
+	Tour    []int            `json:"tour"`
+	Length  float64          `json:"length"`
+	History []IterationStats `json:"history,omitempty"`
+	Config  Config           `json:"config"`
+	// Seed is the seed actually used, even when Config.Seed asked for a clock seed
+	Seed int64 `json:"seed"`
+	// Restarts lists the restarts of the trails, in order
+	Restarts []Restart `json:"restarts,omitempty"`
+	// Alternatives are the Config.Alternatives shortest tours found that
+	// differ in more than where they start or their direction, shortest
+	// first, which makes the first the best tour
+	Alternatives []AlternativeTour `json:"alternatives,omitempty"`
+	// Backbone lists the edges the iteration-best tours took most often, as
+	// many as there are cities, most frequent first; on symmetric instances
+	// they are undirected and given from their lower city
+	Backbone []BackboneEdge `json:"backbone,omitempty"`
+	// Evaluations is the number of tours the ants built, the effort of the
+	// run independent of the machine
+	Evaluations int `json:"evaluations"`
+	// Reloads lists the changes of parameters during the run, in order;
+	// Config holds the last parameters
+	Reloads []Reload `json:"reloads,omitempty"`
+	// Score is the tour's score under the colony's Objective, the one it
+	// minimizes, zero without one; the history then follows scores rather
+	// than lengths
+	Score float64 `json:"score,omitempty"`
+	// Candidates is the length of the candidate lists at the end of the run,
+	// longer than Config.Candidates if Config.CandidateFallbacks grew them
+	Candidates int `json:"candidates,omitempty"`
+	// Metadata holds the City.Metadata of the tour's cities in visiting
+	// order, null for cities without any, when any city has some
+	Metadata []json.RawMessage `json:"metadata,omitempty"`
+	// Routes breaks down what each route of the tour costs the Config.Vehicle,
+	// the tour being a single route, when there is a vehicle
+	Routes []RouteCost `json:"routes,omitempty"`
+}
+
+// MarshalJSON encodes the solution, writing a null length when no tour was found
+func (s *Solution) MarshalJSON() ([]byte, error) {
+	type plain Solution
+	encoded := struct {
+		*plain
+		Length *float64 `json:"length"`
+	}{plain: (*plain)(s)}
+	if !math.IsInf(s.Length, 0) && !math.IsNaN(s.Length) {
+		encoded.Length = &s.Length
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes a solution, reading a null length as +Inf
+func (s *Solution) UnmarshalJSON(data []byte) error {
+	type plain Solution
+	decoded := struct {
+		*plain
+		Length *float64 `json:"length"`
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	s.Length = math.Inf(1)
+	if decoded.Length != nil {
+		s.Length = *decoded.Length
+	}
+	return nil
+}
+
+// Solver runs a colony on an instance one iteration at a time, keeping the best tour and history
+type Solver struct {
+	Colony *AntColony
+	Config Config
+	// Logger receives a progress record every LogEvery iterations and after
+	// the last one, slog's default logger if nil; LogEvery 0 logs nothing
+	Logger   *slog.Logger
+	LogEvery int
+	// Tracer, if set, records a span for every iteration, with child spans
+	// for its phases, under TraceParent, which may be nil or a remote parent
+	Tracer      *Tracer
+	TraceParent *Span
+	runSpan     *Span
+	// Events, if set, receives run.started before the first iteration,
+	// run.best whenever one finds a shorter tour and run.finished after the
+	// last, naming the run EventRun
+	Events    RunListener
+	EventRun  string
+	announced bool
+	// Remote, if set, moves the ants on construction workers
+	Remote *RemoteConstruction
+	// Throttle, if set, pauses the run between iterations
+	Throttle *Throttle
+	// CheckInvariants fails an iteration that leaves a tour that is no
+	// permutation of the cities or of no finite length, or a trail negative
+	// or NaN, which only a bug can. It reads every trail, so it is slow
+	CheckInvariants bool
+	// Snapshots, if set, receives a Snapshot every SnapshotEvery iterations,
+	// every one if it is below 2, and after the last
+	Snapshots     *SnapshotWriter
+	SnapshotEvery int
+
+	seed      int64
+	iteration int
+	// evaluations counts the tours built in the iterations run
+	evaluations int
+	bestTour    []int
+	bestLength  float64
+	history     []IterationStats
+	timings     Timings
+	// lastImprovement and lastRestart are the iterations after which the
+	// best tour last got shorter and the trails were last restarted
+	lastImprovement, lastRestart int
+	restarts                     []Restart
+	alternatives                 tourArchive
+	// elite holds the tours laid on the trails again after restarts
+	elite tourArchive
+	edges edgeFrequency
+	// reload holds the parameters Reload set for the next iteration, and
+	// reloads the changes made so far
+	reloadMu sync.Mutex
+	reload   *Config
+	reloads  []Reload
+	// startConfig is Config as the run started, and instanceFingerprint
+	// fingerprints the instance solved, both for Provenance
+	startConfig         Config
+	instanceFingerprint func() string
+}
+
+// Timings splits the time spent by a solver between the phases of its
+// iterations, and the setup before them
+type Timings struct {
+	// DistanceMatrix is the time spent computing the distances between the
+	// cities, or building the matrix of an instance that came with one as it
+	// was loaded, and CandidateLists that spent sorting them into candidate
+	// lists, neither part of any iteration
+	DistanceMatrix time.Duration `json:"distance_matrix_ns"`
+	CandidateLists time.Duration `json:"candidate_lists_ns"`
+	Construction   time.Duration `json:"construction_ns"`
+	Update         time.Duration `json:"update_ns"`
+	LocalSearch    time.Duration `json:"local_search_ns"`
+	// Total is the time spent in iterations, setup excluded
+	Total time.Duration `json:"total_ns"`
+	// WorkerBusy sums the time each worker spent building tours; divided by
+	// Construction and the number of workers, it is their utilization
+	WorkerBusy time.Duration `json:"worker_busy_ns"`
+	// Iterations is the number of iterations timed
+	Iterations int `json:"iterations"`
+}
+
+// PerIteration returns the mean time an iteration spent in each phase,
+// without the setup
+func (t Timings) PerIteration() Timings {
+	n := time.Duration(max(t.Iterations, 1))
+	return Timings{
+		Construction: t.Construction / n,
+		Update:       t.Update / n,
+		LocalSearch:  t.LocalSearch / n,
+		Total:        t.Total / n,
+		WorkerBusy:   t.WorkerBusy / n,
+		Iterations:   1,
+	}
+}
+
+// SolverOption customizes a solver beyond its Config, for callers embedding
+// the solver in their own programs
+type SolverOption func(*Solver)
+
+// WithHeuristic replaces the inverse edge cost as the desirability eta of
+// moving from city i to city j, given the ant's tour so far, which eta must
+// not modify. It takes precedence over Config.Heuristic, plays no part in
+// multi-objective runs, and must be safe for concurrent use with Workers
+func WithHeuristic(eta func(i, j int, partialTour []int) float64) SolverOption {
+	return func(s *Solver) {
+		s.Colony.Heuristic = func(_ *AntColony, ant *Ant, from, to int) float64 {
+			return eta(from, to, ant.Tour)
+		}
+	}
+}
+
+// NewSolver validates the instance and prepares a colony configured by cfg
+// and the options
+func NewSolver(inst *Instance, cfg Config, options ...SolverOption) (*Solver, error) {
+	return NewSolverContext(context.Background(), inst, cfg, options...)
+}
+
+// NewSolverContext is NewSolver, giving up with ctx's error once ctx is done
+func NewSolverContext(ctx context.Context, inst *Instance, cfg Config, options ...SolverOption) (*Solver, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := checkMemory(inst, cfg, 1); err != nil {
+		return nil, err
+	}
+	colony, err := newAntColony(ctx, cfg.NumAnts, cfg.Alpha, cfg.Beta, cfg.Rho, cfg.Q, inst.Cities, inst.Distances, inst.metric(), cfg.SparsePheromones)
+	if err != nil {
+		return nil, err
+	}
+	if inst.Distances != nil {
+		colony.matrixTime = inst.matrixTime
+	}
+	if inst.Constraints != nil {
+		colony.Constraints = inst.Constraints
+	}
+	colony.setCostFloor()
+	colony.ReturnToStart = inst.ReturnToStart
+	colony.Variant = cfg.Variant
+	colony.Q0 = cfg.Q0
+	colony.Workers = cfg.Workers
+	colony.Adaptive = cfg.Adaptive
+	if colony.Castes, err = parseCastes(cfg.Castes); err != nil {
+		return nil, err
+	}
+	colony.CandidateList = cfg.Candidates
+	colony.Heuristic = heuristics[cfg.Heuristic]
+	colony.Attributes = inst.Attributes
+	if colony.Vehicle, err = parseVehicle(cfg.Vehicle); err != nil {
+		return nil, err
+	}
+	if colony.Objective, err = colony.configObjective(cfg); err != nil {
+		return nil, err
+	}
+	colony.Placement, colony.StartCity = cfg.Placement, cfg.StartCity
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	if err := colony.setCostNoise(cfg.CostNoise, seed); err != nil {
+		return nil, err
+	}
+	if colony.TimeCost, err = colony.rushHourCost(cfg.RushHour); err != nil {
+		return nil, err
+	}
+	if colony.TimeCost != nil && cfg.LocalSearch {
+		slog.Info("local search re-evaluates whole tours under time-dependent costs", "search", cmp.Or(cfg.Search, TwoOptSearch))
+	}
+	if cfg.LengthCache > 0 {
+		colony.lengths = newLengthCache(cfg.LengthCache)
+	}
+	if err := colony.checkStartCity(); err != nil {
+		return nil, err
+	}
+	if cfg.TrailMin > 0 || cfg.TrailMax > 0 {
+		colony.boundTrails(cfg.TrailMin, cfg.TrailMax)
+	}
+	colony.Seed(seed)
+	colony.InitializePheromones()
+	solver := &Solver{Colony: colony, Config: cfg, seed: seed, bestLength: math.Inf(1), alternatives: tourArchive{k: cfg.Alternatives},
+		elite: tourArchive{k: cfg.RestartArchive}, startConfig: cfg, instanceFingerprint: sync.OnceValue(inst.Fingerprint)}
+	for _, option := range options {
+		option(solver)
+	}
+	if cfg.SeedHeuristic != "" {
+		if _, err := solver.Immigrate(constructions[cfg.SeedHeuristic](colony)); err != nil {
+			return nil, fmt.Errorf("seed heuristic %s: %w", cfg.SeedHeuristic, err)
+		}
+	}
+	return solver, nil
+}
+
+// errBudgetSpent is the error of iterations beyond the evaluation budget
+var errBudgetSpent = errors.New("evaluation budget spent")
+
+// Step runs a single iteration and returns its statistics
+func (s *Solver) Step() IterationStats {
+	stats, _ := s.StepContext(context.Background())
+	return stats
+}
+
+// StepContext is Step, abandoning the iteration with ctx's error once ctx is
+// done. An abandoned iteration leaves the best tour, history and iteration
+// count as they were, though the trails may carry the local updates of Ant
+// Colony System ants
+func (s *Solver) StepContext(ctx context.Context) (IterationStats, error) {
+	if err := ctx.Err(); err != nil {
+		return IterationStats{}, err
+	}
+	if s.Config.Evaluations > 0 && s.evaluations >= s.Config.Evaluations {
+		return IterationStats{}, errBudgetSpent
+	}
+	if s.iteration > 0 {
+		if err := s.Throttle.Wait(ctx); err != nil {
+			return IterationStats{}, err
+		}
+	}
+	s.applyReload()
+	if s.Config.Q0Schedule != "" && s.Config.Q0Schedule != Q0Constant {
+		s.Colony.Q0 = s.Config.scheduledQ0(s.iteration)
+	}
+	s.Colony.ctx = ctx
+	defer func() { s.Colony.ctx = nil }()
+	span := s.Tracer.Start(s.TraceParent, "aco.iteration", "aco.iteration", s.iteration+1)
+	defer span.End()
+	if !s.announced {
+		s.announced = true
+		s.publish(EventRunStarted, "")
+	}
+	// Candidate lists are sorted before the iteration's clock starts, timed apart
+	s.Colony.prepareCandidateLists()
+	start := time.Now()
+	phase := s.Tracer.Start(span, "aco.construction", "aco.ants", s.Colony.NumAnts, "aco.workers", max(s.Colony.Workers, 1))
+	ants := s.Colony.InitializeAnts()
+	if s.Config.Evaluations > 0 {
+		ants = ants[:min(len(ants), s.Config.Evaluations-s.evaluations)]
+	}
+	if s.Remote != nil {
+		s.Remote.Construct(s.Colony, ants)
+	} else {
+		s.Colony.AntsMove(ants)
+	}
+	phase.End()
+	constructed := time.Now()
+	s.timings.Construction += constructed.Sub(start)
+	if err := ctx.Err(); err != nil {
+		span.SetAttributes("aco.cancelled", true)
+		return IterationStats{}, err
+	}
+	if s.Config.LocalSearch {
+		phase = s.Tracer.Start(span, "aco.local_search")
+		search := localSearches[cmp.Or(s.Config.Search, TwoOptSearch)]
+		for _, ant := range ants {
+			ant.Tour = search(s.Colony, ant.Tour)
+		}
+		phase.End()
+		s.timings.LocalSearch += time.Since(constructed)
+		if err := ctx.Err(); err != nil {
+			span.SetAttributes("aco.cancelled", true)
+			return IterationStats{}, err
+		}
+	}
+	phase = s.Tracer.Start(span, "aco.update")
+	updating := time.Now()
+	stats := IterationStats{Iteration: s.iteration, Best: math.Inf(1), Worst: math.Inf(-1)}
+	previous := s.bestLength
+	lengths := make([]float64, len(ants))
+	for k, ant := range ants {
+		length := s.Colony.Score(ant.Tour)
+		lengths[k] = length
+		stats.Mean += length / float64(len(ants))
+		stats.Best = math.Min(stats.Best, length)
+		stats.Worst = math.Max(stats.Worst, length)
+		if length < s.bestLength {
+			s.bestLength = length
+			s.bestTour = append(s.bestTour[:0], ant.Tour...)
+		}
+	}
+	s.Colony.clamped = 0
+	s.Colony.UpdateTrails(ants, lengths, s.bestTour, s.bestLength)
+	if s.Colony.trailBounds {
+		s.Colony.clamped = s.Colony.clampPheromones()
+	}
+	if s.Colony.Adaptive {
+		s.Colony.adaptParameters(ants, lengths)
+	}
+	phase.End()
+	if s.CheckInvariants {
+		if err := s.Colony.checkInvariants(ants); err != nil {
+			return IterationStats{}, fmt.Errorf("iteration %d: %w", s.iteration+1, err)
+		}
+	}
+	s.timings.Update += time.Since(updating)
+	s.timings.Total += time.Since(start)
+	s.timings.Iterations++
+	stats.BestSoFar = s.bestLength
+	stats.Diversity = s.Colony.PheromoneDiversity()
+	stats.Branching = s.Colony.BranchingFactor()
+	symmetric := s.Colony.symmetric()
+	iterationBest := -1
+	for k, ant := range ants {
+		s.alternatives.offer(s.Colony, ant.Tour, lengths[k], symmetric)
+		s.elite.offer(s.Colony, ant.Tour, lengths[k], symmetric)
+		if iterationBest < 0 || lengths[k] < lengths[iterationBest] {
+			iterationBest = k
+		}
+	}
+	if iterationBest >= 0 {
+		s.edges.add(s.Colony, ants[iterationBest].Tour, symmetric)
+	}
+	s.Colony.populationStats(&stats, ants, lengths, symmetric)
+	span.SetAttributes("aco.best_length", stats.Best, "aco.best_so_far", stats.BestSoFar)
+	s.evaluations += len(ants)
+	stats.Evaluations = s.evaluations
+	if s.Colony.Adaptive {
+		_, _, stats.Q0 = s.Colony.AdaptedParameters()
+	} else if s.Colony.Variant == AntColonySystem {
+		stats.Q0 = s.Colony.Q0
+	}
+	stats.CandidateFallbacks = candidateFallbacks(ants)
+	if s.Colony.boundedTrails() {
+		stats.TrailMin, stats.Clamped = s.Colony.minPheromone, s.Colony.clamped
+		if !math.IsInf(s.Colony.maxPheromone, 1) {
+			stats.TrailMax = s.Colony.maxPheromone
+		}
+	}
+	if s.Config.CandidateFallbacks > 0 && stats.CandidateFallbacks > s.Config.CandidateFallbacks &&
+		s.Colony.CandidateList > 0 && s.Colony.CandidateList < len(s.Colony.Cities)-1 {
+		s.Colony.growCandidateLists()
+		s.logger().Info("grew candidate lists", "iteration", s.iteration, "fallbacks", stats.CandidateFallbacks,
+			"candidates", s.Colony.CandidateList)
+	}
+	s.history = append(s.history, stats)
+	s.iteration++
+	if s.bestLength < previous {
+		s.lastImprovement = s.iteration
+		s.publish(EventRunBest, "")
+	}
+	if s.Snapshots != nil && (s.iteration%max(s.SnapshotEvery, 1) == 0 || s.Done()) {
+		s.Snapshots.Write(s.Snapshot(stats))
+	}
+	if reason, value := s.restartReason(stats); reason != "" {
+		s.Colony.restartTrails(s.Config.RestartAction, s.bestTour, s.bestLength)
+		s.Colony.injectArchive(s.elite.tours, s.Config.RestartStrength)
+		s.lastRestart = s.iteration
+		s.restarts = append(s.restarts, Restart{Iteration: stats.Iteration, Reason: reason, Value: value})
+		span.SetAttributes("aco.restart", reason)
+	}
+	// Iterations beyond the last, which callers may still run, finish nothing
+	last := s.Done() && (s.Config.Evaluations > 0 || s.iteration == s.Config.Iterations)
+	if last {
+		s.publish(EventRunFinished, RunDone)
+	}
+	if s.LogEvery > 0 && (s.iteration%s.LogEvery == 0 || last) {
+		s.logger().Info("progress",
+			"iteration", s.iteration,
+			"best", stats.BestSoFar,
+			"mean", stats.Mean,
+			"elapsed", s.timings.Total.Round(time.Millisecond))
+	}
+	return stats, nil
+}
+
+// logger returns the logger progress records go to
+func (s *Solver) logger() *slog.Logger {
+	if s.Logger == nil {
+		return slog.Default()
+	}
+	return s.Logger
+}
+
+// Run performs the iterations remaining from the configured total and returns the solution
+func (s *Solver) Run() *Solution {
+	solution, _ := s.RunContext(context.Background())
+	return solution
+}
+
+// RunContext is Run, stopping once ctx is done with the best tour found so
+// far and ctx's error; the run can then go on with another call
+func (s *Solver) RunContext(ctx context.Context) (*Solution, error) {
+	defer s.TraceRun()()
+	for !s.Done() {
+		if _, err := s.StepContext(ctx); err != nil {
+			s.publish(EventRunFinished, RunCancelled)
+			return s.Solution(), err
+		}
+	}
+	return s.Solution(), nil
+}
+
+// Stream runs the solver in a goroutine of its own as an anytime algorithm,
+// sending the solution on the returned channel every time an iteration finds
+// a shorter tour, until the run is over or ctx is done, when it closes the
+// channel. A receiver that falls behind gets the latest solution only, the
+// run never waiting for it; the channel holds the last one after it closes.
+// Nothing else may use the solver until then
+func (s *Solver) Stream(ctx context.Context) <-chan Solution {
+	solutions := make(chan Solution, 1)
+	go func() {
+		defer close(solutions)
+		defer s.TraceRun()()
+		for !s.Done() {
+			best := s.bestLength
+			if _, err := s.StepContext(ctx); err != nil {
+				s.publish(EventRunFinished, RunCancelled)
+				return
+			}
+			if s.bestLength < best {
+				// Replace a solution not yet received, which is older
+				select {
+				case <-solutions:
+				default:
+				}
+				solutions <- *s.Solution()
+			}
+		}
+	}()
+	return solutions
+}
+
+// Done reports whether the run is over: it has run Config.Iterations, or
+// spent Config.Evaluations if that is set
+func (s *Solver) Done() bool {
+	if s.Config.Evaluations > 0 {
+		return s.evaluations >= s.Config.Evaluations
+	}
+	return s.iteration >= s.Config.Iterations
+}
+
+// TraceRun starts a span covering the iterations that follow, until the
+// function it returns is called, and traces them under it. It does nothing
+// without a Tracer or inside a span TraceRun already started
+func (s *Solver) TraceRun() (end func()) {
+	if s.Tracer == nil || s.runSpan != nil {
+		return func() {}
+	}
+	parent := s.TraceParent
+	variant := s.Colony.Variant
+	if variant == "" {
+		variant = AntSystem
+	}
+	run := s.Tracer.Start(parent, "aco.run",
+		"aco.cities", len(s.Colony.Cities),
+		"aco.variant", variant,
+		"aco.ants", s.Config.NumAnts,
+		"aco.iterations", s.Config.iterationLimit(),
+		"aco.seed", s.seed)
+	s.TraceParent, s.runSpan = run, run
+	return func() {
+		s.TraceParent, s.runSpan = parent, nil
+		run.SetAttributes("aco.iterations_run", s.iteration, "aco.evaluations", s.evaluations, "aco.best_length", s.bestLength)
+		run.End()
+	}
+}
+
+// Immigrate offers the colony a tour found elsewhere, such as by another
+// island. A tour shorter, or of lower score, than the best so far replaces it and lays a trail as
+// an ant's would; others are ignored. It reports whether the tour was taken
+func (s *Solver) Immigrate(tour []int) (bool, error) {
+	if err := ValidateTour(tour, len(s.Colony.Cities), s.Colony.Constraints); err != nil {
+		return false, err
+	}
+	length := s.Colony.Score(tour)
+	if length >= s.bestLength {
+		return false, nil
+	}
+	s.bestLength = length
+	s.bestTour = append(s.bestTour[:0], tour...)
+	s.alternatives.offer(s.Colony, tour, length, s.Colony.symmetric())
+	s.elite.offer(s.Colony, tour, length, s.Colony.symmetric())
+	// A tour of no length deposits as if of the least cost, as in UpdateTrails
+	floored := max(length, s.Colony.costFloor)
+	s.Colony.deposit(tour, s.Colony.Q/floored)
+	if s.Colony.Variant == MaxMinAntSystem {
+		s.Colony.setPheromoneBounds(floored)
+	}
+	if s.Colony.boundedTrails() {
+		s.Colony.clampPheromones()
+	}
+	return true, nil
+}
+
+// Solution returns the best tour found so far. Tours are in canonical form,
+// their lengths measured again in that form so that equal tours have equal
+// lengths
+func (s *Solver) Solution() *Solution {
+	// The best length is the one the run ranked tours by, which with
+	// stochastic costs a fresh sample would not repeat
+	tour, length, score := s.Colony.CanonicalTour(s.bestTour), s.bestLength, 0.0
+	if len(tour) > 0 && s.Colony.Objective != nil {
+		length, score = s.Colony.TourLength(tour), s.bestLength
+	}
+	var alternatives []AlternativeTour
+	for _, alternative := range s.alternatives.tours {
+		canonical := s.Colony.CanonicalTour(alternative.Tour)
+		alternatives = append(alternatives, AlternativeTour{Tour: canonical, Length: s.Colony.TourLength(canonical)})
+	}
+	return &Solution{
+		Tour:         tour,
+		Length:       length,
+		Score:        score,
+		History:      append([]IterationStats(nil), s.history...),
+		Config:       s.Config,
+		Seed:         s.seed,
+		Restarts:     append([]Restart(nil), s.restarts...),
+		Alternatives: alternatives,
+		Backbone:     s.edges.backbone(len(s.Colony.Cities)),
+		Evaluations:  s.evaluations,
+		Reloads:      append([]Reload(nil), s.reloads...),
+		Candidates:   s.Colony.CandidateList,
+		Metadata:     tourMetadata(s.Colony.Cities, tour),
+		Routes:       s.Colony.routeCosts(tour),
+	}
+}
+
+// Timings returns the time spent in setup and in each phase of the
+// iterations run so far
+func (s *Solver) Timings() Timings {
+	timings := s.timings
+	timings.DistanceMatrix, timings.CandidateLists = s.Colony.matrixTime, s.Colony.listTime
+	timings.WorkerBusy = s.Colony.busy
+	return timings
+}
+
+// ColonyState is a snapshot of a solver between iterations: its pheromone
+// trails and the best tour found so far
+type ColonyState struct {
+	Config    Config `json:"config"`
+	Seed      int64  `json:"seed"`
+	Iteration int    `json:"iteration"`
+	// Provenance records how the run the state belongs to was produced
+	Provenance        *Provenance `json:"provenance,omitempty"`
+	Pheromones        [][]float64 `json:"pheromones"`
+	ClusterPheromones [][]float64 `json:"cluster_pheromones,omitempty"`
+	BestTour          []int       `json:"best_tour"`
+	BestLength        float64     `json:"best_length"`
+	// Cities are those the trails run between, by which WarmStart matches
+	// them to the cities of a changed instance
+	Cities []*City `json:"cities,omitempty"`
+}
+
+// State returns a copy of the solver's current state
+func (s *Solver) State() *ColonyState {
+	copyMatrix := func(m [][]float64) [][]float64 {
+		if m == nil {
+			return nil
+		}
+		c := make([][]float64, len(m))
+		for i := range m {
+			c[i] = append([]float64(nil), m[i]...)
+		}
+		return c
+	}
+	provenance := s.Provenance()
+	return &ColonyState{
+		Config:            s.Config,
+		Seed:              s.seed,
+		Iteration:         s.iteration,
+		Provenance:        &provenance,
+		Pheromones:        copyMatrix(s.Colony.pheromoneMatrix()),
+		ClusterPheromones: copyMatrix(s.Colony.ClusterPheromones),
+		BestTour:          append([]int(nil), s.bestTour...),
+		BestLength:        s.bestLength,
+		Cities:            append([]*City(nil), s.Colony.Cities...),
+	}
+}
+
+// MarshalJSON encodes the state, writing a null best length when no tour was found
+func (cs *ColonyState) MarshalJSON() ([]byte, error) {
+	type plain ColonyState
+	encoded := struct {
+		*plain
+		BestLength *float64 `json:"best_length"`
+	}{plain: (*plain)(cs)}
+	if !math.IsInf(cs.BestLength, 0) && !math.IsNaN(cs.BestLength) {
+		encoded.BestLength = &cs.BestLength
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes a state, reading a null best length as +Inf
+func (cs *ColonyState) UnmarshalJSON(data []byte) error {
+	type plain ColonyState
+	decoded := struct {
+		*plain
+		BestLength *float64 `json:"best_length"`
+	}{plain: (*plain)(cs)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	cs.BestLength = math.Inf(1)
+	if decoded.BestLength != nil {
+		cs.BestLength = *decoded.BestLength
+	}
+	return nil
+}
+
+// WriteColonyState writes the state to the file at path as JSON, or as an
+// aco.v1.ColonyState protobuf message if the file name ends in .pb
+func WriteColonyState(path string, cs *ColonyState) error {
+	if !strings.EqualFold(filepath.Ext(path), ".pb") {
+		return writeJSON(path, cs)
+	}
+	return writeFile(path, func(w io.Writer) error {
+		_, err := w.Write(cs.MarshalProto())
+		return err
+	})
+}
+
+// LoadColonyState reads a state written by WriteColonyState
+func LoadColonyState(path string) (*ColonyState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cs := &ColonyState{}
+	if strings.EqualFold(filepath.Ext(path), ".pb") {
+		err = cs.UnmarshalProto(data)
+	} else {
+		err = json.Unmarshal(data, cs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cs, nil
+}
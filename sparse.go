@@ -0,0 +1,194 @@
+package main
+
+import "math"
+
+// sparseTrails holds the trails of a colony edge by edge, keeping only those
+// that differ from a level shared by all the others. On huge instances ants
+// only ever touch a small share of the n² edges, so memory grows with the
+// edges touched rather than with n²
+type sparseTrails struct {
+	// level is the trail of every edge not in edges
+	level float64
+	// edges holds the trail of each touched edge, keyed by arcKey
+	edges map[uint64]*float64
+}
+
+func newSparseTrails(level float64) *sparseTrails {
+	return &sparseTrails{level: level, edges: make(map[uint64]*float64)}
+}
+
+// arcKey identifies the edge from city i to city j, whatever the number of cities
+func arcKey(i, j int) uint64 {
+	return uint64(i)<<32 | uint64(uint32(j))
+}
+
+// get returns the trail on the edge from city i to city j
+func (t *sparseTrails) get(i, j int) float64 {
+	if trail, ok := t.edges[arcKey(i, j)]; ok {
+		return *trail
+	}
+	return t.level
+}
+
+// at points at the trail on the edge from city i to city j, storing the edge
+// from now on
+func (t *sparseTrails) at(i, j int) *float64 {
+	key := arcKey(i, j)
+	trail, ok := t.edges[key]
+	if !ok {
+		level := t.level
+		trail = &level
+		t.edges[key] = trail
+	}
+	return trail
+}
+
+// fill sets every trail to level, forgetting the touched edges
+func (t *sparseTrails) fill(level float64) {
+	t.level = level
+	clear(t.edges)
+}
+
+// update replaces every trail x, the shared level included, by f(x). Edges
+// whose trail f brings back to the shared level, as evaporation clamped to the
+// MMAS lower bound does, are forgotten
+func (t *sparseTrails) update(f func(float64) float64) {
+	t.level = f(t.level)
+	for key, trail := range t.edges {
+		if *trail = f(*trail); *trail == t.level {
+			delete(t.edges, key)
+		}
+	}
+}
+
+// max returns the strongest trail
+func (t *sparseTrails) max() float64 {
+	strongest := t.level
+	for _, trail := range t.edges {
+		strongest = math.Max(strongest, *trail)
+	}
+	return strongest
+}
+
+// mean returns the average trail over the n(n-1) edges between distinct cities
+func (t *sparseTrails) mean(n int) float64 {
+	count := n * (n - 1)
+	if count == 0 {
+		return 0
+	}
+	sum, stored := 0.0, 0
+	for key, trail := range t.edges {
+		if key>>32 != key&math.MaxUint32 {
+			sum += *trail
+			stored++
+		}
+	}
+	return (sum + float64(count-stored)*t.level) / float64(count)
+}
+
+// removeCity forgets the edges of city i and renumbers the cities above it
+func (t *sparseTrails) removeCity(i int) {
+	edges := make(map[uint64]*float64, len(t.edges))
+	for key, trail := range t.edges {
+		from, to := int(key>>32), int(key&math.MaxUint32)
+		if from == i || to == i {
+			continue
+		}
+		if from > i {
+			from--
+		}
+		if to > i {
+			to--
+		}
+		edges[arcKey(from, to)] = trail
+	}
+	t.edges = edges
+}
+
+// rows returns the stored trails out of each of n cities, but those of the
+// diagonal, the other n-1 edges minus those of each row being at the level
+func (t *sparseTrails) rows(n int) [][]float64 {
+	rows := make([][]float64, n)
+	for key, trail := range t.edges {
+		if from, to := key>>32, key&math.MaxUint32; from != to {
+			rows[from] = append(rows[from], *trail)
+		}
+	}
+	return rows
+}
+
+// diversity is PheromoneDiversity computed from the stored trails alone
+func (t *sparseTrails) diversity(n int) float64 {
+	total := 0.0
+	for _, row := range t.rows(n) {
+		rest := float64(n - 1 - len(row))
+		sum := rest * t.level
+		for _, trail := range row {
+			sum += trail
+		}
+		if sum <= 0 {
+			total++
+			continue
+		}
+		entropy := 0.0
+		if p := t.level / sum; rest > 0 && p > 0 {
+			entropy -= rest * p * math.Log(p)
+		}
+		for _, trail := range row {
+			if p := trail / sum; p > 0 {
+				entropy -= p * math.Log(p)
+			}
+		}
+		total += entropy / math.Log(float64(n-1))
+	}
+	return total / float64(n)
+}
+
+// branching is BranchingFactor computed from the stored trails alone
+func (t *sparseTrails) branching(n int) float64 {
+	total := 0
+	for _, row := range t.rows(n) {
+		rest := n - 1 - len(row)
+		low, high := math.Inf(1), math.Inf(-1)
+		if rest > 0 {
+			low, high = t.level, t.level
+		}
+		for _, trail := range row {
+			low, high = math.Min(low, trail), math.Max(high, trail)
+		}
+		threshold := low + branchingLambda*(high-low)
+		if rest > 0 && t.level >= threshold {
+			total += rest
+		}
+		for _, trail := range row {
+			if trail >= threshold {
+				total++
+			}
+		}
+	}
+	return float64(total) / float64(n)
+}
+
+// dense returns the trails between n cities as a full matrix
+func (t *sparseTrails) dense(n int) [][]float64 {
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+		for j := range matrix[i] {
+			matrix[i][j] = t.level
+		}
+	}
+	for key, trail := range t.edges {
+		matrix[key>>32][key&math.MaxUint32] = *trail
+	}
+	return matrix
+}
+
+// pheromoneMatrix returns the city-level trails as a full matrix: the
+// colony's own with dense trails, a copy built for the occasion with sparse ones
+func (ac *AntColony) pheromoneMatrix() [][]float64 {
+	if ac.sparse != nil {
+		return ac.sparse.dense(len(ac.Cities))
+	}
+	return ac.Pheromones
+}
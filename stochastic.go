@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// EdgeSampler draws a random cost for travelling from city i to city j
+type EdgeSampler func(i, j int) float64
+
+// RobustMode selects how the sampled costs of a tour are summarized
+type RobustMode int
+
+const (
+	// ExpectedCost scores a tour by its mean sampled cost
+	ExpectedCost RobustMode = iota
+	// CVaRCost scores a tour by the mean of its worst Tail share of sampled costs
+	CVaRCost
+)
+
+// StochasticCosts makes edge costs random and tours scored by a robust statistic over Samples draws
+type StochasticCosts struct {
+	Sampler EdgeSampler
+	Samples int
+	Mode    RobustMode
+	Tail    float64
+}
+
+// NormalSampler draws edge costs from normal distributions around the colony's
+// distances with the given standard deviations, truncated at zero. Its draws
+// follow from seed alone, leaving the colony's own random choices as they
+// would be without stochastic costs
+func (ac *AntColony) NormalSampler(stddev [][]float64, seed int64) EdgeSampler {
+	return ac.normalSampler(func(i, j int) float64 { return stddev[i][j] }, seed)
+}
+
+// normalSampler is NormalSampler with the standard deviations of a function,
+// which can follow the cities AddCity and RemoveCity change
+func (ac *AntColony) normalSampler(stddev func(i, j int) float64, seed int64) EdgeSampler {
+	var mu sync.Mutex
+	rng := rand.New(rand.NewSource(seed))
+	return func(i, j int) float64 {
+		mu.Lock()
+		noise := rng.NormFloat64()
+		mu.Unlock()
+		return math.Max(0, ac.distance(i, j)+noise*stddev(i, j))
+	}
+}
+
+// costNoise is Config.CostNoise read: the edge attribute holding the standard
+// deviations, or the share of the distances they are if it is empty
+type costNoise struct {
+	attribute string
+	share     float64
+	samples   int
+	mode      RobustMode
+	tail      float64
+}
+
+// parseCostNoise reads Config.CostNoise, nil if it is empty
+func parseCostNoise(noise string) (*costNoise, error) {
+	noise = strings.TrimSpace(noise)
+	if noise == "" {
+		return nil, nil
+	}
+	fields := strings.Split(noise, ":")
+	if len(fields) != 2 && len(fields) != 3 {
+		return nil, fmt.Errorf("cost noise %q, want stddev:samples[:tail]", noise)
+	}
+	cn := &costNoise{}
+	stddev := strings.TrimSpace(fields[0])
+	if share, err := strconv.ParseFloat(stddev, 64); err == nil {
+		if share < 0 || math.IsInf(share, 0) || math.IsNaN(share) {
+			return nil, fmt.Errorf("cost noise %s: share %g must be a non-negative number", noise, share)
+		}
+		cn.share = share
+	} else if stddev == "" {
+		return nil, fmt.Errorf("cost noise %s: missing stddev", noise)
+	} else {
+		cn.attribute = stddev
+	}
+	samples, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+	if err != nil || samples < 1 {
+		return nil, fmt.Errorf("cost noise %s: samples %q must be a positive integer", noise, fields[1])
+	}
+	cn.samples = samples
+	if len(fields) == 3 {
+		tail, err := strconv.ParseFloat(strings.TrimSpace(fields[2]), 64)
+		if err != nil || !(tail > 0 && tail <= 1) {
+			return nil, fmt.Errorf("cost noise %s: tail %q must be in (0, 1]", noise, fields[2])
+		}
+		cn.mode, cn.tail = CVaRCost, tail
+	}
+	return cn, nil
+}
+
+// setCostNoise makes the colony's costs stochastic as Config.CostNoise asks,
+// sampling them from seed, and leaves them fixed if noise is empty
+func (ac *AntColony) setCostNoise(noise string, seed int64) error {
+	cn, err := parseCostNoise(noise)
+	if cn == nil || err != nil {
+		return err
+	}
+	stddev := func(i, j int) float64 { return cn.share * ac.distance(i, j) }
+	if cn.attribute != "" {
+		if _, err := ac.attributeMatrix(cn.attribute); err != nil {
+			return fmt.Errorf("cost noise: %w", err)
+		}
+		// Looked up on every draw, so that the matrix AddCity and RemoveCity
+		// resize is the one read
+		stddev = func(i, j int) float64 {
+			matrix, _ := ac.attributeMatrix(cn.attribute)
+			return matrix[i][j]
+		}
+	}
+	ac.Stochastic = &StochasticCosts{Sampler: ac.normalSampler(stddev, seed), Samples: cn.samples, Mode: cn.mode, Tail: cn.tail}
+	return nil
+}
+
+// RobustLength samples the cost of tour and summarizes it according to the colony's robust mode
+func (ac *AntColony) RobustLength(tour []int) float64 {
+	sc := ac.Stochastic
+	samples := make([]float64, max(sc.Samples, 1))
+	edges := len(tour) - 1
+	if ac.ReturnToStart {
+		edges = len(tour)
+	}
+	for k := range samples {
+		samples[k] = ac.serviceTime(tour)
+		for i := 0; i < edges; i++ {
+			samples[k] += sc.Sampler(tour[i], tour[(i+1)%len(tour)])
+		}
+	}
+	if sc.Mode == CVaRCost {
+		sort.Sort(sort.Reverse(sort.Float64Slice(samples)))
+		tail := int(math.Ceil(sc.Tail * float64(len(samples))))
+		samples = samples[:min(max(tail, 1), len(samples))]
+	}
+	sum := 0.0
+	for _, s := range samples {
+		sum += s
+	}
+	return sum / float64(len(samples))
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+// TestCostNoise checks that Config.CostNoise makes a solver's costs
+// stochastic, and that its solution reports the length the run ranked its
+// best tour by rather than a fresh sample
+func TestCostNoise(t *testing.T) {
+	g, err := NewGenerator(UniformCities, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := g.Instance(15)
+	spread := make([][]float64, 15)
+	for i := range spread {
+		spread[i] = make([]float64, 15)
+		for j := range spread[i] {
+			spread[i][j] = 5
+		}
+	}
+	inst.Attributes = map[string][][]float64{"spread": spread}
+	for _, noise := range []string{"0.3:10", "spread:10:0.2"} {
+		config := DefaultConfig()
+		config.Seed, config.Iterations, config.CostNoise = 1, 20, noise
+		if err := config.Validate(); err != nil {
+			t.Fatalf("%s: %v", noise, err)
+		}
+		solver, err := NewSolver(inst, config)
+		if err != nil {
+			t.Fatalf("%s: %v", noise, err)
+		}
+		if solver.Colony.Stochastic == nil {
+			t.Fatalf("%s: costs not stochastic", noise)
+		}
+		solution := solver.Run()
+		if solution.Length != solver.bestLength || solver.Solution().Length != solution.Length {
+			t.Errorf("%s: solutions of length %g and %g, best length %g", noise, solution.Length, solver.Solution().Length, solver.bestLength)
+		}
+	}
+}
+
+// TestParseCostNoise checks that malformed Config.CostNoise values are refused
+func TestParseCostNoise(t *testing.T) {
+	for _, noise := range []string{"0.2", "0.2:0", ":5", "-1:5", "0.2:5:0", "0.2:5:1.5", "0.2:x", "a:1:0.5:2"} {
+		config := DefaultConfig()
+		config.CostNoise = noise
+		if config.Validate() == nil {
+			t.Errorf("%q: no error", noise)
+		}
+	}
+}
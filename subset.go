@@ -0,0 +1,479 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// SetCover is a weighted set covering instance: choose sets covering every
+// element of the universe at minimum total cost
+type SetCover struct {
+	NumElements int
+	Sets        [][]int
+	Costs       []float64
+}
+
+// NewSetCover builds a set covering instance, checking that every element can be covered
+func NewSetCover(numElements int, sets [][]int, costs []float64) (*SetCover, error) {
+	if len(costs) != len(sets) {
+		return nil, fmt.Errorf("got %d costs for %d sets", len(costs), len(sets))
+	}
+	covered := make([]bool, numElements)
+	for s, set := range sets {
+		if costs[s] <= 0 {
+			return nil, fmt.Errorf("set %d: cost %g must be positive", s, costs[s])
+		}
+		for _, e := range set {
+			if e < 0 || e >= numElements {
+				return nil, fmt.Errorf("set %d: element %d out of range [0, %d)", s, e, numElements)
+			}
+			covered[e] = true
+		}
+	}
+	for e, ok := range covered {
+		if !ok {
+			return nil, fmt.Errorf("element %d is not in any set", e)
+		}
+	}
+	return &SetCover{NumElements: numElements, Sets: sets, Costs: costs}, nil
+}
+
+// ParseSetCover reads a set covering instance in the OR-Library format: the
+// number of elements and of sets, the cost of every set, then for every
+// element the number of sets that cover it followed by those sets, numbered
+// from 1
+func ParseSetCover(r io.Reader) (*SetCover, error) {
+	values, err := scanNumbers(r)
+	if err != nil {
+		return nil, err
+	}
+	nr := &numberReader{values: values}
+	numElements, err := nr.count("element count")
+	if err != nil {
+		return nil, err
+	}
+	numSets, err := nr.count("set count")
+	if err != nil {
+		return nil, err
+	}
+	costs := make([]float64, numSets)
+	for s := range costs {
+		if costs[s], err = nr.next(fmt.Sprintf("cost of set %d", s+1)); err != nil {
+			return nil, err
+		}
+	}
+	sets := make([][]int, numSets)
+	for e := 0; e < numElements; e++ {
+		covering, err := nr.count(fmt.Sprintf("number of sets covering element %d", e+1))
+		if err != nil {
+			return nil, err
+		}
+		for k := 0; k < covering; k++ {
+			s, err := nr.count(fmt.Sprintf("set covering element %d", e+1))
+			if err != nil {
+				return nil, err
+			}
+			if s < 1 || s > numSets {
+				return nil, fmt.Errorf("element %d: set %d out of range [1, %d]", e+1, s, numSets)
+			}
+			sets[s-1] = append(sets[s-1], e)
+		}
+	}
+	if err := nr.end(); err != nil {
+		return nil, err
+	}
+	return NewSetCover(numElements, sets, costs)
+}
+
+// PheromoneShape places pheromone on the sets themselves
+func (sc *SetCover) PheromoneShape() (rows, cols int) {
+	return 1, len(sc.Sets)
+}
+
+// NewConstruction starts with no sets chosen
+func (sc *SetCover) NewConstruction() Construction {
+	return &setCoverConstruction{
+		instance:  sc,
+		coverage:  make([]int, sc.NumElements),
+		chosen:    make([]bool, len(sc.Sets)),
+		uncovered: sc.NumElements,
+	}
+}
+
+// setCoverConstruction adds sets until every element is covered
+type setCoverConstruction struct {
+	instance  *SetCover
+	coverage  []int
+	chosen    []bool
+	uncovered int
+}
+
+func (c *setCoverConstruction) Moves() []Move {
+	if c.uncovered == 0 {
+		return nil
+	}
+	var moves []Move
+	for s, set := range c.instance.Sets {
+		if c.chosen[s] {
+			continue
+		}
+		gain := 0
+		for _, e := range set {
+			if c.coverage[e] == 0 {
+				gain++
+			}
+		}
+		if gain > 0 {
+			// Newly covered elements per unit cost
+			moves = append(moves, Move{Row: 0, Col: s, Heuristic: float64(gain) / c.instance.Costs[s]})
+		}
+	}
+	return moves
+}
+
+func (c *setCoverConstruction) Apply(m Move) {
+	c.chosen[m.Col] = true
+	for _, e := range c.instance.Sets[m.Col] {
+		if c.coverage[e] == 0 {
+			c.uncovered--
+		}
+		c.coverage[e]++
+	}
+	if c.uncovered == 0 {
+		c.dropRedundant()
+	}
+}
+
+// dropRedundant removes chosen sets whose elements are all covered by other
+// chosen sets, most expensive first
+func (c *setCoverConstruction) dropRedundant() {
+	for {
+		drop := -1
+		for s, chosen := range c.chosen {
+			if !chosen || (drop >= 0 && c.instance.Costs[s] <= c.instance.Costs[drop]) {
+				continue
+			}
+			redundant := true
+			for _, e := range c.instance.Sets[s] {
+				if c.coverage[e] == 1 {
+					redundant = false
+					break
+				}
+			}
+			if redundant {
+				drop = s
+			}
+		}
+		if drop < 0 {
+			return
+		}
+		c.chosen[drop] = false
+		for _, e := range c.instance.Sets[drop] {
+			c.coverage[e]--
+		}
+	}
+}
+
+func (c *setCoverConstruction) Cost() float64 {
+	cost := 0.0
+	for s, chosen := range c.chosen {
+		if chosen {
+			cost += c.instance.Costs[s]
+		}
+	}
+	return cost
+}
+
+func (c *setCoverConstruction) Solution() []int {
+	var sets []int
+	for s, chosen := range c.chosen {
+		if chosen {
+			sets = append(sets, s)
+		}
+	}
+	return sets
+}
+
+// Knapsack is a multidimensional 0-1 knapsack instance: choose items maximizing
+// total value without exceeding any capacity
+type Knapsack struct {
+	Values     []float64
+	Weights    [][]float64 // Weights[item][dimension]
+	Capacities []float64
+}
+
+// NewKnapsack builds a knapsack instance, checking that every item has one weight per capacity
+func NewKnapsack(values []float64, weights [][]float64, capacities []float64) (*Knapsack, error) {
+	if len(weights) != len(values) {
+		return nil, fmt.Errorf("got %d weight vectors for %d items", len(weights), len(values))
+	}
+	for i, w := range weights {
+		if len(w) != len(capacities) {
+			return nil, fmt.Errorf("item %d: got %d weights for %d capacities", i, len(w), len(capacities))
+		}
+	}
+	return &Knapsack{Values: values, Weights: weights, Capacities: capacities}, nil
+}
+
+// ParseKnapsack reads a multidimensional knapsack instance in the OR-Library
+// format: the number of items and of capacities and the optimal value, 0 if
+// unknown, then the value of every item, the weights of the items for each
+// capacity in turn, and the capacities. It returns the optimum with the instance
+func ParseKnapsack(r io.Reader) (*Knapsack, float64, error) {
+	values, err := scanNumbers(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	nr := &numberReader{values: values}
+	numItems, err := nr.count("item count")
+	if err != nil {
+		return nil, 0, err
+	}
+	numCapacities, err := nr.count("capacity count")
+	if err != nil {
+		return nil, 0, err
+	}
+	optimum, err := nr.next("optimal value")
+	if err != nil {
+		return nil, 0, err
+	}
+	itemValues := make([]float64, numItems)
+	for i := range itemValues {
+		if itemValues[i], err = nr.next(fmt.Sprintf("value of item %d", i+1)); err != nil {
+			return nil, 0, err
+		}
+	}
+	weights := make([][]float64, numItems)
+	for i := range weights {
+		weights[i] = make([]float64, numCapacities)
+	}
+	for d := 0; d < numCapacities; d++ {
+		for i := range weights {
+			if weights[i][d], err = nr.next(fmt.Sprintf("weight %d of item %d", d+1, i+1)); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	capacities := make([]float64, numCapacities)
+	for d := range capacities {
+		if capacities[d], err = nr.next(fmt.Sprintf("capacity %d", d+1)); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := nr.end(); err != nil {
+		return nil, 0, err
+	}
+	knapsack, err := NewKnapsack(itemValues, weights, capacities)
+	return knapsack, optimum, err
+}
+
+// Value returns the total value of the selected items
+func (k *Knapsack) Value(items []int) float64 {
+	value := 0.0
+	for _, i := range items {
+		value += k.Values[i]
+	}
+	return value
+}
+
+// PheromoneShape places pheromone on the items themselves
+func (k *Knapsack) PheromoneShape() (rows, cols int) {
+	return 1, len(k.Values)
+}
+
+// NewConstruction starts with an empty knapsack
+func (k *Knapsack) NewConstruction() Construction {
+	return &knapsackConstruction{
+		instance:  k,
+		remaining: append([]float64(nil), k.Capacities...),
+		chosen:    make([]bool, len(k.Values)),
+	}
+}
+
+// knapsackConstruction adds items while any still fits
+type knapsackConstruction struct {
+	instance  *Knapsack
+	remaining []float64
+	chosen    []bool
+	items     []int
+}
+
+func (c *knapsackConstruction) fits(item int) bool {
+	for d, w := range c.instance.Weights[item] {
+		if w > c.remaining[d] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *knapsackConstruction) Moves() []Move {
+	var moves []Move
+	for item, chosen := range c.chosen {
+		if chosen || !c.fits(item) {
+			continue
+		}
+		// Pseudo-utility: value per unit of relative resource consumption
+		load := 0.0
+		for d, w := range c.instance.Weights[item] {
+			if c.instance.Capacities[d] > 0 {
+				load += w / c.instance.Capacities[d]
+			}
+		}
+		moves = append(moves, Move{Row: 0, Col: item, Heuristic: c.instance.Values[item] / (load + 1e-9)})
+	}
+	return moves
+}
+
+func (c *knapsackConstruction) Apply(m Move) {
+	c.chosen[m.Col] = true
+	c.items = append(c.items, m.Col)
+	for d, w := range c.instance.Weights[m.Col] {
+		c.remaining[d] -= w
+	}
+}
+
+// Cost is the value left out of the knapsack, so that minimizing it maximizes the packed value
+func (c *knapsackConstruction) Cost() float64 {
+	left := 0.0
+	for item, chosen := range c.chosen {
+		if !chosen {
+			left += c.instance.Values[item]
+		}
+	}
+	return left
+}
+
+func (c *knapsackConstruction) Solution() []int {
+	return append([]int(nil), c.items...)
+}
+
+// numberReader hands out the numbers of an OR-Library file in turn
+type numberReader struct {
+	values []float64
+	pos    int
+}
+
+// next returns the next number, what naming it for the error if there is none
+func (nr *numberReader) next(what string) (float64, error) {
+	if nr.pos == len(nr.values) {
+		return 0, fmt.Errorf("missing %s", what)
+	}
+	nr.pos++
+	return nr.values[nr.pos-1], nil
+}
+
+// count returns the next number, which must be a non-negative integer
+func (nr *numberReader) count(what string) (int, error) {
+	v, err := nr.next(what)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 || v != float64(int(v)) {
+		return 0, fmt.Errorf("%s %g is not a non-negative integer", what, v)
+	}
+	return int(v), nil
+}
+
+// end fails if numbers are left over
+func (nr *numberReader) end() error {
+	if left := len(nr.values) - nr.pos; left > 0 {
+		return fmt.Errorf("%d numbers after the end of the instance", left)
+	}
+	return nil
+}
+
+// setCoverCommand chooses the cheapest sets it can find that cover an
+// OR-Library set covering instance
+func setCoverCommand(args []string) error {
+	flags := flag.NewFlagSet("setcover", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s setcover [flags] instance\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Chooses sets of an OR-Library set covering file, - for stdin, that cover every element")
+		fmt.Fprintln(flags.Output(), "at the least total cost it can find, and prints them numbered from 1 as in the file. Of")
+		fmt.Fprintln(flags.Output(), "the parameters only -ants, -alpha, -beta, -rho, -q, -iterations and -seed apply.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("setcover: want exactly one instance file")
+	}
+	config, err := parameters(DefaultConfig())
+	if err != nil {
+		return err
+	}
+	data, err := readInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	sc, err := ParseSetCover(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", inputName(flags.Arg(0)), err)
+	}
+	sets, cost, err := solveProblem(sc, config)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Best cover:", numberedFromOne(sets))
+	fmt.Println("Best cost:", cost)
+	return nil
+}
+
+// knapsackCommand packs the items of an OR-Library multidimensional knapsack
+// instance for the most value it can find
+func knapsackCommand(args []string) error {
+	flags := flag.NewFlagSet("knapsack", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s knapsack [flags] instance\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Packs items of an OR-Library multidimensional knapsack file, - for stdin, for the most")
+		fmt.Fprintln(flags.Output(), "value it can find within every capacity, and prints them numbered from 1 as in the file,")
+		fmt.Fprintln(flags.Output(), "with the gap to the optimum if the file gives it. Of the parameters only -ants, -alpha,")
+		fmt.Fprintln(flags.Output(), "-beta, -rho, -q, -iterations and -seed apply.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("knapsack: want exactly one instance file")
+	}
+	config, err := parameters(DefaultConfig())
+	if err != nil {
+		return err
+	}
+	data, err := readInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	knapsack, optimum, err := ParseKnapsack(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", inputName(flags.Arg(0)), err)
+	}
+	items, _, err := solveProblem(knapsack, config)
+	if err != nil {
+		return err
+	}
+	slices.Sort(items)
+	value := knapsack.Value(items)
+	fmt.Println("Best items:", numberedFromOne(items))
+	fmt.Println("Best value:", value)
+	if optimum > 0 {
+		fmt.Printf("Optimum: %g (gap %.2f%%)\n", optimum, 100*(optimum-value)/optimum)
+	}
+	return nil
+}
+
+// numberedFromOne returns indices numbered from 1, as OR-Library files number them
+func numberedFromOne(indices []int) []int {
+	numbered := make([]int, len(indices))
+	for i, index := range indices {
+		numbered[i] = index + 1
+	}
+	return numbered
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// scp6 is an OR-Library set covering instance of 6 elements and 6 sets
+const scp6 = `6 6
+3 2 4 1 5 2
+2 1 4
+2 1 2
+2 2 6
+3 3 5 6
+2 3 4
+2 5 6
+`
+
+// mknap8 is an OR-Library knapsack instance of 8 items and 2 capacities,
+// whose optimum is left unknown
+const mknap8 = `8 2 0
+15 100 90 60 40 15 10 1
+2 20 20 30 40 30 60 10
+4 30 10 20 30 40 50 5
+80 90
+`
+
+// TestSetCover checks that a seeded colony covers every element of an
+// OR-Library instance at the least cost, found by trying every choice of sets
+func TestSetCover(t *testing.T) {
+	sc, err := ParseSetCover(strings.NewReader(scp6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	optimum := 0.0
+	for _, c := range sc.Costs {
+		optimum += c
+	}
+	for mask := 0; mask < 1<<len(sc.Sets); mask++ {
+		var sets []int
+		cost := 0.0
+		for s := range sc.Sets {
+			if mask&(1<<s) != 0 {
+				sets = append(sets, s)
+				cost += sc.Costs[s]
+			}
+		}
+		if uncoveredElement(sc, sets) < 0 {
+			optimum = min(optimum, cost)
+		}
+	}
+
+	config := DefaultConfig()
+	config.Seed = 1
+	sets, cost, err := solveProblem(sc, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := uncoveredElement(sc, sets); e >= 0 {
+		t.Errorf("sets %v leave element %d uncovered", sets, e)
+	}
+	total := 0.0
+	for _, s := range sets {
+		total += sc.Costs[s]
+	}
+	if total != cost {
+		t.Errorf("sets %v cost %g, reported %g", sets, total, cost)
+	}
+	if cost != optimum {
+		t.Errorf("best cost %g, optimum %g", cost, optimum)
+	}
+}
+
+// TestKnapsack checks that a seeded colony packs an OR-Library instance
+// within every capacity and for the most value, found by trying every choice
+// of items
+func TestKnapsack(t *testing.T) {
+	knapsack, optimum, err := ParseKnapsack(strings.NewReader(mknap8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if optimum != 0 {
+		t.Errorf("optimum %g, want 0 for unknown", optimum)
+	}
+	best := 0.0
+	for mask := 0; mask < 1<<len(knapsack.Values); mask++ {
+		var items []int
+		for i := range knapsack.Values {
+			if mask&(1<<i) != 0 {
+				items = append(items, i)
+			}
+		}
+		if overfullCapacity(knapsack, items) < 0 {
+			best = max(best, knapsack.Value(items))
+		}
+	}
+
+	config := DefaultConfig()
+	config.Seed = 1
+	items, left, err := solveProblem(knapsack, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := overfullCapacity(knapsack, items); d >= 0 {
+		t.Errorf("items %v exceed capacity %d", items, d)
+	}
+	total := 0.0
+	for _, v := range knapsack.Values {
+		total += v
+	}
+	if value := knapsack.Value(items); value != total-left {
+		t.Errorf("items %v of value %g, reported %g left out of %g", items, value, left, total)
+	}
+	if value := knapsack.Value(items); value != best {
+		t.Errorf("best value %g, optimum %g", value, best)
+	}
+}
+
+// TestParseSubsetErrors checks that malformed set covering and knapsack
+// instances are refused
+func TestParseSubsetErrors(t *testing.T) {
+	for _, input := range []string{"", "2 1\n1\n1 1\n", "1 1\n1\n1 2\n", "1 1\n1\n1 1\n7\n", "1.5 1\n1\n1 1\n"} {
+		if _, err := ParseSetCover(strings.NewReader(input)); err == nil {
+			t.Errorf("set cover %q: no error", input)
+		}
+	}
+	for _, input := range []string{"", "2 1 0\n1 2\n3\n", "1 1 0\n1\n1\n", "1 1 0\n1\n1\n1\n1\n", "-1 1 0\n"} {
+		if _, _, err := ParseKnapsack(strings.NewReader(input)); err == nil {
+			t.Errorf("knapsack %q: no error", input)
+		}
+	}
+}
+
+// uncoveredElement returns an element no set of sets covers, -1 if they
+// cover them all
+func uncoveredElement(sc *SetCover, sets []int) int {
+	covered := make([]bool, sc.NumElements)
+	for _, s := range sets {
+		for _, e := range sc.Sets[s] {
+			covered[e] = true
+		}
+	}
+	for e, ok := range covered {
+		if !ok {
+			return e
+		}
+	}
+	return -1
+}
+
+// overfullCapacity returns a capacity items together exceed, -1 if they fit
+// in all of them
+func overfullCapacity(knapsack *Knapsack, items []int) int {
+	for d, capacity := range knapsack.Capacities {
+		load := 0.0
+		for _, i := range items {
+			load += knapsack.Weights[i][d]
+		}
+		if load > capacity {
+			return d
+		}
+	}
+	return -1
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// benchmarkFiles holds the TSPLIB instances the suites are made of
+//
+//go:embed benchmarks/*.tsp
+var benchmarkFiles embed.FS
+
+// BenchmarkSuites maps the names of the built-in benchmark suites to the
+// TSPLIB instances in them, all with optima in KnownOptima, so that running
+// a suite measures the gaps an algorithm leaves on problems solved exactly
+var BenchmarkSuites = map[string][]string{
+	"classic": {"eil51", "berlin52", "kroA100"},
+}
+
+// suiteNames lists the built-in suites in order
+func suiteNames() []string {
+	names := make([]string, 0, len(BenchmarkSuites))
+	for name := range BenchmarkSuites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadSuite returns the instances of a built-in benchmark suite
+func LoadSuite(name string) ([]*Instance, error) {
+	names, ok := BenchmarkSuites[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown benchmark suite %q, want one of %s", name, strings.Join(suiteNames(), ", "))
+	}
+	instances := make([]*Instance, 0, len(names))
+	for _, name := range names {
+		data, err := benchmarkFiles.ReadFile("benchmarks/" + name + ".tsp")
+		if err != nil {
+			return nil, err
+		}
+		instance, err := parseInstance(FormatTSPLIB, data)
+		if err != nil {
+			return nil, fmt.Errorf("benchmark instance %s: %w", name, err)
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
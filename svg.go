@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// svgSize is the default width and height of rendered images in pixels,
+// margins included
+const (
+	svgSize   = 800
+	svgMargin = 20
+)
+
+// Label modes of RenderOptions
+const (
+	// LabelsAuto labels the cities of small instances that have names
+	LabelsAuto = "auto"
+	// LabelsAlways labels every city, by index if it has no name
+	LabelsAlways = "always"
+	// LabelsNever draws no labels
+	LabelsNever = "never"
+)
+
+// RenderOptions controls how Render draws a tour
+type RenderOptions struct {
+	// Format is "svg" or "png", svg if empty
+	Format string
+	// Size is the width and height of the image in pixels, svgSize if zero
+	Size int
+	// Labels is one of the label modes, LabelsAuto if empty
+	Labels string
+	// Closed draws the edge back from the last city to the first
+	Closed bool
+	// Threshold is the share of the strongest trail below which pheromone
+	// overlays leave trails out, pheromoneThreshold if zero
+	Threshold float64
+}
+
+// renderFormat returns the image format for the file at path: png for a
+// .png file and svg otherwise
+func renderFormat(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".png") {
+		return "png"
+	}
+	return "svg"
+}
+
+// Render draws the cities and the tour through them as an image
+func Render(w io.Writer, cities []*City, tour []int, options RenderOptions) error {
+	if options.Size == 0 {
+		options.Size = svgSize
+	}
+	if options.Size <= 2*svgMargin {
+		return fmt.Errorf("image size %d is too small", options.Size)
+	}
+	switch options.Labels {
+	case "":
+		options.Labels = LabelsAuto
+	case LabelsAuto, LabelsAlways, LabelsNever:
+	default:
+		return fmt.Errorf("unknown label mode %q, want auto, always or never", options.Labels)
+	}
+	switch options.Format {
+	case "", "svg":
+		return renderSVG(w, cities, tour, options)
+	case "png":
+		return renderPNG(w, cities, tour, options)
+	}
+	return fmt.Errorf("unknown image format %q, want svg or png", options.Format)
+}
+
+// WriteTourSVG draws the cities and the tour through them as an SVG image,
+// closed back to the first city when closed is set. Names label the cities of
+// small instances
+func WriteTourSVG(w io.Writer, cities []*City, tour []int, closed bool) error {
+	return Render(w, cities, tour, RenderOptions{Closed: closed})
+}
+
+// labelled reports whether the options ask for the cities to be labelled
+func (o RenderOptions) labelled(cities []*City) bool {
+	switch o.Labels {
+	case LabelsAlways:
+		return true
+	case LabelsNever:
+		return false
+	}
+	return hasNames(cities) && len(cities) <= 100
+}
+
+// projection maps city coordinates to image pixels, keeping the aspect ratio
+type projection struct {
+	minX, minY, scale float64
+	size              int
+}
+
+// newProjection fits cities into a square image of size pixels
+func newProjection(cities []*City, size int) projection {
+	p := projection{minX: math.Inf(1), minY: math.Inf(1), size: size}
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, c := range cities {
+		p.minX, maxX = math.Min(p.minX, c.X), math.Max(maxX, c.X)
+		p.minY, maxY = math.Min(p.minY, c.Y), math.Max(maxY, c.Y)
+	}
+	p.scale = float64(size-2*svgMargin) / math.Max(math.Max(maxX-p.minX, maxY-p.minY), 1e-9)
+	return p
+}
+
+// point returns the pixel of a city. Image y axes point down, so north ends up at the top
+func (p projection) point(c *City) (float64, float64) {
+	return svgMargin + (c.X-p.minX)*p.scale, float64(p.size) - svgMargin - (c.Y-p.minY)*p.scale
+}
+
+// renderSVG draws the tour as SVG
+func renderSVG(w io.Writer, cities []*City, tour []int, options RenderOptions) error {
+	project := newProjection(cities, options.Size)
+	size := options.Size
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", size, size, size, size)
+	fmt.Fprintln(bw, `<rect width="100%" height="100%" fill="white"/>`)
+	fmt.Fprint(bw, `<polyline fill="none" stroke="steelblue" stroke-width="1.5" points="`)
+	for k, c := range tourRoute(cities, tour, options.Closed) {
+		x, y := project.point(c)
+		if k > 0 {
+			bw.WriteByte(' ')
+		}
+		fmt.Fprintf(bw, "%.2f,%.2f", x, y)
+	}
+	fmt.Fprintln(bw, `"/>`)
+	labelled := options.labelled(cities)
+	for i, c := range cities {
+		x, y := project.point(c)
+		fmt.Fprintf(bw, "<circle cx=\"%.2f\" cy=\"%.2f\" r=\"3\" fill=\"firebrick\"/>\n", x, y)
+		if labelled {
+			fmt.Fprintf(bw, "<text x=\"%.2f\" y=\"%.2f\" font-size=\"10\" font-family=\"sans-serif\">", x+5, y-5)
+			xml.EscapeText(bw, []byte(c.Label(i)))
+			fmt.Fprintln(bw, "</text>")
+		}
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
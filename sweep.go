@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// sweepCommand implements "sweep", which varies one parameter over a range,
+// holding the others fixed, and writes the quality at each value as CSV
+func sweepCommand(args []string) error {
+	flags := flag.NewFlagSet("sweep", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	logging := logFlags(flags)
+	param := flags.String("param", "beta", "the parameter to vary, named like its flag: ants, alpha, beta, rho, q, q0 or iterations")
+	from := flags.Float64("from", 1, "first value of the parameter")
+	to := flags.Float64("to", 10, "last value of the parameter")
+	steps := flags.Int("steps", 10, "number of evenly spaced values from -from to -to")
+	valueList := flags.String("values", "", "comma-separated `values` to try instead of -from, -to and -steps")
+	seeds := flags.Int("seeds", 5, "number of seeds to run each value with")
+	parallel := flags.Int("parallel", 1, "number of seeds to run at once")
+	outPath := flags.String("o", "-", "write the CSV to this `file`, - for stdout")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s sweep [flags] file\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Solves the instance in file at each value of -param over seeds -seed, -seed+1, ..., one CSV row per value.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("sweep: want exactly one instance file")
+	}
+	if *seeds <= 0 {
+		return fmt.Errorf("sweep: -seeds must be positive")
+	}
+	values, err := sweepValues(*valueList, *from, *to, *steps)
+	if err != nil {
+		return fmt.Errorf("sweep: %w", err)
+	}
+	logEvery, err := logging()
+	if err != nil {
+		return err
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	base := DefaultConfig()
+	if instance.Options != nil {
+		base = *instance.Options
+	}
+	if base, err = parameters(base); err != nil {
+		return err
+	}
+	if _, ok := configFields(&base)[*param]; !ok || *param == "variant" || *param == "seed" {
+		return fmt.Errorf("sweep: cannot vary %q", *param)
+	}
+
+	return writeFile(*outPath, func(w io.Writer) error {
+		cw := csv.NewWriter(w)
+		cw.Write([]string{*param, "seeds", "min", "mean", "mean_ci_low", "mean_ci_high", "median", "stddev", "mean_seconds"})
+		format := func(x float64) string { return strconv.FormatFloat(x, 'g', -1, 64) }
+		for _, value := range values {
+			experiment := &Experiment{
+				Instance: instance,
+				Config:   base,
+				Seeds:    *seeds,
+				Parallel: *parallel,
+				Logger:   slog.With(*param, value),
+				LogEvery: logEvery,
+			}
+			field := configFields(&experiment.Config)[*param]
+			if err := setConfigField(field, format(value)); err != nil {
+				return fmt.Errorf("sweep: %s: %w", *param, err)
+			}
+			stats, err := experiment.Run()
+			if err != nil {
+				return err
+			}
+			cw.Write([]string{
+				format(value),
+				strconv.Itoa(*seeds),
+				format(stats.Min),
+				format(stats.Mean),
+				format(stats.MeanCI[0]),
+				format(stats.MeanCI[1]),
+				format(stats.Median),
+				format(stats.Stddev),
+				format(stats.MeanTime.Seconds()),
+			})
+			// Flush each row so long sweeps can be watched as they go
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// sweepValues returns the values of a sweep: the given list, or steps evenly
+// spaced values from from to to
+func sweepValues(list string, from, to float64, steps int) ([]float64, error) {
+	if list != "" {
+		return parseFloats(list)
+	}
+	if steps < 1 {
+		return nil, fmt.Errorf("-steps must be positive")
+	}
+	if steps == 1 {
+		return []float64{from}, nil
+	}
+	values := make([]float64, steps)
+	for k := range values {
+		values[k] = from + (to-from)*float64(k)/float64(steps-1)
+	}
+	return values, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bounds of the pause a throttle backs off with while the system is loaded;
+// the load average it watches takes about a minute to follow a change
+const (
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// Throttle holds a solver back between iterations so that runs in the
+// background of a shared machine leave room for its other work
+type Throttle struct {
+	// NiceInterval is a pause before every iteration but the first
+	NiceInterval time.Duration
+	// MaxLoad, if positive, pauses the run for as long as the one-minute
+	// load average per core, the solver's own share included, exceeds it,
+	// backing off from minBackoff to maxBackoff between checks
+	MaxLoad float64
+}
+
+// Wait pauses before an iteration as the throttle asks, returning ctx's error
+// if ctx is done first. A nil throttle does not pause
+func (t *Throttle) Wait(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	if err := sleepContext(ctx, t.NiceInterval); err != nil {
+		return err
+	}
+	if t.MaxLoad <= 0 {
+		return nil
+	}
+	backoff := minBackoff
+	for {
+		load, err := loadAverage()
+		if err != nil || load/float64(runtime.NumCPU()) <= t.MaxLoad {
+			return nil
+		}
+		slog.Debug("backing off", "load", load, "cores", runtime.NumCPU(), "pause", backoff)
+		if err := sleepContext(ctx, backoff); err != nil {
+			return err
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// sleepContext sleeps for d, or until ctx is done with its error
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// loadAverage returns the one-minute system load average, which only Linux
+// reports, in /proc/loadavg
+func loadAverage() (float64, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("/proc/loadavg is empty")
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// throttleFlags adds the resource flags of long runs to flags, returning a
+// function that limits the cores Go runs on and returns the throttle the
+// flags ask for, nil if none
+func throttleFlags(flags *flag.FlagSet) func() (*Throttle, error) {
+	maxCores := flags.Int("max-cores", 0, "run on at most this many cores, 0 for all")
+	niceInterval := flags.Duration("nice-interval", 0, "sleep this long between iterations, leaving the processor to other work")
+	maxLoad := flags.Float64("max-load", 0, "pause while the one-minute load average per core, this run's included, exceeds this, 0 for never; Linux only")
+	return func() (*Throttle, error) {
+		if *maxCores < 0 || *niceInterval < 0 || *maxLoad < 0 {
+			return nil, fmt.Errorf("-max-cores, -nice-interval and -max-load must not be negative")
+		}
+		if *maxCores > 0 {
+			runtime.GOMAXPROCS(*maxCores)
+		}
+		if *maxLoad > 0 {
+			if _, err := loadAverage(); err != nil {
+				return nil, fmt.Errorf("-max-load: no system load average: %w", err)
+			}
+		}
+		if *niceInterval == 0 && *maxLoad == 0 {
+			return nil, nil
+		}
+		return &Throttle{NiceInterval: *niceInterval, MaxLoad: *maxLoad}, nil
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TimeDependentCost returns the cost of travelling from city i to city j when
+// departing at time t. The cost is also the travel time, so an ant's clock
+// advances by it
+type TimeDependentCost func(i, j int, t float64) float64
+
+// edgeCost returns the cost of the edge from city i to city j departing at time t
+func (ac *AntColony) edgeCost(i, j int, t float64) float64 {
+	if ac.TimeCost == nil {
+		return ac.distance(i, j)
+	}
+	return ac.TimeCost(i, j, t)
+}
+
+// RushHour returns a time-dependent cost that multiplies the colony's distances
+// by factor whenever the departure falls within [start, end) of each period
+func (ac *AntColony) RushHour(period, start, end, factor float64) TimeDependentCost {
+	return func(i, j int, t float64) float64 {
+		phase := math.Mod(t, period)
+		if phase >= start && phase < end {
+			return ac.distance(i, j) * factor
+		}
+		return ac.distance(i, j)
+	}
+}
+
+// rushHourCost returns the time-dependent cost Config.RushHour asks for, nil
+// if it is empty
+func (ac *AntColony) rushHourCost(rushHour string) (TimeDependentCost, error) {
+	values, err := parseRushHour(rushHour)
+	if values == nil || err != nil {
+		return nil, err
+	}
+	return ac.RushHour(values[0], values[1], values[2], values[3]), nil
+}
+
+// parseRushHour reads Config.RushHour into its period, start, end and
+// factor, nil if it is empty
+func parseRushHour(rushHour string) ([]float64, error) {
+	rushHour = strings.TrimSpace(rushHour)
+	if rushHour == "" {
+		return nil, nil
+	}
+	fields := strings.Split(rushHour, ":")
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("rush hour %q, want period:start:end:factor", rushHour)
+	}
+	values := make([]float64, 4)
+	for k, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || !(v >= 0) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("rush hour %s: %q is not a non-negative number", rushHour, field)
+		}
+		values[k] = v
+	}
+	period, start, end, factor := values[0], values[1], values[2], values[3]
+	switch {
+	case period == 0:
+		return nil, fmt.Errorf("rush hour %s: period must be positive", rushHour)
+	case start >= end || end > period:
+		return nil, fmt.Errorf("rush hour %s: want start < end <= period", rushHour)
+	case factor == 0:
+		return nil, fmt.Errorf("rush hour %s: factor must be positive", rushHour)
+	}
+	return values, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestRushHour checks that a rush hour at the start of the day makes the
+// colony choose another tour: of the cycles of four cities, 0-1-2-3 is the
+// shortest, but the others start with the edge 0-2 of length 1, the one to
+// leave on while every edge costs ten times its distance. Local search must
+// not undo the choice by comparing edge lengths
+func TestRushHour(t *testing.T) {
+	inst := &Instance{
+		Cities: []*City{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}},
+		Distances: [][]float64{
+			{0, 10, 1, 10},
+			{10, 0, 10, 24},
+			{1, 10, 0, 10},
+			{10, 24, 10, 0},
+		},
+		ReturnToStart: true,
+	}
+	solve := func(rushHour string) (*Solver, []int) {
+		t.Helper()
+		config := DefaultConfig()
+		config.Seed, config.Iterations, config.LocalSearch, config.RushHour = 1, 20, true, rushHour
+		solver, err := NewSolver(inst, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return solver, solver.Run().Tour
+	}
+	_, static := solve("")
+	if edges := sortedEdges(static); !slices.Equal(edges, [][2]int{{0, 1}, {0, 3}, {1, 2}, {2, 3}}) {
+		t.Fatalf("tour %v without rush hour, want the cycle 0-1-2-3", static)
+	}
+	rush, tour := solve("1000:0:5:10")
+	if slices.Equal(sortedEdges(tour), sortedEdges(static)) {
+		t.Fatalf("tour %v in rush hour, the cycle chosen without it", tour)
+	}
+	length := rush.Colony.TourLength(tour)
+	for start := range static {
+		rotated := append(slices.Clone(static[start:]), static[:start]...)
+		reversed := slices.Clone(rotated)
+		slices.Reverse(reversed)
+		for _, other := range [][]int{rotated, reversed} {
+			if otherLength := rush.Colony.TourLength(other); otherLength <= length {
+				t.Errorf("tour %v of length %g in rush hour, %v of the static cycle takes %g", tour, length, other, otherLength)
+			}
+		}
+	}
+}
+
+// sortedEdges returns the undirected edges of a closed tour in order
+func sortedEdges(tour []int) [][2]int {
+	edges := tourEdges(tour, true, false)
+	slices.SortFunc(edges, func(a, b [2]int) int { return slices.Compare(a[:], b[:]) })
+	return edges
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// traceBatchSize is the number of ended spans a tracer sends at once
+const traceBatchSize = 512
+
+// Tracer records spans of the solver's work and sends them to an
+// OpenTelemetry collector over OTLP/HTTP with JSON encoding, so runs show up
+// in the traces of the services embedding the solver. A nil *Tracer and the
+// nil spans it starts do nothing
+type Tracer struct {
+	// Endpoint is the collector's base URL, such as http://localhost:4318;
+	// spans are posted to Endpoint/v1/traces
+	Endpoint string
+	// Service names the process in traces
+	Service string
+	Client  *http.Client
+
+	mu    sync.Mutex
+	ended []*Span
+}
+
+// Span is a timed operation of a trace
+type Span struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	ParentID   [8]byte
+	Name       string
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes map[string]any
+
+	tracer *Tracer
+}
+
+// NewTracerFromEnv returns a tracer sending to endpoint, or to the
+// collector OTEL_EXPORTER_OTLP_ENDPOINT names if endpoint is empty, naming
+// the service by OTEL_SERVICE_NAME or "aco"; nil if neither gives an endpoint
+func NewTracerFromEnv(endpoint string) *Tracer {
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		return nil
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "aco"
+	}
+	return &Tracer{Endpoint: strings.TrimSuffix(endpoint, "/"), Service: service, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Start begins a span, a child of parent, or the root of a new trace if
+// parent is nil. Attributes are given as alternating keys and values
+func (t *Tracer) Start(parent *Span, name string, attributes ...any) *Span {
+	if t == nil {
+		return nil
+	}
+	span := &Span{Name: name, StartTime: time.Now(), Attributes: make(map[string]any), tracer: t}
+	if parent != nil {
+		span.TraceID, span.ParentID = parent.TraceID, parent.SpanID
+	} else {
+		rand.Read(span.TraceID[:])
+	}
+	rand.Read(span.SpanID[:])
+	span.SetAttributes(attributes...)
+	return span
+}
+
+// SetAttributes records alternating keys and values on the span
+func (s *Span) SetAttributes(attributes ...any) {
+	if s == nil {
+		return
+	}
+	for k := 0; k+1 < len(attributes); k += 2 {
+		s.Attributes[fmt.Sprint(attributes[k])] = attributes[k+1]
+	}
+}
+
+// End finishes the span and queues it for sending
+func (s *Span) End() {
+	if s == nil || s.tracer == nil {
+		return
+	}
+	s.EndTime = time.Now()
+	t := s.tracer
+	t.mu.Lock()
+	t.ended = append(t.ended, s)
+	full := len(t.ended) >= traceBatchSize
+	t.mu.Unlock()
+	if full {
+		go t.Flush()
+	}
+}
+
+// FlushEvery sends the ended spans every interval, for long-running servers
+func (t *Tracer) FlushEvery(interval time.Duration) {
+	if t == nil {
+		return
+	}
+	go func() {
+		for range time.Tick(interval) {
+			t.Flush()
+		}
+	}()
+}
+
+// Flush sends the spans ended so far
+func (t *Tracer) Flush() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans := t.ended
+	t.ended = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(t.otlpRequest(spans))
+	if err != nil {
+		return err
+	}
+	resp, err := t.Client.Post(t.Endpoint+"/v1/traces", "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Warn("sending traces", "err", err)
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		err = fmt.Errorf("sending traces: %s", resp.Status)
+		slog.Warn("sending traces", "err", err)
+	}
+	return err
+}
+
+// otlpRequest builds an OTLP ExportTraceServiceRequest in its JSON mapping,
+// in which ids are hex and 64-bit integers strings
+func (t *Tracer) otlpRequest(spans []*Span) map[string]any {
+	encoded := make([]map[string]any, len(spans))
+	for k, s := range spans {
+		span := map[string]any{
+			"traceId":           hex.EncodeToString(s.TraceID[:]),
+			"spanId":            hex.EncodeToString(s.SpanID[:]),
+			"name":              s.Name,
+			"kind":              1,
+			"startTimeUnixNano": strconv.FormatInt(s.StartTime.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.Attributes),
+		}
+		if s.ParentID != [8]byte{} {
+			span["parentSpanId"] = hex.EncodeToString(s.ParentID[:])
+		}
+		encoded[k] = span
+	}
+	return map[string]any{"resourceSpans": []any{map[string]any{
+		"resource": map[string]any{"attributes": otlpAttributes(map[string]any{"service.name": t.Service})},
+		"scopeSpans": []any{map[string]any{
+			"scope": map[string]any{"name": "aco"},
+			"spans": encoded,
+		}},
+	}}}
+}
+
+// otlpAttributes encodes attributes as OTLP key-value pairs
+func otlpAttributes(attributes map[string]any) []any {
+	encoded := []any{}
+	for key, v := range attributes {
+		var value map[string]any
+		switch v := v.(type) {
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+			if finite(v) == nil {
+				// JSON has no infinity
+				value = map[string]any{"stringValue": strconv.FormatFloat(v, 'g', -1, 64)}
+			}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		encoded = append(encoded, map[string]any{"key": key, "value": value})
+	}
+	return encoded
+}
+
+// SpanFromTraceParent returns the remote parent a W3C traceparent header
+// names, such as 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01,
+// or nil if the header is missing or malformed
+func SpanFromTraceParent(header string) *Span {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return nil
+	}
+	span := &Span{}
+	if _, err := hex.Decode(span.TraceID[:], []byte(parts[1])); err != nil {
+		return nil
+	}
+	if _, err := hex.Decode(span.SpanID[:], []byte(parts[2])); err != nil {
+		return nil
+	}
+	if span.TraceID == [16]byte{} || span.SpanID == [8]byte{} {
+		return nil
+	}
+	return span
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TSPLIBInstance is a problem read from a TSPLIB .tsp file. Distances holds
+// the matrix of EXPLICIT files; the distances of the others follow from the
+// coordinates by the rounding conventions of EDGE_WEIGHT_TYPE, computed as
+// they are needed, so tour lengths are comparable with published optima
+type TSPLIBInstance struct {
+	Name             string
+	Type             string
+	Comment          string
+	Dimension        int
+	EdgeWeightType   string
+	EdgeWeightFormat string
+	Cities           []*City
+	Distances        [][]float64
+	// matrixTime is the time spent reading EDGE_WEIGHT_SECTION into Distances
+	matrixTime time.Duration
+}
+
+// LoadTSPLIB reads a TSPLIB instance from the file at path
+func LoadTSPLIB(path string) (*TSPLIBInstance, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	inst, err := ParseTSPLIB(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return inst, nil
+}
+
+// ParseTSPLIB reads a TSPLIB instance with NODE_COORD_SECTION or EDGE_WEIGHT_SECTION data
+func ParseTSPLIB(r io.Reader) (*TSPLIBInstance, error) {
+	inst := &TSPLIBInstance{}
+	var weights []float64
+	var weightsStart time.Time
+	section := ""
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if text == "EOF" {
+			break
+		}
+		if strings.HasSuffix(text, "_SECTION") {
+			section = text
+			if section == "EDGE_WEIGHT_SECTION" && weightsStart.IsZero() {
+				if err := checkMatrixMemory(inst.Dimension); err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+				weightsStart = time.Now()
+			}
+			if inst.Dimension <= 0 {
+				return nil, fmt.Errorf("line %d: %s before DIMENSION", line, section)
+			}
+			if inst.Cities == nil {
+				inst.Cities = make([]*City, inst.Dimension)
+			}
+			continue
+		}
+		if !startsWithNumber(text) {
+			key, value, ok := strings.Cut(text, ":")
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown keyword %q", line, text)
+			}
+			section = ""
+			if err := inst.setKeyword(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			continue
+		}
+		switch section {
+		case "NODE_COORD_SECTION", "DISPLAY_DATA_SECTION":
+			if err := inst.parseCoord(text); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		case "EDGE_WEIGHT_SECTION":
+			for _, field := range strings.Fields(text) {
+				w, err := strconv.ParseFloat(field, 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: edge weight %q is not a number", line, field)
+				}
+				weights = append(weights, w)
+			}
+		default:
+			// Sections the solver has no use for, such as FIXED_EDGES_SECTION, are skipped
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inst.Dimension <= 0 {
+		return nil, fmt.Errorf("missing DIMENSION")
+	}
+	if inst.Cities == nil {
+		return nil, fmt.Errorf("missing NODE_COORD_SECTION or EDGE_WEIGHT_SECTION")
+	}
+	for i, c := range inst.Cities {
+		if c == nil {
+			if inst.EdgeWeightType != "EXPLICIT" {
+				return nil, fmt.Errorf("missing coordinates for node %d", i+1)
+			}
+			inst.Cities[i] = &City{}
+		}
+	}
+	if inst.EdgeWeightType == "EXPLICIT" {
+		distances, err := explicitDistances(inst.Dimension, inst.EdgeWeightFormat, weights)
+		if err != nil {
+			return nil, err
+		}
+		inst.Distances = distances
+		inst.matrixTime = time.Since(weightsStart)
+		return inst, nil
+	}
+	if _, err := tsplibDistance(inst.EdgeWeightType); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// startsWithNumber reports whether a data line begins with a numeric field
+func startsWithNumber(text string) bool {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return false
+	}
+	_, err := strconv.ParseFloat(fields[0], 64)
+	return err == nil
+}
+
+// setKeyword records a "KEY : VALUE" specification line
+func (inst *TSPLIBInstance) setKeyword(key, value string) error {
+	switch key {
+	case "NAME":
+		inst.Name = value
+	case "TYPE":
+		inst.Type = value
+	case "COMMENT":
+		inst.Comment = value
+	case "DIMENSION":
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid DIMENSION %q", value)
+		}
+		inst.Dimension = n
+	case "EDGE_WEIGHT_TYPE":
+		inst.EdgeWeightType = value
+	case "EDGE_WEIGHT_FORMAT":
+		inst.EdgeWeightFormat = value
+	}
+	return nil
+}
+
+// parseCoord reads an "id x y [z]" coordinate line
+func (inst *TSPLIBInstance) parseCoord(text string) error {
+	fields := strings.Fields(text)
+	if len(fields) < 3 {
+		return fmt.Errorf("coordinate line needs an id and at least two coordinates")
+	}
+	id, err := strconv.Atoi(fields[0])
+	if err != nil || id < 1 || id > inst.Dimension {
+		return fmt.Errorf("node id %q out of range [1, %d]", fields[0], inst.Dimension)
+	}
+	coords := make([]float64, len(fields)-1)
+	for k, field := range fields[1:] {
+		if coords[k], err = strconv.ParseFloat(field, 64); err != nil {
+			return fmt.Errorf("coordinate %q is not a number", field)
+		}
+	}
+	city := &City{X: coords[0], Y: coords[1]}
+	if len(coords) > 2 {
+		city.Z = coords[2]
+	}
+	inst.Cities[id-1] = city
+	return nil
+}
+
+// nint rounds to the nearest integer the way TSPLIB does
+func nint(x float64) float64 {
+	return math.Floor(x + 0.5)
+}
+
+// tsplibDistance returns the distance function for an EDGE_WEIGHT_TYPE
+func tsplibDistance(edgeWeightType string) (func(a, b *City) float64, error) {
+	switch edgeWeightType {
+	case "EUC_2D", "EUC_3D":
+		return func(a, b *City) float64 { return nint(a.Distance(b)) }, nil
+	case "CEIL_2D":
+		return func(a, b *City) float64 { return math.Ceil(a.Distance(b)) }, nil
+	case "MAN_2D":
+		return func(a, b *City) float64 { return nint(math.Abs(a.X-b.X) + math.Abs(a.Y-b.Y)) }, nil
+	case "MAX_2D":
+		return func(a, b *City) float64 { return math.Max(nint(math.Abs(a.X-b.X)), nint(math.Abs(a.Y-b.Y))) }, nil
+	case "ATT":
+		return func(a, b *City) float64 {
+			dx, dy := a.X-b.X, a.Y-b.Y
+			r := math.Sqrt((dx*dx + dy*dy) / 10)
+			if t := nint(r); t >= r {
+				return t
+			}
+			return nint(r) + 1
+		}, nil
+	case "GEO":
+		return geoDistance, nil
+	}
+	return nil, fmt.Errorf("unsupported EDGE_WEIGHT_TYPE %q", edgeWeightType)
+}
+
+// geoDistance is the TSPLIB GEO distance between coordinates given as DDD.MM latitude and longitude
+func geoDistance(a, b *City) float64 {
+	const rrr = 6378.388
+	radians := func(x float64) float64 {
+		deg := math.Trunc(x)
+		return 3.141592 * (deg + 5.0*(x-deg)/3.0) / 180.0
+	}
+	latA, lonA := radians(a.X), radians(a.Y)
+	latB, lonB := radians(b.X), radians(b.Y)
+	q1 := math.Cos(lonA - lonB)
+	q2 := math.Cos(latA - latB)
+	q3 := math.Cos(latA + latB)
+	return math.Trunc(rrr*math.Acos(0.5*((1.0+q1)*q2-(1.0-q1)*q3)) + 1.0)
+}
+
+// explicitDistances expands EDGE_WEIGHT_SECTION values into a full matrix
+func explicitDistances(n int, format string, weights []float64) ([][]float64, error) {
+	distances := make([][]float64, n)
+	for i := range distances {
+		distances[i] = make([]float64, n)
+	}
+	// Each format lists one triangle (or the full matrix) row by row; the
+	// column-wise variants visit the mirrored triangle in the same order
+	var include func(i, j int) bool
+	switch format {
+	case "FULL_MATRIX":
+		include = func(i, j int) bool { return true }
+	case "UPPER_ROW", "LOWER_COL":
+		include = func(i, j int) bool { return j > i }
+	case "LOWER_ROW", "UPPER_COL":
+		include = func(i, j int) bool { return j < i }
+	case "UPPER_DIAG_ROW", "LOWER_DIAG_COL":
+		include = func(i, j int) bool { return j >= i }
+	case "LOWER_DIAG_ROW", "UPPER_DIAG_COL":
+		include = func(i, j int) bool { return j <= i }
+	default:
+		return nil, fmt.Errorf("unsupported EDGE_WEIGHT_FORMAT %q", format)
+	}
+	k := 0
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if !include(i, j) {
+				continue
+			}
+			if k >= len(weights) {
+				return nil, fmt.Errorf("EDGE_WEIGHT_SECTION has %d values, too few for %s of dimension %d", len(weights), format, n)
+			}
+			distances[i][j] = weights[k]
+			if format != "FULL_MATRIX" {
+				distances[j][i] = weights[k]
+			}
+			k++
+		}
+	}
+	if k != len(weights) {
+		return nil, fmt.Errorf("EDGE_WEIGHT_SECTION has %d values, want %d for %s of dimension %d", len(weights), k, format, n)
+	}
+	return distances, nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// tuneCommand implements "tune", which searches combinations of alpha, beta,
+// rho, ants and q0, exhaustively over a grid or by sampling at random, on one
+// or more training instances and ranks them
+func tuneCommand(args []string) error {
+	flags := flag.NewFlagSet("tune", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	logging := logFlags(flags)
+	alphaList := flags.String("alphas", "0.5,1,2", "comma-separated alpha `values` to try")
+	betaList := flags.String("betas", "1,2,5", "comma-separated beta `values` to try")
+	rhoList := flags.String("rhos", "0.1,0.3,0.5", "comma-separated rho `values` to try")
+	antList := flags.String("antcounts", "", "comma-separated ant `counts` to try, -ants alone if empty")
+	q0List := flags.String("q0s", "", "comma-separated q0 `values` to try, -q0 alone if empty")
+	method := flags.String("method", "grid", "try every combination (grid), -trials drawn between each list's extremes (random), "+
+		"-trials chosen by a Gaussian process surrogate between the extremes (bayes), or race the grid's combinations, dropping inferior ones early (race)")
+	trials := flags.Int("trials", 20, "with -method random or bayes, number of combinations to try")
+	runs := flags.Int("runs", 3, "number of seeds to average each combination over; with -method race, the most seeds per instance")
+	firstTest := flags.Int("firsttest", 5, "with -method race, number of instance and seed blocks before dropping combinations")
+	significance := flags.Float64("significance", 0.05, "with -method race, significance level of the Friedman test")
+	parallel := flags.Int("parallel", 1, "number of seeds to run at once")
+	budget := flags.Duration("budget", 0, "time allowed to each combination, shared by its runs, 0 for no limit")
+	top := flags.Int("top", 10, "show this many of the best combinations")
+	outPath := flags.String("o", "", "write the best combination as a JSON, YAML or TOML config `file` for -config")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s tune [flags] file...\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Searches alpha, beta, rho, ants and q0 on the training instances, other parameters taken from the flags.")
+		fmt.Fprintln(flags.Output(), "With several instances, combinations are ranked by SCORE, the mean over instances of their mean")
+		fmt.Fprintln(flags.Output(), "length divided by the best mean any combination reached there.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("tune: want at least one instance file")
+	}
+	if *runs <= 0 {
+		return fmt.Errorf("tune: -runs must be positive")
+	}
+	if (*method == "random" || *method == "bayes") && *trials <= 0 {
+		return fmt.Errorf("tune: -trials must be positive with -method %s", *method)
+	}
+	logEvery, err := logging()
+	if err != nil {
+		return err
+	}
+	instances := make([]*Instance, flags.NArg())
+	for k, path := range flags.Args() {
+		if instances[k], err = ReadInstance(path, ""); err != nil {
+			return err
+		}
+	}
+	base := DefaultConfig()
+	if instances[0].Options != nil {
+		base = *instances[0].Options
+	}
+	if base, err = parameters(base); err != nil {
+		return err
+	}
+	if base.Seed == 0 {
+		base.Seed = 1
+	}
+	if *antList == "" {
+		*antList = strconv.Itoa(base.NumAnts)
+	}
+	if *q0List == "" {
+		*q0List = strconv.FormatFloat(base.Q0, 'g', -1, 64)
+	}
+	var space [5][]float64
+	for k, list := range []string{*alphaList, *betaList, *rhoList, *antList, *q0List} {
+		if space[k], err = parseFloats(list); err != nil {
+			return fmt.Errorf("tune: %w", err)
+		}
+	}
+	type trial struct {
+		config Config
+		// means holds the mean length on each instance
+		means []float64
+		score float64
+	}
+	toConfig := func(point [5]float64) (Config, error) {
+		config := base
+		config.Alpha, config.Beta, config.Rho = point[0], point[1], point[2]
+		config.NumAnts, config.Q0 = int(math.Round(point[3])), point[4]
+		if err := config.Validate(); err != nil {
+			return config, fmt.Errorf("tune: %w", err)
+		}
+		return config, nil
+	}
+	evaluate := func(config Config) (trial, error) {
+		t := trial{config: config, means: make([]float64, len(instances))}
+		for k, instance := range instances {
+			experiment := &Experiment{
+				Instance: instance,
+				Config:   config,
+				Seeds:    *runs,
+				Parallel: *parallel,
+				Budget:   *budget / time.Duration(len(instances)**runs),
+				Logger:   slog.With("alpha", config.Alpha, "beta", config.Beta, "rho", config.Rho, "ants", config.NumAnts, "q0", config.Q0),
+				LogEvery: logEvery,
+			}
+			stats, err := experiment.Run()
+			if err != nil {
+				return t, err
+			}
+			t.means[k] = stats.Mean
+		}
+		slog.Debug("tried parameters", "alpha", config.Alpha, "beta", config.Beta, "rho", config.Rho,
+			"ants", config.NumAnts, "q0", config.Q0, "means", t.means)
+		return t, nil
+	}
+
+	var results []trial
+	var candidates [][5]float64
+	switch *method {
+	case "grid", "race":
+		candidates = gridPoints(space)
+	case "random":
+		candidates = randomPoints(space, *trials, rand.New(rand.NewSource(base.Seed)))
+	case "bayes":
+		// Lengths are compared across instances relative to a nearest-neighbour tour
+		references := make([]float64, len(instances))
+		for k, instance := range instances {
+			solver, err := NewSolver(instance, base)
+			if err != nil {
+				return err
+			}
+			references[k] = solver.Colony.TourLength(solver.Colony.nearestNeighborTour())
+		}
+		_, err := bayesSearch(space, *trials, rand.New(rand.NewSource(base.Seed)), func(point [5]float64) (float64, error) {
+			config, err := toConfig(point)
+			if err != nil {
+				return 0, err
+			}
+			t, err := evaluate(config)
+			if err != nil {
+				return 0, err
+			}
+			results = append(results, t)
+			objective := 0.0
+			for k, mean := range t.means {
+				objective += mean / references[k] / float64(len(instances))
+			}
+			return objective, nil
+		})
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("tune: unknown method %q, want grid, random, race or bayes", *method)
+	}
+	configs := make([]Config, len(candidates))
+	for c, point := range candidates {
+		if configs[c], err = toConfig(point); err != nil {
+			return err
+		}
+	}
+
+	// A race of one combination has nothing to drop it against and runs no
+	// blocks, so a lone combination is tried as the grid tries it
+	if *method == "race" && len(configs) > 1 {
+		race := &Race{
+			Instances:    instances,
+			Candidates:   configs,
+			Seeds:        *runs,
+			FirstTest:    *firstTest,
+			Significance: *significance,
+			Parallel:     *parallel,
+			Budget:       *budget / time.Duration(len(instances)**runs),
+		}
+		outcome, err := race.Run()
+		if err != nil {
+			return err
+		}
+		for _, c := range outcome.Survivors {
+			t := trial{config: configs[c], means: make([]float64, len(instances))}
+			counts := make([]int, len(instances))
+			for block, length := range outcome.Lengths[c] {
+				t.means[outcome.Instance[block]] += length
+				counts[outcome.Instance[block]]++
+			}
+			for k := range t.means {
+				t.means[k] /= float64(counts[k])
+			}
+			results = append(results, t)
+		}
+		fmt.Printf("%d of %d combinations survived the race, after %d runs of the %d a full grid search makes\n",
+			len(outcome.Survivors), len(configs), outcome.Runs, len(configs)*len(instances)**runs)
+	} else {
+		for _, config := range configs {
+			t, err := evaluate(config)
+			if err != nil {
+				return err
+			}
+			results = append(results, t)
+		}
+	}
+	for k := range instances {
+		best := math.Inf(1)
+		for _, t := range results {
+			best = math.Min(best, t.means[k])
+		}
+		for i := range results {
+			results[i].score += results[i].means[k] / best / float64(len(instances))
+		}
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("tune: no combinations to try")
+	}
+	sort.SliceStable(results, func(a, b int) bool { return results[a].score < results[b].score })
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ALPHA\tBETA\tRHO\tANTS\tQ0\tSCORE\tMEAN")
+	for _, t := range results[:min(*top, len(results))] {
+		means := make([]string, len(t.means))
+		for k, mean := range t.means {
+			means[k] = fmt.Sprintf("%.6g", mean)
+		}
+		fmt.Fprintf(tw, "%.4g\t%.4g\t%.4g\t%d\t%.4g\t%.4f\t%s\n", t.config.Alpha, t.config.Beta, t.config.Rho,
+			t.config.NumAnts, t.config.Q0, t.score, strings.Join(means, " "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	best := results[0].config
+	fmt.Printf("Best parameters: -alpha %g -beta %g -rho %g -ants %d -q0 %g\n", best.Alpha, best.Beta, best.Rho, best.NumAnts, best.Q0)
+	if *outPath != "" {
+		// The seeds were only for comparing fairly, so runs with the file vary
+		best.Seed = 0
+		return WriteConfigFile(*outPath, best)
+	}
+	return nil
+}
+
+// gridPoints returns every combination of one value from each list
+func gridPoints(space [5][]float64) [][5]float64 {
+	points := [][5]float64{{}}
+	for k, values := range space {
+		var next [][5]float64
+		for _, point := range points {
+			for _, v := range values {
+				point[k] = v
+				next = append(next, point)
+			}
+		}
+		points = next
+	}
+	return points
+}
+
+// randomPoints draws n combinations, each value uniform between the smallest
+// and largest of its list
+func randomPoints(space [5][]float64, n int, rng *rand.Rand) [][5]float64 {
+	points := make([][5]float64, n)
+	for i := range points {
+		for k, values := range space {
+			lo, hi := slices.Min(values), slices.Max(values)
+			points[i][k] = lo + rng.Float64()*(hi-lo)
+		}
+	}
+	return points
+}
+
+// parseFloats parses a comma-separated list of numbers
+func parseFloats(list string) ([]float64, error) {
+	var values []float64
+	for _, field := range strings.Split(list, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", field)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
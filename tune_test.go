@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestTuneRaceOneCombination checks that racing a single combination reports
+// its mean lengths, where the race runs no blocks to average
+func TestTuneRaceOneCombination(t *testing.T) {
+	out := captureStdout(t, func() error {
+		return tuneCommand([]string{"-method", "race", "-alphas", "1", "-betas", "2", "-rhos", "0.5", "-runs", "2", "-iterations", "5", "benchmarks/eil51.tsp"})
+	})
+	if strings.Contains(out, "NaN") {
+		t.Errorf("output holds NaN:\n%s", out)
+	}
+	if !strings.Contains(out, "Best parameters: -alpha 1 -beta 2 -rho 0.5") {
+		t.Errorf("output does not give the combination as best:\n%s", out)
+	}
+}
+
+// captureStdout returns what f writes to standard output, failing t if f
+// returns an error
+func captureStdout(t *testing.T, f func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	read := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		read <- string(data)
+	}()
+	err = f()
+	os.Stdout = stdout
+	w.Close()
+	out := <-read
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package main
+
+import "fmt"
+
+// ValidateTour checks that tour visits each of the n cities exactly once and
+// satisfies the constraints as an open tour, from its first city to its last
+func ValidateTour(tour []int, n int, constraints *Constraints) error {
+	return validateTour(tour, n, constraints, false)
+}
+
+// ValidateClosedTour is ValidateTour for tours that return to their first
+// city, whose closing edge must not be forbidden and may be a required one
+func ValidateClosedTour(tour []int, n int, constraints *Constraints) error {
+	return validateTour(tour, n, constraints, true)
+}
+
+// ValidateTour checks a tour of the instance, closed if it returns to start,
+// such as one imported from a file before it is used or scored
+func (inst *Instance) ValidateTour(tour []int) error {
+	return validateTour(tour, len(inst.Cities), inst.Constraints, inst.ReturnToStart)
+}
+
+func validateTour(tour []int, n int, constraints *Constraints, closed bool) error {
+	if len(tour) != n {
+		return fmt.Errorf("tour has %d cities, want %d", len(tour), n)
+	}
+	position := make(map[int]int, n)
+	for i, city := range tour {
+		if city < 0 || city >= n {
+			return fmt.Errorf("tour position %d: city %d out of range [0, %d)", i, city, n)
+		}
+		if prev, ok := position[city]; ok {
+			return fmt.Errorf("tour position %d: city %d already visited at position %d", i, city, prev)
+		}
+		position[city] = i
+	}
+	if constraints == nil {
+		return nil
+	}
+	for _, p := range constraints.precedences() {
+		if position[p.Before] > position[p.After] {
+			return fmt.Errorf("city %d visited before its predecessor %d", p.After, p.Before)
+		}
+	}
+	for i := 0; i < len(tour)-1; i++ {
+		if !constraints.AllowsEdge(tour[i], tour[i+1]) {
+			return fmt.Errorf("tour position %d: forbidden edge %d-%d", i, tour[i], tour[i+1])
+		}
+	}
+	if closed && n > 1 && !constraints.AllowsEdge(tour[n-1], tour[0]) {
+		return fmt.Errorf("forbidden edge %d-%d closes the tour", tour[n-1], tour[0])
+	}
+	for _, e := range constraints.requiredEdges() {
+		for _, city := range e {
+			if city < 0 || city >= n {
+				return fmt.Errorf("required edge %d-%d: city %d out of range [0, %d)", e[0], e[1], city, n)
+			}
+		}
+		d := position[e[0]] - position[e[1]]
+		if d != 1 && d != -1 && !(closed && n > 2 && (d == n-1 || d == 1-n)) {
+			return fmt.Errorf("required edge %d-%d is not in the tour", e[0], e[1])
+		}
+	}
+	for k, cluster := range constraints.Clusters {
+		first, last := n, -1
+		for _, city := range cluster {
+			first = min(first, position[city])
+			last = max(last, position[city])
+		}
+		if len(cluster) > 0 && last-first != len(cluster)-1 {
+			return fmt.Errorf("cluster %d is not visited contiguously", k)
+		}
+	}
+	return nil
+}
+
+// Violations counts the constraints a tour of distinct cities breaks: the
+// precedences it reverses, forbidden edges it takes, required edges it
+// leaves out and clusters it splits
+func (c *Constraints) Violations(tour []int, closed bool) int {
+	if c == nil {
+		return 0
+	}
+	n := len(tour)
+	position := make(map[int]int, n)
+	for i, city := range tour {
+		position[city] = i
+	}
+	violations := 0
+	for _, p := range c.precedences() {
+		if position[p.Before] > position[p.After] {
+			violations++
+		}
+	}
+	for i := 0; i < n-1; i++ {
+		if !c.AllowsEdge(tour[i], tour[i+1]) {
+			violations++
+		}
+	}
+	if closed && n > 1 && !c.AllowsEdge(tour[n-1], tour[0]) {
+		violations++
+	}
+	for _, e := range c.requiredEdges() {
+		d := position[e[0]] - position[e[1]]
+		if d != 1 && d != -1 && !(closed && n > 2 && (d == n-1 || d == 1-n)) {
+			violations++
+		}
+	}
+	for _, cluster := range c.Clusters {
+		first, last := n, -1
+		for _, city := range cluster {
+			first = min(first, position[city])
+			last = max(last, position[city])
+		}
+		if len(cluster) > 0 && last-first != len(cluster)-1 {
+			violations++
+		}
+	}
+	return violations
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ACO variants, selected by Config.Variant
+const (
+	// AntSystem lets every ant deposit pheromone in proportion to its tour's quality
+	AntSystem = "as"
+	// ElitistAntSystem adds a deposit on the best tour so far, weighted by the number of ants
+	ElitistAntSystem = "eas"
+	// RankBasedAntSystem lets only the best-ranked ants of an iteration deposit,
+	// weighted by rank, together with the best tour so far
+	RankBasedAntSystem = "rank"
+	// MaxMinAntSystem lets only the iteration-best ant deposit and keeps every
+	// trail between bounds derived from the best tour so far
+	MaxMinAntSystem = "mmas"
+	// AntColonySystem takes the most attractive edge with probability Q0,
+	// wears trails down as ants cross them and reinforces only the best tour so far
+	AntColonySystem = "acs"
+)
+
+// Variants lists the names of the supported variants
+var Variants = []string{AntSystem, ElitistAntSystem, RankBasedAntSystem, MaxMinAntSystem, AntColonySystem}
+
+const (
+	// rankedAnts is the weight of the best tour in the rank-based Ant System;
+	// the best rankedAnts-1 ants of each iteration deposit too
+	rankedAnts = 6
+	// acsLocalEvaporation is the share of a trail an ACS ant wears back towards
+	// the initial level as it crosses the edge
+	acsLocalEvaporation = 0.1
+	// mmasBestProbability is the chance MMAS aims for a converged colony to
+	// rebuild the best tour, which sets the ratio of its trail bounds
+	mmasBestProbability = 0.05
+)
+
+// variantAliases maps longer names for the variants to their short names
+var variantAliases = map[string]string{
+	"antsystem": AntSystem,
+	"elitist":   ElitistAntSystem,
+	"ranked":    RankBasedAntSystem,
+	"rankbased": RankBasedAntSystem,
+	"maxmin":    MaxMinAntSystem,
+	"colony":    AntColonySystem,
+}
+
+// parseVariants parses a comma-separated list of variant names or aliases
+func parseVariants(list string) ([]string, error) {
+	var variants []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := variantAliases[name]; ok {
+			name = alias
+		}
+		if err := checkVariant(name); err != nil {
+			return nil, err
+		}
+		variants = append(variants, name)
+	}
+	return variants, nil
+}
+
+// checkVariant reports an error for unknown variant names; empty means Ant System
+func checkVariant(variant string) error {
+	if variant != "" && !slices.Contains(Variants, variant) {
+		return fmt.Errorf("unknown variant %q, want one of %v", variant, Variants)
+	}
+	return nil
+}
+
+// InitializePheromones sets every trail to the starting level recommended for
+// the colony's variant, which depends on the length of a nearest-neighbour tour
+func (ac *AntColony) InitializePheromones() {
+	n := float64(len(ac.Cities))
+	length := ac.TourLength(ac.nearestNeighborTour())
+	if length <= 0 || math.IsInf(length, 0) || math.IsNaN(length) {
+		length = 1
+	}
+	m := float64(ac.NumAnts)
+	rule := variantRules[ac.Variant]
+	switch {
+	case rule.InitialPheromone != nil:
+		ac.initialPheromone = rule.InitialPheromone(ac, length)
+	case ac.Variant == ElitistAntSystem:
+		ac.initialPheromone = 2 * m * ac.Q / (ac.Rho * length)
+	case ac.Variant == RankBasedAntSystem:
+		ac.initialPheromone = 0.5 * rankedAnts * (rankedAnts - 1) * ac.Q / (ac.Rho * length)
+	case ac.Variant == MaxMinAntSystem:
+		ac.setPheromoneBounds(length)
+		ac.initialPheromone = ac.maxPheromone
+	case ac.Variant == AntColonySystem:
+		ac.initialPheromone = ac.Q / (n * length)
+	default:
+		ac.initialPheromone = m * ac.Q / length
+	}
+	if ac.trailBounds {
+		ac.initialPheromone = math.Min(math.Max(ac.initialPheromone, ac.minPheromone), ac.maxPheromone)
+	}
+	if len(ac.Constraints.Clusters) > 0 {
+		ac.ensureClusterPheromones()
+	}
+	if ac.sparse != nil {
+		ac.sparse.fill(ac.initialPheromone)
+	}
+	for _, matrix := range [][][]float64{ac.Pheromones, ac.ClusterPheromones} {
+		for i := range matrix {
+			for j := range matrix[i] {
+				matrix[i][j] = ac.initialPheromone
+			}
+		}
+	}
+}
+
+// nearestNeighborTour builds a tour greedily, always moving to the cheapest allowed city
+func (ac *AntColony) nearestNeighborTour() []int {
+	start := ac.startCity()
+	ant := &Ant{Tour: []int{start}, Visited: map[int]bool{start: true}, Elapsed: ac.Cities[start].ServiceTime}
+	for len(ant.Tour) < len(ac.Cities) {
+		current := ant.Tour[len(ant.Tour)-1]
+		next, nextCost := -1, math.Inf(1)
+		for _, city := range ac.candidates(ant) {
+			if cost := ac.edgeCost(current, city, ant.Elapsed); next < 0 || cost < nextCost {
+				next, nextCost = city, cost
+			}
+		}
+		if next < 0 {
+			break
+		}
+		ac.visit(ant, next)
+	}
+	return ant.Tour
+}
+
+// UpdateTrails applies the variant's pheromone update after an iteration
+// whose ants built tours of the given lengths; best is the best tour so far
+func (ac *AntColony) UpdateTrails(ants []*Ant, lengths []float64, best []int, bestLength float64) {
+	// Tours of no length, of a single city or of cities in one place, deposit
+	// as if of the least cost, as the heuristic takes them, so trails stay finite
+	if slices.ContainsFunc(lengths, func(length float64) bool { return length <= 0 }) {
+		lengths = slices.Clone(lengths)
+		for k := range lengths {
+			lengths[k] = max(lengths[k], ac.costFloor)
+		}
+	}
+	bestLength = max(bestLength, ac.costFloor)
+	if rule, ok := variantRules[ac.Variant]; ok {
+		rule.UpdateTrails(ac, ants, lengths, best, bestLength)
+		return
+	}
+	switch ac.Variant {
+	case ElitistAntSystem:
+		ac.evaporate()
+		for k, ant := range ants {
+			ac.deposit(ant.Tour, ac.Q/lengths[k])
+		}
+		ac.deposit(best, float64(ac.NumAnts)*ac.Q/bestLength)
+	case RankBasedAntSystem:
+		order := make([]int, len(ants))
+		for k := range order {
+			order[k] = k
+		}
+		sort.SliceStable(order, func(a, b int) bool { return lengths[order[a]] < lengths[order[b]] })
+		ac.evaporate()
+		for r, k := range order[:min(rankedAnts-1, len(order))] {
+			ac.deposit(ants[k].Tour, float64(rankedAnts-1-r)*ac.Q/lengths[k])
+		}
+		ac.deposit(best, rankedAnts*ac.Q/bestLength)
+	case MaxMinAntSystem:
+		iterationBest := 0
+		for k := range lengths {
+			if lengths[k] < lengths[iterationBest] {
+				iterationBest = k
+			}
+		}
+		ac.evaporate()
+		if len(ants) > 0 {
+			ac.deposit(ants[iterationBest].Tour, ac.Q/lengths[iterationBest])
+		}
+		if !math.IsInf(bestLength, 0) {
+			ac.setPheromoneBounds(bestLength)
+		}
+		ac.clamped = ac.clampPheromones()
+	case AntColonySystem:
+		ac.forEachEdge(best, func(i, j int) {
+			ac.blendTrail(i, j, ac.Rho, ac.Q/bestLength)
+		})
+	default:
+		ac.evaporate()
+		for k, ant := range ants {
+			ac.deposit(ant.Tour, ac.Q/lengths[k])
+		}
+	}
+}
+
+// setPheromoneBounds derives the MMAS trail limits from the best tour length:
+// the upper limit is the level a trail converges to when only that tour
+// deposits, and the lower one makes the tour rebuilt with mmasBestProbability
+func (ac *AntColony) setPheromoneBounds(bestLength float64) {
+	n := float64(len(ac.Cities))
+	ac.maxPheromone = ac.Q / (ac.Rho * bestLength)
+	root := math.Pow(mmasBestProbability, 1/n)
+	ac.minPheromone = ac.maxPheromone * (1 - root) / (math.Max(n/2-1, 1) * root)
+}
+
+// boundTrails keeps the trails of a variant other than MMAS within lower and
+// upper from now on, clamping them after every update as MMAS does with
+// bounds of its own; an upper bound of 0 means none
+func (ac *AntColony) boundTrails(lower, upper float64) {
+	if upper == 0 {
+		upper = math.Inf(1)
+	}
+	ac.minPheromone, ac.maxPheromone, ac.trailBounds = lower, upper, true
+}
+
+// boundedTrails reports whether the trails are kept within minPheromone and
+// maxPheromone, those of MMAS or those boundTrails set
+func (ac *AntColony) boundedTrails() bool {
+	return ac.Variant == MaxMinAntSystem || ac.trailBounds
+}
+
+// clampPheromones keeps every trail within the bounds and returns how many
+// trails it changed, a shared sparse level counting once for every edge it
+// stands for
+func (ac *AntColony) clampPheromones() int {
+	clamped := 0
+	clamp := func(trail float64) float64 {
+		bounded := math.Min(math.Max(trail, ac.minPheromone), ac.maxPheromone)
+		if bounded != trail {
+			clamped++
+		}
+		return bounded
+	}
+	if ac.sparse != nil {
+		level, stored := ac.sparse.level, len(ac.sparse.edges)
+		ac.sparse.update(clamp)
+		if ac.sparse.level != level {
+			// clamp counted the level once, for one of the edges it stands for
+			clamped += len(ac.Cities)*len(ac.Cities) - stored - 1
+		}
+	}
+	for _, matrix := range [][][]float64{ac.Pheromones, ac.ClusterPheromones} {
+		for i := range matrix {
+			for j := range matrix[i] {
+				matrix[i][j] = clamp(matrix[i][j])
+			}
+		}
+	}
+	return clamped
+}
+
+// blendTrail moves the trail on the edge between cities i and j, in both
+// directions, the share rate of the way towards level
+func (ac *AntColony) blendTrail(i, j int, rate, level float64) {
+	for _, t := range []*float64{ac.trail(i, j), ac.trail(j, i)} {
+		// Rounded before the sum so no multiply-add makes trails machine-dependent
+		*t += float64(rate * (level - *t))
+	}
+}
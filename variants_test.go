@@ -0,0 +1,50 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// TestTrailBounds checks that MMAS, which bounds its trails itself, refuses
+// trail_min and trail_max, and that another variant keeps every trail within
+// them and counts the trails it clamped
+func TestTrailBounds(t *testing.T) {
+	g, err := NewGenerator(UniformCities, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := g.Instance(12)
+
+	config := DefaultConfig()
+	config.Seed, config.Variant, config.TrailMin = 1, MaxMinAntSystem, 0.01
+	if err := config.Validate(); err == nil {
+		t.Error("mmas with trail_min validated")
+	}
+	if _, err := NewSolver(inst, config); err == nil {
+		t.Error("solver built for mmas with trail_min")
+	}
+
+	config.Variant, config.TrailMin, config.TrailMax = AntSystem, 0.5, 2
+	solver, err := NewSolver(inst, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clamped := 0
+	for range 20 {
+		stats := solver.Step()
+		if stats.TrailMin != 0.5 || stats.TrailMax != 2 {
+			t.Fatalf("iteration %d: bounds [%g, %g], want [0.5, 2]", stats.Iteration, stats.TrailMin, stats.TrailMax)
+		}
+		clamped += stats.Clamped
+		for i, row := range solver.Colony.Pheromones {
+			for j, trail := range row {
+				if trail < 0.5 || trail > 2 || math.IsNaN(trail) {
+					t.Fatalf("iteration %d: trail %d-%d at %g", stats.Iteration, i, j, trail)
+				}
+			}
+		}
+	}
+	if clamped == 0 {
+		t.Error("no trail clamped in 20 iterations")
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Cost models of Config.CostModel, what the colony minimizes
+const (
+	// CostDistance minimizes the length of the tour, as without a vehicle
+	CostDistance = "distance"
+	// CostTime minimizes the hours the vehicle takes, driving and serving
+	CostTime = "time"
+	// CostMoney minimizes what the route costs to run with the vehicle
+	CostMoney = "cost"
+)
+
+// CostModels lists the valid values of Config.CostModel
+var CostModels = []string{CostDistance, CostTime, CostMoney}
+
+// VehicleProfile is how fast a vehicle goes and what it costs to run, with
+// distances taken as kilometres and service times as hours
+type VehicleProfile struct {
+	Name string `json:"name,omitempty"`
+	// Speed is in km per hour
+	Speed     float64 `json:"speed"`
+	CostPerKm float64 `json:"cost_per_km"`
+	// CostPerHour is paid for the hours of the route, such as a driver's
+	// wage, driving and serving alike
+	CostPerHour float64 `json:"cost_per_hour,omitempty"`
+	// FixedCost is paid once per route the vehicle runs
+	FixedCost float64 `json:"fixed_cost,omitempty"`
+}
+
+// VehicleProfiles holds the built-in profiles of Config.Vehicle, with
+// typical speeds in town and running costs
+var VehicleProfiles = map[string]VehicleProfile{
+	"bike":  {Name: "bike", Speed: 15, CostPerKm: 0.05, CostPerHour: 15, FixedCost: 5},
+	"car":   {Name: "car", Speed: 50, CostPerKm: 0.3},
+	"van":   {Name: "van", Speed: 40, CostPerKm: 0.45, CostPerHour: 25, FixedCost: 50},
+	"truck": {Name: "truck", Speed: 30, CostPerKm: 1.1, CostPerHour: 35, FixedCost: 150},
+}
+
+// vehicleNames lists the built-in vehicle profiles in order
+func vehicleNames() []string {
+	return slices.Sorted(maps.Keys(VehicleProfiles))
+}
+
+// parseVehicle reads Config.Vehicle: a name of VehicleProfiles or
+// "speed:cost_per_km:fixed_cost[:cost_per_hour]"; nil if empty
+func parseVehicle(vehicle string) (*VehicleProfile, error) {
+	vehicle = strings.TrimSpace(vehicle)
+	if vehicle == "" {
+		return nil, nil
+	}
+	if profile, ok := VehicleProfiles[vehicle]; ok {
+		return &profile, nil
+	}
+	fields := strings.Split(vehicle, ":")
+	if len(fields) != 3 && len(fields) != 4 {
+		return nil, fmt.Errorf("unknown vehicle %q, want one of %v or speed:cost_per_km:fixed_cost[:cost_per_hour]", vehicle, vehicleNames())
+	}
+	var values [4]float64
+	for k, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || v < 0 || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("vehicle %s: %q is not a non-negative number", vehicle, field)
+		}
+		values[k] = v
+	}
+	if values[0] == 0 {
+		return nil, fmt.Errorf("vehicle %s: speed must be positive", vehicle)
+	}
+	return &VehicleProfile{Speed: values[0], CostPerKm: values[1], FixedCost: values[2], CostPerHour: values[3]}, nil
+}
+
+// checkCostModel checks that a cost model and vehicle go together: time and
+// cost need a vehicle, and cost one that charges by the km or the hour
+func checkCostModel(model, vehicle string) error {
+	profile, err := parseVehicle(vehicle)
+	if err != nil {
+		return err
+	}
+	switch model {
+	case "", CostDistance:
+		return nil
+	case CostTime, CostMoney:
+		if profile == nil {
+			return fmt.Errorf("cost model %s needs a vehicle", model)
+		}
+		if model == CostMoney && profile.CostPerKm == 0 && profile.CostPerHour == 0 {
+			return fmt.Errorf("cost model cost needs a vehicle with a cost per km or per hour")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown cost model %q, want one of %v", model, CostModels)
+}
+
+// RouteCost breaks down what a route costs the vehicle running it
+type RouteCost struct {
+	Vehicle string `json:"vehicle,omitempty"`
+	Stops   int    `json:"stops"`
+	// Distance is the length of the route's legs, without service times
+	Distance     float64 `json:"distance"`
+	DrivingHours float64 `json:"driving_hours"`
+	ServiceHours float64 `json:"service_hours,omitempty"`
+	FixedCost    float64 `json:"fixed_cost"`
+	DistanceCost float64 `json:"distance_cost"`
+	TimeCost     float64 `json:"time_cost"`
+	Total        float64 `json:"total"`
+}
+
+// Hours returns the time the route takes, driving and serving
+func (r RouteCost) Hours() float64 {
+	return r.DrivingHours + r.ServiceHours
+}
+
+// RouteCost returns the breakdown of what the vehicle spends on a tour, by
+// the distance between cities and counting the closing leg of closed tours
+func (p *VehicleProfile) RouteCost(cities []*City, distance func(i, j int) float64, tour []int, closed bool) RouteCost {
+	route := RouteCost{Vehicle: p.Name, Stops: len(tour)}
+	if len(tour) == 0 {
+		return route
+	}
+	legs := len(tour) - 1
+	if closed && len(tour) > 1 {
+		legs = len(tour)
+	}
+	for k := 0; k < legs; k++ {
+		route.Distance += distance(tour[k], tour[(k+1)%len(tour)])
+	}
+	for _, city := range tour {
+		route.ServiceHours += cities[city].ServiceTime
+	}
+	route.DrivingHours = route.Distance / p.Speed
+	route.FixedCost = p.FixedCost
+	route.DistanceCost = p.CostPerKm * route.Distance
+	route.TimeCost = p.CostPerHour * route.Hours()
+	route.Total = route.FixedCost + route.DistanceCost + route.TimeCost
+	return route
+}
+
+// VehicleObjective scores tours by the hours or the money the vehicle
+// spends on them, by Model, CostTime or CostMoney
+type VehicleObjective struct {
+	Vehicle *VehicleProfile
+	Model   string
+}
+
+// Score returns the route's hours or total cost
+func (o *VehicleObjective) Score(ac *AntColony, tour []int) float64 {
+	route := o.Vehicle.RouteCost(ac.Cities, ac.distance, tour, ac.ReturnToStart)
+	if o.Model == CostTime {
+		return route.Hours()
+	}
+	return route.Total
+}
+
+// routeCosts returns the cost breakdown of each route of a tour by the
+// colony's vehicle, nil without one. A tour is a single route
+func (ac *AntColony) routeCosts(tour []int) []RouteCost {
+	if ac.Vehicle == nil || len(tour) == 0 {
+		return nil
+	}
+	return []RouteCost{ac.Vehicle.RouteCost(ac.Cities, ac.distance, tour, ac.ReturnToStart)}
+}
+
+// WriteRouteCosts prints a line per route with its distance, hours and the
+// parts of its cost
+func WriteRouteCosts(w io.Writer, routes []RouteCost) error {
+	for k, r := range routes {
+		vehicle := r.Vehicle
+		if vehicle == "" {
+			vehicle = "vehicle"
+		}
+		_, err := fmt.Fprintf(w, "Route %d (%s): %d stops, %.1f km, %.2f h (%.2f driving, %.2f serving), cost %.2f = %.2f fixed + %.2f distance + %.2f time\n",
+			k+1, vehicle, r.Stops, r.Distance, r.Hours(), r.DrivingHours, r.ServiceHours, r.Total, r.FixedCost, r.DistanceCost, r.TimeCost)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// visualizeCommand implements "visualize", whose first argument names what to
+// draw, "tour", "convergence", "pheromones" or "animation"; "tour" is assumed
+// when it is left out
+func visualizeCommand(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "tour":
+			return visualizeTour(args[1:])
+		case "convergence":
+			return visualizeConvergence(args[1:])
+		case "pheromones":
+			return visualizePheromones(args[1:])
+		case "animation":
+			return visualizeAnimation(args[1:])
+		case "backbone":
+			return visualizeBackbone(args[1:])
+		}
+	}
+	return visualizeTour(args)
+}
+
+// visualizeTour implements "visualize tour", which draws a tour of an
+// instance as SVG or PNG, solving the instance first when no tour is given
+func visualizeTour(args []string) error {
+	flags := flag.NewFlagSet("visualize tour", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	tourPath := flags.String("tour", "", "draw the tour in this solution JSON or TSPLIB/Concorde tour `file` instead of solving")
+	outPath := flags.String("o", "-", "write the image to this `file`, - for stdout")
+	format := flags.String("format", "", "image `format`, svg or png; by default png for a .png -o file and svg otherwise")
+	size := flags.Int("size", svgSize, "width and height of the image in `pixels`")
+	labels := flags.String("labels", LabelsAuto, "label cities: auto (named cities of small instances), always or never")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s visualize [tour] [flags] file\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("visualize: want exactly one instance file")
+	}
+	if *format == "" {
+		*format = renderFormat(*outPath)
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	var tour []int
+	if *tourPath != "" {
+		if tour, err = loadTourFile(*tourPath); err == nil {
+			err = instance.ValidateTour(tour)
+		}
+	} else {
+		var config Config
+		var solver *Solver
+		if config, err = instanceConfig(instance, parameters, true); err == nil {
+			if solver, err = NewSolver(instance, config); err == nil {
+				tour = solver.Run().Tour
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	options := RenderOptions{Format: *format, Size: *size, Labels: *labels, Closed: instance.ReturnToStart}
+	return writeFile(*outPath, func(w io.Writer) error {
+		return Render(w, instance.Cities, tour, options)
+	})
+}
+
+// visualizeConvergence implements "visualize convergence", which charts the
+// best tour length per iteration of saved runs, or of runs of the given
+// variants on an instance, on one plot
+func visualizeConvergence(args []string) error {
+	flags := flag.NewFlagSet("visualize convergence", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	instancePath := flags.String("instance", "", "run -variants on this instance `file` and chart them instead of reading histories")
+	variantList := flags.String("variants", AntSystem, "with -instance, comma-separated `list` of variants to run")
+	outPath := flags.String("o", "-", "write the chart to this `file`, - for stdout")
+	format := flags.String("format", "", "image `format`, svg or png; by default png for a .png -o file and svg otherwise")
+	width := flags.Int("width", svgSize, "width of the chart in `pixels`")
+	height := flags.Int("height", svgSize*3/4, "height of the chart in `pixels`")
+	mean := flags.Bool("mean", false, "also draw each run's mean tour length per iteration, dashed")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s visualize convergence [flags] history...\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "       %s visualize convergence -instance file [-variants list] [flags]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Charts history CSV files written by solve -history, or solution or run report JSON files.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *format == "" {
+		*format = renderFormat(*outPath)
+	}
+	var series []ConvergenceSeries
+	if *instancePath != "" {
+		instance, err := ReadInstance(*instancePath, "")
+		if err != nil {
+			return err
+		}
+		variants, err := parseVariants(*variantList)
+		if err != nil {
+			return err
+		}
+		for _, variant := range variants {
+			config, err := instanceConfig(instance, parameters, true)
+			if err != nil {
+				return err
+			}
+			config.Variant = variant
+			solver, err := NewSolver(instance, config)
+			if err != nil {
+				return err
+			}
+			series = append(series, ConvergenceSeries{Name: variant, History: solver.Run().History})
+		}
+	}
+	for _, path := range flags.Args() {
+		history, err := LoadHistory(path)
+		if err != nil {
+			return err
+		}
+		series = append(series, ConvergenceSeries{Name: filepath.Base(path), History: history})
+	}
+	if len(series) == 0 {
+		flags.Usage()
+		return fmt.Errorf("visualize convergence: no histories or -instance given")
+	}
+	options := ChartOptions{Format: *format, Width: *width, Height: *height, Mean: *mean}
+	return writeFile(*outPath, func(w io.Writer) error {
+		return RenderConvergence(w, series, options)
+	})
+}
+
+// visualizePheromones implements "visualize pheromones", which runs the
+// colony on an instance, or loads a saved colony state, and draws its trails
+func visualizePheromones(args []string) error {
+	flags := flag.NewFlagSet("visualize pheromones", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	statePath := flags.String("state", "", "draw the trails of this colony state JSON or .pb `file` instead of running the colony")
+	mode := flags.String("mode", PheromoneTrails, "draw trails over the map (trails) or the pheromone matrix (heatmap)")
+	outPath := flags.String("o", "-", "write the image to this `file`, - for stdout")
+	format := flags.String("format", "", "output `format`, svg, png or dot for a Graphviz graph of the trails; by default from the -o file's extension, svg if unknown")
+	size := flags.Int("size", svgSize, "width and height of the image in `pixels`")
+	threshold := flags.Float64("threshold", pheromoneThreshold, "with trails or dot, leave out edges carrying less than this `share` of the strongest trail")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s visualize pheromones [flags] file\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Heatmap rows and columns follow the best tour, so a converged colony shows a band along the diagonal.")
+		fmt.Fprintln(flags.Output(), "The dot format pins cities at their coordinates; lay it out with neato -n2 -Tsvg.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("visualize: want exactly one instance file")
+	}
+	if *format == "" {
+		*format = renderFormat(*outPath)
+		if ext := strings.ToLower(filepath.Ext(*outPath)); ext == ".dot" || ext == ".gv" {
+			*format = "dot"
+		}
+	}
+	if *threshold <= 0 || *threshold > 1 {
+		return fmt.Errorf("visualize: -threshold must be in (0, 1]")
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	var state *ColonyState
+	if *statePath != "" {
+		if state, err = LoadColonyState(*statePath); err != nil {
+			return err
+		}
+	} else {
+		config, err := instanceConfig(instance, parameters, true)
+		if err != nil {
+			return err
+		}
+		solver, err := NewSolver(instance, config)
+		if err != nil {
+			return err
+		}
+		solver.Run()
+		state = solver.State()
+	}
+	var order []int
+	if len(state.BestTour) == len(instance.Cities) {
+		order = state.BestTour
+	}
+	if *format == "dot" {
+		return writeFile(*outPath, func(w io.Writer) error {
+			return WritePheromoneDOT(w, instance.Cities, state.Pheromones, order, instance.ReturnToStart, *threshold)
+		})
+	}
+	options := RenderOptions{Format: *format, Size: *size, Threshold: *threshold}
+	return writeFile(*outPath, func(w io.Writer) error {
+		return RenderPheromones(w, instance.Cities, state.Pheromones, order, *mode, options)
+	})
+}
+
+// visualizeAnimation implements "visualize animation", which runs the colony
+// on an instance and shows its best tour improving, as an animated GIF or as
+// numbered PNG frames
+func visualizeAnimation(args []string) error {
+	flags := flag.NewFlagSet("visualize animation", flag.ExitOnError)
+	parameters := parameterFlags(flags)
+	every := flags.Int("every", 5, "record the best tour every `n` iterations")
+	outPath := flags.String("o", "-", "write the animated GIF to this `file`, - for stdout")
+	framesDir := flags.String("frames", "", "write numbered PNG frames to this `directory` instead of a GIF")
+	delay := flags.Int("delay", 20, "time between GIF frames in hundredths of a second")
+	size := flags.Int("size", svgSize/2, "width and height of the frames in `pixels`")
+	labels := flags.String("labels", LabelsNever, "label cities: auto (named cities of small instances), always or never")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s visualize animation [flags] file\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("visualize: want exactly one instance file")
+	}
+	if *every <= 0 {
+		return fmt.Errorf("visualize: -every must be positive")
+	}
+	instance, err := ReadInstance(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	config, err := instanceConfig(instance, parameters, true)
+	if err != nil {
+		return err
+	}
+	solver, err := NewSolver(instance, config)
+	if err != nil {
+		return err
+	}
+	frames := RecordFrames(solver, *every)
+	options := RenderOptions{Format: "png", Size: *size, Labels: *labels, Closed: instance.ReturnToStart}
+	if *framesDir != "" {
+		return WriteTourFrames(*framesDir, instance.Cities, frames, options)
+	}
+	return writeFile(*outPath, func(w io.Writer) error {
+		return WriteTourGIF(w, instance.Cities, frames, *delay, options)
+	})
+}
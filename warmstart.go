@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WarmStart seeds the solver's trails with those of a previous run on an
+// earlier version of its instance, so that re-solving after a few cities
+// changed, as in rolling-horizon planning, starts from what that run learned
+// instead of from scratch. Cities are matched by name, or by coordinates when
+// unnamed; trails between two matched cities are carried over, and those of
+// new cities start at the mean carried-over level. All trails are then pulled
+// smoothing of the way towards that mean, so that preferences the changes
+// made stale can be unlearned. Call it before the first iteration; it returns
+// the number of cities matched
+func (s *Solver) WarmStart(previous *ColonyState, smoothing float64) (int, error) {
+	if smoothing < 0 || smoothing > 1 {
+		return 0, fmt.Errorf("warm start smoothing %g is not in [0, 1]", smoothing)
+	}
+	if s.Colony.sparse != nil {
+		return 0, errors.New("cannot warm start sparse trails, which carry over only by a full matrix")
+	}
+	if len(previous.Cities) == 0 {
+		return 0, errors.New("the state records no cities to match, write it again with -state")
+	}
+	if len(previous.Pheromones) != len(previous.Cities) {
+		return 0, fmt.Errorf("the state has %d rows of trails for %d cities", len(previous.Pheromones), len(previous.Cities))
+	}
+	for _, row := range previous.Pheromones {
+		if len(row) != len(previous.Cities) {
+			return 0, fmt.Errorf("a trail row of the state has %d values, want %d", len(row), len(previous.Cities))
+		}
+	}
+
+	// Pair every city with an unused previous city of the same key
+	unused := make(map[string][]int)
+	for i, c := range previous.Cities {
+		unused[cityKey(c)] = append(unused[cityKey(c)], i)
+	}
+	ac := s.Colony
+	from := make([]int, len(ac.Cities))
+	matched := 0
+	for i, c := range ac.Cities {
+		from[i] = -1
+		if candidates := unused[cityKey(c)]; len(candidates) > 0 {
+			from[i], unused[cityKey(c)] = candidates[0], candidates[1:]
+			matched++
+		}
+	}
+	if matched == 0 {
+		return 0, errors.New("none of the state's cities is in the instance")
+	}
+
+	sum, count := 0.0, 0
+	for i := range ac.Pheromones {
+		for j := range ac.Pheromones[i] {
+			if i != j && from[i] >= 0 && from[j] >= 0 {
+				ac.Pheromones[i][j] = previous.Pheromones[from[i]][from[j]]
+				sum += ac.Pheromones[i][j]
+				count++
+			}
+		}
+	}
+	mean := ac.initialPheromone
+	if count > 0 {
+		mean = sum / float64(count)
+	}
+	for i := range ac.Pheromones {
+		for j := range ac.Pheromones[i] {
+			if from[i] < 0 || from[j] < 0 {
+				ac.Pheromones[i][j] = mean
+			}
+			ac.Pheromones[i][j] += smoothing * (mean - ac.Pheromones[i][j])
+		}
+	}
+	if ac.boundedTrails() {
+		ac.clampPheromones()
+	}
+	return matched, nil
+}
+
+// cityKey identifies a city across versions of an instance: by its name, or
+// by its coordinates when it has none
+func cityKey(c *City) string {
+	if c.Name != "" {
+		return "name " + c.Name
+	}
+	return fmt.Sprintf("at %g %g %g", c.X, c.Y, c.Z)
+}
@@ -0,0 +1,128 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"syscall/js"
+)
+
+// main, in the WebAssembly build, exposes the solver to JavaScript as the
+// global aco object instead of running the command line, then calls
+// globalThis.acoReady if the page defined it. Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o wasm/aco.wasm .
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" wasm/
+//
+// and load it through wasm/aco.js, whose API is
+//
+//	loadCities(cities, config)  start a colony touring [{x, y, name}] or [[x, y]] cities; config
+//	                            holds parameters named as in JSON config files, the rest
+//	                            recommended for the instance
+//	step(n)                     run n iterations, 1 by default, and return the last one's
+//	                            statistics
+//	bestTour()                  the best tour so far, as city indices
+//	bestLength()                its length, Infinity before the first iteration
+//
+// Failures are returned as Error values, which aco.js throws.
+func main() {
+	var solver *Solver
+	noColony := func() any { return js.Global().Get("Error").New("no cities loaded") }
+	api := map[string]any{
+		"loadCities": js.FuncOf(func(this js.Value, args []js.Value) any {
+			s, err := newJSSolver(args)
+			if err != nil {
+				return js.Global().Get("Error").New(err.Error())
+			}
+			solver = s
+			return nil
+		}),
+		"step": js.FuncOf(func(this js.Value, args []js.Value) any {
+			if solver == nil {
+				return noColony()
+			}
+			n := 1
+			if len(args) > 0 && args[0].Type() == js.TypeNumber {
+				n = max(args[0].Int(), 1)
+			}
+			var stats IterationStats
+			for range n {
+				stats = solver.Step()
+			}
+			return map[string]any{
+				"iteration": stats.Iteration + 1,
+				"best":      stats.Best,
+				"mean":      stats.Mean,
+				"worst":     stats.Worst,
+				"bestSoFar": stats.BestSoFar,
+				"diversity": stats.Diversity,
+			}
+		}),
+		"bestTour": js.FuncOf(func(this js.Value, args []js.Value) any {
+			if solver == nil {
+				return noColony()
+			}
+			tour := make([]any, len(solver.bestTour))
+			for i, city := range solver.bestTour {
+				tour[i] = city
+			}
+			return tour
+		}),
+		"bestLength": js.FuncOf(func(this js.Value, args []js.Value) any {
+			if solver == nil {
+				return noColony()
+			}
+			return solver.bestLength
+		}),
+	}
+	js.Global().Set("aco", js.ValueOf(api))
+	if ready := js.Global().Get("acoReady"); ready.Type() == js.TypeFunction {
+		ready.Invoke()
+	}
+	select {}
+}
+
+// notifyReload does nothing: browsers send no signals
+func notifyReload(chan<- os.Signal) {}
+
+// notifyTerminate does nothing: browsers send no signals
+func notifyTerminate(chan<- os.Signal) {}
+
+// newJSSolver builds a solver from the arguments of loadCities, passed
+// through JSON so they decode as the instance and config files do
+func newJSSolver(args []js.Value) (*Solver, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("loadCities: want an array of cities")
+	}
+	stringify := js.Global().Get("JSON").Get("stringify")
+	data := []byte(stringify.Invoke(args[0]).String())
+	var cities []*City
+	if err := json.Unmarshal(data, &cities); err != nil {
+		var points [][]float64
+		if json.Unmarshal(data, &points) != nil {
+			return nil, fmt.Errorf("loadCities: cities must be {x, y} objects or [x, y] pairs")
+		}
+		cities = nil
+		for _, p := range points {
+			if len(p) < 2 {
+				return nil, fmt.Errorf("loadCities: a city needs x and y")
+			}
+			cities = append(cities, &City{X: p[0], Y: p[1]})
+		}
+	}
+	instance := &Instance{Cities: cities, ReturnToStart: true}
+	config, err := instanceConfig(instance, func(base Config) (Config, error) {
+		if len(args) < 2 || args[1].IsUndefined() || args[1].IsNull() {
+			return base, nil
+		}
+		type plain Config
+		err := json.Unmarshal([]byte(stringify.Invoke(args[1]).String()), (*plain)(&base))
+		return base, err
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+	return NewSolver(instance, config)
+}
@@ -0,0 +1,29 @@
+// aco.js loads the WebAssembly build of the solver and wraps the global aco
+// object it defines in a small API that throws on failure. The page must
+// load wasm_exec.js from the Go distribution first; see wasm.go for the
+// build commands.
+//
+//   const aco = await loadACO("aco.wasm");
+//   aco.loadCities([[0, 0], [3, 1], [1, 4]], { variant: "acs" });
+//   const stats = aco.step(10);
+//   draw(aco.bestTour(), aco.bestLength());
+
+export async function loadACO(url = "aco.wasm") {
+  const go = new Go();
+  const ready = new Promise(resolve => { globalThis.acoReady = resolve; });
+  const { instance } = await WebAssembly.instantiateStreaming(fetch(url), go.importObject);
+  go.run(instance);
+  await ready;
+
+  const call = (name, ...args) => {
+    const result = globalThis.aco[name](...args);
+    if (result instanceof Error) throw result;
+    return result;
+  };
+  return {
+    loadCities: (cities, config = {}) => call("loadCities", cities, config),
+    step: (n = 1) => call("step", n),
+    bestTour: () => call("bestTour"),
+    bestLength: () => call("bestLength"),
+  };
+}
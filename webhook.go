@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RunListener receives the events of runs
+type RunListener interface {
+	Publish(event RunEvent)
+	// Close delivers the events still pending
+	Close() error
+}
+
+// RunListeners hands every event to each of its listeners
+type RunListeners []RunListener
+
+// Publish hands the event to every listener
+func (ls RunListeners) Publish(event RunEvent) {
+	for _, l := range ls {
+		l.Publish(event)
+	}
+}
+
+// Close closes every listener and returns the first error
+func (ls RunListeners) Close() error {
+	var first error
+	for _, l := range ls {
+		if err := l.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Webhook posts a JSON notice to a URL when a run finishes, or when its best
+// tour gets shorter by more than Threshold percent since the last notice. The
+// notice is the run.best or run.finished event with a "text" summary added,
+// which is what Slack's incoming webhooks display
+type Webhook struct {
+	URL string
+	// Threshold is the improvement, in percent of the length last notified,
+	// that a new best tour must exceed to be notified. The first best tour
+	// of a run sets the reference without being notified
+	Threshold float64
+	Client    *http.Client
+
+	mu        sync.Mutex
+	reference map[string]float64
+	once      sync.Once
+	queue     chan RunEvent
+	closed    chan struct{}
+}
+
+// NewWebhook returns a webhook posting to url on improvements beyond
+// threshold percent and at the end of runs
+func NewWebhook(url string, threshold float64) (*Webhook, error) {
+	if threshold < 0 {
+		return nil, fmt.Errorf("webhook threshold must not be negative, not %g", threshold)
+	}
+	return &Webhook{URL: url, Threshold: threshold, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// Publish queues a notice of the event if it deserves one
+func (h *Webhook) Publish(event RunEvent) {
+	h.once.Do(h.start)
+	if !h.notable(event) {
+		return
+	}
+	select {
+	case h.queue <- event:
+	default:
+		slog.Warn("dropping webhook notice, the receiver is too slow", "type", event.Type, "run", event.Run)
+	}
+}
+
+// notable reports whether the event is a finished run or an improvement
+// beyond the threshold, recording it as the new reference
+func (h *Webhook) notable(event RunEvent) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch event.Type {
+	case EventRunFinished:
+		delete(h.reference, event.Run)
+		return true
+	case EventRunBest:
+		if event.Length == nil {
+			return false
+		}
+		reference, ok := h.reference[event.Run]
+		if ok && (reference-*event.Length)/reference*100 <= h.Threshold {
+			return false
+		}
+		h.reference[event.Run] = *event.Length
+		return ok
+	}
+	return false
+}
+
+// start launches the goroutine posting the queued notices
+func (h *Webhook) start() {
+	h.reference = make(map[string]float64)
+	h.queue = make(chan RunEvent, eventQueueSize)
+	h.closed = make(chan struct{})
+	go func() {
+		defer close(h.closed)
+		for event := range h.queue {
+			if err := h.post(event); err != nil {
+				slog.Warn("posting webhook notice", "type", event.Type, "run", event.Run, "err", err)
+			}
+		}
+	}()
+}
+
+// post sends the notice of an event
+func (h *Webhook) post(event RunEvent) error {
+	notice := struct {
+		RunEvent
+		Text string `json:"text"`
+	}{event, webhookText(event)}
+	body, err := json.Marshal(notice)
+	if err != nil {
+		return err
+	}
+	response, err := h.Client.Post(h.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook answered %s", response.Status)
+	}
+	return nil
+}
+
+// webhookText summarizes an event in a sentence
+func webhookText(event RunEvent) string {
+	length := "no tour"
+	if event.Length != nil && !math.IsInf(*event.Length, 0) {
+		length = fmt.Sprintf("best length %.6g", *event.Length)
+	}
+	if event.Type == EventRunFinished {
+		return fmt.Sprintf("Run %s %s after %d iterations: %s", event.Run, event.State, event.Iteration, length)
+	}
+	return fmt.Sprintf("Run %s improved at iteration %d: %s", event.Run, event.Iteration, length)
+}
+
+// Close posts the notices still queued
+func (h *Webhook) Close() error {
+	h.once.Do(h.start)
+	close(h.queue)
+	<-h.closed
+	return nil
+}